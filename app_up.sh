@@ -121,14 +121,6 @@ validate_essential_variables() {
         echo "   Переменная DB_SSLMODE не задана или пуста, установлено значение по умолчанию: $DB_SSLMODE"
     fi
 
-    local migrations_path_default="./migrations"
-    if [ -z "${MIGRATIONS_PATH:-}" ]; then
-         MIGRATIONS_PATH="$migrations_path_default"
-         export MIGRATIONS_PATH
-         echo "   Переменная MIGRATIONS_PATH не задана или пуста, установлен путь по умолчанию: $MIGRATIONS_PATH"
-    fi
-
-
     echo "<< Обязательные переменные найдены и не пусты. Необязательные переменные установлены (если не были заданы):"
     # Выводим значения, маскируя конфиденциальные данные
     echo "   DB_USER: ${DB_USER:0:3}..."
@@ -137,80 +129,9 @@ validate_essential_variables() {
     echo "   DB_PORT: $DB_PORT"
     echo "   DB_NAME: $DB_NAME"
     echo "   DB_SSLMODE: $DB_SSLMODE"
-    echo "   MIGRATIONS_PATH: $MIGRATIONS_PATH"
 }
 
 
-# Функция для установки или обновления утилиты миграции migrate
-install_migration_tool() {
-    echo ">> Проверка и установка/обновление CLI утилиты migrate..."
-
-    # Проверяем, установлен ли Go
-    if ! command -v go &> /dev/null; then
-        echo "!! Ошибка: Команда 'go' не найдена. Убедитесь, что Go установлен и доступен в PATH." >&2
-        exit 1
-    fi
-
-    # Устанавливаем или обновляем утилиту migrate с поддержкой postgres
-    echo "   Выполнение: go install -tags 'postgres' github.com/golang-migrate/migrate/v4/cmd/migrate@latest"
-    if go install -tags 'postgres' github.com/golang-migrate/migrate/v4/cmd/migrate@latest; then
-        echo "<< Утилита migrate успешно установлена/обновлена."
-    else
-        echo "!! Ошибка при установке/обновлении утилиты migrate." >&2
-        echo "   Убедитесь в наличии интернет-соединения и корректной настройке Go proxy." >&2
-        exit 1
-    fi
-
-    # Добавляем каталог бинарников Go в PATH для текущей сессии, если он еще не там
-    # Это необходимо, чтобы команда migrate была доступна сразу после установки.
-    local go_bin_path
-    go_bin_path="$(go env GOPATH)/bin"
-    # Проверяем, существует ли каталог GOPATH/bin и не находится ли он уже в PATH
-    if [ -d "$go_bin_path" ] && [[ ":$PATH:" != *":$go_bin_path:"* ]]; then
-        export PATH="$PATH:$go_bin_path"
-        echo ">> Добавлен Go бинарный путь ($go_bin_path) в PATH для текущей сессии."
-    elif [ ! -d "$go_bin_path" ]; then
-        echo "!! Предупреждение: Каталог бинарников Go '$go_bin_path' не найден. migrate может быть недоступен." >&2
-         echo "   Убедитесь, что GOPATH правильно установлен или Go бинарники компилируются в другой каталог." >&2
-    fi
-}
-
-# Функция для выполнения миграций базы данных
-apply_database_migrations() {
-    echo ">> Применение миграций базы данных из каталога: $MIGRATIONS_PATH"
-
-    # Проверяем, доступна ли команда migrate после установки и добавления в PATH
-    if ! command -v migrate &> /dev/null; then
-        echo "!! Ошибка: Команда 'migrate' не найдена." >&2
-        echo "   Убедитесь, что она успешно установлена и $(go env GOPATH)/bin или $HOME/go/bin находится в вашей переменной окружения PATH." >&2
-        exit 1
-    fi
-
-    # Формируем URL для подключения к базе данных
-    local database_url="postgres://${DB_USER}:${DB_PASSWORD}@${DB_HOST}:${DB_PORT}/${DB_NAME}?sslmode=${DB_SSLMODE}"
-    # Выводим URL, скрывая пароль для безопасности в логах
-    local display_url="postgres://${DB_USER}:******@${DB_HOST}:${DB_PORT}/${DB_NAME}?sslmode=${DB_SSLMODE}"
-    echo ">> Используемый URL базы данных (пароль скрыт): $display_url"
-
-    # Проверяем существование каталога с миграциями
-    if [ ! -d "$MIGRATIONS_PATH" ]; then
-        echo "!! Ошибка: Каталог с миграциями '$MIGRATIONS_PATH' не найден." >&2
-        echo "   Убедитесь, что переменная MIGRATIONS_PATH указана верно и каталог существует." >&2
-        exit 1
-    fi
-
-    # Выполняем миграции
-    echo "   Выполнение: migrate -path \"$MIGRATIONS_PATH\" -database \"<masked_url>\" up"
-    # Передаем URL в двойных кавычках, чтобы избежать проблем с пробелами или спецсимволами (кроме тех, что в пароле и требуют URL-кодирования)
-    if migrate -path "$MIGRATIONS_PATH" -database "$database_url" up; then
-        echo "<< Миграции успешно применены."
-    else
-        echo "!! Ошибка при выполнении миграций." >&2
-        echo "   Проверьте логи команды migrate выше для получения подробностей." >&2
-        exit 1
-    fi
-}
-
 # Функция для загрузки Go модулей
 download_go_modules() {
     echo ">> Загрузка Go модулей..."
@@ -247,6 +168,8 @@ build_go_application() {
     fi
 
     local source_file="cmd/main.go"
+    # Собирается весь пакет cmd: кроме main.go в нем находятся команды CLI
+    local source_package="./cmd"
     # Проверяем наличие основного исходного файла приложения
      if [ ! -f "$source_file" ]; then
         echo "!! Ошибка: Основной исходный файл '$source_file' не найден." >&2
@@ -268,9 +191,9 @@ build_go_application() {
          output_binary_path="./$output_binary_name"
     fi
 
-    echo "   Выполнение: go build -o \"$output_binary_path\" \"$source_file\""
+    echo "   Выполнение: go build -o \"$output_binary_path\" \"$source_package\""
     # Выполняем сборку
-    if go build -o "$output_binary_path" "$source_file"; then
+    if go build -o "$output_binary_path" "$source_package"; then
         echo "<< Сборка завершена успешно. Исполняемый файл: $output_binary_path"
     else
         echo "!! Ошибка при сборке Go приложения." >&2
@@ -291,12 +214,11 @@ set -u
 load_environment_variables
 validate_essential_variables
 setup_database # <-- Исправлена эта функция
-install_migration_tool
-apply_database_migrations
 download_go_modules
 build_go_application
 
 echo "--- Все этапы подготовки и сборки завершены успешно ---"
+echo "   Миграции базы данных применяются самим приложением при запуске (DB_MIGRATE_ON_START)."
 
 exit 0
 
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/IlyushinDM/user-order-api/internal/core"
+	"github.com/IlyushinDM/user-order-api/internal/models/user_model"
+	"github.com/IlyushinDM/user-order-api/internal/repository/database"
+	"github.com/IlyushinDM/user-order-api/internal/utils/config_util"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// usage - справка по командам, выводится при неизвестной команде или флаге -h
+const usage = `Использование: user-order-api <команда> [флаги]
+
+Команды:
+  serve                          Запустить HTTP сервер (команда по умолчанию)
+  migrate up                     Применить все не примененные миграции
+  migrate down [-to N]           Откатить миграции до версии N (по умолчанию - последнюю)
+  migrate status                 Показать состояние миграций
+  seed [флаги]                   Создать демонстрационные продукты, пользователей и заказы
+  create-user [флаги] [--admin]  Создать пользователя; пароль читается из stdin, если не задан флагом
+  create-admin [флаги]           То же, что create-user --admin
+
+Флаги команды можно посмотреть так: user-order-api <команда> -h
+`
+
+// errUsage возвращается при неверном вызове команды; справка к этому моменту уже выведена
+var errUsage = errors.New("неверные аргументы командной строки")
+
+// runCommand выполняет команду, заданную аргументами командной строки
+func runCommand(args []string, logger *logrus.Logger, cfg *config_util.Config) error {
+	if len(args) == 0 {
+		return serve(logger, cfg)
+	}
+
+	switch command, rest := args[0], args[1:]; command {
+	case "serve":
+		return serve(logger, cfg)
+	case "migrate":
+		return migrateCommand(rest, logger, cfg)
+	case "seed":
+		return seedCommand(rest, logger, cfg)
+	case "create-user":
+		return createUserCommand("create-user", rest, false, logger, cfg)
+	case "create-admin":
+		return createUserCommand("create-admin", rest, true, logger, cfg)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return nil
+	default:
+		fmt.Fprintf(os.Stderr, "Неизвестная команда %q\n\n%s", command, usage)
+		return errUsage
+	}
+}
+
+// serve инициализирует приложение и запускает HTTP сервер
+func serve(logger *logrus.Logger, cfg *config_util.Config) error {
+	app, err := core.NewApp(logger, cfg)
+	if err != nil {
+		return fmt.Errorf("ошибка инициализации приложения: %w", err)
+	}
+	return runApp(app)
+}
+
+// migrateCommand выполняет подкоманды migrate up|down|status
+func migrateCommand(args []string, logger *logrus.Logger, cfg *config_util.Config) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, "Укажите подкоманду migrate: up, down или status\n\n"+usage)
+		return errUsage
+	}
+
+	fs := flag.NewFlagSet("migrate "+args[0], flag.ContinueOnError)
+	to := fs.Int("to", -1, "версия, до которой откатить схему (только для down); 0 - откатить все миграции")
+	if err := fs.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+
+	db, err := database.InitDB(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer closeDB(db, logger)
+
+	migrator, err := database.NewEmbeddedMigrator(db, logger)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Применено миграций: %d, версия схемы: %d\n", applied, migrator.LatestVersion())
+		return nil
+	case "down":
+		target := uint(0)
+		if *to >= 0 {
+			target = uint(*to)
+		} else {
+			current, err := migrator.CurrentVersion(ctx)
+			if err != nil {
+				return err
+			}
+			if current > 0 {
+				target = previousVersion(migrator, current)
+			}
+		}
+		reverted, err := migrator.Down(ctx, target)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Откачено миграций: %d, версия схемы: %d\n", reverted, target)
+		return nil
+	case "status":
+		return printMigrationStatus(ctx, os.Stdout, migrator)
+	default:
+		fmt.Fprintf(os.Stderr, "Неизвестная подкоманда migrate %q\n\n%s", args[0], usage)
+		return errUsage
+	}
+}
+
+// previousVersion возвращает версию, предшествующую current среди известных миграций
+func previousVersion(migrator *database.Migrator, current uint) uint {
+	var previous uint
+	for _, migration := range migrator.Migrations() {
+		if migration.Version >= current {
+			break
+		}
+		previous = migration.Version
+	}
+	return previous
+}
+
+// printMigrationStatus выводит таблицу миграций с отметкой о применении
+func printMigrationStatus(ctx context.Context, out io.Writer, migrator *database.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ВЕРСИЯ\tМИГРАЦИЯ\tПРИМЕНЕНА")
+	for _, status := range statuses {
+		applied := "нет"
+		if status.Applied {
+			applied = status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%03d\t%s\t%s\n", status.Version, status.Name, applied)
+	}
+	return w.Flush()
+}
+
+// seedCommand наполняет базу демонстрационными данными
+func seedCommand(args []string, logger *logrus.Logger, cfg *config_util.Config) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	opts := core.SeedOptions{}
+	fs.IntVar(&opts.Users, "users", 10, "количество пользователей")
+	fs.IntVar(&opts.OrdersPerUser, "orders", 3, "количество заказов у каждого пользователя")
+	fs.IntVar(&opts.Products, "products", 5, "количество продуктов в каталоге")
+	fs.StringVar(&opts.Password, "password", "demo-password", "пароль демонстрационных пользователей")
+	fs.Uint64Var(&opts.RandSeed, "rand-seed", 1, "начальное значение генератора случайных данных")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+
+	services, closeFn, err := openServices(logger, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	result, err := core.Seed(context.Background(), services, opts, logger)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Создано продуктов: %d, пользователей: %d (уже существовало: %d), заказов: %d\n",
+		result.ProductsCreated, result.UsersCreated, result.UsersExisting, result.OrdersCreated)
+	return nil
+}
+
+// createUserCommand создает пользователя; с флагом --admin или командой create-admin - администратора
+func createUserCommand(name string, args []string, admin bool, logger *logrus.Logger, cfg *config_util.Config) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	req := user_model.CreateUserRequest{}
+	fs.StringVar(&req.Name, "name", "", "имя пользователя (обязательно)")
+	fs.StringVar(&req.Email, "email", "", "email пользователя (обязательно)")
+	fs.IntVar(&req.Age, "age", 18, "возраст пользователя")
+	fs.StringVar(&req.Password, "password", "", "пароль; если не задан, читается из первой строки stdin")
+	fs.BoolVar(&admin, "admin", admin, "назначить пользователю роль администратора")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if req.Name == "" || req.Email == "" {
+		fmt.Fprintf(os.Stderr, "Флаги -name и -email обязательны\n")
+		fs.PrintDefaults()
+		return errUsage
+	}
+
+	if req.Password == "" {
+		password, err := readPassword(os.Stdin)
+		if err != nil {
+			return err
+		}
+		req.Password = password
+	}
+
+	role := user_model.RoleUser
+	if admin {
+		role = user_model.RoleAdmin
+	}
+
+	services, closeFn, err := openServices(logger, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	user, err := core.CreateUser(context.Background(), services, req, role)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Создан пользователь ID=%d email=%s роль=%s\n", user.ID, user.Email, user.Role)
+	return nil
+}
+
+// readPassword читает пароль из первой строки r
+func readPassword(r io.Reader) (string, error) {
+	fmt.Fprint(os.Stderr, "Пароль: ")
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("не удалось прочитать пароль: %w", err)
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("пароль не задан")
+	}
+	return password, nil
+}
+
+// openServices подключается к базе данных, проверяет схему и создает сервисы.
+// Схема не мигрируется автоматически: команды CLI работают только с актуальной схемой.
+func openServices(logger *logrus.Logger, cfg *config_util.Config) (*core.Services, func(), error) {
+	db, err := database.InitDB(cfg, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := database.CheckSchema(db, logger); err != nil {
+		closeDB(db, logger)
+		return nil, nil, fmt.Errorf("%w; выполните 'migrate up'", err)
+	}
+	return core.NewServices(db, cfg, logger), func() { closeDB(db, logger) }, nil
+}
+
+// closeDB закрывает пул соединений с базой данных
+func closeDB(db *gorm.DB, logger *logrus.Logger) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		logger.WithError(err).Warn("Не удалось получить *sql.DB для закрытия соединений")
+		return
+	}
+	if err := sqlDB.Close(); err != nil {
+		logger.WithError(err).Warn("Ошибка при закрытии соединений с базой данных")
+	}
+}
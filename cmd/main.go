@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -13,10 +14,17 @@ import (
 	"github.com/IlyushinDM/user-order-api/internal/core"
 	"github.com/IlyushinDM/user-order-api/internal/utils/config_util"
 	"github.com/IlyushinDM/user-order-api/internal/utils/logger_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/tracing_util"
 
 	_ "github.com/IlyushinDM/user-order-api/docs"
 )
 
+// appVersion - версия сервиса в ресурсе трассировки; совпадает с версией API в документации
+const appVersion = "1.0"
+
+// tracingShutdownTimeout ограничивает отправку оставшихся спанов при завершении работы
+const tracingShutdownTimeout = 5 * time.Second
+
 // @title User Order API
 // @version 1.0
 // @description Сервер для управления пользователями и их заказами.
@@ -53,18 +61,34 @@ func main() {
 		logger.Fatalf("Ошибка загрузки конфигурации: %v", err)
 	}
 
-	// 3. Инициализация приложения с передачей логгера и конфигурации.
-	app, err := core.NewApp(logger, cfg)
+	// 3. Настройка трассировки OpenTelemetry. Перед выходом накопленные спаны отправляются экспортеру.
+	shutdownTracing, err := tracing_util.Setup(context.Background(), tracing_util.Options{
+		Exporter:     cfg.TracingExporter,
+		OTLPEndpoint: cfg.TracingOTLPEndpoint,
+		FilePath:     cfg.TracingFilePath,
+		SampleRatio:  cfg.TracingSampleRatio,
+		Version:      appVersion,
+	}, logger)
 	if err != nil {
-		logger.Fatalf("Ошибка инициализации приложения: %v", err)
+		logger.Fatalf("Ошибка настройки трассировки: %v", err)
 	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.WithError(err).Error("Ошибка при отправке оставшихся спанов трассировки")
+		}
+	}()
 
-	// 4. Запуск и управление жизненным циклом сервера.
-	if err := runApp(app); err != nil {
-		logger.Fatalf("Ошибка во время выполнения приложения: %v", err)
+	// 4. Выполнение команды; без аргументов запускается HTTP сервер.
+	if err := runCommand(os.Args[1:], logger, cfg); err != nil {
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		logger.Fatalf("Ошибка выполнения команды: %v", err)
 	}
 
-	app.Logger.Info("Приложение завершило работу.")
+	logger.Info("Приложение завершило работу.")
 }
 
 // runApp настраивает маршрутизатор, запускает HTTP сервер и обрабатывает graceful shutdown
@@ -115,6 +139,18 @@ func runApp(app *core.App) error {
 	case <-quit:
 		app.Logger.Info("Получен сигнал остановки, запускается graceful shutdown...")
 
+		// Сначала /readyz начинает отвечать 503, и балансировщик перестает направлять новые запросы.
+		// Сервер продолжает обслуживать запросы, пока не истечет пауза SHUTDOWN_DRAIN_DELAY.
+		if app.HealthHandler != nil {
+			app.HealthHandler.StartDraining()
+			app.Logger.Infof("Проверка готовности переведена в состояние fail, ожидание %s перед остановкой сервера", app.Config.ShutdownDrainDelay)
+			select {
+			case <-time.After(app.Config.ShutdownDrainDelay):
+			case <-quit:
+				app.Logger.Warn("Получен повторный сигнал остановки, ожидание прервано")
+			}
+		}
+
 		// Создаем контекст с таймаутом, используя сконфигурированное значение.
 		ctx, cancel := context.WithTimeout(context.Background(), app.Config.ShutdownTimeout)
 		defer cancel()
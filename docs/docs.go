@@ -24,21 +24,21 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/users": {
+        "/api/products": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Получение списка пользователей с пагинацией и фильтрацией. Требуется аутентификация.",
+                "description": "Возвращает каталог продуктов с пагинацией и поиском по названию и описанию",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Пользователи"
+                    "Продукты"
                 ],
-                "summary": "Получение всех пользователей",
+                "summary": "Список продуктов",
                 "parameters": [
                     {
                         "minimum": 1,
@@ -57,56 +57,53 @@ const docTemplate = `{
                         "name": "limit",
                         "in": "query"
                     },
-                    {
-                        "minimum": 1,
-                        "type": "integer",
-                        "description": "Минимальный возраст для фильтрации",
-                        "name": "min_age",
-                        "in": "query"
-                    },
-                    {
-                        "minimum": 1,
-                        "type": "integer",
-                        "description": "Максимальный возраст для фильтрации",
-                        "name": "max_age",
-                        "in": "query"
-                    },
                     {
                         "type": "string",
-                        "description": "Фильтр по имени (без учета регистра, частичное совпадение)",
-                        "name": "name",
+                        "description": "Поиск по названию и описанию (без учета регистра, частичное совпадение)",
+                        "name": "q",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Список пользователей",
+                        "description": "Список продуктов",
                         "schema": {
-                            "$ref": "#/definitions/user_model.PaginatedUsersResponse"
+                            "$ref": "#/definitions/product_model.PaginatedProductsResponse"
                         }
                     },
                     "400": {
                         "description": "Неверные параметры запроса",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "401": {
-                        "description": "Неавторизован",
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Регистрация нового пользователя с именем, email, возрастом и паролем.",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Добавляет продукт в каталог. Доступно только администратору",
                 "consumes": [
                     "application/json"
                 ],
@@ -114,68 +111,80 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Пользователи"
+                    "Продукты"
                 ],
-                "summary": "Создание нового пользователя",
+                "summary": "Создание продукта",
                 "parameters": [
                     {
-                        "description": "Данные пользователя",
-                        "name": "user",
+                        "description": "Данные продукта",
+                        "name": "product",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/user_model.CreateUserRequest"
+                            "$ref": "#/definitions/product_model.CreateProductRequest"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Пользователь успешно создан",
+                        "description": "Продукт успешно создан",
                         "schema": {
-                            "$ref": "#/definitions/user_model.UserResponse"
+                            "$ref": "#/definitions/product_model.ProductResponse"
                         }
                     },
                     "400": {
                         "description": "Некорректные входные данные",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
-                    "409": {
-                        "description": "Пользователь с таким email уже существует",
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/users/{id}": {
+        "/api/products/{id}": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Получение информации о конкретном пользователе по его ID. Требуется аутентификация.",
+                "description": "Возвращает продукт каталога. Доступно любому аутентифицированному пользователю",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Пользователи"
+                    "Продукты"
                 ],
-                "summary": "Получение пользователя по ID",
+                "summary": "Получение продукта по ID",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "uint",
-                        "description": "ID пользователя",
+                        "description": "ID продукта",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -183,33 +192,39 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Информация о пользователе",
+                        "description": "Продукт",
                         "schema": {
-                            "$ref": "#/definitions/user_model.UserResponse"
+                            "$ref": "#/definitions/product_model.ProductResponse"
                         }
                     },
                     "400": {
-                        "description": "Неверный формат ID пользователя",
+                        "description": "Некорректный ID продукта",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "401": {
-                        "description": "Неавторизован",
+                        "description": "Не авторизован",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "404": {
-                        "description": "Пользователь не найден",
+                        "description": "Продукт не найден",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     }
                 }
@@ -220,7 +235,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Обновление информации о существующем пользователе по ID. Требуется аутентификация. Пользователь может обновлять только свои данные, если он не является администратором (логика администратора здесь не реализована).",
+                "description": "Изменяет название, описание, цену или свободный остаток продукта. Доступно только администратору. Цены в уже оформленных заказах не меняются",
                 "consumes": [
                     "application/json"
                 ],
@@ -228,69 +243,69 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Пользователи"
+                    "Продукты"
                 ],
-                "summary": "Обновление пользователя",
+                "summary": "Обновление продукта",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "uint",
-                        "description": "ID пользователя",
+                        "description": "ID продукта",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Данные пользователя для обновления",
-                        "name": "user",
+                        "description": "Данные для обновления",
+                        "name": "product",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/user_model.UpdateUserRequest"
+                            "$ref": "#/definitions/product_model.UpdateProductRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Пользователь успешно обновлен",
+                        "description": "Обновленный продукт",
                         "schema": {
-                            "$ref": "#/definitions/user_model.UserResponse"
+                            "$ref": "#/definitions/product_model.ProductResponse"
                         }
                     },
                     "400": {
-                        "description": "Некорректные входные данные или неверный формат ID пользователя",
+                        "description": "Некорректные входные данные",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "401": {
-                        "description": "Неавторизован",
+                        "description": "Не авторизован",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "403": {
-                        "description": "Запрещено (попытка обновить другого пользователя - упрощенная проверка)",
+                        "description": "Недостаточно прав",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "404": {
-                        "description": "Пользователь не найден",
+                        "description": "Продукт не найден",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
-                    "409": {
-                        "description": "Email уже используется другим пользователем",
+                    "429": {
+                        "description": "Превышен лимит запросов",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     }
                 }
@@ -301,19 +316,16 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Удаление пользователя по его ID. Требуется аутентификация. Пользователь может удалить только свою учетную запись, если он не является администратором (логика администратора здесь не реализована).",
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Удаляет продукт из каталога. Доступно только администратору. Позиции оформленных заказов сохраняются",
                 "tags": [
-                    "Пользователи"
+                    "Продукты"
                 ],
-                "summary": "Удаление пользователя",
+                "summary": "Удаление продукта",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "uint",
-                        "description": "ID пользователя",
+                        "description": "ID продукта",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -321,65 +333,63 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "204": {
-                        "description": "Пользователь успешно удален"
+                        "description": "Продукт успешно удален"
                     },
                     "400": {
-                        "description": "Неверный формат ID пользователя",
+                        "description": "Некорректный ID продукта",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "401": {
-                        "description": "Неавторизован",
+                        "description": "Не авторизован",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "403": {
-                        "description": "Запрещено (попытка удалить другого пользователя)",
+                        "description": "Недостаточно прав",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "404": {
-                        "description": "Пользователь не найден",
+                        "description": "Продукт не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/users/{id}/orders": {
+        "/api/users": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает список заказов пользователя с пагинацией",
+                "description": "Получение списка пользователей с пагинацией и фильтрацией. Требуется аутентификация. Поддержка и администратор получают всех пользователей, обычный пользователь - только себя.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Заказы"
+                    "Пользователи"
                 ],
-                "summary": "Получение всех заказов пользователя",
+                "summary": "Получение всех пользователей",
                 "parameters": [
-                    {
-                        "type": "integer",
-                        "format": "uint",
-                        "description": "ID пользователя",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
                     {
                         "minimum": 1,
                         "type": "integer",
@@ -396,48 +406,63 @@ const docTemplate = `{
                         "description": "Количество элементов на странице",
                         "name": "limit",
                         "in": "query"
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Минимальный возраст для фильтрации",
+                        "name": "min_age",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Максимальный возраст для фильтрации",
+                        "name": "max_age",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фильтр по имени (без учета регистра, частичное совпадение)",
+                        "name": "name",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Список заказов",
+                        "description": "Список пользователей",
                         "schema": {
-                            "$ref": "#/definitions/order_model.PaginatedOrdersResponse"
+                            "$ref": "#/definitions/user_model.PaginatedUsersResponse"
                         }
                     },
                     "400": {
-                        "description": "Некорректные параметры",
+                        "description": "Неверные параметры запроса",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "401": {
-                        "description": "Не авторизован",
+                        "description": "Неавторизован",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
-                    "403": {
-                        "description": "Доступ запрещен",
+                    "429": {
+                        "description": "Превышен лимит запросов",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     }
                 }
             },
             "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Создает новый заказ для аутентифицированного пользователя",
+                "description": "Регистрация нового пользователя с именем, email, возрастом и паролем.",
                 "consumes": [
                     "application/json"
                 ],
@@ -445,77 +470,69 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Заказы"
+                    "Пользователи"
                 ],
-                "summary": "Создание нового заказа",
+                "summary": "Создание нового пользователя",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "format": "uint",
-                        "description": "ID пользователя",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Данные заказа",
-                        "name": "order",
+                        "description": "Данные пользователя",
+                        "name": "user",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/order_model.CreateOrderRequest"
+                            "$ref": "#/definitions/user_model.CreateUserRequest"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Заказ успешно создан",
+                        "description": "Пользователь успешно создан",
                         "schema": {
-                            "$ref": "#/definitions/order_model.OrderResponse"
+                            "$ref": "#/definitions/user_model.UserResponse"
                         }
                     },
                     "400": {
                         "description": "Некорректные входные данные",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
-                    "401": {
-                        "description": "Не авторизован",
+                    "409": {
+                        "description": "Пользователь с таким email уже существует",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
-                    "403": {
-                        "description": "Доступ запрещен",
+                    "429": {
+                        "description": "Превышен лимит запросов",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/users/{id}/orders/{orderID}": {
+        "/api/users/{id}": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает информацию о конкретном заказе пользователя",
+                "description": "Получение информации о конкретном пользователе по его ID. Требуется аутентификация. Обычный пользователь может получить только свой профиль, поддержка и администратор - любой.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Заказы"
+                    "Пользователи"
                 ],
-                "summary": "Получение заказа по ID",
+                "summary": "Получение пользователя по ID",
                 "parameters": [
                     {
                         "type": "integer",
@@ -524,51 +541,55 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "format": "uint",
-                        "description": "ID заказа",
-                        "name": "orderID",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Информация о заказе",
+                        "description": "Информация о пользователе",
                         "schema": {
-                            "$ref": "#/definitions/order_model.OrderResponse"
+                            "$ref": "#/definitions/user_model.UserResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия пользователя для заголовка If-Match"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Некорректный формат ID",
+                        "description": "Неверный формат ID пользователя",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "401": {
-                        "description": "Не авторизован",
+                        "description": "Неавторизован",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "403": {
-                        "description": "Доступ запрещен",
+                        "description": "Недостаточно прав для просмотра пользователя",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "404": {
-                        "description": "Заказ не найден",
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     }
                 }
@@ -579,7 +600,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Обновляет информацию о заказе пользователя",
+                "description": "Обновление информации о существующем пользователе по ID. Требуется аутентификация. Обычный пользователь может обновлять только свои данные, поддержка и администратор - данные любого пользователя. Изменять роль может только администратор. Требует заголовок If-Match с ETag, полученным при чтении пользователя.",
                 "consumes": [
                     "application/json"
                 ],
@@ -587,9 +608,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Заказы"
+                    "Пользователи"
                 ],
-                "summary": "Обновление заказа",
+                "summary": "Обновление пользователя",
                 "parameters": [
                     {
                         "type": "integer",
@@ -600,58 +621,87 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "format": "uint",
-                        "description": "ID заказа",
-                        "name": "orderID",
-                        "in": "path",
+                        "type": "string",
+                        "description": "ETag пользователя, полученный при чтении",
+                        "name": "If-Match",
+                        "in": "header",
                         "required": true
                     },
                     {
-                        "description": "Данные для обновления",
-                        "name": "order",
+                        "description": "Данные пользователя для обновления",
+                        "name": "user",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/order_model.UpdateOrderRequest"
+                            "$ref": "#/definitions/user_model.UpdateUserRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Обновленный заказ",
+                        "description": "Пользователь успешно обновлен",
                         "schema": {
-                            "$ref": "#/definitions/order_model.OrderResponse"
+                            "$ref": "#/definitions/user_model.UserResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия пользователя"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Некорректные данные",
+                        "description": "Некорректные входные данные или неверный формат ID пользователя",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "401": {
-                        "description": "Не авторизован",
+                        "description": "Неавторизован",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "403": {
-                        "description": "Доступ запрещен",
+                        "description": "Недостаточно прав для обновления пользователя или изменения роли",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "404": {
-                        "description": "Заказ не найден",
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Email уже используется другим пользователем",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Пользователь изменен после чтения: ETag не совпадает",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Не передан заголовок If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     }
                 }
@@ -662,14 +712,14 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Удаляет заказ пользователя по ID",
+                "description": "Удаление пользователя по его ID. Требуется аутентификация. Обычный пользователь может удалить только свою учетную запись, администратор - любую.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Заказы"
+                    "Пользователи"
                 ],
-                "summary": "Удаление заказа",
+                "summary": "Удаление пользователя",
                 "parameters": [
                     {
                         "type": "integer",
@@ -678,48 +728,791 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "format": "uint",
-                        "description": "ID заказа",
-                        "name": "orderID",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "204": {
-                        "description": "Заказ удален"
+                        "description": "Пользователь успешно удален"
                     },
                     "400": {
-                        "description": "Некорректный формат ID",
+                        "description": "Неверный формат ID пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизован",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Запрещено (попытка удалить другого пользователя)",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{id}/logins": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Получение последних попыток входа в учетную запись пользователя от новых к старым: время, IP-адрес, User-Agent и исход. Требуется аутентификация. Обычный пользователь может получить только свою историю, поддержка и администратор - любую.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователи"
+                ],
+                "summary": "История входов пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "uint",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "История входов",
+                        "schema": {
+                            "$ref": "#/definitions/login_attempt_model.PaginatedLoginAttemptsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат ID пользователя или параметров пагинации",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизован",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав для просмотра истории входов",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{id}/orders": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает список заказов пользователя с пагинацией. Поддержка и администратор имеют доступ к заказам любого пользователя",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Заказы"
+                ],
+                "summary": "Получение всех заказов пользователя",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "uint",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "confirmed",
+                            "paid",
+                            "shipped",
+                            "delivered",
+                            "cancelled",
+                            "refunded"
+                        ],
+                        "type": "string",
+                        "description": "Фильтр по состоянию заказа",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список заказов",
+                        "schema": {
+                            "$ref": "#/definitions/order_model.PaginatedOrdersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Доступ запрещен",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создает новый заказ из одной или нескольких позиций. Позиции ссылаются на продукты каталога по ID, цены берутся из каталога, итоговая сумма вычисляется сервером. Количество резервируется на складе; при нехватке товара заказ не создается. Обычный пользователь может создавать заказы только для себя, администратор - для любого пользователя",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Заказы"
+                ],
+                "summary": "Создание нового заказа",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "uint",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности: повтор запроса с тем же ключом возвращает сохраненный ответ",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Данные заказа",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/order_model.CreateOrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Заказ успешно создан",
+                        "schema": {
+                            "$ref": "#/definitions/order_model.OrderResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Доступ запрещен",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Недостаточно товара на складе или запрос с тем же ключом идемпотентности еще выполняется",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Продукт не найден в каталоге, его валюта не совпадает с валютой заказа или ключ идемпотентности использован для другого запроса",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{id}/orders/{orderID}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает информацию о конкретном заказе пользователя. Поддержка и администратор имеют доступ к заказам любого пользователя",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Заказы"
+                ],
+                "summary": "Получение заказа по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "uint",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "uint",
+                        "description": "ID заказа",
+                        "name": "orderID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Информация о заказе",
+                        "schema": {
+                            "$ref": "#/definitions/order_model.OrderResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия заказа для заголовка If-Match"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный формат ID",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Доступ запрещен",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Заказ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Заменяет состав заказа пользователя переданным списком позиций. Цены берутся из каталога продуктов. Требует заголовок If-Match с ETag, полученным при чтении заказа. Доступно только для заказов в состоянии pending. Поддержка и администратор могут исправлять заказы любого пользователя",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Заказы"
+                ],
+                "summary": "Обновление заказа",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "uint",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "uint",
+                        "description": "ID заказа",
+                        "name": "orderID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag заказа, полученный при чтении",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные для обновления",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/order_model.UpdateOrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Обновленный заказ",
+                        "schema": {
+                            "$ref": "#/definitions/order_model.OrderResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Новая версия заказа"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Доступ запрещен",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Заказ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Заказ в текущем состоянии нельзя изменить или недостаточно товара на складе",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Заказ изменен после чтения: ETag не совпадает",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Продукт не найден в каталоге или его валюта не совпадает с валютой заказа",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Не передан заголовок If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет заказ пользователя по ID. Удалять чужие заказы может только администратор. Резерв товара неотправленного заказа возвращается на склад",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Заказы"
+                ],
+                "summary": "Удаление заказа",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "uint",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "uint",
+                        "description": "ID заказа",
+                        "name": "orderID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Заказ удален"
+                    },
+                    "400": {
+                        "description": "Некорректный формат ID",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Доступ запрещен",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Заказ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{id}/orders/{orderID}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Отменяет заказ, который еще не был оплачен. Оплаченный заказ отменить нельзя - для него оформляется возврат. Резерв товара возвращается на склад",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Заказы"
+                ],
+                "summary": "Отмена заказа",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "uint",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "uint",
+                        "description": "ID заказа",
+                        "name": "orderID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Отмененный заказ",
+                        "schema": {
+                            "$ref": "#/definitions/order_model.OrderResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный формат ID",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Доступ запрещен",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Заказ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Заказ в текущем состоянии нельзя отменить",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{id}/orders/{orderID}/status": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Переводит заказ в указанное состояние, если переход допустим (pending -\u003e confirmed -\u003e paid -\u003e shipped -\u003e delivered; отмена до оплаты; возврат после оплаты). Доступно поддержке и администратору",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Заказы"
+                ],
+                "summary": "Смена состояния заказа",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "uint",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "uint",
+                        "description": "ID заказа",
+                        "name": "orderID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Целевое состояние",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/order_model.UpdateOrderStatusRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Заказ в новом состоянии",
+                        "schema": {
+                            "$ref": "#/definitions/order_model.OrderResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "401": {
                         "description": "Не авторизован",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "403": {
                         "description": "Доступ запрещен",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "404": {
                         "description": "Заказ не найден",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Недопустимая смена состояния",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/problem_util.Problem"
                         }
                     }
                 }
@@ -727,7 +1520,7 @@ const docTemplate = `{
         },
         "/auth/login": {
             "post": {
-                "description": "Аутентификация пользователя с использованием email и пароля, возвращает JWT токен.",
+                "description": "Аутентификация пользователя с использованием email и пароля. Возвращает короткоживущий access токен (JWT) и одноразовый refresh токен. Каждая попытка записывается в историю входов. После неудачной попытки следующая возможна только через растущую задержку (429), а серия неудач временно блокирует вход (423).",
                 "consumes": [
                     "application/json"
                 ],
@@ -751,27 +1544,215 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Вход выполнен успешно, включает JWT токен",
-                        "schema": {
-                            "$ref": "#/definitions/user_model.LoginResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Некорректные входные данные",
-                        "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
-                        }
-                    },
-                    "401": {
-                        "description": "Неверные учетные данные",
+                        "description": "Вход выполнен успешно, включает пару токенов",
+                        "schema": {
+                            "$ref": "#/definitions/user_model.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверные учетные данные",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "423": {
+                        "description": "Вход временно заблокирован после неудачных попыток",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        },
+                        "headers": {
+                            "Retry-After": {
+                                "type": "integer",
+                                "description": "Через сколько секунд можно повторить вход"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов или не истекла задержка после неудачной попытки",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        },
+                        "headers": {
+                            "Retry-After": {
+                                "type": "integer",
+                                "description": "Через сколько секунд можно повторить запрос"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/logout": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Завершает сессию: отзывает семейство refresh токенов и текущий access токен. Требуется аутентификация.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Аутентификация"
+                ],
+                "summary": "Выход пользователя",
+                "parameters": [
+                    {
+                        "description": "Refresh токен текущей сессии",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user_model.LogoutRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Сессия завершена"
+                    },
+                    "400": {
+                        "description": "Некорректные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Неавторизован или refresh токен принадлежит другому пользователю",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "description": "Обменивает refresh токен на новую пару токенов. Предъявленный refresh токен становится недействительным; его повторное использование отзывает всю сессию.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Аутентификация"
+                ],
+                "summary": "Обновление пары токенов",
+                "parameters": [
+                    {
+                        "description": "Refresh токен",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user_model.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Новая пара токенов",
+                        "schema": {
+                            "$ref": "#/definitions/user_model.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Refresh токен недействителен, просрочен или уже использован",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/problem_util.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Отвечает 200, пока процесс способен обрабатывать запросы. Зависимости не проверяются.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Проверка живости",
+                "responses": {
+                    "200": {
+                        "description": "Процесс жив",
+                        "schema": {
+                            "$ref": "#/definitions/health_model.LivenessResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Проверяет доступность базы данных и применение всех миграций. Во время остановки сервера отвечает 503.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Проверка готовности",
+                "responses": {
+                    "200": {
+                        "description": "Приложение готово принимать запросы",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/health_model.ReadinessResponse"
                         }
                     },
-                    "500": {
-                        "description": "Внутренняя ошибка сервера",
+                    "503": {
+                        "description": "Приложение не готово; причины в checks",
                         "schema": {
-                            "$ref": "#/definitions/common_handler.ErrorResponse"
+                            "$ref": "#/definitions/health_model.ReadinessResponse"
                         }
                     }
                 }
@@ -779,32 +1760,140 @@ const docTemplate = `{
         }
     },
     "definitions": {
-        "common_handler.ErrorResponse": {
+        "health_model.CheckResult": {
             "type": "object",
             "properties": {
-                "details": {
-                    "type": "string"
+                "duration_ms": {
+                    "description": "Длительность проверки в миллисекундах",
+                    "type": "integer",
+                    "example": 3
                 },
                 "error": {
+                    "description": "Причина неуспешной проверки",
+                    "type": "string"
+                },
+                "pending": {
+                    "description": "Не примененные миграции (только для migrations)",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        7,
+                        8
+                    ]
+                },
+                "status": {
+                    "description": "ok или fail",
+                    "type": "string",
+                    "example": "ok"
+                },
+                "version": {
+                    "description": "Примененная версия схемы (только для migrations)",
+                    "type": "integer",
+                    "example": 6
+                }
+            }
+        },
+        "health_model.LivenessResponse": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "description": "Всегда ok, если процесс отвечает на запросы",
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "health_model.ReadinessResponse": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "description": "Результаты проверок по имени: shutdown, database, migrations",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/health_model.CheckResult"
+                    }
+                },
+                "status": {
+                    "description": "ok, если все проверки успешны, иначе fail",
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "login_attempt_model.LoginAttemptResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "outcome": {
                     "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
+            }
+        },
+        "login_attempt_model.PaginatedLoginAttemptsResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "logins": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/login_attempt_model.LoginAttemptResponse"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
                 }
             }
         },
         "order_model.CreateOrderRequest": {
             "type": "object",
             "required": [
-                "price",
-                "product_name",
-                "quantity"
+                "currency",
+                "items"
             ],
             "properties": {
-                "price": {
-                    "description": "Цена за единицу (положительное число)",
-                    "type": "number"
+                "currency": {
+                    "description": "Валюта заказа; должна совпадать с валютой продуктов",
+                    "type": "string",
+                    "example": "RUB"
                 },
-                "product_name": {
-                    "description": "Название продукта (обязательно)",
-                    "type": "string"
+                "items": {
+                    "description": "Позиции заказа (хотя бы одна)",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/order_model.OrderItemRequest"
+                    }
+                }
+            }
+        },
+        "order_model.OrderItemRequest": {
+            "type": "object",
+            "required": [
+                "product_id",
+                "quantity"
+            ],
+            "properties": {
+                "product_id": {
+                    "description": "ID продукта из каталога (обязательно)",
+                    "type": "integer"
                 },
                 "quantity": {
                     "description": "Количество (положительное число)",
@@ -812,14 +1901,19 @@ const docTemplate = `{
                 }
             }
         },
-        "order_model.OrderResponse": {
+        "order_model.OrderItemResponse": {
             "type": "object",
             "properties": {
                 "id": {
                     "type": "integer"
                 },
-                "price": {
-                    "type": "number"
+                "line_total": {
+                    "description": "Стоимость позиции, вычисленная сервером",
+                    "type": "string",
+                    "example": "399.80"
+                },
+                "product_id": {
+                    "type": "integer"
                 },
                 "product_name": {
                     "type": "string"
@@ -827,11 +1921,71 @@ const docTemplate = `{
                 "quantity": {
                     "type": "integer"
                 },
+                "unit_price": {
+                    "type": "string",
+                    "example": "199.90"
+                }
+            }
+        },
+        "order_model.OrderResponse": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/order_model.OrderItemResponse"
+                    }
+                },
+                "status": {
+                    "$ref": "#/definitions/order_model.OrderStatus"
+                },
+                "total": {
+                    "description": "Итоговая сумма заказа, вычисленная сервером",
+                    "type": "string",
+                    "example": "399.80"
+                },
                 "user_id": {
                     "type": "integer"
                 }
             }
         },
+        "order_model.OrderStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "confirmed",
+                "paid",
+                "shipped",
+                "delivered",
+                "cancelled",
+                "refunded"
+            ],
+            "x-enum-comments": {
+                "StatusCancelled": "Отменен до оплаты (конечное состояние)",
+                "StatusConfirmed": "Подтвержден, ожидает оплаты",
+                "StatusDelivered": "Доставлен покупателю",
+                "StatusPaid": "Оплачен, ожидает отправки",
+                "StatusPending": "Создан, ожидает подтверждения",
+                "StatusRefunded": "Деньги возвращены покупателю (конечное состояние)",
+                "StatusShipped": "Передан в доставку"
+            },
+            "x-enum-varnames": [
+                "StatusPending",
+                "StatusConfirmed",
+                "StatusPaid",
+                "StatusShipped",
+                "StatusDelivered",
+                "StatusCancelled",
+                "StatusRefunded"
+            ]
+        },
         "order_model.PaginatedOrdersResponse": {
             "type": "object",
             "properties": {
@@ -859,20 +2013,216 @@ const docTemplate = `{
         "order_model.UpdateOrderRequest": {
             "type": "object",
             "properties": {
+                "currency": {
+                    "description": "Новая валюта заказа (опционально, только вместе с позициями)",
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "items": {
+                    "description": "Новый состав заказа (опционально)",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/order_model.OrderItemRequest"
+                    }
+                }
+            }
+        },
+        "order_model.UpdateOrderStatusRequest": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "status": {
+                    "description": "Целевое состояние",
+                    "enum": [
+                        "pending",
+                        "confirmed",
+                        "paid",
+                        "shipped",
+                        "delivered",
+                        "cancelled",
+                        "refunded"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/order_model.OrderStatus"
+                        }
+                    ]
+                }
+            }
+        },
+        "problem_util.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "description": "Имя поля в JSON или параметра запроса",
+                    "type": "string",
+                    "example": "items[0].quantity"
+                },
+                "message": {
+                    "description": "Описание на языке ответа",
+                    "type": "string",
+                    "example": "поле items[0].quantity должно быть больше 0"
+                },
+                "rule": {
+                    "description": "Стабильное имя нарушенного правила",
+                    "type": "string",
+                    "example": "gt"
+                }
+            }
+        },
+        "problem_util.Problem": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Стабильный машиночитаемый код ошибки",
+                    "type": "string",
+                    "example": "order_not_found"
+                },
+                "detail": {
+                    "description": "Подробности конкретного случая",
+                    "type": "string"
+                },
+                "errors": {
+                    "description": "Нарушения правил валидации отдельных полей; заполняется только для validation_failed",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/problem_util.FieldError"
+                    }
+                },
+                "instance": {
+                    "description": "Путь запроса, вызвавшего ошибку",
+                    "type": "string",
+                    "example": "/api/users/1/orders/7"
+                },
+                "status": {
+                    "description": "HTTP статус ответа",
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "description": "Краткое описание класса ошибки",
+                    "type": "string",
+                    "example": "Заказ не найден"
+                },
+                "type": {
+                    "description": "URI типа ошибки",
+                    "type": "string",
+                    "example": "urn:user-order-api:problem:order_not_found"
+                }
+            }
+        },
+        "product_model.CreateProductRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "price"
+            ],
+            "properties": {
+                "currency": {
+                    "description": "Код валюты ISO 4217 (по умолчанию RUB)",
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "description": {
+                    "description": "Описание продукта (опционально)",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Название продукта (обязательно)",
+                    "type": "string"
+                },
                 "price": {
-                    "description": "Новая цена (опционально)",
-                    "type": "number"
+                    "description": "Цена за единицу (положительная, строкой)",
+                    "type": "string",
+                    "example": "199.90"
                 },
-                "product_name": {
-                    "description": "Новое название продукта",
+                "stock": {
+                    "description": "Начальный остаток на складе (по умолчанию 0)",
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "product_model.PaginatedProductsResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "description": "Количество элементов на странице",
+                    "type": "integer"
+                },
+                "page": {
+                    "description": "Текущая страница",
+                    "type": "integer"
+                },
+                "products": {
+                    "description": "Список продуктов",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/product_model.ProductResponse"
+                    }
+                },
+                "total": {
+                    "description": "Общее количество продуктов",
+                    "type": "integer"
+                }
+            }
+        },
+        "product_model.ProductResponse": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "description": {
                     "type": "string"
                 },
-                "quantity": {
-                    "description": "Новое количество (опционально)",
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "string",
+                    "example": "199.90"
+                },
+                "stock": {
                     "type": "integer"
                 }
             }
         },
+        "product_model.UpdateProductRequest": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "description": "Новая валюта (опционально)",
+                    "type": "string",
+                    "example": "RUB"
+                },
+                "description": {
+                    "description": "Новое описание (опционально)",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Новое название (опционально)",
+                    "type": "string"
+                },
+                "price": {
+                    "description": "Новая цена (опционально)",
+                    "type": "string",
+                    "example": "199.90"
+                },
+                "stock": {
+                    "description": "Новый свободный остаток (опционально)",
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
         "user_model.CreateUserRequest": {
             "type": "object",
             "required": [
@@ -915,7 +2265,31 @@ const docTemplate = `{
         "user_model.LoginResponse": {
             "type": "object",
             "properties": {
+                "expires_in": {
+                    "description": "Время жизни access токена в секундах",
+                    "type": "integer"
+                },
+                "refresh_token": {
+                    "description": "Одноразовый refresh токен для получения новой пары",
+                    "type": "string"
+                },
                 "token": {
+                    "description": "Короткоживущий access токен (JWT)",
+                    "type": "string"
+                },
+                "token_type": {
+                    "description": "Тип токена для заголовка Authorization",
+                    "type": "string"
+                }
+            }
+        },
+        "user_model.LogoutRequest": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
                     "type": "string"
                 }
             }
@@ -940,6 +2314,17 @@ const docTemplate = `{
                 }
             }
         },
+        "user_model.RefreshRequest": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
         "user_model.UpdateUserRequest": {
             "type": "object",
             "properties": {
@@ -951,6 +2336,15 @@ const docTemplate = `{
                 },
                 "name": {
                     "type": "string"
+                },
+                "role": {
+                    "description": "Изменять роль может только администратор",
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "support",
+                        "admin"
+                    ]
                 }
             }
         },
@@ -968,6 +2362,9 @@ const docTemplate = `{
                 },
                 "name": {
                     "type": "string"
+                },
+                "role": {
+                    "type": "string"
                 }
             }
         }
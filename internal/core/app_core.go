@@ -2,15 +2,15 @@ package core
 
 import (
 	"fmt"
-	"time"
 
 	"github.com/IlyushinDM/user-order-api/internal/handlers/common_handler"
+	"github.com/IlyushinDM/user-order-api/internal/handlers/health_handler"
 	"github.com/IlyushinDM/user-order-api/internal/handlers/order_handler"
+	"github.com/IlyushinDM/user-order-api/internal/handlers/product_handler"
 	"github.com/IlyushinDM/user-order-api/internal/handlers/user_handler"
 	"github.com/IlyushinDM/user-order-api/internal/repository/database"
-	"github.com/IlyushinDM/user-order-api/internal/repository/order_rep"
-	"github.com/IlyushinDM/user-order-api/internal/repository/user_rep"
-	"github.com/IlyushinDM/user-order-api/internal/services/order_service"
+	"github.com/IlyushinDM/user-order-api/internal/repository/idempotency_rep"
+	"github.com/IlyushinDM/user-order-api/internal/repository/rate_limit_rep"
 	"github.com/IlyushinDM/user-order-api/internal/services/user_service"
 	"github.com/IlyushinDM/user-order-api/internal/utils/config_util"
 	"github.com/gin-gonic/gin"
@@ -28,24 +28,36 @@ func NewAppWithInitDB(logger *logrus.Logger, config *config_util.Config, initDB
 	commonHandler := common_handler.NewCommonHandler(logger)
 	userHandler := user_handler.NewUserHandler(nil, commonHandler, logger)
 	orderHandler := order_handler.NewOrderHandler(nil, commonHandler, logger)
+	productHandler := product_handler.NewProductHandler(nil, commonHandler, logger)
+	healthHandler := health_handler.NewHealthHandler(db, config.ReadinessTimeout, logger)
 
 	return &App{
-		Logger:       logger,
-		Config:       config,
-		DB:           db,
-		UserHandler:  userHandler,
-		OrderHandler: orderHandler,
+		Logger:         logger,
+		Config:         config,
+		DB:             db,
+		UserHandler:    userHandler,
+		OrderHandler:   orderHandler,
+		ProductHandler: productHandler,
+		HealthHandler:  healthHandler,
 	}, nil
 }
 
 // App содержит основные компоненты приложения
 type App struct {
-	Config       *config_util.Config
-	Logger       *logrus.Logger
-	DB           *gorm.DB
-	Router       *gin.Engine
-	UserHandler  *user_handler.UserHandler
-	OrderHandler *order_handler.OrderHandler
+	Config         *config_util.Config
+	Logger         *logrus.Logger
+	DB             *gorm.DB
+	Router         *gin.Engine
+	UserService    user_service.UserService
+	UserHandler    *user_handler.UserHandler
+	OrderHandler   *order_handler.OrderHandler
+	ProductHandler *product_handler.ProductHandler
+	// Проверки живости и готовности; если nil, маршруты /healthz и /readyz не регистрируются
+	HealthHandler *health_handler.HealthHandler
+	// Хранилище ключей идемпотентности; если nil, заголовок Idempotency-Key игнорируется
+	IdempotencyStore idempotency_rep.IdempotencyRepository
+	// Хранилище корзин ограничителя запросов; если nil, частота запросов не ограничивается
+	RateLimitStore rate_limit_rep.RateLimitRepository
 }
 
 // NewApp создает и инициализирует новый экземпляр приложения
@@ -58,32 +70,59 @@ func NewApp(logger *logrus.Logger, config *config_util.Config) (*App, error) {
 		logger.Infof("База данных успешно подключена")
 	}
 
-	// Инициализация репозиториев
-	userRepo := user_rep.NewGormUserRepository(db, logger)
-	orderRepo := order_rep.NewGormOrderRepository(db, logger)
+	// Миграции схемы: реплики применяют их под advisory lock, поэтому одновременный старт безопасен.
+	// Если миграции при старте выключены, приложение не запускается с отстающей схемой.
+	if config.DBMigrateOnStart {
+		if err := database.RunMigrations(db, logger); err != nil {
+			return nil, err
+		}
+	}
+	if err := database.CheckSchema(db, logger); err != nil {
+		return nil, fmt.Errorf("схема базы данных не готова: %w", err)
+	}
 
-	// Инициализация сервисов
-	userService := user_service.NewUserService(
-		userRepo,
-		logger,
-		config.JWTSecret,
-		int(config.JWTExpiration/time.Second))
-	orderService := order_service.NewOrderService(orderRepo, logger)
+	services := NewServices(db, config, logger)
+	idempotencyStore, err := newIdempotencyStore(config, db, logger)
+	if err != nil {
+		return nil, err
+	}
 
 	// Инициализация common handler
 	commonHandler := common_handler.NewCommonHandler(logger)
 
 	// Инициализация обработчиков
-	userHandler := user_handler.NewUserHandler(userService, commonHandler, logger)
-	orderHandler := order_handler.NewOrderHandler(orderService, commonHandler, logger)
+	userHandler := user_handler.NewUserHandler(services.User, commonHandler, logger)
+	orderHandler := order_handler.NewOrderHandler(services.Order, commonHandler, logger)
+	productHandler := product_handler.NewProductHandler(services.Product, commonHandler, logger)
+	healthHandler := health_handler.NewHealthHandler(db, config.ReadinessTimeout, logger)
 
 	app := &App{
-		Config:       config,
-		Logger:       logger,
-		DB:           db,
-		UserHandler:  userHandler,
-		OrderHandler: orderHandler,
+		Config:         config,
+		Logger:         logger,
+		DB:             db,
+		UserService:    services.User,
+		UserHandler:    userHandler,
+		OrderHandler:   orderHandler,
+		ProductHandler: productHandler,
+		HealthHandler:  healthHandler,
+
+		IdempotencyStore: idempotencyStore,
+	}
+	if config.RateLimitEnabled {
+		app.RateLimitStore = rate_limit_rep.NewMemoryRateLimitRepository(logger)
 	}
 
 	return app, nil
 }
+
+// newIdempotencyStore выбирает хранилище ключей идемпотентности по конфигурации
+func newIdempotencyStore(config *config_util.Config, db *gorm.DB, logger *logrus.Logger) (idempotency_rep.IdempotencyRepository, error) {
+	switch config.IdempotencyStore {
+	case "memory":
+		return idempotency_rep.NewMemoryIdempotencyRepository(logger), nil
+	case "database", "":
+		return idempotency_rep.NewGormIdempotencyRepository(db, logger), nil
+	default:
+		return nil, fmt.Errorf("неизвестное хранилище ключей идемпотентности '%s'", config.IdempotencyStore)
+	}
+}
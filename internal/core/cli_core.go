@@ -0,0 +1,184 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/money_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/order_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/product_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/user_model"
+	"github.com/IlyushinDM/user-order-api/internal/services/user_service"
+	"github.com/IlyushinDM/user-order-api/internal/utils/i18n_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/validation_util"
+	"github.com/sirupsen/logrus"
+)
+
+// seedProductPrefix - префикс названий демонстрационных продуктов; по нему seed находит продукты,
+// созданные предыдущим запуском, и не дублирует каталог
+const seedProductPrefix = "Demo product"
+
+// seedProductStock - остаток демонстрационных продуктов, которого хватает на любое количество заказов seed
+const seedProductStock = 1_000_000
+
+// SeedOptions задает объем демонстрационных данных
+type SeedOptions struct {
+	Users         int    // Количество пользователей
+	OrdersPerUser int    // Количество заказов у каждого пользователя
+	Products      int    // Количество продуктов в каталоге
+	Password      string // Пароль всех демонстрационных пользователей
+	RandSeed      uint64 // Начальное значение генератора; одинаковое значение дает одинаковые данные
+}
+
+// SeedResult описывает, сколько записей создал seed
+type SeedResult struct {
+	UsersCreated    int
+	UsersExisting   int
+	ProductsCreated int
+	OrdersCreated   int
+}
+
+// CreateUser создает пользователя через сервис пользователей и назначает ему роль.
+// Запрос проверяется теми же правилами, что и тело POST /api/users.
+// Роль назначается так же, как через API: обычным обновлением пользователя.
+// Создание и назначение роли выполняются в одной транзакции: если роль назначить
+// не удалось, пользователь не создается.
+func CreateUser(
+	ctx context.Context,
+	services *Services,
+	req user_model.CreateUserRequest,
+	role string,
+) (*user_model.User, error) {
+	if !user_model.IsValidRole(role) {
+		return nil, fmt.Errorf("%w: неизвестная роль %q", user_service.ErrInvalidServiceInput, role)
+	}
+	if err := validation_util.ValidateStruct(req); err != nil {
+		return nil, fmt.Errorf("%w: %s", user_service.ErrInvalidServiceInput, validation_util.Describe(i18n_util.Default, err))
+	}
+
+	var user *user_model.User
+	err := services.Tx.WithinTx(ctx, func(ctx context.Context) error {
+		created, err := services.User.CreateUser(ctx, req)
+		if err != nil {
+			return err
+		}
+		user = created
+		if role == created.Role {
+			return nil
+		}
+		user, err = services.User.UpdateUser(ctx, created.ID, created.Version, user_model.UpdateUserRequest{Role: role})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Seed наполняет базу демонстрационными продуктами, пользователями и заказами.
+// Пользователи с уже существующим email не создаются повторно, но получают новые заказы.
+func Seed(ctx context.Context, services *Services, opts SeedOptions, log *logrus.Logger) (*SeedResult, error) {
+	if opts.Users < 0 || opts.OrdersPerUser < 0 || opts.Products <= 0 {
+		return nil, fmt.Errorf("%w: количество пользователей и заказов не может быть отрицательным, продуктов - не меньше одного",
+			user_service.ErrInvalidServiceInput)
+	}
+
+	result := &SeedResult{}
+	rnd := rand.New(rand.NewPCG(opts.RandSeed, opts.RandSeed))
+
+	products, err := seedProducts(ctx, services, opts.Products, rnd, result)
+	if err != nil {
+		return result, err
+	}
+
+	for i := 1; i <= opts.Users; i++ {
+		user, err := seedUser(ctx, services, i, opts.Password, rnd, result)
+		if err != nil {
+			return result, err
+		}
+		for j := 0; j < opts.OrdersPerUser; j++ {
+			req := order_model.CreateOrderRequest{Currency: money_model.DefaultCurrency}
+			for k := rnd.IntN(3) + 1; k > 0; k-- {
+				req.Items = append(req.Items, order_model.OrderItemRequest{
+					ProductID: products[rnd.IntN(len(products))].ID,
+					Quantity:  rnd.IntN(3) + 1,
+				})
+			}
+			if _, err := services.Order.CreateOrder(ctx, user.ID, req); err != nil {
+				return result, fmt.Errorf("не удалось создать заказ пользователя %s: %w", user.Email, err)
+			}
+			result.OrdersCreated++
+		}
+	}
+
+	log.WithFields(logrus.Fields{
+		"users_created":    result.UsersCreated,
+		"users_existing":   result.UsersExisting,
+		"products_created": result.ProductsCreated,
+		"orders_created":   result.OrdersCreated,
+	}).Info("Демонстрационные данные созданы")
+	return result, nil
+}
+
+// seedProducts возвращает count демонстрационных продуктов, создавая недостающие
+func seedProducts(
+	ctx context.Context,
+	services *Services,
+	count int,
+	rnd *rand.Rand,
+	result *SeedResult,
+) ([]product_model.Product, error) {
+	products, _, err := services.Product.GetAllProducts(ctx, 1, count, seedProductPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить демонстрационные продукты: %w", err)
+	}
+
+	for i := len(products) + 1; i <= count; i++ {
+		product, err := services.Product.CreateProduct(ctx, product_model.CreateProductRequest{
+			Name:        fmt.Sprintf("%s %d", seedProductPrefix, i),
+			Description: "Создан командой seed",
+			Price:       money_model.NewAmount(int64(rnd.IntN(5000)+100), int64(rnd.IntN(100))),
+			Currency:    money_model.DefaultCurrency,
+			Stock:       seedProductStock,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("не удалось создать демонстрационный продукт: %w", err)
+		}
+		products = append(products, *product)
+		result.ProductsCreated++
+	}
+	return products, nil
+}
+
+// seedUser создает демонстрационного пользователя с номером n или возвращает уже существующего
+func seedUser(
+	ctx context.Context,
+	services *Services,
+	n int,
+	password string,
+	rnd *rand.Rand,
+	result *SeedResult,
+) (*user_model.User, error) {
+	email := fmt.Sprintf("demo-user-%d@example.com", n)
+	user, err := services.User.CreateUser(ctx, user_model.CreateUserRequest{
+		Name:     fmt.Sprintf("Демо пользователь %d", n),
+		Email:    email,
+		Age:      rnd.IntN(50) + 18,
+		Password: password,
+	})
+	if errors.Is(err, user_service.ErrUserAlreadyExists) {
+		user, err = services.User.GetUserByEmail(ctx, email)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось получить пользователя %s: %w", email, err)
+		}
+		result.UsersExisting++
+		return user, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать пользователя %s: %w", email, err)
+	}
+	result.UsersCreated++
+	return user, nil
+}
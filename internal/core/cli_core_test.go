@@ -0,0 +1,132 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/login_attempt_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/order_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/product_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/token_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/user_model"
+	"github.com/IlyushinDM/user-order-api/internal/services/user_service"
+	"github.com/IlyushinDM/user-order-api/internal/utils/config_util"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupTestServices(t *testing.T) (*Services, *gorm.DB, *logrus.Logger) {
+	dsn := filepath.Join(t.TempDir(), "cli.db") + "?_busy_timeout=10000"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&user_model.User{},
+		&product_model.Product{},
+		&order_model.Order{},
+		&order_model.OrderItem{},
+		&token_model.RefreshToken{},
+		&token_model.RevokedAccessToken{},
+		&login_attempt_model.LoginAttempt{},
+	))
+
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	cfg := &config_util.Config{
+		JWTSecret:            "secret",
+		JWTExpiration:        time.Hour,
+		JWTRefreshExpiration: 24 * time.Hour,
+	}
+	return NewServices(db, cfg, log), db, log
+}
+
+func TestCreateUser_Admin(t *testing.T) {
+	services, _, _ := setupTestServices(t)
+	ctx := context.Background()
+	req := user_model.CreateUserRequest{Name: "Admin", Email: "admin@example.com", Age: 30, Password: "secret123"}
+
+	user, err := CreateUser(ctx, services, req, user_model.RoleAdmin)
+	require.NoError(t, err)
+	assert.Equal(t, user_model.RoleAdmin, user.Role)
+
+	// Пароль хешируется тем же сервисом, что и в API, поэтому с ним можно войти
+	login, err := services.User.LoginUser(ctx, user_model.LoginRequest{Email: req.Email, Password: req.Password}, login_attempt_model.ClientInfo{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, login.Token)
+
+	_, err = CreateUser(ctx, services, req, user_model.RoleAdmin)
+	assert.True(t, errors.Is(err, user_service.ErrUserAlreadyExists))
+}
+
+func TestCreateUser_ValidatesLikeAPI(t *testing.T) {
+	services, db, _ := setupTestServices(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		req  user_model.CreateUserRequest
+		want string
+	}{
+		{"Некорректный email", user_model.CreateUserRequest{Name: "A", Email: "foo", Age: 30, Password: "secret123"}, "email"},
+		{"Короткий пароль", user_model.CreateUserRequest{Name: "A", Email: "a@example.com", Age: 30, Password: "1"}, "password"},
+		{"Пустое имя", user_model.CreateUserRequest{Name: "   ", Email: "a@example.com", Age: 30, Password: "secret123"}, "name"},
+		{"Возраст вне диапазона", user_model.CreateUserRequest{Name: "A", Email: "a@example.com", Age: 200, Password: "secret123"}, "age"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CreateUser(ctx, services, tt.req, user_model.RoleAdmin)
+			assert.ErrorIs(t, err, user_service.ErrInvalidServiceInput)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+
+	var users int64
+	require.NoError(t, db.Model(&user_model.User{}).Count(&users).Error)
+	assert.Zero(t, users)
+}
+
+func TestCreateUser_UnknownRole(t *testing.T) {
+	services, _, _ := setupTestServices(t)
+	req := user_model.CreateUserRequest{Name: "U", Email: "u@example.com", Age: 30, Password: "secret123"}
+
+	_, err := CreateUser(context.Background(), services, req, "root")
+	assert.True(t, errors.Is(err, user_service.ErrInvalidServiceInput))
+}
+
+func TestSeed_CreatesDataAndReusesExisting(t *testing.T) {
+	services, db, log := setupTestServices(t)
+	ctx := context.Background()
+	opts := SeedOptions{Users: 3, OrdersPerUser: 2, Products: 4, Password: "demo-password", RandSeed: 7}
+
+	result, err := Seed(ctx, services, opts, log)
+	require.NoError(t, err)
+	assert.Equal(t, &SeedResult{UsersCreated: 3, ProductsCreated: 4, OrdersCreated: 6}, result)
+
+	// Повторный запуск не дублирует пользователей и продукты, но добавляет заказы
+	result, err = Seed(ctx, services, opts, log)
+	require.NoError(t, err)
+	assert.Equal(t, &SeedResult{UsersExisting: 3, OrdersCreated: 6}, result)
+
+	var users, products, orders int64
+	db.Model(&user_model.User{}).Count(&users)
+	db.Model(&product_model.Product{}).Count(&products)
+	db.Model(&order_model.Order{}).Count(&orders)
+	assert.Equal(t, int64(3), users)
+	assert.Equal(t, int64(4), products)
+	assert.Equal(t, int64(12), orders)
+}
+
+func TestSeed_InvalidOptions(t *testing.T) {
+	services, _, log := setupTestServices(t)
+
+	_, err := Seed(context.Background(), services, SeedOptions{Users: 1, Products: 0}, log)
+	assert.True(t, errors.Is(err, user_service.ErrInvalidServiceInput))
+}
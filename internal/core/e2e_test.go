@@ -0,0 +1,394 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/login_attempt_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/order_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/user_model"
+	"github.com/IlyushinDM/user-order-api/internal/repository/rate_limit_rep"
+	"github.com/IlyushinDM/user-order-api/internal/utils/config_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/metrics_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/problem_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/request_id_util"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// e2eClient выполняет запросы к приложению, собранному целиком поверх SQLite в памяти
+type e2eClient struct {
+	t      *testing.T
+	app    *App
+	router *gin.Engine
+}
+
+func newE2EClient(t *testing.T) *e2eClient {
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	return newE2EClientWithLogger(t, log)
+}
+
+// newE2EClientWithLogger собирает приложение с заданным логгером
+func newE2EClientWithLogger(t *testing.T, log *logrus.Logger) *e2eClient {
+	return newE2EClientWithConfig(t, log, nil)
+}
+
+// newE2EClientWithConfig собирает приложение с заданным логгером; configure, если задана,
+// изменяет конфигурацию до создания приложения
+func newE2EClientWithConfig(t *testing.T, log *logrus.Logger, configure func(cfg *config_util.Config)) *e2eClient {
+	cfg := &config_util.Config{
+		GinMode:              gin.TestMode,
+		DBDriver:             config_util.DBDriverSQLite,
+		DBPath:               ":memory:",
+		DBMigrateOnStart:     true,
+		JWTSecret:            "e2e-secret",
+		JWTExpiration:        time.Hour,
+		JWTRefreshExpiration: 24 * time.Hour,
+		IdempotencyTTL:       time.Hour,
+		IdempotencyLease:     time.Minute,
+		IdempotencyStore:     "database",
+	}
+	if configure != nil {
+		configure(cfg)
+	}
+
+	app, err := NewApp(log, cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if sqlDB, err := app.DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return &e2eClient{t: t, app: app, router: SetupRouter(app)}
+}
+
+// do отправляет запрос и разбирает JSON ответа в out, если out не nil
+func (c *e2eClient) do(method, path, token string, body any, headers map[string]string, out any) *httptest.ResponseRecorder {
+	c.t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		require.NoError(c.t, err)
+		reader = bytes.NewReader(raw)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	c.router.ServeHTTP(w, req)
+
+	if out != nil && w.Code < http.StatusBadRequest {
+		require.NoError(c.t, json.Unmarshal(w.Body.Bytes(), out), w.Body.String())
+	}
+	return w
+}
+
+func (c *e2eClient) login(email, password string) string {
+	c.t.Helper()
+	var resp user_model.LoginResponse
+	w := c.do(http.MethodPost, "/auth/login", "", user_model.LoginRequest{Email: email, Password: password}, nil, &resp)
+	require.Equal(c.t, http.StatusOK, w.Code, w.Body.String())
+	return resp.Token
+}
+
+// assertE2EProblem проверяет ответ с ошибкой в формате problem+json
+func assertE2EProblem(t *testing.T, w *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	assert.Equal(t, status, w.Code, w.Body.String())
+	assert.Equal(t, problem_util.ContentType, w.Header().Get("Content-Type"))
+	var problem problem_util.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem), w.Body.String())
+	assert.Equal(t, code, problem.Code)
+	assert.Equal(t, problem_util.TypePrefix+code, problem.Type)
+}
+
+func TestE2E_ErrorsAreProblemJSON(t *testing.T) {
+	c := newE2EClient(t)
+
+	assertE2EProblem(t, c.do(http.MethodGet, "/api/unknown", "", nil, nil, nil), http.StatusNotFound, "route_not_found")
+	assertE2EProblem(t, c.do(http.MethodPatch, "/auth/login", "", nil, nil, nil), http.StatusMethodNotAllowed, "method_not_allowed")
+	assertE2EProblem(t, c.do(http.MethodGet, "/api/users", "", nil, nil, nil), http.StatusUnauthorized, "unauthorized")
+	assertE2EProblem(t, c.do(http.MethodPost, "/auth/login", "", user_model.LoginRequest{
+		Email: "nobody@example.com", Password: "password",
+	}, nil, nil), http.StatusUnauthorized, "invalid_credentials")
+}
+
+func TestE2E_ErrorsFollowAcceptLanguage(t *testing.T) {
+	c := newE2EClient(t)
+	english := map[string]string{"Accept-Language": "en-US,en;q=0.9,ru;q=0.5"}
+
+	w := c.do(http.MethodPost, "/api/users", "", map[string]any{"name": "Bob", "email": "bob", "age": 0}, english, nil)
+	assertE2EProblem(t, w, http.StatusBadRequest, "validation_failed")
+	assert.Equal(t, "en", w.Header().Get("Content-Language"))
+	var problem problem_util.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "Invalid input", problem.Title)
+	assert.Equal(t, "field email must be a valid email address; field age is required; field password is required", problem.Detail)
+	require.Len(t, problem.Errors, 3)
+	assert.Equal(t, problem_util.FieldError{Field: "email", Rule: "email", Message: "field email must be a valid email address"}, problem.Errors[0])
+	assert.Equal(t, []string{"age", "password"}, []string{problem.Errors[1].Field, problem.Errors[2].Field})
+
+	w = c.do(http.MethodGet, "/api/users", "", nil, nil, nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "ru", w.Header().Get("Content-Language"))
+	assert.Equal(t, "Требуется аутентификация", problem.Title)
+	assert.Equal(t, "Требуется заголовок Authorization", problem.Detail)
+}
+
+func TestE2E_MetricsEndpoint(t *testing.T) {
+	c := newE2EClient(t)
+	usersBefore := testutil.ToFloat64(metrics_util.UsersCreated)
+	failedLoginsBefore := testutil.ToFloat64(metrics_util.Logins.WithLabelValues(metrics_util.LoginFailed))
+
+	w := c.do(http.MethodPost, "/api/users", "", user_model.CreateUserRequest{
+		Name: "Alice", Email: "alice@example.com", Age: 30, Password: "alice-password",
+	}, nil, nil)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	c.do(http.MethodPost, "/auth/login", "", user_model.LoginRequest{Email: "alice@example.com", Password: "wrong"}, nil, nil)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics_util.UsersCreated)-usersBefore)
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics_util.Logins.WithLabelValues(metrics_util.LoginFailed))-failedLoginsBefore)
+
+	w = c.do(http.MethodGet, "/metrics", "", nil, nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+	body := w.Body.String()
+	assert.Contains(t, body, `user_order_api_http_request_duration_seconds_count{method="POST",route="/api/users",status="201"}`)
+	assert.Contains(t, body, "user_order_api_http_requests_in_flight 1")
+	assert.Contains(t, body, `go_sql_max_open_connections{db_name="main"} 1`)
+	assert.Contains(t, body, `user_order_api_order_operations_total{operation="created"}`)
+	assert.Contains(t, body, "user_order_api_log_entries_dropped_total")
+}
+
+func TestE2E_LoginRateLimit(t *testing.T) {
+	c := newE2EClient(t)
+	c.app.Config.RateLimitLogin = "3/1m"
+	c.app.Config.RateLimitRegister = "10/1h"
+	c.app.Config.RateLimitRefresh = "30/1m"
+	c.app.Config.RateLimitAPI = "300/1m"
+	c.app.RateLimitStore = rate_limit_rep.NewMemoryRateLimitRepository(c.app.Logger)
+	c.router = SetupRouter(c.app)
+
+	wrong := user_model.LoginRequest{Email: "alice@example.com", Password: "wrong-password"}
+	for i := 0; i < 3; i++ {
+		// Подмена X-Forwarded-For без доверенных прокси не дает новую корзину
+		w := c.do(http.MethodPost, "/auth/login", "", wrong, map[string]string{"X-Forwarded-For": fmt.Sprintf("198.51.100.%d", i)}, nil)
+		assertE2EProblem(t, w, http.StatusUnauthorized, "invalid_credentials")
+		assert.Equal(t, "3", w.Header().Get("RateLimit-Limit"))
+	}
+
+	w := c.do(http.MethodPost, "/auth/login", "", wrong, nil, nil)
+	assertE2EProblem(t, w, http.StatusTooManyRequests, "too_many_requests")
+	assert.Equal(t, "20", w.Header().Get("Retry-After"))
+
+	// Другие лимиты считаются отдельно
+	w = c.do(http.MethodPost, "/api/users", "", user_model.CreateUserRequest{
+		Name: "Alice", Email: "alice@example.com", Age: 30, Password: "alice-password",
+	}, nil, nil)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, "9", w.Header().Get("RateLimit-Remaining"))
+}
+
+func TestE2E_LoginLockoutAndHistory(t *testing.T) {
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	c := newE2EClientWithConfig(t, log, func(cfg *config_util.Config) {
+		cfg.LoginMaxFailures = 3
+		cfg.LoginFailureWindow = 15 * time.Minute
+		cfg.LoginLockoutDuration = 15 * time.Minute
+	})
+
+	var alice user_model.UserResponse
+	w := c.do(http.MethodPost, "/api/users", "", user_model.CreateUserRequest{
+		Name: "Alice", Email: "alice@example.com", Age: 30, Password: "alice-password",
+	}, nil, &alice)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	aliceToken := c.login("alice@example.com", "alice-password")
+
+	browser := map[string]string{"User-Agent": "e2e-browser/1.0"}
+	wrong := user_model.LoginRequest{Email: "alice@example.com", Password: "wrong-password"}
+	for i := 0; i < 3; i++ {
+		assertE2EProblem(t, c.do(http.MethodPost, "/auth/login", "", wrong, browser, nil), http.StatusUnauthorized, "invalid_credentials")
+	}
+
+	// После серии неудач не помогает и верный пароль
+	w = c.do(http.MethodPost, "/auth/login", "", user_model.LoginRequest{Email: "alice@example.com", Password: "alice-password"}, browser, nil)
+	assertE2EProblem(t, w, http.StatusLocked, "account_locked")
+	assert.Equal(t, "900", w.Header().Get("Retry-After"))
+	// Неудачи считаются по email без учета регистра
+	w = c.do(http.MethodPost, "/auth/login", "", user_model.LoginRequest{Email: "Alice@Example.com", Password: "alice-password"}, nil, nil)
+	assertE2EProblem(t, w, http.StatusLocked, "account_locked")
+
+	// Блокировка по неизвестному email выглядит так же, как по существующему
+	unknown := user_model.LoginRequest{Email: "nobody@example.com", Password: "password"}
+	for i := 0; i < 3; i++ {
+		assertE2EProblem(t, c.do(http.MethodPost, "/auth/login", "", unknown, nil, nil), http.StatusUnauthorized, "invalid_credentials")
+	}
+	assertE2EProblem(t, c.do(http.MethodPost, "/auth/login", "", unknown, nil, nil), http.StatusLocked, "account_locked")
+
+	var history login_attempt_model.PaginatedLoginAttemptsResponse
+	w = c.do(http.MethodGet, fmt.Sprintf("/api/users/%d/logins?limit=3", alice.ID), aliceToken, nil, nil, &history)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, int64(5), history.Total)
+	require.Len(t, history.Logins, 3)
+	assert.Equal(t, login_attempt_model.OutcomeLocked, history.Logins[0].Outcome)
+	assert.Equal(t, login_attempt_model.OutcomeInvalidPassword, history.Logins[1].Outcome)
+	assert.Equal(t, "e2e-browser/1.0", history.Logins[0].UserAgent)
+	assert.Equal(t, "192.0.2.1", history.Logins[0].IP)
+	assert.False(t, history.Logins[0].CreatedAt.IsZero())
+
+	// Чужая история входов недоступна обычному пользователю
+	var bob user_model.UserResponse
+	w = c.do(http.MethodPost, "/api/users", "", user_model.CreateUserRequest{
+		Name: "Bob", Email: "bob@example.com", Age: 30, Password: "bob-password",
+	}, nil, &bob)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	bobToken := c.login("bob@example.com", "bob-password")
+	assertE2EProblem(t, c.do(http.MethodGet, fmt.Sprintf("/api/users/%d/logins", alice.ID), bobToken, nil, nil, nil),
+		http.StatusForbidden, "forbidden")
+}
+
+func TestE2E_RequestIDInResponseAndLogs(t *testing.T) {
+	var logs bytes.Buffer
+	log := logrus.New()
+	log.SetOutput(&logs)
+	log.SetFormatter(&logrus.JSONFormatter{})
+	// На уровне Trace в лог попадают и SQL запросы GORM
+	log.SetLevel(logrus.TraceLevel)
+	log.AddHook(request_id_util.LogHook{})
+	c := newE2EClientWithLogger(t, log)
+	logs.Reset()
+
+	w := c.do(http.MethodPost, "/api/users", "", user_model.CreateUserRequest{
+		Name: "Alice", Email: "alice@example.com", Age: 30, Password: "alice-password",
+	}, map[string]string{request_id_util.Header: "e2e-request-1"}, nil)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, "e2e-request-1", w.Header().Get(request_id_util.Header))
+
+	// Записи обработчика, сервиса, репозитория, GORM и итоговая запись о запросе несут один request_id
+	var tagged, sql, access int
+	for _, line := range bytes.Split(bytes.TrimSpace(logs.Bytes()), []byte("\n")) {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(line, &entry), string(line))
+		if entry[request_id_util.LogField] != "e2e-request-1" {
+			continue
+		}
+		tagged++
+		if msg, _ := entry["msg"].(string); bytes.Contains([]byte(msg), []byte("INSERT INTO")) {
+			sql++
+		}
+		if entry["path"] == "/api/users" {
+			access++
+		}
+	}
+	assert.Greater(t, tagged, 2)
+	assert.Positive(t, sql, "SQL запросы GORM должны содержать request_id")
+	assert.Equal(t, 1, access)
+
+	// Без заголовка идентификатор генерируется
+	w = c.do(http.MethodGet, "/healthz", "", nil, nil, nil)
+	assert.Len(t, w.Header().Get(request_id_util.Header), 32)
+}
+
+func TestE2E_OrderLifecycle(t *testing.T) {
+	c := newE2EClient(t)
+
+	// Администратор создается так же, как командой create-user --admin
+	services := NewServices(c.app.DB, c.app.Config, c.app.Logger)
+	_, err := CreateUser(t.Context(), services, user_model.CreateUserRequest{
+		Name: "Admin", Email: "admin@example.com", Age: 40, Password: "admin-password",
+	}, user_model.RoleAdmin)
+	require.NoError(t, err)
+	adminToken := c.login("admin@example.com", "admin-password")
+
+	// Регистрация пользователя через публичный API
+	var alice user_model.UserResponse
+	w := c.do(http.MethodPost, "/api/users", "", user_model.CreateUserRequest{
+		Name: "Alice", Email: "alice@example.com", Age: 30, Password: "alice-password",
+	}, nil, &alice)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = c.do(http.MethodPost, "/api/users", "", user_model.CreateUserRequest{
+		Name: "Alice 2", Email: "alice@example.com", Age: 31, Password: "alice-password",
+	}, nil, nil)
+	assertE2EProblem(t, w, http.StatusConflict, "email_already_taken")
+	aliceToken := c.login("alice@example.com", "alice-password")
+
+	// Каталог может пополнять только администратор
+	productReq := map[string]any{"name": "Кофе", "price": "199.90", "currency": "RUB", "stock": 5}
+	w = c.do(http.MethodPost, "/api/products", aliceToken, productReq, nil, nil)
+	assertE2EProblem(t, w, http.StatusForbidden, "forbidden")
+	var product struct {
+		ID    uint   `json:"id"`
+		Price string `json:"price"`
+		Stock int    `json:"stock"`
+	}
+	w = c.do(http.MethodPost, "/api/products", adminToken, productReq, nil, &product)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, "199.90", product.Price)
+
+	// Создание заказа резервирует остаток; повтор с тем же ключом не создает второй заказ
+	ordersPath := fmt.Sprintf("/api/users/%d/orders", alice.ID)
+	orderReq := order_model.CreateOrderRequest{
+		Currency: "RUB",
+		Items:    []order_model.OrderItemRequest{{ProductID: product.ID, Quantity: 2}},
+	}
+	idempotency := map[string]string{"Idempotency-Key": "order-1"}
+	var order struct {
+		ID     uint   `json:"id"`
+		Status string `json:"status"`
+	}
+	w = c.do(http.MethodPost, ordersPath, aliceToken, orderReq, idempotency, &order)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+	assert.Equal(t, "pending", order.Status)
+
+	replay := c.do(http.MethodPost, ordersPath, aliceToken, orderReq, idempotency, nil)
+	assert.Equal(t, http.StatusCreated, replay.Code)
+	assert.Equal(t, "true", replay.Header().Get("Idempotent-Replayed"))
+	assert.JSONEq(t, w.Body.String(), replay.Body.String())
+	assert.Equal(t, w.Header().Get("ETag"), replay.Header().Get("ETag"))
+
+	productPath := fmt.Sprintf("/api/products/%d", product.ID)
+	c.do(http.MethodGet, productPath, aliceToken, nil, nil, &product)
+	assert.Equal(t, 3, product.Stock)
+
+	// Изменение заказа требует актуальный If-Match
+	orderPath := fmt.Sprintf("%s/%d", ordersPath, order.ID)
+	update := order_model.UpdateOrderRequest{Items: []order_model.OrderItemRequest{{ProductID: product.ID, Quantity: 1}}}
+	w = c.do(http.MethodPut, orderPath, aliceToken, update, map[string]string{"If-Match": `"1"`}, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	w = c.do(http.MethodPut, orderPath, aliceToken, update, map[string]string{"If-Match": `"1"`}, nil)
+	assertE2EProblem(t, w, http.StatusPreconditionFailed, "version_mismatch")
+
+	// Отмена возвращает резерв на склад
+	w = c.do(http.MethodPost, orderPath+"/cancel", aliceToken, nil, nil, &order)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "cancelled", order.Status)
+	c.do(http.MethodGet, productPath, aliceToken, nil, nil, &product)
+	assert.Equal(t, 5, product.Stock)
+
+	// Удаление пользователя каскадно удаляет его заказы
+	w = c.do(http.MethodDelete, fmt.Sprintf("/api/users/%d", alice.ID), adminToken, nil, nil, nil)
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	var orders int64
+	require.NoError(t, c.app.DB.Unscoped().Model(&order_model.Order{}).Count(&orders).Error)
+	assert.Zero(t, orders)
+}
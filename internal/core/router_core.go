@@ -1,8 +1,20 @@
 package core
 
 import (
+	"strings"
+
 	auth_mw "github.com/IlyushinDM/user-order-api/internal/middleware/auth_middleware"
+	idem_mw "github.com/IlyushinDM/user-order-api/internal/middleware/idempotency_middleware"
+	locale_mw "github.com/IlyushinDM/user-order-api/internal/middleware/locale_middleware"
 	log_mw "github.com/IlyushinDM/user-order-api/internal/middleware/logger_middleware"
+	metrics_mw "github.com/IlyushinDM/user-order-api/internal/middleware/metrics_middleware"
+	rate_limit_mw "github.com/IlyushinDM/user-order-api/internal/middleware/rate_limit_middleware"
+	request_id_mw "github.com/IlyushinDM/user-order-api/internal/middleware/request_id_middleware"
+	tracing_mw "github.com/IlyushinDM/user-order-api/internal/middleware/tracing_middleware"
+	"github.com/IlyushinDM/user-order-api/internal/models/rate_limit_model"
+	"github.com/IlyushinDM/user-order-api/internal/utils/i18n_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/metrics_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/problem_util"
 	"github.com/gin-gonic/gin"
 
 	swaggerFiles "github.com/swaggo/files"
@@ -15,27 +27,90 @@ func SetupRouter(app *App) *gin.Engine {
 	gin.SetMode(app.Config.GinMode)
 
 	router := gin.New()
+	// IP клиента берется из X-Forwarded-For только за доверенными прокси, иначе клиент
+	// мог бы подменить адрес и обойти лимиты запросов. Значения проверены при загрузке конфигурации.
+	if err := router.SetTrustedProxies(trustedProxies(app.Config.TrustedProxies)); err != nil {
+		app.Logger.WithError(err).Warn("Доверенные прокси не заданы, IP клиента берется из адреса соединения")
+		_ = router.SetTrustedProxies(nil)
+	}
 
-	// Подключение middleware
-	router.Use(gin.Recovery())
+	// Идентификатор запроса назначается до всех остальных middleware, чтобы попасть во все записи лога
+	router.Use(request_id_mw.RequestIDMiddleware())
+	// Метрики подключаются до восстановления после паники, чтобы учитывать и ответы 500 после паники обработчика
+	router.Use(metrics_mw.MetricsMiddleware())
+	// Спан запроса охватывает остальные middleware и обработчик, включая восстановление после паники
+	router.Use(tracing_mw.TracingMiddleware())
+	// Подключение middleware. Паника обработчика превращается в ответ 500 в формате problem+json
+	router.Use(gin.CustomRecovery(problem_util.Recovery))
 	// Передаем экземпляр логгера в middleware
 	router.Use(log_mw.LoggerMiddleware(app.Logger))
+	// Язык сообщений выбирается по Accept-Language; значение из конфигурации проверено при загрузке
+	defaultLang, err := i18n_util.ParseLang(app.Config.DefaultLanguage)
+	if err != nil {
+		app.Logger.WithError(err).Warnf("Используется язык сообщений по умолчанию: %s", i18n_util.Default)
+		defaultLang = i18n_util.Default
+	}
+	router.Use(locale_mw.LocaleMiddleware(defaultLang))
+
+	// Неизвестный маршрут и неподдерживаемый метод тоже отвечают в формате problem+json
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(problem_util.NoRoute)
+	router.NoMethod(problem_util.NoMethod)
+
+	// Проверки живости и готовности для оркестратора и балансировщика
+	if app.HealthHandler != nil {
+		router.GET("/healthz", app.HealthHandler.Liveness)
+		router.GET("/readyz", app.HealthHandler.Readiness)
+	}
+
+	// Метрики в текстовом формате Prometheus
+	router.GET("/metrics", gin.WrapH(metrics_util.Handler()))
 
 	// Маршрут для документации Swagger
 	// @BasePath /
 	// @schemes http https
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	// Публичные маршруты (не требуют аутентификации)
-	// Маршрут для входа пользователя
-	router.POST("/auth/login", app.UserHandler.LoginUser)
-	// Маршрут для создания пользователя
-	router.POST("/api/users", app.UserHandler.CreateUser)
+	// Передаем JWT Secret из конфигурации в AuthMiddleware.
+	// Отозванные access токены отклоняются по списку отзыва.
+	authMiddleware := auth_mw.AuthMiddleware(app.Logger, app.Config.JWTSecret)
+	if app.UserService != nil {
+		authMiddleware = auth_mw.AuthMiddlewareWithRevocation(
+			app.Logger, app.Config.JWTSecret, app.UserService.IsTokenRevoked)
+	}
+
+	// Повторы создания заказа с тем же Idempotency-Key получают сохраненный ответ
+	createOrderHandlers := []gin.HandlerFunc{app.OrderHandler.CreateOrder}
+	if app.IdempotencyStore != nil {
+		createOrderHandlers = append([]gin.HandlerFunc{
+			idem_mw.IdempotencyMiddleware(app.IdempotencyStore, app.Config.IdempotencyTTL, app.Config.IdempotencyLease, app.Logger),
+		}, createOrderHandlers...)
+	}
+
+	// Лимиты запросов: публичные маршруты ограничиваются по IP, защищенные - по пользователю из JWT
+	apiLimit := rateLimit(app, "api", app.Config.RateLimitAPI, rate_limit_mw.ByUserID)
+
+	// Маршруты аутентификации
+	authRoutes := router.Group("/auth")
+	{
+		// Публичные маршруты для входа и обновления пары токенов
+		authRoutes.POST("/login", append(rateLimit(app, "login", app.Config.RateLimitLogin, rate_limit_mw.ByClientIP),
+			app.UserHandler.LoginUser)...)
+		authRoutes.POST("/refresh", append(rateLimit(app, "refresh", app.Config.RateLimitRefresh, rate_limit_mw.ByClientIP),
+			app.UserHandler.RefreshToken)...)
+		// Выход требует действующий access токен
+		authRoutes.POST("/logout", append(append([]gin.HandlerFunc{authMiddleware}, apiLimit...),
+			app.UserHandler.LogoutUser)...)
+	}
+
+	// Публичный маршрут для создания пользователя
+	router.POST("/api/users", append(rateLimit(app, "register", app.Config.RateLimitRegister, rate_limit_mw.ByClientIP),
+		app.UserHandler.CreateUser)...)
 
 	// Защищенные маршруты API (требуют аутентификации)
 	api := router.Group("/api")
-	// Передаем JWT Secret из конфигурации в AuthMiddleware
-	api.Use(auth_mw.AuthMiddleware(app.Logger, app.Config.JWTSecret))
+	api.Use(authMiddleware)
+	api.Use(apiLimit...)
 	{
 		// Маршруты для работы с пользователями
 		userRoutes := api.Group("/users")
@@ -44,14 +119,52 @@ func SetupRouter(app *App) *gin.Engine {
 			userRoutes.GET("/:id", app.UserHandler.GetUserByID)
 			userRoutes.PUT("/:id", app.UserHandler.UpdateUser)
 			userRoutes.DELETE("/:id", app.UserHandler.DeleteUser)
+			userRoutes.GET("/:id/logins", app.UserHandler.GetLoginHistory)
 
 			// Маршруты для работы с заказами конкретного пользователя
-			userRoutes.POST("/:id/orders", app.OrderHandler.CreateOrder)
+			userRoutes.POST("/:id/orders", createOrderHandlers...)
 			userRoutes.GET("/:id/orders", app.OrderHandler.GetAllOrdersByUser)
 			userRoutes.GET("/:id/orders/:orderID", app.OrderHandler.GetOrderByID)
 			userRoutes.PUT("/:id/orders/:orderID", app.OrderHandler.UpdateOrder)
 			userRoutes.DELETE("/:id/orders/:orderID", app.OrderHandler.DeleteOrder)
+			userRoutes.POST("/:id/orders/:orderID/cancel", app.OrderHandler.CancelOrder)
+			userRoutes.PATCH("/:id/orders/:orderID/status", app.OrderHandler.UpdateOrderStatus)
+		}
+
+		// Маршруты каталога продуктов: чтение доступно всем, изменение - только администратору
+		productRoutes := api.Group("/products")
+		{
+			productRoutes.GET("", app.ProductHandler.GetAllProducts)
+			productRoutes.GET("/:id", app.ProductHandler.GetProductByID)
+			productRoutes.POST("", app.ProductHandler.CreateProduct)
+			productRoutes.PUT("/:id", app.ProductHandler.UpdateProduct)
+			productRoutes.DELETE("/:id", app.ProductHandler.DeleteProduct)
 		}
 	}
 	return router
 }
+
+// rateLimit возвращает middleware ограничения частоты запросов с лимитом spec
+// или пустой список, если ограничение выключено
+func rateLimit(app *App, name, spec string, key rate_limit_mw.KeyFunc) []gin.HandlerFunc {
+	if app.RateLimitStore == nil {
+		return nil
+	}
+	limit, err := rate_limit_model.ParseLimit(spec)
+	if err != nil {
+		app.Logger.WithError(err).Warnf("Лимит запросов %s не применяется", name)
+		return nil
+	}
+	return []gin.HandlerFunc{rate_limit_mw.RateLimitMiddleware(app.RateLimitStore, name, limit, key, app.Logger)}
+}
+
+// trustedProxies отбрасывает пустые значения TRUSTED_PROXIES
+func trustedProxies(proxies []string) []string {
+	var trusted []string
+	for _, proxy := range proxies {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trusted = append(trusted, proxy)
+		}
+	}
+	return trusted
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/repository/database"
+	"github.com/IlyushinDM/user-order-api/internal/repository/login_attempt_rep"
+	"github.com/IlyushinDM/user-order-api/internal/repository/order_rep"
+	"github.com/IlyushinDM/user-order-api/internal/repository/product_rep"
+	"github.com/IlyushinDM/user-order-api/internal/repository/token_rep"
+	"github.com/IlyushinDM/user-order-api/internal/repository/user_rep"
+	"github.com/IlyushinDM/user-order-api/internal/services/order_service"
+	"github.com/IlyushinDM/user-order-api/internal/services/product_service"
+	"github.com/IlyushinDM/user-order-api/internal/services/user_service"
+	"github.com/IlyushinDM/user-order-api/internal/utils/config_util"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Services содержит сервисы бизнес-логики. Их используют и HTTP API, и команды CLI,
+// поэтому правила (хеширование паролей, уникальность email, резервирование остатков) везде одинаковы.
+type Services struct {
+	User    user_service.UserService
+	Order   order_service.OrderService
+	Product product_service.ProductService
+	// Tx выполняет несколько вызовов сервисов в одной транзакции
+	Tx database.TxManager
+}
+
+// NewServices создает репозитории поверх db и сервисы поверх них
+func NewServices(db *gorm.DB, config *config_util.Config, logger *logrus.Logger) *Services {
+	// Инициализация репозиториев
+	userRepo := user_rep.NewGormUserRepository(db, logger)
+	orderRepo := order_rep.NewGormOrderRepository(db, logger)
+	tokenRepo := token_rep.NewGormTokenRepository(db, logger)
+	productRepo := product_rep.NewGormProductRepository(db, logger)
+	loginRepo := login_attempt_rep.NewGormLoginAttemptRepository(db, logger)
+
+	// Инициализация сервисов; каждый метод сервиса создает спан трассировки
+	return &Services{
+		User: user_service.NewTracedUserService(user_service.NewUserServiceWithLoginAudit(
+			userRepo,
+			tokenRepo,
+			loginRepo,
+			config.LoginLockoutPolicy(),
+			logger,
+			config.JWTSecret,
+			int(config.JWTExpiration/time.Second),
+			int(config.JWTRefreshExpiration/time.Second))),
+		Order:   order_service.NewTracedOrderService(order_service.NewOrderService(orderRepo, productRepo, logger)),
+		Product: product_service.NewTracedProductService(product_service.NewProductService(productRepo, logger)),
+		Tx:      database.NewGormTxManager(db, logger),
+	}
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/order_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/product_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/user_model"
+	"github.com/IlyushinDM/user-order-api/internal/repository/order_rep"
+	"github.com/IlyushinDM/user-order-api/internal/repository/user_rep"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// createUserWithOrder создает пользователя и заказ, резервирующий товар, через GORM репозитории
+func createUserWithOrder(ctx context.Context, db *gorm.DB, log *logrus.Logger, productID uint) error {
+	users := user_rep.NewGormUserRepository(db, log)
+	orders := order_rep.NewGormOrderRepository(db, log)
+
+	user := &user_model.User{Name: "Alice", Email: "alice@example.com", Age: 30, PasswordHash: "hash"}
+	if err := users.Create(ctx, user); err != nil {
+		return err
+	}
+	return orders.Create(ctx, &order_model.Order{
+		UserID: user.ID,
+		Items:  []order_model.OrderItem{{ProductID: &productID, ProductName: "SKU", Quantity: 3, UnitPrice: 100}},
+	})
+}
+
+func seedTxProduct(t *testing.T, db *gorm.DB) *product_model.Product {
+	product := &product_model.Product{Name: "SKU", Price: 100, Currency: "RUB", Stock: 5}
+	require.NoError(t, db.Create(product).Error)
+	return product
+}
+
+func assertNothingPersisted(t *testing.T, db *gorm.DB, productID uint) {
+	var users, orders int64
+	require.NoError(t, db.Model(&user_model.User{}).Count(&users).Error)
+	require.NoError(t, db.Unscoped().Model(&order_model.Order{}).Count(&orders).Error)
+	assert.Zero(t, users)
+	assert.Zero(t, orders)
+
+	var product product_model.Product
+	require.NoError(t, db.First(&product, productID).Error)
+	assert.Equal(t, 5, product.Stock, "резерв товара должен быть откачен вместе с заказом")
+}
+
+func TestWithinTx_CommitsAcrossRepositories(t *testing.T) {
+	services, db, log := setupTestServices(t)
+	product := seedTxProduct(t, db)
+
+	err := services.Tx.WithinTx(context.Background(), func(ctx context.Context) error {
+		return createUserWithOrder(ctx, db, log, product.ID)
+	})
+	require.NoError(t, err)
+
+	var orders int64
+	require.NoError(t, db.Model(&order_model.Order{}).Count(&orders).Error)
+	assert.EqualValues(t, 1, orders)
+	require.NoError(t, db.First(product, product.ID).Error)
+	assert.Equal(t, 2, product.Stock)
+}
+
+func TestWithinTx_RollsBackAcrossRepositoriesOnError(t *testing.T) {
+	services, db, log := setupTestServices(t)
+	product := seedTxProduct(t, db)
+	errAudit := errors.New("не удалось записать аудит")
+
+	err := services.Tx.WithinTx(context.Background(), func(ctx context.Context) error {
+		if err := createUserWithOrder(ctx, db, log, product.ID); err != nil {
+			return err
+		}
+		return errAudit
+	})
+	require.ErrorIs(t, err, errAudit)
+	assertNothingPersisted(t, db, product.ID)
+}
+
+func TestWithinTx_RollsBackAcrossRepositoriesOnPanic(t *testing.T) {
+	services, db, log := setupTestServices(t)
+	product := seedTxProduct(t, db)
+
+	assert.PanicsWithValue(t, "boom", func() {
+		_ = services.Tx.WithinTx(context.Background(), func(ctx context.Context) error {
+			if err := createUserWithOrder(ctx, db, log, product.ID); err != nil {
+				return err
+			}
+			panic("boom")
+		})
+	})
+	assertNothingPersisted(t, db, product.ID)
+}
+
+// TestWithinTx_ServiceCallOnSingleConnection выполняет создание заказа сервисом внутри транзакции
+// на конфигурации приложения с одним соединением SQLite: репозиторий, читающий мимо транзакции,
+// ждал бы занятое транзакцией соединение до истечения таймаута
+func TestWithinTx_ServiceCallOnSingleConnection(t *testing.T) {
+	c := newE2EClient(t)
+	services := NewServices(c.app.DB, c.app.Config, c.app.Logger)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := services.User.CreateUser(ctx, user_model.CreateUserRequest{
+		Name: "Alice", Email: "alice@example.com", Age: 30, Password: "alice-password",
+	})
+	require.NoError(t, err)
+	product, err := services.Product.CreateProduct(ctx, product_model.CreateProductRequest{
+		Name: "SKU", Price: 100, Currency: "RUB", Stock: 5,
+	})
+	require.NoError(t, err)
+	req := order_model.CreateOrderRequest{
+		Currency: "RUB",
+		Items:    []order_model.OrderItemRequest{{ProductID: product.ID, Quantity: 3}},
+	}
+
+	errAudit := errors.New("не удалось записать аудит")
+	err = services.Tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := services.Order.CreateOrder(ctx, user.ID, req); err != nil {
+			return err
+		}
+		return errAudit
+	})
+	require.ErrorIs(t, err, errAudit)
+	got, err := services.Product.GetProductByID(ctx, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 5, got.Stock, "резерв товара должен быть откачен вместе с заказом")
+
+	err = services.Tx.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := services.Order.CreateOrder(ctx, user.ID, req)
+		return err
+	})
+	require.NoError(t, err)
+	got, err = services.Product.GetProductByID(ctx, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.Stock)
+}
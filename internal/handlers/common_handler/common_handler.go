@@ -1,19 +1,15 @@
 package common_handler
 
 import (
-	"errors"
 	"strconv"
+	"strings"
 
+	"github.com/IlyushinDM/user-order-api/internal/utils/i18n_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/problem_util"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
-// ErrorResponse определяет стандартную структуру ответа с ошибкой
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Details string `json:"details,omitempty"`
-}
-
 // CommonHandlerInterface определяет интерфейс для общих вспомогательных функций,
 // используемых другими модулями, например, для пагинации и фильтрации.
 type CommonHandlerInterface interface {
@@ -46,16 +42,14 @@ func (h *CommonHandler) GetPaginationParams(c *gin.Context) (page, limit int, er
 	if parseErr != nil || page < 1 {
 		h.log.WithContext(c.Request.Context()).Warnf(
 			"Неверный формат параметра страницы: %s, значение по умолчанию равно 1", pageStr)
-		return 0, 0, errors.New(
-			"недопустимый параметр страницы: должен быть положительным целым числом")
+		return 0, 0, NewValidationError(Violation{Field: "page", Rule: RulePositiveInt, Message: i18n_util.MsgInvalidPage})
 	}
 
 	limit, parseErr = strconv.Atoi(limitStr)
 	if parseErr != nil || limit < 1 {
 		h.log.WithContext(c.Request.Context()).Warnf(
 			"Недопустимый формат параметра ограничения: %s, значение по умолчанию равно 10", limitStr)
-		return 0, 0, errors.New(
-			"недопустимый параметр limit: должен быть положительным целым числом")
+		return 0, 0, NewValidationError(Violation{Field: "limit", Rule: RulePositiveInt, Message: i18n_util.MsgInvalidLimit})
 	}
 
 	// Ограничение максимального значения limit для защиты от перегрузки
@@ -79,8 +73,7 @@ func (h *CommonHandler) GetFilteringParams(c *gin.Context) (map[string]any, erro
 			filters["min_age"] = minAge
 		} else {
 			log.Warnf("Некорректный параметр min_age: %s", minAgeStr)
-			return nil, errors.New(
-				"недопустимый параметр min_age: должен быть положительным целым числом")
+			return nil, NewValidationError(Violation{Field: "min_age", Rule: RulePositiveInt, Message: i18n_util.MsgInvalidMinAge})
 		}
 	}
 
@@ -91,8 +84,7 @@ func (h *CommonHandler) GetFilteringParams(c *gin.Context) (map[string]any, erro
 			filters["max_age"] = maxAge
 		} else {
 			log.Warnf("Некорректный параметр max_age: %s", maxAgeStr)
-			return nil, errors.New(
-				"недопустимый параметр max_age: должен быть положительным целым числом")
+			return nil, NewValidationError(Violation{Field: "max_age", Rule: RulePositiveInt, Message: i18n_util.MsgInvalidMaxAge})
 		}
 	}
 
@@ -103,3 +95,34 @@ func (h *CommonHandler) GetFilteringParams(c *gin.Context) (map[string]any, erro
 
 	return filters, nil
 }
+
+// ETag возвращает значение заголовка ETag для версии записи
+func ETag(version uint) string {
+	return `"` + strconv.FormatUint(uint64(version), 10) + `"`
+}
+
+// SetETag устанавливает заголовок ETag ответа по версии записи
+func SetETag(c *gin.Context, version uint) {
+	c.Header("ETag", ETag(version))
+}
+
+// RequireIfMatch извлекает ожидаемую версию записи из заголовка If-Match.
+// Если заголовка нет, отвечает 428, если его значение не является ETag, выданным сервером, - 400.
+func RequireIfMatch(c *gin.Context) (uint, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		problem_util.Respond(c, problem_util.PreconditionRequired, "")
+		return 0, false
+	}
+
+	if len(header) < 3 || header[0] != '"' || header[len(header)-1] != '"' {
+		problem_util.Respond(c, problem_util.InvalidIfMatch, i18n_util.MsgInvalidIfMatchValue, header)
+		return 0, false
+	}
+	version, err := strconv.ParseUint(header[1:len(header)-1], 10, 32)
+	if err != nil || version == 0 {
+		problem_util.Respond(c, problem_util.InvalidIfMatch, i18n_util.MsgInvalidIfMatchValue, header)
+		return 0, false
+	}
+	return uint(version), true
+}
@@ -0,0 +1,76 @@
+package common_handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/IlyushinDM/user-order-api/internal/services/order_service"
+	"github.com/IlyushinDM/user-order-api/internal/services/product_service"
+	"github.com/IlyushinDM/user-order-api/internal/services/user_service"
+	"github.com/IlyushinDM/user-order-api/internal/utils/i18n_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/problem_util"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// serviceErrorKinds сопоставляет ошибки сервисов с классами ошибок API.
+// Ошибка проверяется через errors.Is сверху вниз, поэтому доменные ошибки
+// стоят раньше общих ошибок входных данных. Не найденные здесь ошибки считаются внутренними.
+var serviceErrorKinds = []struct {
+	err  error
+	kind problem_util.Kind
+}{
+	{user_service.ErrUserNotFound, problem_util.UserNotFound},
+	{user_service.ErrUserAlreadyExists, problem_util.EmailAlreadyTaken},
+	{user_service.ErrEmailAlreadyTaken, problem_util.EmailAlreadyTaken},
+	{user_service.ErrInvalidCredentials, problem_util.InvalidCredentials},
+	{user_service.ErrInvalidRefreshToken, problem_util.RefreshTokenInvalid},
+	{user_service.ErrRefreshTokenReused, problem_util.RefreshTokenReused},
+	{user_service.ErrVersionMismatch, problem_util.VersionMismatch},
+	{user_service.ErrAccountLocked, problem_util.AccountLocked},
+	{user_service.ErrLoginThrottled, problem_util.TooManyRequests},
+	{user_service.ErrInvalidServiceInput, problem_util.ValidationFailed},
+
+	{order_service.ErrOrderNotFound, problem_util.OrderNotFound},
+	{order_service.ErrOrderNotEditable, problem_util.OrderNotEditable},
+	{order_service.ErrInvalidStatusTransition, problem_util.InvalidTransition},
+	{order_service.ErrInsufficientStock, problem_util.InsufficientStock},
+	{order_service.ErrProductNotFound, problem_util.UnknownProduct},
+	{order_service.ErrCurrencyMismatch, problem_util.CurrencyMismatch},
+	{order_service.ErrVersionMismatch, problem_util.VersionMismatch},
+	{order_service.ErrInvalidServiceInput, problem_util.ValidationFailed},
+
+	{product_service.ErrProductNotFound, problem_util.ProductNotFound},
+	{product_service.ErrInvalidServiceInput, problem_util.ValidationFailed},
+}
+
+// ProblemKindOf возвращает класс ошибки API для ошибки сервиса
+func ProblemKindOf(err error) problem_util.Kind {
+	for _, mapping := range serviceErrorKinds {
+		if errors.Is(err, mapping.err) {
+			return mapping.kind
+		}
+	}
+	return problem_util.Internal
+}
+
+// RespondError отвечает на ошибку сервиса в формате application/problem+json и записывает ее в лог.
+// Текст ошибки передается клиенту только для ошибок клиента (4xx): ошибки сервера
+// могут содержать подробности о базе данных. Сервисы пишут ошибки на языке по умолчанию,
+// поэтому клиенту на другом языке достаются только переведенные заголовок и код.
+func RespondError(c *gin.Context, logger logrus.FieldLogger, err error) {
+	kind := ProblemKindOf(err)
+	logger = logger.WithError(err).WithFields(logrus.Fields{"code": kind.Code, "status": kind.Status})
+
+	if kind.Status >= http.StatusInternalServerError {
+		logger.Error("Запрос завершился ошибкой сервера")
+		problem_util.RespondDetail(c, kind, "")
+		return
+	}
+	logger.Warn("Запрос отклонен")
+	detail := ""
+	if i18n_util.FromContext(c) == i18n_util.Default {
+		detail = err.Error()
+	}
+	problem_util.RespondDetail(c, kind, detail)
+}
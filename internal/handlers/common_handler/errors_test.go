@@ -0,0 +1,79 @@
+package common_handler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/handlers/common_handler"
+	"github.com/IlyushinDM/user-order-api/internal/services/order_service"
+	"github.com/IlyushinDM/user-order-api/internal/services/product_service"
+	"github.com/IlyushinDM/user-order-api/internal/services/user_service"
+	"github.com/IlyushinDM/user-order-api/internal/utils/problem_util"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProblemKindOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind problem_util.Kind
+	}{
+		{"Пользователь не найден", user_service.ErrUserNotFound, problem_util.UserNotFound},
+		{"Email занят", fmt.Errorf("%w: alice@example.com", user_service.ErrEmailAlreadyTaken), problem_util.EmailAlreadyTaken},
+		{"Вход заблокирован", &user_service.LoginBlockedError{RetryAfter: time.Minute, Locked: true}, problem_util.AccountLocked},
+		{"Вход отложен", &user_service.LoginBlockedError{RetryAfter: time.Second}, problem_util.TooManyRequests},
+		{"Конфликт версий заказа", order_service.ErrVersionMismatch, problem_util.VersionMismatch},
+		{"Продукт заказа не найден", order_service.ErrProductNotFound, problem_util.UnknownProduct},
+		{"Продукт каталога не найден", product_service.ErrProductNotFound, problem_util.ProductNotFound},
+		{"Недостаточно товара", fmt.Errorf("%w: продукт 3", order_service.ErrInsufficientStock), problem_util.InsufficientStock},
+		{"Неизвестная ошибка", errors.New("connection refused"), problem_util.Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.kind, common_handler.ProblemKindOf(tt.err))
+		})
+	}
+}
+
+func TestRespondError(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	t.Run("Ошибка клиента содержит подробности", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := setupTestGinContext(w, "/api/users/1/orders/7")
+		err := fmt.Errorf("%w: заказ 7", order_service.ErrOrderNotFound)
+
+		common_handler.RespondError(c, logger, err)
+
+		assert.True(t, c.IsAborted())
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, problem_util.ContentType, w.Header().Get("Content-Type"))
+		var problem problem_util.Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, "order_not_found", problem.Code)
+		assert.Equal(t, err.Error(), problem.Detail)
+		assert.Equal(t, "/api/users/1/orders/7", problem.Instance)
+	})
+
+	t.Run("Ошибка сервера скрывает подробности", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := setupTestGinContext(w, "/api/users")
+
+		common_handler.RespondError(c, logger, errors.New("pq: password authentication failed"))
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		var problem problem_util.Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, "internal_error", problem.Code)
+		assert.Empty(t, problem.Detail)
+		assert.NotContains(t, w.Body.String(), "password")
+	})
+}
@@ -0,0 +1,112 @@
+package common_handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/money_model"
+	"github.com/IlyushinDM/user-order-api/internal/utils/i18n_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/problem_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/validation_util"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+)
+
+func init() {
+	// Собственные правила моделей и имена полей из JSON в ошибках валидации
+	if err := validation_util.RegisterBinding(); err != nil {
+		logrus.WithError(err).Panic("Не удалось зарегистрировать правила валидации")
+	}
+}
+
+// Правила проверки параметров запроса, которые проверяются без валидатора
+const (
+	RulePositiveInt = "positive_int"
+	RuleType        = "type"
+)
+
+// Violation описывает нарушение правила валидации поля; текст переводится при ответе
+type Violation = validation_util.Violation
+
+// ValidationError - ошибка входных данных с нарушениями правил отдельных полей
+type ValidationError struct {
+	Violations []Violation
+}
+
+// NewValidationError создает ошибку входных данных из нарушений правил полей
+func NewValidationError(violations ...Violation) *ValidationError {
+	return &ValidationError{Violations: violations}
+}
+
+// Error возвращает описание всех нарушений на языке по умолчанию (для логов)
+func (e *ValidationError) Error() string {
+	return joinMessages(e.fieldErrors(i18n_util.Default))
+}
+
+// fieldErrors переводит нарушения на язык lang
+func (e *ValidationError) fieldErrors(lang i18n_util.Lang) []problem_util.FieldError {
+	fields := make([]problem_util.FieldError, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		fields = append(fields, problem_util.FieldError{
+			Field:   violation.Field,
+			Rule:    violation.Rule,
+			Message: violation.Text(lang),
+		})
+	}
+	return fields
+}
+
+// RespondInvalidInput отвечает ошибкой validation_failed на некорректные входные данные запроса:
+// ошибки привязки и валидации тела, параметров пути и запроса. Нарушения правил отдельных полей
+// перечисляются в поле errors ответа, тексты переводятся на язык запроса.
+func RespondInvalidInput(c *gin.Context, err error) {
+	detail, fields := InvalidInput(i18n_util.FromContext(c), err)
+	problem_util.RespondFields(c, detail, fields)
+}
+
+// InvalidInput возвращает описание ошибки входных данных на языке lang
+// и список нарушений правил полей, если ошибка относится к конкретным полям
+func InvalidInput(lang i18n_util.Lang, err error) (string, []problem_util.FieldError) {
+	var (
+		validationErrs validator.ValidationErrors
+		inputErr       *ValidationError
+		syntaxErr      *json.SyntaxError
+		typeErr        *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &validationErrs):
+		inputErr = NewValidationError(validation_util.Violations(validationErrs)...)
+	case errors.As(err, &inputErr):
+	case errors.As(err, &typeErr):
+		inputErr = NewValidationError(Violation{
+			Field:   typeErr.Field,
+			Rule:    RuleType,
+			Message: i18n_util.MsgInvalidFieldType,
+			Args:    []any{typeErr.Field, typeErr.Type.String()},
+		})
+	case errors.Is(err, money_model.ErrInvalidAmount):
+		return i18n_util.T(lang, i18n_util.MsgInvalidAmount), nil
+	case errors.As(err, &syntaxErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return i18n_util.T(lang, i18n_util.MsgMalformedBody), nil
+	default:
+		// Текст прочих ошибок разбора написан на языке по умолчанию, на другие языки он не переводится
+		if lang == i18n_util.Default {
+			return err.Error(), nil
+		}
+		return i18n_util.T(lang, i18n_util.MsgInvalidRequest), nil
+	}
+
+	fields := inputErr.fieldErrors(lang)
+	return joinMessages(fields), fields
+}
+
+func joinMessages(fields []problem_util.FieldError) string {
+	messages := make([]string, 0, len(fields))
+	for _, field := range fields {
+		messages = append(messages, field.Message)
+	}
+	return strings.Join(messages, "; ")
+}
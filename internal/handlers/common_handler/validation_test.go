@@ -0,0 +1,148 @@
+package common_handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/IlyushinDM/user-order-api/internal/handlers/common_handler"
+	"github.com/IlyushinDM/user-order-api/internal/models/order_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/product_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/user_model"
+	"github.com/IlyushinDM/user-order-api/internal/utils/i18n_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/problem_util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bindError привязывает JSON тело к obj и возвращает ошибку привязки
+func bindError(t *testing.T, body string, obj any) error {
+	t.Helper()
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	err := c.ShouldBindJSON(obj)
+	require.Error(t, err)
+	return err
+}
+
+func TestInvalidInputMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		obj    any
+		ru, en string
+		rules  []string // нарушенные правила в виде поле:правило
+	}{
+		{
+			name:  "Обязательное поле и email",
+			body:  `{"email": "not-an-email", "age": 30, "password": "secret1"}`,
+			obj:   &user_model.CreateUserRequest{},
+			ru:    "поле name обязательно; поле email должно содержать корректный email",
+			en:    "field name is required; field email must be a valid email address",
+			rules: []string{"name:required", "email:email"},
+		},
+		{
+			name:  "Вложенная позиция заказа",
+			body:  `{"currency": "RUB", "items": [{"product_id": 1, "quantity": -1}]}`,
+			obj:   &order_model.CreateOrderRequest{},
+			ru:    "поле items[0].quantity должно быть больше 0",
+			en:    "field items[0].quantity must be greater than 0",
+			rules: []string{"items[0].quantity:gt"},
+		},
+		{
+			name:  "Допустимые значения",
+			body:  `{"status": "lost"}`,
+			obj:   &order_model.UpdateOrderStatusRequest{},
+			ru:    "поле status должно иметь одно из значений: pending, confirmed, paid, shipped, delivered, cancelled, refunded",
+			en:    "field status must be one of: pending, confirmed, paid, shipped, delivered, cancelled, refunded",
+			rules: []string{"status:oneof"},
+		},
+		{
+			name:  "Длина строки",
+			body:  `{"name": "Alice", "email": "a@example.com", "age": 30, "password": "123"}`,
+			obj:   &user_model.CreateUserRequest{},
+			ru:    "поле password должно содержать не менее 6 символов",
+			en:    "field password must be at least 6 characters long",
+			rules: []string{"password:min"},
+		},
+		{
+			name:  "Пустое название продукта",
+			body:  `{"name": "   "}`,
+			obj:   &product_model.UpdateProductRequest{},
+			ru:    "поле name не должно быть пустым и должно содержать не более 255 символов",
+			en:    "field name must not be blank and must be at most 255 characters long",
+			rules: []string{"name:product_name"},
+		},
+		{
+			name:  "Имя из пробелов и возраст вне диапазона",
+			body:  `{"name": " ", "email": "a@example.com", "age": 200, "password": "secret1"}`,
+			obj:   &user_model.CreateUserRequest{},
+			ru:    "поле name не должно быть пустым и должно содержать не более 255 символов; поле age должно быть от 1 до 150",
+			en:    "field name must not be blank and must be at most 255 characters long; field age must be between 1 and 150",
+			rules: []string{"name:person_name", "age:age"},
+		},
+		{
+			name:  "Неверный тип поля",
+			body:  `{"name": "Alice", "email": "a@example.com", "age": "thirty", "password": "secret1"}`,
+			obj:   &user_model.CreateUserRequest{},
+			ru:    "Поле age имеет неверный тип, ожидается int",
+			en:    "Field age has an invalid type, expected int",
+			rules: []string{"age:type"},
+		},
+		{
+			name: "Некорректная денежная сумма",
+			body: `{"name": "Кофе", "price": 1.5}`,
+			obj:  &product_model.CreateProductRequest{},
+			ru:   `Некорректная денежная сумма: ожидается строка, например "12.50"`,
+			en:   `Invalid money amount: a string such as "12.50" is expected`,
+		},
+		{
+			name: "Некорректный JSON",
+			body: `{"name":`,
+			obj:  &user_model.CreateUserRequest{},
+			ru:   "Тело запроса не является корректным JSON",
+			en:   "Request body is not valid JSON",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := bindError(t, tt.body, tt.obj)
+			ru, fields := common_handler.InvalidInput(i18n_util.Ru, err)
+			assert.Equal(t, tt.ru, ru)
+			en, enFields := common_handler.InvalidInput(i18n_util.En, err)
+			assert.Equal(t, tt.en, en)
+
+			var rules []string
+			for i, field := range fields {
+				rules = append(rules, field.Field+":"+field.Rule)
+				assert.Equal(t, field.Field, enFields[i].Field)
+				assert.NotEqual(t, field.Message, enFields[i].Message)
+			}
+			assert.Equal(t, tt.rules, rules)
+		})
+	}
+}
+
+func TestRespondInvalidInput_QueryParameters(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := setupTestGinContext(w, "/api/users?page=0")
+	i18n_util.SetLang(c, i18n_util.En)
+
+	_, _, err := common_handler.NewCommonHandler(nil).GetPaginationParams(c)
+	require.Error(t, err)
+	common_handler.RespondInvalidInput(c, err)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var problem problem_util.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "validation_failed", problem.Code)
+	assert.Equal(t, []problem_util.FieldError{{
+		Field:   "page",
+		Rule:    common_handler.RulePositiveInt,
+		Message: "invalid page parameter: must be a positive integer",
+	}}, problem.Errors)
+}
@@ -0,0 +1,156 @@
+package health_handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/health_model"
+	"github.com/IlyushinDM/user-order-api/internal/repository/database"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// errShuttingDown сообщает, что приложение останавливается и новые запросы не принимает
+var errShuttingDown = errors.New("приложение останавливается")
+
+// HealthHandler отвечает на проверки живости и готовности приложения
+type HealthHandler struct {
+	db       *gorm.DB
+	migrator *database.Migrator
+	timeout  time.Duration
+	draining atomic.Bool
+	log      *logrus.Logger
+}
+
+// NewHealthHandler создает HealthHandler. timeout ограничивает время каждой проверки готовности.
+func NewHealthHandler(db *gorm.DB, timeout time.Duration, log *logrus.Logger) *HealthHandler {
+	if db == nil {
+		logrus.Panic("Экземпляр *gorm.DB не может быть nil в NewHealthHandler")
+	}
+	if log == nil {
+		defaultLog := logrus.New()
+		defaultLog.SetLevel(logrus.InfoLevel)
+		defaultLog.Warn("Logger не указан в NewHealthHandler, используется logger по умолчанию")
+		log = defaultLog
+	}
+
+	// Без мигратора проверка миграций всегда неуспешна, но проверка базы данных продолжает работать
+	migrator, err := database.NewEmbeddedMigrator(db, log)
+	if err != nil {
+		log.WithError(err).Error("Не удалось создать мигратор для проверки готовности")
+	}
+	return &HealthHandler{db: db, migrator: migrator, timeout: timeout, log: log}
+}
+
+// StartDraining переводит проверку готовности в неуспешное состояние перед остановкой сервера,
+// чтобы балансировщик перестал направлять новые запросы
+func (h *HealthHandler) StartDraining() {
+	h.draining.Store(true)
+}
+
+// Liveness godoc
+// @Summary Проверка живости
+// @Description Отвечает 200, пока процесс способен обрабатывать запросы. Зависимости не проверяются.
+// @Tags health
+// @Produce json
+// @Success 200 {object} health_model.LivenessResponse "Процесс жив"
+// @Router /healthz [get]
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, health_model.LivenessResponse{Status: health_model.StatusOK})
+}
+
+// Readiness godoc
+// @Summary Проверка готовности
+// @Description Проверяет доступность базы данных и применение всех миграций. Во время остановки сервера отвечает 503.
+// @Tags health
+// @Produce json
+// @Success 200 {object} health_model.ReadinessResponse "Приложение готово принимать запросы"
+// @Failure 503 {object} health_model.ReadinessResponse "Приложение не готово; причины в checks"
+// @Router /readyz [get]
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	response := health_model.ReadinessResponse{
+		Status: health_model.StatusOK,
+		Checks: map[string]health_model.CheckResult{
+			"shutdown":   h.runCheck(c.Request.Context(), h.checkShutdown),
+			"database":   h.runCheck(c.Request.Context(), h.checkDatabase),
+			"migrations": h.runCheck(c.Request.Context(), h.checkMigrations),
+		},
+	}
+
+	status := http.StatusOK
+	for name, check := range response.Checks {
+		if check.Status != health_model.StatusOK {
+			response.Status = health_model.StatusFail
+			status = http.StatusServiceUnavailable
+			h.log.WithContext(c.Request.Context()).WithField("check", name).WithField("error", check.Error).Warn("Проверка готовности не пройдена")
+		}
+	}
+	c.JSON(status, response)
+}
+
+// runCheck выполняет проверку с ограничением по времени и измеряет ее длительность
+func (h *HealthHandler) runCheck(
+	ctx context.Context,
+	check func(ctx context.Context, result *health_model.CheckResult) error,
+) health_model.CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	result := health_model.CheckResult{Status: health_model.StatusOK}
+	if err := check(ctx, &result); err != nil {
+		result.Status = health_model.StatusFail
+		result.Error = err.Error()
+	}
+	result.DurationMS = time.Since(start).Milliseconds()
+	return result
+}
+
+func (h *HealthHandler) checkShutdown(_ context.Context, _ *health_model.CheckResult) error {
+	if h.draining.Load() {
+		return errShuttingDown
+	}
+	return nil
+}
+
+// checkDatabase проверяет соединение с базой данных
+func (h *HealthHandler) checkDatabase(ctx context.Context, _ *health_model.CheckResult) error {
+	sqlDB, err := h.db.DB()
+	if err != nil {
+		return fmt.Errorf("не удалось получить пул соединений: %w", err)
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("база данных недоступна: %w", err)
+	}
+	return nil
+}
+
+// checkMigrations проверяет, что применены все миграции, известные приложению
+func (h *HealthHandler) checkMigrations(ctx context.Context, result *health_model.CheckResult) error {
+	if h.migrator == nil {
+		return errors.New("мигратор не инициализирован")
+	}
+	statuses, err := h.migrator.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("не удалось получить состояние миграций: %w", err)
+	}
+
+	var version uint
+	for _, status := range statuses {
+		if status.Applied {
+			version = status.Version
+		} else {
+			result.Pending = append(result.Pending, status.Version)
+		}
+	}
+	result.Version = &version
+	if len(result.Pending) > 0 {
+		return fmt.Errorf("%w: не применены миграции %v", database.ErrSchemaOutdated, result.Pending)
+	}
+	return nil
+}
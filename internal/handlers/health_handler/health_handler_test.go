@@ -0,0 +1,116 @@
+package health_handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/health_model"
+	"github.com/IlyushinDM/user-order-api/internal/repository/database"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestHandler(t *testing.T, migrate bool) (*HealthHandler, *gorm.DB) {
+	t.Helper()
+	log := logrus.New()
+	log.SetLevel(logrus.PanicLevel)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if migrate {
+		require.NoError(t, database.RunMigrations(db, log))
+	}
+	return NewHealthHandler(db, time.Second, log), db
+}
+
+func readiness(t *testing.T, h *HealthHandler) (int, health_model.ReadinessResponse) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/readyz", nil)
+
+	h.Readiness(c)
+
+	var resp health_model.ReadinessResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestLiveness(t *testing.T) {
+	h, _ := newTestHandler(t, false)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/healthz", nil)
+
+	h.Liveness(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+}
+
+func TestReadiness_Ready(t *testing.T) {
+	h, _ := newTestHandler(t, true)
+
+	code, resp := readiness(t, h)
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, health_model.StatusOK, resp.Status)
+	for _, name := range []string{"shutdown", "database", "migrations"} {
+		assert.Equal(t, health_model.StatusOK, resp.Checks[name].Status, name)
+	}
+	require.NotNil(t, resp.Checks["migrations"].Version)
+	assert.Equal(t, h.migrator.LatestVersion(), *resp.Checks["migrations"].Version)
+	assert.Empty(t, resp.Checks["migrations"].Pending)
+}
+
+func TestReadiness_PendingMigrations(t *testing.T) {
+	h, _ := newTestHandler(t, false)
+
+	code, resp := readiness(t, h)
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health_model.StatusFail, resp.Status)
+	assert.Equal(t, health_model.StatusOK, resp.Checks["database"].Status)
+	migrations := resp.Checks["migrations"]
+	assert.Equal(t, health_model.StatusFail, migrations.Status)
+	assert.Len(t, migrations.Pending, len(h.migrator.Migrations()))
+	assert.Contains(t, migrations.Error, "не применены миграции")
+}
+
+func TestReadiness_DatabaseUnavailable(t *testing.T) {
+	h, db := newTestHandler(t, true)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+
+	code, resp := readiness(t, h)
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health_model.StatusFail, resp.Checks["database"].Status)
+	assert.NotEmpty(t, resp.Checks["database"].Error)
+}
+
+func TestReadiness_Draining(t *testing.T) {
+	h, _ := newTestHandler(t, true)
+	h.StartDraining()
+
+	code, resp := readiness(t, h)
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health_model.StatusFail, resp.Checks["shutdown"].Status)
+	assert.Equal(t, health_model.StatusOK, resp.Checks["database"].Status)
+}
@@ -7,7 +7,10 @@ import (
 
 	"github.com/IlyushinDM/user-order-api/internal/handlers/common_handler"
 	"github.com/IlyushinDM/user-order-api/internal/models/order_model"
+	"github.com/IlyushinDM/user-order-api/internal/policy/access_policy"
 	"github.com/IlyushinDM/user-order-api/internal/services/order_service"
+	"github.com/IlyushinDM/user-order-api/internal/utils/i18n_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/problem_util"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
@@ -41,100 +44,118 @@ func NewOrderHandler(
 	return &OrderHandler{orderService, commonHandler, log}
 }
 
-// checkUserIDMatch проверяет соответствие userID из URL и аутентифицированного userID из контекста
-func (h *OrderHandler) checkUserIDMatch(c *gin.Context) (uint, bool) {
-	authUserID, exists := c.Get("userID")
+// newOrderResponse преобразует модель заказа в ответ API, вычисляя стоимость позиций и итоговую сумму
+func newOrderResponse(order *order_model.Order) order_model.OrderResponse {
+	items := make([]order_model.OrderItemResponse, len(order.Items))
+	for i, item := range order.Items {
+		items[i] = order_model.OrderItemResponse{
+			ID:          item.ID,
+			ProductID:   item.ProductID,
+			ProductName: item.ProductName,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			LineTotal:   item.LineTotal(),
+		}
+	}
+	return order_model.OrderResponse{
+		ID:       order.ID,
+		UserID:   order.UserID,
+		Status:   order.Status,
+		Currency: order.Currency,
+		Items:    items,
+		Total:    order.Total(),
+	}
+}
+
+// authorizeOrderAccess проверяет по политике доступа, может ли аутентифицированный пользователь
+// выполнить действие над заказами пользователя из URL. Возвращает ID владельца заказов из URL.
+func (h *OrderHandler) authorizeOrderAccess(c *gin.Context, action access_policy.Action) (uint, bool) {
+	subject, exists := access_policy.CurrentSubject(c)
 	if !exists {
-		h.log.Error("Ошибка аутентификации: userID не найден в контексте")
-		c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Ошибка аутентификации"})
+		h.requestLogger(c).Error("Ошибка аутентификации: userID не найден в контексте")
+		problem_util.Respond(c, problem_util.Internal, "")
 		return 0, false
 	}
 
 	urlUserIDStr := c.Param("id")
 	urlUserID, err := strconv.ParseUint(urlUserIDStr, 10, 32)
 	if err != nil {
-		h.log.WithError(err).Warnf("Неверный формат userID в URL: '%s'", urlUserIDStr)
-		c.JSON(http.StatusBadRequest, common_handler.ErrorResponse{Error: "Некорректный формат ID пользователя"})
+		h.requestLogger(c).WithError(err).Warnf("Неверный формат userID в URL: '%s'", urlUserIDStr)
+		problem_util.Respond(c, problem_util.ValidationFailed, i18n_util.MsgInvalidUserID)
 		return 0, false
 	}
 
-	if uint(urlUserID) != authUserID.(uint) {
-		h.log.Warnf("Доступ запрещен: пользователь %d пытается получить доступ к данным пользователя %d", authUserID.(uint), urlUserID)
-		c.JSON(http.StatusForbidden, common_handler.ErrorResponse{Error: "Доступ запрещен"})
+	if !access_policy.Can(subject, action, access_policy.ResourceOrder, uint(urlUserID)) {
+		h.requestLogger(c).Warnf("Доступ запрещен: пользователь %d (роль %s) пытается выполнить %s над заказами пользователя %d",
+			subject.UserID, subject.Role, action, urlUserID)
+		problem_util.Respond(c, problem_util.Forbidden, "")
 		return 0, false
 	}
 
-	return authUserID.(uint), true
+	return uint(urlUserID), true
 }
 
 // CreateOrder godoc
 // @Summary Создание нового заказа
-// @Description Создает новый заказ для аутентифицированного пользователя
+// @Description Создает новый заказ из одной или нескольких позиций. Позиции ссылаются на продукты каталога по ID, цены берутся из каталога, итоговая сумма вычисляется сервером. Количество резервируется на складе; при нехватке товара заказ не создается. Обычный пользователь может создавать заказы только для себя, администратор - для любого пользователя
 // @Tags Заказы
 // @Accept json
 // @Produce json
 // @Param id path int true "ID пользователя" Format(uint)
+// @Param Idempotency-Key header string false "Ключ идемпотентности: повтор запроса с тем же ключом возвращает сохраненный ответ"
 // @Param order body order_model.CreateOrderRequest true "Данные заказа"
 // @Success 201 {object} order_model.OrderResponse "Заказ успешно создан"
-// @Failure 400 {object} common_handler.ErrorResponse "Некорректные входные данные"
-// @Failure 401 {object} common_handler.ErrorResponse "Не авторизован"
-// @Failure 403 {object} common_handler.ErrorResponse "Доступ запрещен"
-// @Failure 500 {object} common_handler.ErrorResponse "Внутренняя ошибка сервера"
+// @Failure 400 {object} problem_util.Problem "Некорректные входные данные"
+// @Failure 401 {object} problem_util.Problem "Не авторизован"
+// @Failure 403 {object} problem_util.Problem "Доступ запрещен"
+// @Failure 409 {object} problem_util.Problem "Недостаточно товара на складе или запрос с тем же ключом идемпотентности еще выполняется"
+// @Failure 422 {object} problem_util.Problem "Продукт не найден в каталоге, его валюта не совпадает с валютой заказа или ключ идемпотентности использован для другого запроса"
+// @Failure 429 {object} problem_util.Problem "Превышен лимит запросов"
+// @Failure 500 {object} problem_util.Problem "Внутренняя ошибка сервера"
 // @Security BearerAuth
 // @Router /api/users/{id}/orders [post]
 func (h *OrderHandler) CreateOrder(c *gin.Context) {
-	authUserID, ok := h.checkUserIDMatch(c)
+	ownerID, ok := h.authorizeOrderAccess(c, access_policy.ActionCreate)
 	if !ok {
 		return
 	}
 
 	var req order_model.CreateOrderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.log.WithError(err).Warn("Некорректный формат запроса")
-		c.JSON(http.StatusBadRequest, common_handler.ErrorResponse{Error: "Некорректные входные данные"})
+		h.requestLogger(c).WithError(err).Warn("Некорректный формат запроса")
+		common_handler.RespondInvalidInput(c, err)
 		return
 	}
 
-	order, err := h.orderService.CreateOrder(c.Request.Context(), authUserID, req)
+	order, err := h.orderService.CreateOrder(c.Request.Context(), ownerID, req)
 	if err != nil {
-		h.log.WithError(err).Errorf("Ошибка при создании заказа для пользователя %d", authUserID)
-		switch {
-		case errors.Is(err, order_service.ErrInvalidServiceInput):
-			c.JSON(http.StatusBadRequest, common_handler.ErrorResponse{Error: err.Error()})
-		case errors.Is(err, order_service.ErrServiceDatabaseError):
-			c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Ошибка при создании заказа"})
-		default:
-			c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Внутренняя ошибка сервера"})
-		}
+		common_handler.RespondError(c, h.requestLogger(c).WithField("user_id", ownerID), err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, order_model.OrderResponse{
-		ID:          order.ID,
-		UserID:      order.UserID,
-		ProductName: order.ProductName,
-		Quantity:    order.Quantity,
-		Price:       order.Price,
-	})
+	common_handler.SetETag(c, order.Version)
+	c.JSON(http.StatusCreated, newOrderResponse(order))
 }
 
 // GetOrderByID godoc
 // @Summary Получение заказа по ID
-// @Description Возвращает информацию о конкретном заказе пользователя
+// @Description Возвращает информацию о конкретном заказе пользователя. Поддержка и администратор имеют доступ к заказам любого пользователя
 // @Tags Заказы
 // @Produce json
 // @Param id path int true "ID пользователя" Format(uint)
 // @Param orderID path int true "ID заказа" Format(uint)
 // @Success 200 {object} order_model.OrderResponse "Информация о заказе"
-// @Failure 400 {object} common_handler.ErrorResponse "Некорректный формат ID"
-// @Failure 401 {object} common_handler.ErrorResponse "Не авторизован"
-// @Failure 403 {object} common_handler.ErrorResponse "Доступ запрещен"
-// @Failure 404 {object} common_handler.ErrorResponse "Заказ не найден"
-// @Failure 500 {object} common_handler.ErrorResponse "Внутренняя ошибка сервера"
+// @Header 200 {string} ETag "Версия заказа для заголовка If-Match"
+// @Failure 400 {object} problem_util.Problem "Некорректный формат ID"
+// @Failure 401 {object} problem_util.Problem "Не авторизован"
+// @Failure 403 {object} problem_util.Problem "Доступ запрещен"
+// @Failure 404 {object} problem_util.Problem "Заказ не найден"
+// @Failure 429 {object} problem_util.Problem "Превышен лимит запросов"
+// @Failure 500 {object} problem_util.Problem "Внутренняя ошибка сервера"
 // @Security BearerAuth
 // @Router /api/users/{id}/orders/{orderID} [get]
 func (h *OrderHandler) GetOrderByID(c *gin.Context) {
-	authUserID, ok := h.checkUserIDMatch(c)
+	ownerID, ok := h.authorizeOrderAccess(c, access_policy.ActionRead)
 	if !ok {
 		return
 	}
@@ -142,80 +163,67 @@ func (h *OrderHandler) GetOrderByID(c *gin.Context) {
 	orderIDStr := c.Param("orderID")
 	orderID, err := strconv.ParseUint(orderIDStr, 10, 32)
 	if err != nil {
-		h.log.WithError(err).Warnf("Некорректный формат orderID: '%s'", orderIDStr)
-		c.JSON(http.StatusBadRequest, common_handler.ErrorResponse{Error: "Некорректный формат ID заказа"})
+		h.requestLogger(c).WithError(err).Warnf("Некорректный формат orderID: '%s'", orderIDStr)
+		problem_util.Respond(c, problem_util.ValidationFailed, i18n_util.MsgInvalidOrderID)
 		return
 	}
 
-	order, err := h.orderService.GetOrderByID(c.Request.Context(), uint(orderID), authUserID)
+	order, err := h.orderService.GetOrderByID(c.Request.Context(), uint(orderID), ownerID)
 	if err != nil {
-		switch {
-		case errors.Is(err, order_service.ErrOrderNotFound):
-			c.JSON(http.StatusNotFound, common_handler.ErrorResponse{Error: "Заказ не найден"})
-		case errors.Is(err, order_service.ErrInvalidServiceInput):
-			c.JSON(http.StatusBadRequest, common_handler.ErrorResponse{Error: "Некорректный запрос"})
-		case errors.Is(err, order_service.ErrServiceDatabaseError):
-			h.log.WithError(err).Errorf("Ошибка БД при получении заказа %d для пользователя %d", orderID, authUserID)
-			c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Ошибка при получении заказа"})
-		default:
-			h.log.WithError(err).Errorf("Ошибка при получении заказа %d для пользователя %d", orderID, authUserID)
-			c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Внутренняя ошибка сервера"})
-		}
+		common_handler.RespondError(c, h.orderLogger(c, uint(orderID), ownerID), err)
 		return
 	}
 
-	c.JSON(http.StatusOK, order_model.OrderResponse{
-		ID:          order.ID,
-		UserID:      order.UserID,
-		ProductName: order.ProductName,
-		Quantity:    order.Quantity,
-		Price:       order.Price,
-	})
+	common_handler.SetETag(c, order.Version)
+	c.JSON(http.StatusOK, newOrderResponse(order))
 }
 
 // GetAllOrdersByUser godoc
 // @Summary Получение всех заказов пользователя
-// @Description Возвращает список заказов пользователя с пагинацией
+// @Description Возвращает список заказов пользователя с пагинацией. Поддержка и администратор имеют доступ к заказам любого пользователя
 // @Tags Заказы
 // @Produce json
 // @Param id path int true "ID пользователя" Format(uint)
 // @Param page query int false "Номер страницы" default(1) minimum(1)
 // @Param limit query int false "Количество элементов на странице" default(10) minimum(1) maximum(100)
+// @Param status query string false "Фильтр по состоянию заказа" Enums(pending, confirmed, paid, shipped, delivered, cancelled, refunded)
 // @Success 200 {object} order_model.PaginatedOrdersResponse "Список заказов"
-// @Failure 400 {object} common_handler.ErrorResponse "Некорректные параметры"
-// @Failure 401 {object} common_handler.ErrorResponse "Не авторизован"
-// @Failure 403 {object} common_handler.ErrorResponse "Доступ запрещен"
-// @Failure 500 {object} common_handler.ErrorResponse "Внутренняя ошибка сервера"
+// @Failure 400 {object} problem_util.Problem "Некорректные параметры"
+// @Failure 401 {object} problem_util.Problem "Не авторизован"
+// @Failure 403 {object} problem_util.Problem "Доступ запрещен"
+// @Failure 429 {object} problem_util.Problem "Превышен лимит запросов"
+// @Failure 500 {object} problem_util.Problem "Внутренняя ошибка сервера"
 // @Security BearerAuth
 // @Router /api/users/{id}/orders [get]
 func (h *OrderHandler) GetAllOrdersByUser(c *gin.Context) {
-	authUserID, ok := h.checkUserIDMatch(c)
+	ownerID, ok := h.authorizeOrderAccess(c, access_policy.ActionRead)
 	if !ok {
 		return
 	}
 
 	page, limit, err := h.commonHandler.GetPaginationParams(c)
 	if err != nil {
-		h.log.WithError(err).Warnf("Некорректные параметры пагинации для пользователя %d", authUserID)
-		c.JSON(http.StatusBadRequest, common_handler.ErrorResponse{Error: "Неверные параметры пагинации"})
+		h.requestLogger(c).WithError(err).Warnf("Некорректные параметры пагинации для пользователя %d", ownerID)
+		common_handler.RespondInvalidInput(c, err)
 		return
 	}
 
-	orders, total, err := h.orderService.GetAllOrdersByUser(c.Request.Context(), authUserID, page, limit)
-	if err != nil {
-		switch {
-		case errors.Is(err, order_service.ErrInvalidServiceInput):
-			h.log.WithError(err).Warnf("Ошибка валидации сервиса для пользователя %d", authUserID)
-			c.JSON(http.StatusBadRequest, common_handler.ErrorResponse{Error: "Неверные параметры запроса"})
-		case errors.Is(err, order_service.ErrServiceDatabaseError):
-			h.log.WithError(err).Errorf("Ошибка БД при получении заказов пользователя %d", authUserID)
-			c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{
-				Error: "Ошибка при получении списка заказов",
-			})
-		default:
-			h.log.WithError(err).Errorf("Ошибка при получении заказов пользователя %d", authUserID)
-			c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Внутренняя ошибка сервера"})
+	filters := make(map[string]any)
+	if statusStr := c.Query("status"); statusStr != "" {
+		status := order_model.OrderStatus(statusStr)
+		if !status.IsValid() {
+			h.requestLogger(c).Warnf("Некорректный параметр status: %s", statusStr)
+			common_handler.RespondInvalidInput(c, common_handler.NewValidationError(common_handler.Violation{
+				Field: "status", Rule: "oneof", Message: i18n_util.MsgUnknownOrderStatus, Args: []any{statusStr},
+			}))
+			return
 		}
+		filters["status"] = status
+	}
+
+	orders, total, err := h.orderService.GetAllOrdersByUser(c.Request.Context(), ownerID, page, limit, filters)
+	if err != nil {
+		common_handler.RespondError(c, h.requestLogger(c).WithField("user_id", ownerID), err)
 		return
 	}
 
@@ -227,13 +235,7 @@ func (h *OrderHandler) GetAllOrdersByUser(c *gin.Context) {
 	}
 
 	for i, order := range orders {
-		response.Orders[i] = order_model.OrderResponse{
-			ID:          order.ID,
-			UserID:      order.UserID,
-			ProductName: order.ProductName,
-			Quantity:    order.Quantity,
-			Price:       order.Price,
-		}
+		response.Orders[i] = newOrderResponse(&order)
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -241,23 +243,30 @@ func (h *OrderHandler) GetAllOrdersByUser(c *gin.Context) {
 
 // UpdateOrder godoc
 // @Summary Обновление заказа
-// @Description Обновляет информацию о заказе пользователя
+// @Description Заменяет состав заказа пользователя переданным списком позиций. Цены берутся из каталога продуктов. Требует заголовок If-Match с ETag, полученным при чтении заказа. Доступно только для заказов в состоянии pending. Поддержка и администратор могут исправлять заказы любого пользователя
 // @Tags Заказы
 // @Accept json
 // @Produce json
 // @Param id path int true "ID пользователя" Format(uint)
 // @Param orderID path int true "ID заказа" Format(uint)
+// @Param If-Match header string true "ETag заказа, полученный при чтении"
 // @Param order body order_model.UpdateOrderRequest true "Данные для обновления"
 // @Success 200 {object} order_model.OrderResponse "Обновленный заказ"
-// @Failure 400 {object} common_handler.ErrorResponse "Некорректные данные"
-// @Failure 401 {object} common_handler.ErrorResponse "Не авторизован"
-// @Failure 403 {object} common_handler.ErrorResponse "Доступ запрещен"
-// @Failure 404 {object} common_handler.ErrorResponse "Заказ не найден"
-// @Failure 500 {object} common_handler.ErrorResponse "Внутренняя ошибка сервера"
+// @Header 200 {string} ETag "Новая версия заказа"
+// @Failure 400 {object} problem_util.Problem "Некорректные данные"
+// @Failure 401 {object} problem_util.Problem "Не авторизован"
+// @Failure 403 {object} problem_util.Problem "Доступ запрещен"
+// @Failure 404 {object} problem_util.Problem "Заказ не найден"
+// @Failure 409 {object} problem_util.Problem "Заказ в текущем состоянии нельзя изменить или недостаточно товара на складе"
+// @Failure 412 {object} problem_util.Problem "Заказ изменен после чтения: ETag не совпадает"
+// @Failure 422 {object} problem_util.Problem "Продукт не найден в каталоге или его валюта не совпадает с валютой заказа"
+// @Failure 428 {object} problem_util.Problem "Не передан заголовок If-Match"
+// @Failure 429 {object} problem_util.Problem "Превышен лимит запросов"
+// @Failure 500 {object} problem_util.Problem "Внутренняя ошибка сервера"
 // @Security BearerAuth
 // @Router /api/users/{id}/orders/{orderID} [put]
 func (h *OrderHandler) UpdateOrder(c *gin.Context) {
-	authUserID, ok := h.checkUserIDMatch(c)
+	ownerID, ok := h.authorizeOrderAccess(c, access_policy.ActionUpdate)
 	if !ok {
 		return
 	}
@@ -265,78 +274,57 @@ func (h *OrderHandler) UpdateOrder(c *gin.Context) {
 	orderIDStr := c.Param("orderID")
 	orderID, err := strconv.ParseUint(orderIDStr, 10, 32)
 	if err != nil {
-		h.log.WithError(err).Warnf("Некорректный формат orderID: '%s'", orderIDStr)
-		c.JSON(http.StatusBadRequest, common_handler.ErrorResponse{Error: "Некорректный формат ID заказа"})
+		h.requestLogger(c).WithError(err).Warnf("Некорректный формат orderID: '%s'", orderIDStr)
+		problem_util.Respond(c, problem_util.ValidationFailed, i18n_util.MsgInvalidOrderID)
+		return
+	}
+
+	version, ok := common_handler.RequireIfMatch(c)
+	if !ok {
+		h.requestLogger(c).Warnf("Обновление заказа %d без корректного заголовка If-Match", orderID)
 		return
 	}
 
 	var req order_model.UpdateOrderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.log.WithError(err).Warnf("Некорректный формат запроса для заказа %d", orderID)
-		c.JSON(http.StatusBadRequest, common_handler.ErrorResponse{Error: "Некорректные данные для обновления"})
+		h.requestLogger(c).WithError(err).Warnf("Некорректный формат запроса для заказа %d", orderID)
+		common_handler.RespondInvalidInput(c, err)
 		return
 	}
 
-	order, err := h.orderService.UpdateOrder(c.Request.Context(), uint(orderID), authUserID, req)
+	order, err := h.orderService.UpdateOrder(c.Request.Context(), uint(orderID), ownerID, version, req)
+	if errors.Is(err, order_service.ErrNoUpdateFields) {
+		h.requestLogger(c).WithField("order_id", orderID).Info("Получен запрос на обновление без изменений")
+		// Вернуть существующий заказ, если нет изменений
+		order, err = h.orderService.GetOrderByID(c.Request.Context(), uint(orderID), ownerID)
+	}
 	if err != nil {
-		switch {
-		case errors.Is(err, order_service.ErrOrderNotFound):
-			c.JSON(http.StatusNotFound, common_handler.ErrorResponse{Error: "Заказ не найден"})
-		case errors.Is(err, order_service.ErrNoUpdateFields):
-			h.log.WithField("order_id", orderID).Info("Получен запрос на обновление без изменений")
-			// Вернуть существующий заказ, если нет изменений
-			existingOrder, getErr := h.orderService.GetOrderByID(c.Request.Context(), uint(orderID), authUserID)
-			if getErr != nil {
-				h.log.WithError(getErr).Errorf("Ошибка при получении существующего заказа %d после ErrNoUpdateFields", orderID)
-				c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Ошибка при получении заказа"})
-				return
-			}
-			c.JSON(http.StatusOK, order_model.OrderResponse{
-				ID:          existingOrder.ID,
-				UserID:      existingOrder.UserID,
-				ProductName: existingOrder.ProductName,
-				Quantity:    existingOrder.Quantity,
-				Price:       existingOrder.Price,
-			})
-			return
-		case errors.Is(err, order_service.ErrInvalidServiceInput):
-			c.JSON(http.StatusBadRequest, common_handler.ErrorResponse{Error: err.Error()})
-		case errors.Is(err, order_service.ErrServiceDatabaseError):
-			h.log.WithError(err).Errorf("Ошибка БД при обновлении заказа %d для пользователя %d", orderID, authUserID)
-			c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Ошибка при обновлении заказа"})
-		default:
-			h.log.WithError(err).Errorf("Ошибка при обновлении заказа %d для пользователя %d", orderID, authUserID)
-			c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Внутренняя ошибка сервера"})
-		}
+		common_handler.RespondError(c, h.orderLogger(c, uint(orderID), ownerID), err)
 		return
 	}
 
-	c.JSON(http.StatusOK, order_model.OrderResponse{
-		ID:          order.ID,
-		UserID:      order.UserID,
-		ProductName: order.ProductName,
-		Quantity:    order.Quantity,
-		Price:       order.Price,
-	})
+	common_handler.SetETag(c, order.Version)
+	c.JSON(http.StatusOK, newOrderResponse(order))
 }
 
 // DeleteOrder godoc
 // @Summary Удаление заказа
-// @Description Удаляет заказ пользователя по ID
+// @Description Удаляет заказ пользователя по ID. Удалять чужие заказы может только администратор. Резерв товара неотправленного заказа возвращается на склад
 // @Tags Заказы
 // @Produce json
 // @Param id path int true "ID пользователя" Format(uint)
 // @Param orderID path int true "ID заказа" Format(uint)
 // @Success 204 "Заказ удален"
-// @Failure 400 {object} common_handler.ErrorResponse "Некорректный формат ID"
-// @Failure 401 {object} common_handler.ErrorResponse "Не авторизован"
-// @Failure 403 {object} common_handler.ErrorResponse "Доступ запрещен"
-// @Failure 404 {object} common_handler.ErrorResponse "Заказ не найден"
-// @Failure 500 {object} common_handler.ErrorResponse "Внутренняя ошибка сервера"
+// @Failure 400 {object} problem_util.Problem "Некорректный формат ID"
+// @Failure 401 {object} problem_util.Problem "Не авторизован"
+// @Failure 403 {object} problem_util.Problem "Доступ запрещен"
+// @Failure 404 {object} problem_util.Problem "Заказ не найден"
+// @Failure 429 {object} problem_util.Problem "Превышен лимит запросов"
+// @Failure 500 {object} problem_util.Problem "Внутренняя ошибка сервера"
 // @Security BearerAuth
 // @Router /api/users/{id}/orders/{orderID} [delete]
 func (h *OrderHandler) DeleteOrder(c *gin.Context) {
-	authUserID, ok := h.checkUserIDMatch(c)
+	ownerID, ok := h.authorizeOrderAccess(c, access_policy.ActionDelete)
 	if !ok {
 		return
 	}
@@ -344,26 +332,116 @@ func (h *OrderHandler) DeleteOrder(c *gin.Context) {
 	orderIDStr := c.Param("orderID")
 	orderID, err := strconv.ParseUint(orderIDStr, 10, 32)
 	if err != nil {
-		h.log.WithError(err).Warnf("Некорректный формат orderID: '%s'", orderIDStr)
-		c.JSON(http.StatusBadRequest, common_handler.ErrorResponse{Error: "Некорректный формат ID заказа"})
+		h.requestLogger(c).WithError(err).Warnf("Некорректный формат orderID: '%s'", orderIDStr)
+		problem_util.Respond(c, problem_util.ValidationFailed, i18n_util.MsgInvalidOrderID)
 		return
 	}
 
-	if err := h.orderService.DeleteOrder(c.Request.Context(), uint(orderID), authUserID); err != nil {
-		switch {
-		case errors.Is(err, order_service.ErrOrderNotFound):
-			c.JSON(http.StatusNotFound, common_handler.ErrorResponse{Error: "Заказ не найден"})
-		case errors.Is(err, order_service.ErrInvalidServiceInput):
-			c.JSON(http.StatusBadRequest, common_handler.ErrorResponse{Error: "Некорректные данные запроса"})
-		case errors.Is(err, order_service.ErrServiceDatabaseError):
-			h.log.WithError(err).Errorf("Ошибка БД при удалении заказа %d для пользователя %d", orderID, authUserID)
-			c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Ошибка при удалении заказа"})
-		default:
-			h.log.WithError(err).Errorf("Ошибка при удалении заказа %d для пользователя %d", orderID, authUserID)
-			c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Внутренняя ошибка сервера"})
-		}
+	if err := h.orderService.DeleteOrder(c.Request.Context(), uint(orderID), ownerID); err != nil {
+		common_handler.RespondError(c, h.orderLogger(c, uint(orderID), ownerID), err)
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
+
+// CancelOrder godoc
+// @Summary Отмена заказа
+// @Description Отменяет заказ, который еще не был оплачен. Оплаченный заказ отменить нельзя - для него оформляется возврат. Резерв товара возвращается на склад
+// @Tags Заказы
+// @Produce json
+// @Param id path int true "ID пользователя" Format(uint)
+// @Param orderID path int true "ID заказа" Format(uint)
+// @Success 200 {object} order_model.OrderResponse "Отмененный заказ"
+// @Failure 400 {object} problem_util.Problem "Некорректный формат ID"
+// @Failure 401 {object} problem_util.Problem "Не авторизован"
+// @Failure 403 {object} problem_util.Problem "Доступ запрещен"
+// @Failure 404 {object} problem_util.Problem "Заказ не найден"
+// @Failure 409 {object} problem_util.Problem "Заказ в текущем состоянии нельзя отменить"
+// @Failure 429 {object} problem_util.Problem "Превышен лимит запросов"
+// @Failure 500 {object} problem_util.Problem "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /api/users/{id}/orders/{orderID}/cancel [post]
+func (h *OrderHandler) CancelOrder(c *gin.Context) {
+	ownerID, ok := h.authorizeOrderAccess(c, access_policy.ActionUpdate)
+	if !ok {
+		return
+	}
+
+	orderIDStr := c.Param("orderID")
+	orderID, err := strconv.ParseUint(orderIDStr, 10, 32)
+	if err != nil {
+		h.requestLogger(c).WithError(err).Warnf("Некорректный формат orderID: '%s'", orderIDStr)
+		problem_util.Respond(c, problem_util.ValidationFailed, i18n_util.MsgInvalidOrderID)
+		return
+	}
+
+	order, err := h.orderService.CancelOrder(c.Request.Context(), uint(orderID), ownerID)
+	if err != nil {
+		common_handler.RespondError(c, h.orderLogger(c, uint(orderID), ownerID), err)
+		return
+	}
+
+	common_handler.SetETag(c, order.Version)
+	c.JSON(http.StatusOK, newOrderResponse(order))
+}
+
+// UpdateOrderStatus godoc
+// @Summary Смена состояния заказа
+// @Description Переводит заказ в указанное состояние, если переход допустим (pending -> confirmed -> paid -> shipped -> delivered; отмена до оплаты; возврат после оплаты). Доступно поддержке и администратору
+// @Tags Заказы
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя" Format(uint)
+// @Param orderID path int true "ID заказа" Format(uint)
+// @Param request body order_model.UpdateOrderStatusRequest true "Целевое состояние"
+// @Success 200 {object} order_model.OrderResponse "Заказ в новом состоянии"
+// @Failure 400 {object} problem_util.Problem "Некорректные данные"
+// @Failure 401 {object} problem_util.Problem "Не авторизован"
+// @Failure 403 {object} problem_util.Problem "Доступ запрещен"
+// @Failure 404 {object} problem_util.Problem "Заказ не найден"
+// @Failure 409 {object} problem_util.Problem "Недопустимая смена состояния"
+// @Failure 429 {object} problem_util.Problem "Превышен лимит запросов"
+// @Failure 500 {object} problem_util.Problem "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /api/users/{id}/orders/{orderID}/status [patch]
+func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
+	ownerID, ok := h.authorizeOrderAccess(c, access_policy.ActionChangeStatus)
+	if !ok {
+		return
+	}
+
+	orderIDStr := c.Param("orderID")
+	orderID, err := strconv.ParseUint(orderIDStr, 10, 32)
+	if err != nil {
+		h.requestLogger(c).WithError(err).Warnf("Некорректный формат orderID: '%s'", orderIDStr)
+		problem_util.Respond(c, problem_util.ValidationFailed, i18n_util.MsgInvalidOrderID)
+		return
+	}
+
+	var req order_model.UpdateOrderStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.requestLogger(c).WithError(err).Warnf("Некорректный формат запроса смены состояния заказа %d", orderID)
+		common_handler.RespondInvalidInput(c, err)
+		return
+	}
+
+	order, err := h.orderService.ChangeOrderStatus(c.Request.Context(), uint(orderID), ownerID, req.Status)
+	if err != nil {
+		common_handler.RespondError(c, h.orderLogger(c, uint(orderID), ownerID), err)
+		return
+	}
+
+	common_handler.SetETag(c, order.Version)
+	c.JSON(http.StatusOK, newOrderResponse(order))
+}
+
+// requestLogger возвращает запись лога с контекстом запроса (request_id, trace_id)
+func (h *OrderHandler) requestLogger(c *gin.Context) *logrus.Entry {
+	return h.log.WithContext(c.Request.Context())
+}
+
+// orderLogger возвращает запись лога с ID заказа и ID его владельца
+func (h *OrderHandler) orderLogger(c *gin.Context, orderID, ownerID uint) *logrus.Entry {
+	return h.requestLogger(c).WithFields(logrus.Fields{"order_id": orderID, "user_id": ownerID})
+}
@@ -11,7 +11,10 @@ import (
 
 	"github.com/IlyushinDM/user-order-api/internal/handlers/common_handler"
 	"github.com/IlyushinDM/user-order-api/internal/models/order_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/user_model"
+	"github.com/IlyushinDM/user-order-api/internal/policy/access_policy"
 	"github.com/IlyushinDM/user-order-api/internal/services/order_service"
+	"github.com/IlyushinDM/user-order-api/internal/utils/problem_util"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -36,8 +39,8 @@ func (m *mockOrderService) GetOrderByID(ctx context.Context, orderID, userID uin
 	return order, args.Error(1)
 }
 
-func (m *mockOrderService) GetAllOrdersByUser(ctx context.Context, userID uint, page, limit int) ([]order_model.Order, int64, error) {
-	args := m.Called(ctx, userID, page, limit)
+func (m *mockOrderService) GetAllOrdersByUser(ctx context.Context, userID uint, page, limit int, filters map[string]any) ([]order_model.Order, int64, error) {
+	args := m.Called(ctx, userID, page, limit, filters)
 	orders, _ := args.Get(0).([]order_model.Order)
 	var total int64
 	switch v := args.Get(1).(type) {
@@ -51,8 +54,8 @@ func (m *mockOrderService) GetAllOrdersByUser(ctx context.Context, userID uint,
 	return orders, total, args.Error(2)
 }
 
-func (m *mockOrderService) UpdateOrder(ctx context.Context, orderID, userID uint, req order_model.UpdateOrderRequest) (*order_model.Order, error) {
-	args := m.Called(ctx, orderID, userID, req)
+func (m *mockOrderService) UpdateOrder(ctx context.Context, orderID, userID, version uint, req order_model.UpdateOrderRequest) (*order_model.Order, error) {
+	args := m.Called(ctx, orderID, userID, version, req)
 	order, _ := args.Get(0).(*order_model.Order)
 	return order, args.Error(1)
 }
@@ -62,6 +65,18 @@ func (m *mockOrderService) DeleteOrder(ctx context.Context, orderID, userID uint
 	return args.Error(0)
 }
 
+func (m *mockOrderService) ChangeOrderStatus(ctx context.Context, orderID, userID uint, status order_model.OrderStatus) (*order_model.Order, error) {
+	args := m.Called(ctx, orderID, userID, status)
+	order, _ := args.Get(0).(*order_model.Order)
+	return order, args.Error(1)
+}
+
+func (m *mockOrderService) CancelOrder(ctx context.Context, orderID, userID uint) (*order_model.Order, error) {
+	args := m.Called(ctx, orderID, userID)
+	order, _ := args.Get(0).(*order_model.Order)
+	return order, args.Error(1)
+}
+
 type mockCommonHandler struct {
 	mock.Mock
 }
@@ -96,17 +111,19 @@ func TestCreateOrder_Success(t *testing.T) {
 	handler := NewOrderHandler(mockSvc, mockCommon, log)
 
 	userID := uint(1)
-	reqBody := order_model.CreateOrderRequest{
-		ProductName: "TestProduct",
-		Quantity:    2,
-		Price:       100,
-	}
+	reqBody := order_model.CreateOrderRequest{Currency: "RUB", Items: []order_model.OrderItemRequest{
+		{ProductID: 1, Quantity: 2},
+		{ProductID: 2, Quantity: 1},
+	}}
 	order := &order_model.Order{
-		ID:          10,
-		UserID:      userID,
-		ProductName: reqBody.ProductName,
-		Quantity:    reqBody.Quantity,
-		Price:       reqBody.Price,
+		ID:       10,
+		UserID:   userID,
+		Status:   order_model.StatusPending,
+		Currency: "RUB",
+		Items: []order_model.OrderItem{
+			{ID: 1, ProductName: "TestProduct", Quantity: 2, UnitPrice: 10000},
+			{ID: 2, ProductName: "Other", Quantity: 1, UnitPrice: 5005},
+		},
 	}
 	mockSvc.On("CreateOrder", mock.Anything, userID, reqBody).Return(order, nil)
 
@@ -130,9 +147,14 @@ func TestCreateOrder_Success(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, order.ID, resp.ID)
 	assert.Equal(t, order.UserID, resp.UserID)
-	assert.Equal(t, order.ProductName, resp.ProductName)
-	assert.Equal(t, order.Quantity, resp.Quantity)
-	assert.Equal(t, order.Price, resp.Price)
+	if assert.Len(t, resp.Items, 2) {
+		assert.Equal(t, "TestProduct", resp.Items[0].ProductName)
+		assert.Equal(t, "200.00", resp.Items[0].LineTotal.String())
+	}
+	assert.Equal(t, "250.05", resp.Total.String())
+	assert.Equal(t, "RUB", resp.Currency)
+	// Суммы передаются строками, чтобы клиенты не теряли точность
+	assert.Contains(t, w.Body.String(), `"total":"250.05"`)
 }
 
 func TestCreateOrder_BadRequest_BindJSON(t *testing.T) {
@@ -186,11 +208,10 @@ func TestGetOrderByID_Success(t *testing.T) {
 	userID := uint(1)
 	orderID := uint(10)
 	order := &order_model.Order{
-		ID:          orderID,
-		UserID:      userID,
-		ProductName: "TestProduct",
-		Quantity:    2,
-		Price:       100,
+		ID:      orderID,
+		UserID:  userID,
+		Items:   []order_model.OrderItem{{ProductName: "TestProduct", Quantity: 2, UnitPrice: 100}},
+		Version: 3,
 	}
 	mockSvc.On("GetOrderByID", mock.Anything, orderID, userID).Return(order, nil)
 
@@ -212,6 +233,7 @@ func TestGetOrderByID_Success(t *testing.T) {
 	err := json.Unmarshal(w.Body.Bytes(), &resp)
 	assert.NoError(t, err)
 	assert.Equal(t, order.ID, resp.ID)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
 }
 
 func TestGetOrderByID_NotFound(t *testing.T) {
@@ -249,15 +271,15 @@ func TestGetAllOrdersByUser_Success(t *testing.T) {
 	userID := uint(1)
 	page, limit := 1, 10
 	orders := []order_model.Order{
-		{ID: 1, UserID: userID, ProductName: "A", Quantity: 1, Price: 10},
-		{ID: 2, UserID: userID, ProductName: "B", Quantity: 2, Price: 20},
+		{ID: 1, UserID: userID, Items: []order_model.OrderItem{{ProductName: "A", Quantity: 1, UnitPrice: 10}}},
+		{ID: 2, UserID: userID, Items: []order_model.OrderItem{{ProductName: "B", Quantity: 2, UnitPrice: 20}}},
 	}
 	total := int64(2)
 
 	mockCommon.On("GetPaginationParams", mock.Anything).Return(page, limit, nil)
 	// При необходимости, настройте ожидание для GetFilteringParams, даже если он не используется в GetAllOrdersByUser напрямую
 	mockCommon.On("GetFilteringParams", mock.Anything).Return(nil, nil)
-	mockSvc.On("GetAllOrdersByUser", mock.Anything, userID, page, limit).Return(orders, total, nil)
+	mockSvc.On("GetAllOrdersByUser", mock.Anything, userID, page, limit, map[string]any{}).Return(orders, total, nil)
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/api/users/1/orders?page=1&limit=10", nil)
@@ -309,19 +331,15 @@ func TestUpdateOrder_Success(t *testing.T) {
 
 	userID := uint(1)
 	orderID := uint(10)
-	reqBody := order_model.UpdateOrderRequest{
-		ProductName: "Updated",
-		Quantity:    3,
-		Price:       200,
-	}
+	reqBody := order_model.UpdateOrderRequest{Items: []order_model.OrderItemRequest{
+		{ProductID: 3, Quantity: 3},
+	}}
 	order := &order_model.Order{
-		ID:          orderID,
-		UserID:      userID,
-		ProductName: reqBody.ProductName,
-		Quantity:    reqBody.Quantity,
-		Price:       reqBody.Price,
+		ID:     orderID,
+		UserID: userID,
+		Items:  []order_model.OrderItem{{ProductName: "Updated", Quantity: 3, UnitPrice: 20000}},
 	}
-	mockSvc.On("UpdateOrder", mock.Anything, orderID, userID, reqBody).Return(order, nil)
+	mockSvc.On("UpdateOrder", mock.Anything, orderID, userID, uint(1), reqBody).Return(order, nil)
 	// При необходимости, настройте ожидание для GetPaginationParams и GetFilteringParams
 	mockCommon.On("GetPaginationParams", mock.Anything).Return(1, 10, nil)
 	mockCommon.On("GetFilteringParams", mock.Anything).Return(nil, nil)
@@ -330,6 +348,7 @@ func TestUpdateOrder_Success(t *testing.T) {
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("PUT", "/api/users/1/orders/10", bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"1"`)
 
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
@@ -346,7 +365,10 @@ func TestUpdateOrder_Success(t *testing.T) {
 	err := json.Unmarshal(w.Body.Bytes(), &resp)
 	assert.NoError(t, err)
 	assert.Equal(t, order.ID, resp.ID)
-	assert.Equal(t, order.ProductName, resp.ProductName)
+	if assert.Len(t, resp.Items, 1) {
+		assert.Equal(t, "Updated", resp.Items[0].ProductName)
+	}
+	assert.Equal(t, "600.00", resp.Total.String())
 }
 
 // Дополнительный тест для ErrNoUpdateFields в UpdateOrder
@@ -358,18 +380,14 @@ func TestUpdateOrder_NoUpdateFields(t *testing.T) {
 
 	userID := uint(1)
 	orderID := uint(10)
-	reqBody := order_model.UpdateOrderRequest{
-		ProductName: "NoChange",
-	}
+	reqBody := order_model.UpdateOrderRequest{}
 	existingOrder := &order_model.Order{
-		ID:          orderID,
-		UserID:      userID,
-		ProductName: "NoChange", // Предполагаем, что текущее имя продукта такое же
-		Quantity:    5,
-		Price:       250,
+		ID:     orderID,
+		UserID: userID,
+		Items:  []order_model.OrderItem{{ProductName: "NoChange", Quantity: 5, UnitPrice: 250}},
 	}
 
-	mockSvc.On("UpdateOrder", mock.Anything, orderID, userID, reqBody).Return(nil, order_service.ErrNoUpdateFields)
+	mockSvc.On("UpdateOrder", mock.Anything, orderID, userID, uint(1), reqBody).Return(nil, order_service.ErrNoUpdateFields)
 	// Мок сервиса для GetOrderByID, так как хендлер вызывает его при ErrNoUpdateFields
 	mockSvc.On("GetOrderByID", mock.Anything, orderID, userID).Return(existingOrder, nil)
 	// При необходимости, настройте ожидание для GetPaginationParams и GetFilteringParams
@@ -380,6 +398,7 @@ func TestUpdateOrder_NoUpdateFields(t *testing.T) {
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("PUT", "/api/users/1/orders/10", bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"1"`)
 
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
@@ -396,7 +415,9 @@ func TestUpdateOrder_NoUpdateFields(t *testing.T) {
 	err := json.Unmarshal(w.Body.Bytes(), &resp)
 	assert.NoError(t, err)
 	assert.Equal(t, existingOrder.ID, resp.ID)
-	assert.Equal(t, existingOrder.ProductName, resp.ProductName)
+	if assert.Len(t, resp.Items, 1) {
+		assert.Equal(t, "NoChange", resp.Items[0].ProductName)
+	}
 }
 
 func TestDeleteOrder_Success(t *testing.T) {
@@ -457,7 +478,7 @@ func TestDeleteOrder_NotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
-func TestCheckUserIDMatch_AuthMissing(t *testing.T) {
+func TestAuthorizeOrderAccess_AuthMissing(t *testing.T) {
 	mockSvc := new(mockOrderService)
 	mockCommon := new(mockCommonHandler)
 	log := logrus.New()
@@ -472,13 +493,13 @@ func TestCheckUserIDMatch_AuthMissing(t *testing.T) {
 	mockCommon.On("GetPaginationParams", mock.Anything).Return(1, 10, nil)
 	mockCommon.On("GetFilteringParams", mock.Anything).Return(nil, nil)
 
-	uid, ok := handler.checkUserIDMatch(c)
+	uid, ok := handler.authorizeOrderAccess(c, access_policy.ActionRead)
 	assert.False(t, ok)
 	assert.Equal(t, uint(0), uid)
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
 
-func TestCheckUserIDMatch_BadUserIDFormat(t *testing.T) {
+func TestAuthorizeOrderAccess_BadUserIDFormat(t *testing.T) {
 	mockSvc := new(mockOrderService)
 	mockCommon := new(mockCommonHandler)
 	log := logrus.New()
@@ -494,13 +515,13 @@ func TestCheckUserIDMatch_BadUserIDFormat(t *testing.T) {
 	mockCommon.On("GetPaginationParams", mock.Anything).Return(1, 10, nil)
 	mockCommon.On("GetFilteringParams", mock.Anything).Return(nil, nil)
 
-	uid, ok := handler.checkUserIDMatch(c)
+	uid, ok := handler.authorizeOrderAccess(c, access_policy.ActionRead)
 	assert.False(t, ok)
 	assert.Equal(t, uint(0), uid)
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
-func TestCheckUserIDMatch_Forbidden(t *testing.T) {
+func TestAuthorizeOrderAccess_Forbidden(t *testing.T) {
 	mockSvc := new(mockOrderService)
 	mockCommon := new(mockCommonHandler)
 	log := logrus.New()
@@ -516,12 +537,59 @@ func TestCheckUserIDMatch_Forbidden(t *testing.T) {
 	mockCommon.On("GetPaginationParams", mock.Anything).Return(1, 10, nil)
 	mockCommon.On("GetFilteringParams", mock.Anything).Return(nil, nil)
 
-	uid, ok := handler.checkUserIDMatch(c)
+	uid, ok := handler.authorizeOrderAccess(c, access_policy.ActionRead)
 	assert.False(t, ok)
 	assert.Equal(t, uint(0), uid)
 	assert.Equal(t, http.StatusForbidden, w.Code)
 }
 
+func TestAuthorizeOrderAccess_SupportReadsOtherUser(t *testing.T) {
+	handler := NewOrderHandler(new(mockOrderService), new(mockCommonHandler), logrus.New())
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/api/users/2/orders", nil)
+	c.Params = gin.Params{{Key: "id", Value: "2"}}
+	addAuthUserID(c, uint(1))
+	c.Set("userRole", user_model.RoleSupport)
+
+	uid, ok := handler.authorizeOrderAccess(c, access_policy.ActionRead)
+	assert.True(t, ok)
+	assert.Equal(t, uint(2), uid, "Должен возвращаться владелец заказов из URL")
+}
+
+func TestAuthorizeOrderAccess_SupportCannotDelete(t *testing.T) {
+	handler := NewOrderHandler(new(mockOrderService), new(mockCommonHandler), logrus.New())
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("DELETE", "/api/users/2/orders/5", nil)
+	c.Params = gin.Params{{Key: "id", Value: "2"}, {Key: "orderID", Value: "5"}}
+	addAuthUserID(c, uint(1))
+	c.Set("userRole", user_model.RoleSupport)
+
+	_, ok := handler.authorizeOrderAccess(c, access_policy.ActionDelete)
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestDeleteOrder_AdminDeletesOtherUsersOrder(t *testing.T) {
+	mockSvc := new(mockOrderService)
+	handler := NewOrderHandler(mockSvc, new(mockCommonHandler), logrus.New())
+	mockSvc.On("DeleteOrder", mock.Anything, uint(5), uint(2)).Return(nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("DELETE", "/api/users/2/orders/5", nil)
+	c.Params = gin.Params{{Key: "id", Value: "2"}, {Key: "orderID", Value: "5"}}
+	addAuthUserID(c, uint(1))
+	c.Set("userRole", user_model.RoleAdmin)
+
+	handler.DeleteOrder(c)
+	assert.Equal(t, http.StatusNoContent, c.Writer.Status())
+	mockSvc.AssertExpectations(t)
+}
+
 // --- Additional error cases for coverage ---
 
 func TestCreateOrder_ServiceError(t *testing.T) {
@@ -531,11 +599,9 @@ func TestCreateOrder_ServiceError(t *testing.T) {
 	handler := NewOrderHandler(mockSvc, mockCommon, log)
 
 	userID := uint(1)
-	reqBody := order_model.CreateOrderRequest{
-		ProductName: "TestProduct",
-		Quantity:    2,
-		Price:       100,
-	}
+	reqBody := order_model.CreateOrderRequest{Currency: "RUB", Items: []order_model.OrderItemRequest{
+		{ProductID: 1, Quantity: 2},
+	}}
 	mockSvc.On("CreateOrder", mock.Anything, userID, reqBody).Return(nil, order_service.ErrInvalidServiceInput)
 	// При необходимости, настройте ожидание для GetPaginationParams и GetFilteringParams
 	mockCommon.On("GetPaginationParams", mock.Anything).Return(1, 10, nil)
@@ -556,6 +622,73 @@ func TestCreateOrder_ServiceError(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestCreateOrder_UnknownProduct(t *testing.T) {
+	mockSvc := new(mockOrderService)
+	handler := NewOrderHandler(mockSvc, new(mockCommonHandler), logrus.New())
+
+	userID := uint(1)
+	reqBody := order_model.CreateOrderRequest{Currency: "RUB", Items: []order_model.OrderItemRequest{{ProductID: 99, Quantity: 1}}}
+	mockSvc.On("CreateOrder", mock.Anything, userID, reqBody).Return(nil, order_service.ErrProductNotFound)
+
+	body, _ := json.Marshal(reqBody)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/users/1/orders", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	addAuthUserID(c, userID)
+
+	handler.CreateOrder(c)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestCreateOrder_InsufficientStock(t *testing.T) {
+	mockSvc := new(mockOrderService)
+	handler := NewOrderHandler(mockSvc, new(mockCommonHandler), logrus.New())
+
+	userID := uint(1)
+	reqBody := order_model.CreateOrderRequest{Currency: "RUB", Items: []order_model.OrderItemRequest{{ProductID: 1, Quantity: 50}}}
+	mockSvc.On("CreateOrder", mock.Anything, userID, reqBody).Return(nil, order_service.ErrInsufficientStock)
+
+	body, _ := json.Marshal(reqBody)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/users/1/orders", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	addAuthUserID(c, userID)
+
+	handler.CreateOrder(c)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "Недостаточно товара на складе")
+}
+
+func TestCreateOrder_InvalidCurrency(t *testing.T) {
+	mockSvc := new(mockOrderService)
+	handler := NewOrderHandler(mockSvc, new(mockCommonHandler), logrus.New())
+
+	body := []byte(`{"currency":"RUBLES","items":[{"product_id":1,"quantity":1}]}`)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/users/1/orders", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	addAuthUserID(c, 1)
+
+	handler.CreateOrder(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockSvc.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestGetOrderByID_BadOrderIDFormat(t *testing.T) {
 	mockSvc := new(mockOrderService)
 	mockCommon := new(mockCommonHandler)
@@ -619,6 +752,7 @@ func TestUpdateOrder_BadRequest_BindJSON(t *testing.T) {
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("PUT", "/api/users/1/orders/10", bytes.NewReader([]byte("{invalid json")))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"1"`)
 
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
@@ -698,7 +832,7 @@ func TestGetAllOrdersByUser_ServiceError(t *testing.T) {
 	mockCommon.On("GetPaginationParams", mock.Anything).Return(page, limit, nil)
 	// При необходимости, настройте ожидание для GetFilteringParams
 	mockCommon.On("GetFilteringParams", mock.Anything).Return(nil, nil)
-	mockSvc.On("GetAllOrdersByUser", mock.Anything, userID, page, limit).Return(nil, int64(0), order_service.ErrInvalidServiceInput)
+	mockSvc.On("GetAllOrdersByUser", mock.Anything, userID, page, limit, map[string]any{}).Return(nil, int64(0), order_service.ErrInvalidServiceInput)
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/api/users/1/orders?page=1&limit=10", nil)
@@ -777,7 +911,7 @@ func TestGetAllOrdersByUser_EmptyOrders(t *testing.T) {
 	mockCommon.On("GetPaginationParams", mock.Anything).Return(page, limit, nil)
 	// При необходимости, настройте ожидание для GetFilteringParams
 	mockCommon.On("GetFilteringParams", mock.Anything).Return(nil, nil)
-	mockSvc.On("GetAllOrdersByUser", mock.Anything, userID, page, limit).Return(orders, total, nil)
+	mockSvc.On("GetAllOrdersByUser", mock.Anything, userID, page, limit, map[string]any{}).Return(orders, total, nil)
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/api/users/1/orders?page=1&limit=10", nil)
@@ -811,7 +945,7 @@ func TestGetAllOrdersByUser_EmptyOrdersWithFilters(t *testing.T) {
 	mockCommon.On("GetPaginationParams", mock.Anything).Return(page, limit, nil)
 	// Настройка ожидания для GetFilteringParams с пустыми фильтрами
 	mockCommon.On("GetFilteringParams", mock.Anything).Return(map[string]any{}, nil)
-	mockSvc.On("GetAllOrdersByUser", mock.Anything, userID, page, limit).Return(orders, total, nil)
+	mockSvc.On("GetAllOrdersByUser", mock.Anything, userID, page, limit, map[string]any{}).Return(orders, total, nil)
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/api/users/1/orders?page=1&limit=10", nil)
@@ -830,3 +964,215 @@ func TestGetAllOrdersByUser_EmptyOrdersWithFilters(t *testing.T) {
 	assert.Equal(t, total, resp.Total)
 	assert.Len(t, resp.Orders, 0)
 }
+
+func TestGetAllOrdersByUser_StatusFilter(t *testing.T) {
+	mockSvc := new(mockOrderService)
+	mockCommon := new(mockCommonHandler)
+	handler := NewOrderHandler(mockSvc, mockCommon, logrus.New())
+
+	userID := uint(1)
+	filters := map[string]any{"status": order_model.StatusShipped}
+	mockCommon.On("GetPaginationParams", mock.Anything).Return(1, 10, nil)
+	mockSvc.On("GetAllOrdersByUser", mock.Anything, userID, 1, 10, filters).Return([]order_model.Order{}, int64(0), nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/api/users/1/orders?status=shipped", nil)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	addAuthUserID(c, userID)
+
+	handler.GetAllOrdersByUser(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestGetAllOrdersByUser_UnknownStatus(t *testing.T) {
+	mockSvc := new(mockOrderService)
+	mockCommon := new(mockCommonHandler)
+	handler := NewOrderHandler(mockSvc, mockCommon, logrus.New())
+	mockCommon.On("GetPaginationParams", mock.Anything).Return(1, 10, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/api/users/1/orders?status=lost", nil)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	addAuthUserID(c, uint(1))
+
+	handler.GetAllOrdersByUser(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var problem problem_util.Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	if assert.Len(t, problem.Errors, 1) {
+		assert.Equal(t, "status", problem.Errors[0].Field)
+		assert.Equal(t, "oneof", problem.Errors[0].Rule)
+	}
+	mockSvc.AssertNotCalled(t, "GetAllOrdersByUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCancelOrder_Success(t *testing.T) {
+	mockSvc := new(mockOrderService)
+	handler := NewOrderHandler(mockSvc, new(mockCommonHandler), logrus.New())
+
+	cancelled := &order_model.Order{ID: 5, UserID: 1, Items: []order_model.OrderItem{{ProductName: "A", Quantity: 1, UnitPrice: 1}}, Status: order_model.StatusCancelled}
+	mockSvc.On("CancelOrder", mock.Anything, uint(5), uint(1)).Return(cancelled, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/api/users/1/orders/5/cancel", nil)
+	c.Params = gin.Params{{Key: "id", Value: "1"}, {Key: "orderID", Value: "5"}}
+	addAuthUserID(c, uint(1))
+
+	handler.CancelOrder(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp order_model.OrderResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, order_model.StatusCancelled, resp.Status)
+}
+
+func TestCancelOrder_InvalidTransition(t *testing.T) {
+	mockSvc := new(mockOrderService)
+	handler := NewOrderHandler(mockSvc, new(mockCommonHandler), logrus.New())
+
+	transitionErr := &order_service.StatusTransitionError{From: order_model.StatusShipped, To: order_model.StatusCancelled}
+	mockSvc.On("CancelOrder", mock.Anything, uint(5), uint(1)).Return(nil, transitionErr)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/api/users/1/orders/5/cancel", nil)
+	c.Params = gin.Params{{Key: "id", Value: "1"}, {Key: "orderID", Value: "5"}}
+	addAuthUserID(c, uint(1))
+
+	handler.CancelOrder(c)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestUpdateOrderStatus_ForbiddenForRegularUser(t *testing.T) {
+	mockSvc := new(mockOrderService)
+	handler := NewOrderHandler(mockSvc, new(mockCommonHandler), logrus.New())
+
+	body, _ := json.Marshal(order_model.UpdateOrderStatusRequest{Status: order_model.StatusShipped})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("PATCH", "/api/users/1/orders/5/status", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: "1"}, {Key: "orderID", Value: "5"}}
+	addAuthUserID(c, uint(1))
+
+	handler.UpdateOrderStatus(c)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockSvc.AssertNotCalled(t, "ChangeOrderStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateOrderStatus_SupportShipsOrder(t *testing.T) {
+	mockSvc := new(mockOrderService)
+	handler := NewOrderHandler(mockSvc, new(mockCommonHandler), logrus.New())
+
+	shipped := &order_model.Order{ID: 5, UserID: 2, Status: order_model.StatusShipped}
+	mockSvc.On("ChangeOrderStatus", mock.Anything, uint(5), uint(2), order_model.StatusShipped).Return(shipped, nil)
+
+	body, _ := json.Marshal(order_model.UpdateOrderStatusRequest{Status: order_model.StatusShipped})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("PATCH", "/api/users/2/orders/5/status", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: "2"}, {Key: "orderID", Value: "5"}}
+	addAuthUserID(c, uint(1))
+	c.Set("userRole", user_model.RoleSupport)
+
+	handler.UpdateOrderStatus(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestUpdateOrder_NotEditable(t *testing.T) {
+	mockSvc := new(mockOrderService)
+	handler := NewOrderHandler(mockSvc, new(mockCommonHandler), logrus.New())
+
+	reqBody := order_model.UpdateOrderRequest{Items: []order_model.OrderItemRequest{{ProductID: 1, Quantity: 3}}}
+	mockSvc.On("UpdateOrder", mock.Anything, uint(5), uint(1), uint(1), reqBody).Return(nil, order_service.ErrOrderNotEditable)
+
+	body, _ := json.Marshal(reqBody)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("PUT", "/api/users/1/orders/5", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.Header.Set("If-Match", `"1"`)
+	c.Params = gin.Params{{Key: "id", Value: "1"}, {Key: "orderID", Value: "5"}}
+	addAuthUserID(c, uint(1))
+
+	handler.UpdateOrder(c)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestCreateOrder_EmptyItems(t *testing.T) {
+	mockSvc := new(mockOrderService)
+	handler := NewOrderHandler(mockSvc, new(mockCommonHandler), logrus.New())
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/api/users/1/orders", bytes.NewReader([]byte(`{"items":[]}`)))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	addAuthUserID(c, uint(1))
+
+	handler.CreateOrder(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockSvc.AssertNotCalled(t, "CreateOrder", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateOrder_MissingIfMatch(t *testing.T) {
+	mockSvc := new(mockOrderService)
+	handler := NewOrderHandler(mockSvc, new(mockCommonHandler), logrus.New())
+
+	body, _ := json.Marshal(order_model.UpdateOrderRequest{Items: []order_model.OrderItemRequest{{ProductID: 1, Quantity: 3}}})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("PUT", "/api/users/1/orders/5", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: "1"}, {Key: "orderID", Value: "5"}}
+	addAuthUserID(c, uint(1))
+
+	handler.UpdateOrder(c)
+
+	assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+	mockSvc.AssertNotCalled(t, "UpdateOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateOrder_InvalidIfMatch(t *testing.T) {
+	mockSvc := new(mockOrderService)
+	handler := NewOrderHandler(mockSvc, new(mockCommonHandler), logrus.New())
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("PUT", "/api/users/1/orders/5", bytes.NewReader([]byte("{}")))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.Header.Set("If-Match", "3")
+	c.Params = gin.Params{{Key: "id", Value: "1"}, {Key: "orderID", Value: "5"}}
+	addAuthUserID(c, uint(1))
+
+	handler.UpdateOrder(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUpdateOrder_VersionMismatch(t *testing.T) {
+	mockSvc := new(mockOrderService)
+	handler := NewOrderHandler(mockSvc, new(mockCommonHandler), logrus.New())
+
+	reqBody := order_model.UpdateOrderRequest{Items: []order_model.OrderItemRequest{{ProductID: 1, Quantity: 3}}}
+	mockSvc.On("UpdateOrder", mock.Anything, uint(5), uint(1), uint(2), reqBody).Return(nil, order_service.ErrVersionMismatch)
+
+	body, _ := json.Marshal(reqBody)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("PUT", "/api/users/1/orders/5", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.Header.Set("If-Match", `"2"`)
+	c.Params = gin.Params{{Key: "id", Value: "1"}, {Key: "orderID", Value: "5"}}
+	addAuthUserID(c, uint(1))
+
+	handler.UpdateOrder(c)
+
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	mockSvc.AssertExpectations(t)
+}
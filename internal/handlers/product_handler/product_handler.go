@@ -0,0 +1,279 @@
+package product_handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/IlyushinDM/user-order-api/internal/handlers/common_handler"
+	"github.com/IlyushinDM/user-order-api/internal/models/product_model"
+	"github.com/IlyushinDM/user-order-api/internal/policy/access_policy"
+	"github.com/IlyushinDM/user-order-api/internal/services/product_service"
+	"github.com/IlyushinDM/user-order-api/internal/utils/i18n_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/problem_util"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type ProductHandler struct {
+	productService product_service.ProductService
+	commonHandler  common_handler.CommonHandlerInterface
+	log            *logrus.Logger
+}
+
+// NewProductHandler инициализирует ProductHandler с проверкой зависимостей
+func NewProductHandler(
+	productService product_service.ProductService,
+	commonHandler common_handler.CommonHandlerInterface,
+	log *logrus.Logger,
+) *ProductHandler {
+	if productService == nil {
+		logrus.Panic("ProductService не может быть nil")
+	}
+	if commonHandler == nil {
+		logrus.Panic("CommonHandler не может быть nil")
+	}
+	if log == nil {
+		defaultLog := logrus.New()
+		defaultLog.SetLevel(logrus.InfoLevel)
+		defaultLog.Warn("Logger не указан в NewProductHandler, используется logger по умолчанию")
+		log = defaultLog
+	}
+	return &ProductHandler{productService: productService, commonHandler: commonHandler, log: log}
+}
+
+// newProductResponse преобразует модель продукта в ответ API
+func newProductResponse(product *product_model.Product) product_model.ProductResponse {
+	return product_model.ProductResponse{
+		ID:          product.ID,
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       product.Price,
+		Currency:    product.Currency,
+		Stock:       product.Stock,
+	}
+}
+
+// authorize проверяет право текущего пользователя на действие с каталогом.
+// При отказе записывает ответ и возвращает false.
+func (h *ProductHandler) authorize(c *gin.Context, logger *logrus.Entry, action access_policy.Action) bool {
+	subject, ok := access_policy.CurrentSubject(c)
+	if !ok {
+		logger.Error("userID не найден в context (Возможна ошибка в middleware)")
+		problem_util.Respond(c, problem_util.Internal, "")
+		return false
+	}
+	if !access_policy.Can(subject, action, access_policy.ResourceProduct, 0) {
+		logger.WithFields(logrus.Fields{"auth_user_id": subject.UserID, "role": subject.Role, "action": action}).
+			Warn("Доступ запрещен политикой доступа")
+		problem_util.Respond(c, problem_util.Forbidden, i18n_util.MsgInsufficientRights)
+		return false
+	}
+	return true
+}
+
+// parseProductID извлекает ID продукта из URL. При ошибке записывает ответ 400 и возвращает false.
+func (h *ProductHandler) parseProductID(c *gin.Context, logger *logrus.Entry) (uint, bool) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil || id == 0 {
+		logger.WithError(err).Warnf("Неверный формат ID продукта в URL: '%s'", idStr)
+		problem_util.Respond(c, problem_util.ValidationFailed, i18n_util.MsgInvalidProductID)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// CreateProduct godoc
+// @Summary Создание продукта
+// @Description Добавляет продукт в каталог. Доступно только администратору
+// @Tags Продукты
+// @Accept json
+// @Produce json
+// @Param product body product_model.CreateProductRequest true "Данные продукта"
+// @Success 201 {object} product_model.ProductResponse "Продукт успешно создан"
+// @Failure 400 {object} problem_util.Problem "Некорректные входные данные"
+// @Failure 401 {object} problem_util.Problem "Не авторизован"
+// @Failure 403 {object} problem_util.Problem "Недостаточно прав"
+// @Failure 429 {object} problem_util.Problem "Превышен лимит запросов"
+// @Failure 500 {object} problem_util.Problem "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /api/products [post]
+func (h *ProductHandler) CreateProduct(c *gin.Context) {
+	logger := h.log.WithContext(c.Request.Context()).WithField("method", "ProductHandler.CreateProduct")
+	if !h.authorize(c, logger, access_policy.ActionCreate) {
+		return
+	}
+
+	var req product_model.CreateProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.WithError(err).Warn("Некорректный формат запроса")
+		common_handler.RespondInvalidInput(c, err)
+		return
+	}
+
+	product, err := h.productService.CreateProduct(c.Request.Context(), req)
+	if err != nil {
+		common_handler.RespondError(c, logger, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newProductResponse(product))
+}
+
+// GetProductByID godoc
+// @Summary Получение продукта по ID
+// @Description Возвращает продукт каталога. Доступно любому аутентифицированному пользователю
+// @Tags Продукты
+// @Produce json
+// @Param id path int true "ID продукта" Format(uint)
+// @Success 200 {object} product_model.ProductResponse "Продукт"
+// @Failure 400 {object} problem_util.Problem "Некорректный ID продукта"
+// @Failure 401 {object} problem_util.Problem "Не авторизован"
+// @Failure 404 {object} problem_util.Problem "Продукт не найден"
+// @Failure 429 {object} problem_util.Problem "Превышен лимит запросов"
+// @Failure 500 {object} problem_util.Problem "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /api/products/{id} [get]
+func (h *ProductHandler) GetProductByID(c *gin.Context) {
+	logger := h.log.WithContext(c.Request.Context()).WithField("method", "ProductHandler.GetProductByID")
+	if !h.authorize(c, logger, access_policy.ActionRead) {
+		return
+	}
+	id, ok := h.parseProductID(c, logger)
+	if !ok {
+		return
+	}
+
+	product, err := h.productService.GetProductByID(c.Request.Context(), id)
+	if err != nil {
+		common_handler.RespondError(c, logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newProductResponse(product))
+}
+
+// GetAllProducts godoc
+// @Summary Список продуктов
+// @Description Возвращает каталог продуктов с пагинацией и поиском по названию и описанию
+// @Tags Продукты
+// @Produce json
+// @Param page query int false "Номер страницы" default(1) minimum(1)
+// @Param limit query int false "Количество элементов на странице" default(10) minimum(1) maximum(100)
+// @Param q query string false "Поиск по названию и описанию (без учета регистра, частичное совпадение)"
+// @Success 200 {object} product_model.PaginatedProductsResponse "Список продуктов"
+// @Failure 400 {object} problem_util.Problem "Неверные параметры запроса"
+// @Failure 401 {object} problem_util.Problem "Не авторизован"
+// @Failure 429 {object} problem_util.Problem "Превышен лимит запросов"
+// @Failure 500 {object} problem_util.Problem "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /api/products [get]
+func (h *ProductHandler) GetAllProducts(c *gin.Context) {
+	logger := h.log.WithContext(c.Request.Context()).WithField("method", "ProductHandler.GetAllProducts")
+	if !h.authorize(c, logger, access_policy.ActionList) {
+		return
+	}
+
+	page, limit, err := h.commonHandler.GetPaginationParams(c)
+	if err != nil {
+		logger.WithError(err).Warn("Недопустимые параметры разбивки на страницы")
+		common_handler.RespondInvalidInput(c, err)
+		return
+	}
+	search := c.Query("q")
+
+	products, total, err := h.productService.GetAllProducts(c.Request.Context(), page, limit, search)
+	if err != nil {
+		common_handler.RespondError(c, logger, err)
+		return
+	}
+
+	resp := product_model.PaginatedProductsResponse{
+		Page:     page,
+		Limit:    limit,
+		Total:    total,
+		Products: make([]product_model.ProductResponse, len(products)),
+	}
+	for i := range products {
+		resp.Products[i] = newProductResponse(&products[i])
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// UpdateProduct godoc
+// @Summary Обновление продукта
+// @Description Изменяет название, описание, цену или свободный остаток продукта. Доступно только администратору. Цены в уже оформленных заказах не меняются
+// @Tags Продукты
+// @Accept json
+// @Produce json
+// @Param id path int true "ID продукта" Format(uint)
+// @Param product body product_model.UpdateProductRequest true "Данные для обновления"
+// @Success 200 {object} product_model.ProductResponse "Обновленный продукт"
+// @Failure 400 {object} problem_util.Problem "Некорректные входные данные"
+// @Failure 401 {object} problem_util.Problem "Не авторизован"
+// @Failure 403 {object} problem_util.Problem "Недостаточно прав"
+// @Failure 404 {object} problem_util.Problem "Продукт не найден"
+// @Failure 429 {object} problem_util.Problem "Превышен лимит запросов"
+// @Failure 500 {object} problem_util.Problem "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /api/products/{id} [put]
+func (h *ProductHandler) UpdateProduct(c *gin.Context) {
+	logger := h.log.WithContext(c.Request.Context()).WithField("method", "ProductHandler.UpdateProduct")
+	if !h.authorize(c, logger, access_policy.ActionUpdate) {
+		return
+	}
+	id, ok := h.parseProductID(c, logger)
+	if !ok {
+		return
+	}
+
+	var req product_model.UpdateProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.WithError(err).Warn("Некорректный формат запроса")
+		common_handler.RespondInvalidInput(c, err)
+		return
+	}
+
+	product, err := h.productService.UpdateProduct(c.Request.Context(), id, req)
+	if err != nil && !errors.Is(err, product_service.ErrNoUpdateFields) {
+		common_handler.RespondError(c, logger, err)
+		return
+	}
+
+	// При отсутствии изменений возвращается текущее состояние продукта
+	c.JSON(http.StatusOK, newProductResponse(product))
+}
+
+// DeleteProduct godoc
+// @Summary Удаление продукта
+// @Description Удаляет продукт из каталога. Доступно только администратору. Позиции оформленных заказов сохраняются
+// @Tags Продукты
+// @Param id path int true "ID продукта" Format(uint)
+// @Success 204 "Продукт успешно удален"
+// @Failure 400 {object} problem_util.Problem "Некорректный ID продукта"
+// @Failure 401 {object} problem_util.Problem "Не авторизован"
+// @Failure 403 {object} problem_util.Problem "Недостаточно прав"
+// @Failure 404 {object} problem_util.Problem "Продукт не найден"
+// @Failure 429 {object} problem_util.Problem "Превышен лимит запросов"
+// @Failure 500 {object} problem_util.Problem "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /api/products/{id} [delete]
+func (h *ProductHandler) DeleteProduct(c *gin.Context) {
+	logger := h.log.WithContext(c.Request.Context()).WithField("method", "ProductHandler.DeleteProduct")
+	if !h.authorize(c, logger, access_policy.ActionDelete) {
+		return
+	}
+	id, ok := h.parseProductID(c, logger)
+	if !ok {
+		return
+	}
+
+	if err := h.productService.DeleteProduct(c.Request.Context(), id); err != nil {
+		common_handler.RespondError(c, logger, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,247 @@
+package product_handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/money_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/product_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/user_model"
+	"github.com/IlyushinDM/user-order-api/internal/services/product_service"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// --- Моки ---
+
+type mockProductService struct {
+	mock.Mock
+}
+
+func (m *mockProductService) CreateProduct(ctx context.Context, req product_model.CreateProductRequest) (*product_model.Product, error) {
+	args := m.Called(ctx, req)
+	product, _ := args.Get(0).(*product_model.Product)
+	return product, args.Error(1)
+}
+
+func (m *mockProductService) GetProductByID(ctx context.Context, id uint) (*product_model.Product, error) {
+	args := m.Called(ctx, id)
+	product, _ := args.Get(0).(*product_model.Product)
+	return product, args.Error(1)
+}
+
+func (m *mockProductService) GetAllProducts(ctx context.Context, page, limit int, search string) ([]product_model.Product, int64, error) {
+	args := m.Called(ctx, page, limit, search)
+	products, _ := args.Get(0).([]product_model.Product)
+	return products, args.Get(1).(int64), args.Error(2)
+}
+
+func (m *mockProductService) UpdateProduct(ctx context.Context, id uint, req product_model.UpdateProductRequest) (*product_model.Product, error) {
+	args := m.Called(ctx, id, req)
+	product, _ := args.Get(0).(*product_model.Product)
+	return product, args.Error(1)
+}
+
+func (m *mockProductService) DeleteProduct(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+type mockCommonHandler struct {
+	mock.Mock
+}
+
+func (m *mockCommonHandler) GetPaginationParams(c *gin.Context) (int, int, error) {
+	args := m.Called(c)
+	return args.Int(0), args.Int(1), args.Error(2)
+}
+
+func (m *mockCommonHandler) GetFilteringParams(c *gin.Context) (map[string]any, error) {
+	args := m.Called(c)
+	return args.Get(0).(map[string]any), args.Error(1)
+}
+
+// --- Helpers ---
+
+func setupProductHandlerTest() (*mockProductService, *mockCommonHandler, *ProductHandler) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(mockProductService)
+	mockCommon := new(mockCommonHandler)
+	return mockSvc, mockCommon, NewProductHandler(mockSvc, mockCommon, logrus.New())
+}
+
+func newTestContext(method, target string, body any, role string) (*gin.Context, *httptest.ResponseRecorder) {
+	var reader *bytes.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(method, target, reader)
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("userID", uint(1))
+	c.Set("userRole", role)
+	return c, w
+}
+
+// --- Тесты ---
+
+func TestCreateProduct(t *testing.T) {
+	reqBody := product_model.CreateProductRequest{Name: "Кофе", Price: 950}
+
+	t.Run("администратор создает продукт", func(t *testing.T) {
+		mockSvc, _, handler := setupProductHandlerTest()
+		mockSvc.On("CreateProduct", mock.Anything, reqBody).Return(&product_model.Product{ID: 7, Name: "Кофе", Price: 950, Currency: "RUB"}, nil)
+
+		c, w := newTestContext("POST", "/api/products", reqBody, user_model.RoleAdmin)
+		handler.CreateProduct(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var resp product_model.ProductResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, uint(7), resp.ID)
+		assert.Contains(t, w.Body.String(), `"price":"9.50"`)
+		mockSvc.AssertExpectations(t)
+	})
+
+	t.Run("обычный пользователь получает 403", func(t *testing.T) {
+		mockSvc, _, handler := setupProductHandlerTest()
+
+		c, w := newTestContext("POST", "/api/products", reqBody, user_model.RoleUser)
+		handler.CreateProduct(c)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockSvc.AssertNotCalled(t, "CreateProduct", mock.Anything, mock.Anything)
+	})
+
+	t.Run("цена числом вместо строки", func(t *testing.T) {
+		mockSvc, _, handler := setupProductHandlerTest()
+
+		c, w := newTestContext("POST", "/api/products", map[string]any{"name": "Кофе", "price": 9.5}, user_model.RoleAdmin)
+		handler.CreateProduct(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockSvc.AssertNotCalled(t, "CreateProduct", mock.Anything, mock.Anything)
+	})
+
+	t.Run("некорректное тело запроса", func(t *testing.T) {
+		_, _, handler := setupProductHandlerTest()
+
+		c, w := newTestContext("POST", "/api/products", map[string]any{"name": "Кофе", "price": "-1.00"}, user_model.RoleAdmin)
+		handler.CreateProduct(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestGetProductByID(t *testing.T) {
+	t.Run("пользователь читает продукт", func(t *testing.T) {
+		mockSvc, _, handler := setupProductHandlerTest()
+		mockSvc.On("GetProductByID", mock.Anything, uint(3)).Return(&product_model.Product{ID: 3, Name: "Чай", Price: 2}, nil)
+
+		c, w := newTestContext("GET", "/api/products/3", nil, user_model.RoleUser)
+		c.Params = gin.Params{{Key: "id", Value: "3"}}
+		handler.GetProductByID(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("продукт не найден", func(t *testing.T) {
+		mockSvc, _, handler := setupProductHandlerTest()
+		mockSvc.On("GetProductByID", mock.Anything, uint(3)).Return(nil, product_service.ErrProductNotFound)
+
+		c, w := newTestContext("GET", "/api/products/3", nil, user_model.RoleUser)
+		c.Params = gin.Params{{Key: "id", Value: "3"}}
+		handler.GetProductByID(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("некорректный ID", func(t *testing.T) {
+		_, _, handler := setupProductHandlerTest()
+
+		c, w := newTestContext("GET", "/api/products/abc", nil, user_model.RoleUser)
+		c.Params = gin.Params{{Key: "id", Value: "abc"}}
+		handler.GetProductByID(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestGetAllProducts_Search(t *testing.T) {
+	mockSvc, mockCommon, handler := setupProductHandlerTest()
+	mockCommon.On("GetPaginationParams", mock.Anything).Return(2, 5, nil)
+	mockSvc.On("GetAllProducts", mock.Anything, 2, 5, "кофе").
+		Return([]product_model.Product{{ID: 6, Name: "Кофе", Price: 3}}, int64(6), nil)
+
+	c, w := newTestContext("GET", "/api/products?q=кофе&page=2&limit=5", nil, user_model.RoleUser)
+	handler.GetAllProducts(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp product_model.PaginatedProductsResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, int64(6), resp.Total)
+	assert.Equal(t, 2, resp.Page)
+	assert.Len(t, resp.Products, 1)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestUpdateProduct(t *testing.T) {
+	price := money_model.Amount(1200)
+	reqBody := product_model.UpdateProductRequest{Price: &price}
+
+	t.Run("администратор изменяет цену", func(t *testing.T) {
+		mockSvc, _, handler := setupProductHandlerTest()
+		mockSvc.On("UpdateProduct", mock.Anything, uint(4), reqBody).Return(&product_model.Product{ID: 4, Name: "Сыр", Price: 12}, nil)
+
+		c, w := newTestContext("PUT", "/api/products/4", reqBody, user_model.RoleAdmin)
+		c.Params = gin.Params{{Key: "id", Value: "4"}}
+		handler.UpdateProduct(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("поддержка получает 403", func(t *testing.T) {
+		mockSvc, _, handler := setupProductHandlerTest()
+
+		c, w := newTestContext("PUT", "/api/products/4", reqBody, user_model.RoleSupport)
+		c.Params = gin.Params{{Key: "id", Value: "4"}}
+		handler.UpdateProduct(c)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockSvc.AssertNotCalled(t, "UpdateProduct", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestDeleteProduct(t *testing.T) {
+	t.Run("администратор удаляет продукт", func(t *testing.T) {
+		mockSvc, _, handler := setupProductHandlerTest()
+		mockSvc.On("DeleteProduct", mock.Anything, uint(4)).Return(nil)
+
+		c, _ := newTestContext("DELETE", "/api/products/4", nil, user_model.RoleAdmin)
+		c.Params = gin.Params{{Key: "id", Value: "4"}}
+		handler.DeleteProduct(c)
+
+		assert.Equal(t, http.StatusNoContent, c.Writer.Status())
+	})
+
+	t.Run("продукт не найден", func(t *testing.T) {
+		mockSvc, _, handler := setupProductHandlerTest()
+		mockSvc.On("DeleteProduct", mock.Anything, uint(4)).Return(product_service.ErrProductNotFound)
+
+		c, w := newTestContext("DELETE", "/api/products/4", nil, user_model.RoleAdmin)
+		c.Params = gin.Params{{Key: "id", Value: "4"}}
+		handler.DeleteProduct(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
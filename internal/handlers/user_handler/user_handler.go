@@ -2,12 +2,17 @@ package user_handler
 
 import (
 	"errors"
+	"math"
 	"net/http"
 	"strconv"
 
 	"github.com/IlyushinDM/user-order-api/internal/handlers/common_handler"
+	"github.com/IlyushinDM/user-order-api/internal/models/login_attempt_model"
 	"github.com/IlyushinDM/user-order-api/internal/models/user_model"
+	"github.com/IlyushinDM/user-order-api/internal/policy/access_policy"
 	"github.com/IlyushinDM/user-order-api/internal/services/user_service"
+	"github.com/IlyushinDM/user-order-api/internal/utils/i18n_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/problem_util"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
@@ -35,6 +40,35 @@ func NewUserHandler(userService user_service.UserService, commonHandler common_h
 	return &UserHandler{userService: userService, commonHandler: commonHandler, log: log}
 }
 
+// newUserResponse преобразует модель пользователя в ответ API без конфиденциальных данных
+func newUserResponse(user *user_model.User) user_model.UserResponse {
+	return user_model.UserResponse{
+		ID:    user.ID,
+		Name:  user.Name,
+		Email: user.Email,
+		Age:   user.Age,
+		Role:  user.Role,
+	}
+}
+
+// authorize проверяет по политике доступа, может ли текущий пользователь выполнить действие
+// над пользователем ownerID. При отказе записывает ответ и возвращает false.
+func (h *UserHandler) authorize(c *gin.Context, logger *logrus.Entry, action access_policy.Action, ownerID uint) bool {
+	subject, ok := access_policy.CurrentSubject(c)
+	if !ok {
+		logger.Error("userID не найден в context (Возможна ошибка в middleware)")
+		problem_util.Respond(c, problem_util.Internal, "")
+		return false
+	}
+	if !access_policy.Can(subject, action, access_policy.ResourceUser, ownerID) {
+		logger.WithFields(logrus.Fields{"auth_user_id": subject.UserID, "role": subject.Role, "action": action}).
+			Warn("Доступ запрещен политикой доступа")
+		problem_util.Respond(c, problem_util.Forbidden, i18n_util.MsgInsufficientRights)
+		return false
+	}
+	return true
+}
+
 // CreateUser godoc
 // @Summary Создание нового пользователя
 // @Description Регистрация нового пользователя с именем, email, возрастом и паролем.
@@ -43,9 +77,10 @@ func NewUserHandler(userService user_service.UserService, commonHandler common_h
 // @Produce json
 // @Param user body user_model.CreateUserRequest true "Данные пользователя"
 // @Success 201 {object} user_model.UserResponse "Пользователь успешно создан"
-// @Failure 400 {object} common_handler.ErrorResponse "Некорректные входные данные"
-// @Failure 409 {object} common_handler.ErrorResponse "Пользователь с таким email уже существует"
-// @Failure 500 {object} common_handler.ErrorResponse "Внутренняя ошибка сервера"
+// @Failure 400 {object} problem_util.Problem "Некорректные входные данные"
+// @Failure 409 {object} problem_util.Problem "Пользователь с таким email уже существует"
+// @Failure 429 {object} problem_util.Problem "Превышен лимит запросов"
+// @Failure 500 {object} problem_util.Problem "Внутренняя ошибка сервера"
 // @Router /api/users [post]
 func (h *UserHandler) CreateUser(c *gin.Context) {
 	logger := h.log.WithContext(c.Request.Context()).WithField("method", "UserHandler.CreateUser")
@@ -53,49 +88,36 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logger.WithError(err).Warn("Неправильный формат запроса")
-		c.JSON(http.StatusBadRequest, common_handler.ErrorResponse{Error: "Недопустимые входные данные", Details: err.Error()})
+		common_handler.RespondInvalidInput(c, err)
 		return
 	}
 
 	user, err := h.userService.CreateUser(c.Request.Context(), req)
 	// Обработка ошибок сервисного слоя
 	if err != nil {
-		logger.WithError(err).Error("Сервис вернул ошибку при создании пользователя")
-		switch {
-		case errors.Is(err, user_service.ErrInvalidServiceInput):
-			c.JSON(http.StatusBadRequest, common_handler.ErrorResponse{Error: "Недопустимые входные данные", Details: err.Error()})
-		case errors.Is(err, user_service.ErrUserAlreadyExists):
-			c.JSON(http.StatusConflict, common_handler.ErrorResponse{Error: "Пользователь с таким email уже существует"})
-		case errors.Is(err, user_service.ErrInternalServiceError):
-			c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Внутренняя ошибка сервера"})
-		case errors.Is(err, user_service.ErrServiceDatabaseError):
-			c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Сбой операции с базой данных"})
-		default:
-			c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Не удалось создать пользователя"})
-		}
+		common_handler.RespondError(c, logger, err)
 		return
 	}
 
 	logger.WithField("user_id", user.ID).Info("Пользователь успешно создан")
-	c.JSON(http.StatusCreated, user_model.UserResponse{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
-		Age:   user.Age,
-	})
+	common_handler.SetETag(c, user.Version)
+	c.JSON(http.StatusCreated, newUserResponse(user))
 }
 
 // GetUserByID godoc
 // @Summary Получение пользователя по ID
-// @Description Получение информации о конкретном пользователе по его ID. Требуется аутентификация.
+// @Description Получение информации о конкретном пользователе по его ID. Требуется аутентификация. Обычный пользователь может получить только свой профиль, поддержка и администратор - любой.
 // @Tags Пользователи
 // @Produce json
 // @Param id path int true "ID пользователя" Format(uint)
 // @Success 200 {object} user_model.UserResponse "Информация о пользователе"
-// @Failure 400 {object} common_handler.ErrorResponse "Неверный формат ID пользователя"
-// @Failure 401 {object} common_handler.ErrorResponse "Неавторизован"
-// @Failure 404 {object} common_handler.ErrorResponse "Пользователь не найден"
-// @Failure 500 {object} common_handler.ErrorResponse "Внутренняя ошибка сервера"
+// @Header 200 {string} ETag "Версия пользователя для заголовка If-Match"
+// @Failure 400 {object} problem_util.Problem "Неверный формат ID пользователя"
+// @Failure 401 {object} problem_util.Problem "Неавторизован"
+// @Failure 403 {object} problem_util.Problem "Недостаточно прав для просмотра пользователя"
+// @Failure 404 {object} problem_util.Problem "Пользователь не найден"
+// @Failure 429 {object} problem_util.Problem "Превышен лимит запросов"
+// @Failure 500 {object} problem_util.Problem "Внутренняя ошибка сервера"
 // @Security BearerAuth
 // @Router /api/users/{id} [get]
 func (h *UserHandler) GetUserByID(c *gin.Context) {
@@ -105,46 +127,30 @@ func (h *UserHandler) GetUserByID(c *gin.Context) {
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
 		logger.WithError(err).Warnf("Недопустимый формат идентификатора '%s'", idStr)
-		c.JSON(http.StatusBadRequest, common_handler.ErrorResponse{Error: "Неверный формат идентификатора пользователя"})
+		problem_util.Respond(c, problem_util.ValidationFailed, i18n_util.MsgInvalidUserID)
 		return
 	}
 	logger = logger.WithField("user_id", uint(id))
 
+	if !h.authorize(c, logger, access_policy.ActionRead, uint(id)) {
+		return
+	}
+
 	user, err := h.userService.GetUserByID(c.Request.Context(), uint(id))
 	// Обработка ошибок сервисного слоя
 	if err != nil {
-		logger.WithError(err).Error("Служба вернула ошибку при получении пользователя по идентификатору")
-		switch {
-		case errors.Is(err, user_service.ErrUserNotFound):
-			// Пользователь не найден
-			c.JSON(http.StatusNotFound, common_handler.ErrorResponse{Error: "Пользователь не найден"})
-		case errors.Is(err, user_service.ErrInvalidServiceInput):
-			// Ошибка валидации ID на уровне сервиса (например, ID=0) - маппим к 404, т.к. 0 не существует.
-			c.JSON(http.StatusNotFound, common_handler.ErrorResponse{
-				Error: "Пользователь не найден: " + user_service.ErrUserNotFound.Error(),
-			})
-		case errors.Is(err, user_service.ErrServiceDatabaseError):
-			// Ошибка, связанная с БД
-			c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Сбой операции с базой данных"})
-		default:
-			// Неизвестная ошибка сервиса
-			c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Неизвестная ошибка сервиса"})
-		}
+		common_handler.RespondError(c, logger, err)
 		return
 	}
 
 	logger.Info("Пользователь успешно восстановлен по ID")
-	c.JSON(http.StatusOK, user_model.UserResponse{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
-		Age:   user.Age,
-	})
+	common_handler.SetETag(c, user.Version)
+	c.JSON(http.StatusOK, newUserResponse(user))
 }
 
 // GetAllUsers godoc
 // @Summary Получение всех пользователей
-// @Description Получение списка пользователей с пагинацией и фильтрацией. Требуется аутентификация.
+// @Description Получение списка пользователей с пагинацией и фильтрацией. Требуется аутентификация. Поддержка и администратор получают всех пользователей, обычный пользователь - только себя.
 // @Tags Пользователи
 // @Produce json
 // @Param page query int false "Номер страницы" default(1) minimum(1)
@@ -153,9 +159,10 @@ func (h *UserHandler) GetUserByID(c *gin.Context) {
 // @Param max_age query int false "Максимальный возраст для фильтрации" minimum(1)
 // @Param name query string false "Фильтр по имени (без учета регистра, частичное совпадение)"
 // @Success 200 {object} user_model.PaginatedUsersResponse "Список пользователей"
-// @Failure 400 {object} common_handler.ErrorResponse "Неверные параметры запроса"
-// @Failure 401 {object} common_handler.ErrorResponse "Неавторизован"
-// @Failure 500 {object} common_handler.ErrorResponse "Внутренняя ошибка сервера"
+// @Failure 400 {object} problem_util.Problem "Неверные параметры запроса"
+// @Failure 401 {object} problem_util.Problem "Неавторизован"
+// @Failure 429 {object} problem_util.Problem "Превышен лимит запросов"
+// @Failure 500 {object} problem_util.Problem "Внутренняя ошибка сервера"
 // @Security BearerAuth
 // @Router /api/users [get]
 func (h *UserHandler) GetAllUsers(c *gin.Context) {
@@ -165,7 +172,7 @@ func (h *UserHandler) GetAllUsers(c *gin.Context) {
 	page, limit, err := h.commonHandler.GetPaginationParams(c)
 	if err != nil {
 		logger.WithError(err).Warn("Недопустимые параметры разбивки на страницы")
-		c.JSON(http.StatusBadRequest, common_handler.ErrorResponse{Error: "Недопустимые параметры разбивки на страницы", Details: err.Error()})
+		common_handler.RespondInvalidInput(c, err)
 		return
 	}
 
@@ -173,38 +180,35 @@ func (h *UserHandler) GetAllUsers(c *gin.Context) {
 	filters, err := h.commonHandler.GetFilteringParams(c)
 	if err != nil {
 		logger.WithError(err).Warn("Недопустимые параметры фильтрации")
-		c.JSON(http.StatusBadRequest, common_handler.ErrorResponse{Error: "Недопустимые параметры фильтрации", Details: err.Error()})
+		common_handler.RespondInvalidInput(c, err)
 		return
 	}
 
 	logger = logger.WithFields(logrus.Fields{"page": page, "limit": limit, "filters": filters})
 
+	subject, ok := access_policy.CurrentSubject(c)
+	if !ok {
+		logger.Error("userID не найден в context (Возможна ошибка в middleware)")
+		problem_util.Respond(c, problem_util.Internal, "")
+		return
+	}
+	if !access_policy.Can(subject, access_policy.ActionList, access_policy.ResourceUser, 0) {
+		// Обычному пользователю список всех пользователей недоступен - возвращаем только его самого
+		h.respondWithSelfOnly(c, logger, subject.UserID, page, limit)
+		return
+	}
+
 	users, total, err := h.userService.GetAllUsers(c.Request.Context(), page, limit, filters)
 	// Обработка ошибок сервисного слоя
 	if err != nil {
-		logger.WithError(err).Error("Ошибка во время получения всех пользователей")
-		switch {
-		case errors.Is(err, user_service.ErrInvalidServiceInput):
-			c.JSON(http.StatusBadRequest, common_handler.ErrorResponse{Error: "Недопустимые параметры запроса", Details: err.Error()})
-		case errors.Is(err, user_service.ErrServiceDatabaseError):
-			// Ошибка, связанная с БД
-			c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Сбой операции с базой данных"})
-		default:
-			// Неизвестная ошибка сервиса
-			c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Неизвестная ошибка сервиса"})
-		}
+		common_handler.RespondError(c, logger, err)
 		return
 	}
 
 	logger.WithField("count", len(users)).Info("Пользователи были успешно восстановлены")
 	userResponses := make([]user_model.UserResponse, len(users))
 	for i, user := range users {
-		userResponses[i] = user_model.UserResponse{
-			ID:    user.ID,
-			Name:  user.Name,
-			Email: user.Email,
-			Age:   user.Age,
-		}
+		userResponses[i] = newUserResponse(&user)
 	}
 
 	response := user_model.PaginatedUsersResponse{
@@ -217,21 +221,112 @@ func (h *UserHandler) GetAllUsers(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// GetLoginHistory godoc
+// @Summary История входов пользователя
+// @Description Получение последних попыток входа в учетную запись пользователя от новых к старым: время, IP-адрес, User-Agent и исход. Требуется аутентификация. Обычный пользователь может получить только свою историю, поддержка и администратор - любую.
+// @Tags Пользователи
+// @Produce json
+// @Param id path int true "ID пользователя" Format(uint)
+// @Param page query int false "Номер страницы" default(1) minimum(1)
+// @Param limit query int false "Количество элементов на странице" default(10) minimum(1) maximum(100)
+// @Success 200 {object} login_attempt_model.PaginatedLoginAttemptsResponse "История входов"
+// @Failure 400 {object} problem_util.Problem "Неверный формат ID пользователя или параметров пагинации"
+// @Failure 401 {object} problem_util.Problem "Неавторизован"
+// @Failure 403 {object} problem_util.Problem "Недостаточно прав для просмотра истории входов"
+// @Failure 404 {object} problem_util.Problem "Пользователь не найден"
+// @Failure 429 {object} problem_util.Problem "Превышен лимит запросов"
+// @Failure 500 {object} problem_util.Problem "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /api/users/{id}/logins [get]
+func (h *UserHandler) GetLoginHistory(c *gin.Context) {
+	logger := h.log.WithContext(c.Request.Context()).WithField("method", "UserHandler.GetLoginHistory")
+	idStr := c.Param("id")
+
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		logger.WithError(err).Warnf("Недопустимый формат идентификатора '%s'", idStr)
+		problem_util.Respond(c, problem_util.ValidationFailed, i18n_util.MsgInvalidUserID)
+		return
+	}
+	logger = logger.WithField("user_id", uint(id))
+
+	if !h.authorize(c, logger, access_policy.ActionRead, uint(id)) {
+		return
+	}
+
+	page, limit, err := h.commonHandler.GetPaginationParams(c)
+	if err != nil {
+		logger.WithError(err).Warn("Недопустимые параметры разбивки на страницы")
+		common_handler.RespondInvalidInput(c, err)
+		return
+	}
+
+	attempts, total, err := h.userService.GetLoginHistory(c.Request.Context(), uint(id), page, limit)
+	if err != nil {
+		common_handler.RespondError(c, logger, err)
+		return
+	}
+
+	logins := make([]login_attempt_model.LoginAttemptResponse, len(attempts))
+	for i, attempt := range attempts {
+		logins[i] = login_attempt_model.LoginAttemptResponse{
+			ID:        attempt.ID,
+			IP:        attempt.IP,
+			UserAgent: attempt.UserAgent,
+			Outcome:   attempt.Outcome,
+			CreatedAt: attempt.CreatedAt,
+		}
+	}
+
+	logger.WithField("count", len(logins)).Info("История входов успешно получена")
+	c.JSON(http.StatusOK, login_attempt_model.PaginatedLoginAttemptsResponse{
+		Page:   page,
+		Limit:  limit,
+		Total:  total,
+		Logins: logins,
+	})
+}
+
+// respondWithSelfOnly отвечает на запрос списка пользователей страницей, содержащей только вызывающего
+func (h *UserHandler) respondWithSelfOnly(c *gin.Context, logger *logrus.Entry, userID uint, page, limit int) {
+	user, err := h.userService.GetUserByID(c.Request.Context(), userID)
+	if err != nil {
+		common_handler.RespondError(c, logger, err)
+		return
+	}
+
+	users := []user_model.UserResponse{}
+	if page == 1 {
+		users = append(users, newUserResponse(user))
+	}
+	c.JSON(http.StatusOK, user_model.PaginatedUsersResponse{
+		Page:  page,
+		Limit: limit,
+		Total: 1,
+		Users: users,
+	})
+}
+
 // UpdateUser godoc
 // @Summary Обновление пользователя
-// @Description Обновление информации о существующем пользователе по ID. Требуется аутентификация. Пользователь может обновлять только свои данные, если он не является администратором (логика администратора здесь не реализована).
+// @Description Обновление информации о существующем пользователе по ID. Требуется аутентификация. Обычный пользователь может обновлять только свои данные, поддержка и администратор - данные любого пользователя. Изменять роль может только администратор. Требует заголовок If-Match с ETag, полученным при чтении пользователя.
 // @Tags Пользователи
 // @Accept json
 // @Produce json
 // @Param id path int true "ID пользователя" Format(uint)
+// @Param If-Match header string true "ETag пользователя, полученный при чтении"
 // @Param user body user_model.UpdateUserRequest true "Данные пользователя для обновления"
 // @Success 200 {object} user_model.UserResponse "Пользователь успешно обновлен"
-// @Failure 400 {object} common_handler.ErrorResponse "Некорректные входные данные или неверный формат ID пользователя"
-// @Failure 401 {object} common_handler.ErrorResponse "Неавторизован"
-// @Failure 403 {object} common_handler.ErrorResponse "Запрещено (попытка обновить другого пользователя - упрощенная проверка)"
-// @Failure 404 {object} common_handler.ErrorResponse "Пользователь не найден"
-// @Failure 409 {object} common_handler.ErrorResponse "Email уже используется другим пользователем"
-// @Failure 500 {object} common_handler.ErrorResponse "Внутренняя ошибка сервера"
+// @Header 200 {string} ETag "Новая версия пользователя"
+// @Failure 400 {object} problem_util.Problem "Некорректные входные данные или неверный формат ID пользователя"
+// @Failure 401 {object} problem_util.Problem "Неавторизован"
+// @Failure 403 {object} problem_util.Problem "Недостаточно прав для обновления пользователя или изменения роли"
+// @Failure 404 {object} problem_util.Problem "Пользователь не найден"
+// @Failure 409 {object} problem_util.Problem "Email уже используется другим пользователем"
+// @Failure 412 {object} problem_util.Problem "Пользователь изменен после чтения: ETag не совпадает"
+// @Failure 428 {object} problem_util.Problem "Не передан заголовок If-Match"
+// @Failure 429 {object} problem_util.Problem "Превышен лимит запросов"
+// @Failure 500 {object} problem_util.Problem "Внутренняя ошибка сервера"
 // @Security BearerAuth
 // @Router /api/users/{id} [put]
 func (h *UserHandler) UpdateUser(c *gin.Context) {
@@ -241,21 +336,18 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
 		logger.WithError(err).Warnf("Недопустимый формат идентификатора '%s'", idStr)
-		c.JSON(http.StatusBadRequest, common_handler.ErrorResponse{Error: "Неверный формат идентификатора пользователя"})
+		problem_util.Respond(c, problem_util.ValidationFailed, i18n_util.MsgInvalidUserID)
 		return
 	}
 	logger = logger.WithField("user_id", uint(id))
 
-	// Проверка авторизованного пользователя
-	authUserID, exists := c.Get("userID")
-	if !exists {
-		logger.Error("userID не найден в context (Возможна ошибка в middleware)")
-		c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Ошибка context аутентификации"})
+	if !h.authorize(c, logger, access_policy.ActionUpdate, uint(id)) {
 		return
 	}
-	if authUserID.(uint) != uint(id) {
-		logger.Warnf("Forbidden attempt by user %d to update user %d", authUserID.(uint), id)
-		c.JSON(http.StatusForbidden, common_handler.ErrorResponse{Error: "Forbidden: You can only update your own profile"})
+
+	version, ok := common_handler.RequireIfMatch(c)
+	if !ok {
+		logger.Warn("Обновление пользователя без корректного заголовка If-Match")
 		return
 	}
 
@@ -263,59 +355,46 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logger.WithError(err).Warn("Bad request format")
-		c.JSON(http.StatusBadRequest, common_handler.ErrorResponse{Error: "Недопустимые входные данные", Details: err.Error()})
+		common_handler.RespondInvalidInput(c, err)
 		return
 	}
 
-	user, err := h.userService.UpdateUser(c.Request.Context(), uint(id), req)
+	if req.Role != "" && !h.authorize(c, logger, access_policy.ActionManageRoles, uint(id)) {
+		return
+	}
+
+	user, err := h.userService.UpdateUser(c.Request.Context(), uint(id), version, req)
 	// Обработка ошибок сервисного слоя
+	if errors.Is(err, user_service.ErrNoUpdateFields) {
+		// При отсутствии изменений возвращается текущее состояние пользователя
+		logger.Info("Нет полей для обновления")
+		common_handler.SetETag(c, user.Version)
+		c.JSON(http.StatusOK, newUserResponse(user))
+		return
+	}
 	if err != nil {
-		logger.WithError(err).Error("Service returned error during user update")
-		switch {
-		case errors.Is(err, user_service.ErrInvalidServiceInput):
-			c.JSON(http.StatusBadRequest, common_handler.ErrorResponse{Error: "Недопустимые входные данные", Details: err.Error()})
-		case errors.Is(err, user_service.ErrUserNotFound):
-			c.JSON(http.StatusNotFound, common_handler.ErrorResponse{Error: "Пользователь не найден "})
-		case errors.Is(err, user_service.ErrEmailAlreadyTaken):
-			c.JSON(http.StatusConflict, common_handler.ErrorResponse{Error: "Email уже занят другим пользователем"})
-		case errors.Is(err, user_service.ErrNoUpdateFields):
-			logger.Info("Нет полей для обновлени")
-			c.JSON(http.StatusOK, user_model.UserResponse{
-				ID:    user.ID,
-				Name:  user.Name,
-				Email: user.Email,
-				Age:   user.Age,
-			})
-			return
-		case errors.Is(err, user_service.ErrServiceDatabaseError):
-			c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Сбой операции с базой данных"})
-		default:
-			c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Неизвестная ошибка сервиса"})
-		}
+		common_handler.RespondError(c, logger, err)
 		return
 	}
 
 	logger.Info("User updated successfully")
-	c.JSON(http.StatusOK, user_model.UserResponse{
-		ID:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
-		Age:   user.Age,
-	})
+	common_handler.SetETag(c, user.Version)
+	c.JSON(http.StatusOK, newUserResponse(user))
 }
 
 // DeleteUser godoc
 // @Summary Удаление пользователя
-// @Description Удаление пользователя по его ID. Требуется аутентификация. Пользователь может удалить только свою учетную запись, если он не является администратором (логика администратора здесь не реализована).
+// @Description Удаление пользователя по его ID. Требуется аутентификация. Обычный пользователь может удалить только свою учетную запись, администратор - любую.
 // @Tags Пользователи
 // @Produce json
 // @Param id path int true "ID пользователя" Format(uint)
 // @Success 204 "Пользователь успешно удален"
-// @Failure 400 {object} common_handler.ErrorResponse "Неверный формат ID пользователя"
-// @Failure 401 {object} common_handler.ErrorResponse "Неавторизован"
-// @Failure 403 {object} common_handler.ErrorResponse "Запрещено (попытка удалить другого пользователя)"
-// @Failure 404 {object} common_handler.ErrorResponse "Пользователь не найден"
-// @Failure 500 {object} common_handler.ErrorResponse "Внутренняя ошибка сервера"
+// @Failure 400 {object} problem_util.Problem "Неверный формат ID пользователя"
+// @Failure 401 {object} problem_util.Problem "Неавторизован"
+// @Failure 403 {object} problem_util.Problem "Запрещено (попытка удалить другого пользователя)"
+// @Failure 404 {object} problem_util.Problem "Пользователь не найден"
+// @Failure 429 {object} problem_util.Problem "Превышен лимит запросов"
+// @Failure 500 {object} problem_util.Problem "Внутренняя ошибка сервера"
 // @Security BearerAuth
 // @Router /api/users/{id} [delete]
 func (h *UserHandler) DeleteUser(c *gin.Context) {
@@ -325,42 +404,19 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
 		logger.WithError(err).Warnf("Недопустимый формат идентификатора '%s'", idStr)
-		c.JSON(http.StatusBadRequest, common_handler.ErrorResponse{Error: "Неверный формат идентификатора пользователя"})
+		problem_util.Respond(c, problem_util.ValidationFailed, i18n_util.MsgInvalidUserID)
 		return
 	}
 	logger = logger.WithField("user_id", uint(id))
 
-	// Проверка авторизованного пользователя (остается в обработчике)
-	authUserID, exists := c.Get("userID")
-	if !exists {
-		logger.Error("userID not found in context")
-		c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Authentication context error"})
-		return
-	}
-	if authUserID.(uint) != uint(id) {
-		logger.Warnf("Forbidden attempt by user %d to delete user %d", authUserID.(uint), id)
-		c.JSON(http.StatusForbidden, common_handler.ErrorResponse{Error: "Forbidden: You can only delete your own account"})
+	if !h.authorize(c, logger, access_policy.ActionDelete, uint(id)) {
 		return
 	}
 
 	err = h.userService.DeleteUser(c.Request.Context(), uint(id))
 	// Обработка ошибок сервисного слоя
 	if err != nil {
-		logger.WithError(err).Error("Service returned error during user deletion")
-		switch {
-		case errors.Is(err, user_service.ErrInvalidServiceInput):
-			// Ошибка валидации ID на уровне сервиса (например, ID=0) - маппим к 404, т.к. 0 не существует.
-			c.JSON(http.StatusNotFound, common_handler.ErrorResponse{Error: user_service.ErrUserNotFound.Error()})
-		case errors.Is(err, user_service.ErrUserNotFound):
-			// Пользователь не найден
-			c.JSON(http.StatusNotFound, common_handler.ErrorResponse{Error: "Пользователь не найден"})
-		case errors.Is(err, user_service.ErrServiceDatabaseError):
-			// Ошибка, связанная с БД
-			c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Сбой операции с базой данных"})
-		default:
-			// Неизвестная ошибка сервиса
-			c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Не удалось удалить пользователяr"})
-		}
+		common_handler.RespondError(c, logger, err)
 		return
 	}
 
@@ -370,15 +426,19 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 
 // LoginUser godoc
 // @Summary Вход пользователя
-// @Description Аутентификация пользователя с использованием email и пароля, возвращает JWT токен.
+// @Description Аутентификация пользователя с использованием email и пароля. Возвращает короткоживущий access токен (JWT) и одноразовый refresh токен. Каждая попытка записывается в историю входов. После неудачной попытки следующая возможна только через растущую задержку (429), а серия неудач временно блокирует вход (423).
 // @Tags Аутентификация
 // @Accept json
 // @Produce json
 // @Param credentials body user_model.LoginRequest true "Учетные данные для входа"
-// @Success 200 {object} user_model.LoginResponse "Вход выполнен успешно, включает JWT токен"
-// @Failure 400 {object} common_handler.ErrorResponse "Некорректные входные данные"
-// @Failure 401 {object} common_handler.ErrorResponse "Неверные учетные данные"
-// @Failure 500 {object} common_handler.ErrorResponse "Внутренняя ошибка сервера"
+// @Success 200 {object} user_model.LoginResponse "Вход выполнен успешно, включает пару токенов"
+// @Failure 400 {object} problem_util.Problem "Некорректные входные данные"
+// @Failure 401 {object} problem_util.Problem "Неверные учетные данные"
+// @Failure 423 {object} problem_util.Problem "Вход временно заблокирован после неудачных попыток"
+// @Header 423 {integer} Retry-After "Через сколько секунд можно повторить вход"
+// @Failure 429 {object} problem_util.Problem "Превышен лимит запросов или не истекла задержка после неудачной попытки"
+// @Header 429 {integer} Retry-After "Через сколько секунд можно повторить запрос"
+// @Failure 500 {object} problem_util.Problem "Внутренняя ошибка сервера"
 // @Router /auth/login [post]
 func (h *UserHandler) LoginUser(c *gin.Context) {
 	logger := h.log.WithContext(c.Request.Context()).WithField("method", "UserHandler.LoginUser")
@@ -387,30 +447,101 @@ func (h *UserHandler) LoginUser(c *gin.Context) {
 	// Bind JSON request body
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logger.WithError(err).Warn("Неправильный формат запроса")
-		c.JSON(http.StatusBadRequest, common_handler.ErrorResponse{Error: "Недопустимые входные данные", Details: err.Error()})
+		common_handler.RespondInvalidInput(c, err)
 		return
 	}
 	logger = logger.WithField("email", req.Email)
 
-	token, err := h.userService.LoginUser(c.Request.Context(), req)
+	client := login_attempt_model.ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	tokens, err := h.userService.LoginUser(c.Request.Context(), req, client)
 	// Обработка ошибок сервисного слоя
 	if err != nil {
-		logger.WithError(err).Error("Служба вернула ошибку при входе в систему")
-		switch {
-		case errors.Is(err, user_service.ErrInvalidServiceInput):
-			c.JSON(http.StatusBadRequest, common_handler.ErrorResponse{Error: "Недопустимые входные данные", Details: err.Error()})
-		case errors.Is(err, user_service.ErrInvalidCredentials):
-			c.JSON(http.StatusUnauthorized, common_handler.ErrorResponse{Error: "Неверные учетные данные"})
-		case errors.Is(err, user_service.ErrInternalServiceError):
-			c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Внутренняя ошибка сервера"})
-		case errors.Is(err, user_service.ErrServiceDatabaseError):
-			c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Сбой операции с базой данных"})
-		default:
-			c.JSON(http.StatusInternalServerError, common_handler.ErrorResponse{Error: "Ошибка входа"})
+		var blocked *user_service.LoginBlockedError
+		if errors.As(err, &blocked) {
+			c.Header("Retry-After", strconv.Itoa(max(int(math.Ceil(blocked.RetryAfter.Seconds())), 1)))
 		}
+		common_handler.RespondError(c, logger, err)
 		return
 	}
 
-	logger.Info("Пользователь успешно вошел в систему, токен сгенерирован")
-	c.JSON(http.StatusOK, user_model.LoginResponse{Token: token})
+	logger.Info("Пользователь успешно вошел в систему, токены сгенерированы")
+	c.JSON(http.StatusOK, tokens)
+}
+
+// RefreshToken godoc
+// @Summary Обновление пары токенов
+// @Description Обменивает refresh токен на новую пару токенов. Предъявленный refresh токен становится недействительным; его повторное использование отзывает всю сессию.
+// @Tags Аутентификация
+// @Accept json
+// @Produce json
+// @Param request body user_model.RefreshRequest true "Refresh токен"
+// @Success 200 {object} user_model.LoginResponse "Новая пара токенов"
+// @Failure 400 {object} problem_util.Problem "Некорректные входные данные"
+// @Failure 401 {object} problem_util.Problem "Refresh токен недействителен, просрочен или уже использован"
+// @Failure 429 {object} problem_util.Problem "Превышен лимит запросов"
+// @Failure 500 {object} problem_util.Problem "Внутренняя ошибка сервера"
+// @Router /auth/refresh [post]
+func (h *UserHandler) RefreshToken(c *gin.Context) {
+	logger := h.log.WithContext(c.Request.Context()).WithField("method", "UserHandler.RefreshToken")
+	var req user_model.RefreshRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.WithError(err).Warn("Неправильный формат запроса")
+		common_handler.RespondInvalidInput(c, err)
+		return
+	}
+
+	tokens, err := h.userService.RefreshTokens(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		common_handler.RespondError(c, logger, err)
+		return
+	}
+
+	logger.Info("Пара токенов успешно обновлена")
+	c.JSON(http.StatusOK, tokens)
+}
+
+// LogoutUser godoc
+// @Summary Выход пользователя
+// @Description Завершает сессию: отзывает семейство refresh токенов и текущий access токен. Требуется аутентификация.
+// @Tags Аутентификация
+// @Accept json
+// @Produce json
+// @Param request body user_model.LogoutRequest true "Refresh токен текущей сессии"
+// @Success 204 "Сессия завершена"
+// @Failure 400 {object} problem_util.Problem "Некорректные входные данные"
+// @Failure 401 {object} problem_util.Problem "Неавторизован или refresh токен принадлежит другому пользователю"
+// @Failure 429 {object} problem_util.Problem "Превышен лимит запросов"
+// @Failure 500 {object} problem_util.Problem "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /auth/logout [post]
+func (h *UserHandler) LogoutUser(c *gin.Context) {
+	logger := h.log.WithContext(c.Request.Context()).WithField("method", "UserHandler.LogoutUser")
+
+	authUserID, exists := c.Get("userID")
+	if !exists {
+		logger.Error("userID не найден в context (Возможна ошибка в middleware)")
+		problem_util.Respond(c, problem_util.Internal, "")
+		return
+	}
+	logger = logger.WithField("user_id", authUserID.(uint))
+
+	var req user_model.LogoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.WithError(err).Warn("Неправильный формат запроса")
+		common_handler.RespondInvalidInput(c, err)
+		return
+	}
+
+	tokenID := c.GetString("tokenID")
+	expiresAt := c.GetTime("tokenExpiresAt")
+
+	err := h.userService.LogoutUser(c.Request.Context(), authUserID.(uint), req.RefreshToken, tokenID, expiresAt)
+	if err != nil {
+		common_handler.RespondError(c, logger, err)
+		return
+	}
+
+	logger.Info("Пользователь вышел из системы")
+	c.Status(http.StatusNoContent)
 }
@@ -7,7 +7,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/IlyushinDM/user-order-api/internal/models/login_attempt_model"
 	"github.com/IlyushinDM/user-order-api/internal/models/user_model"
 	"github.com/IlyushinDM/user-order-api/internal/services/user_service"
 	"github.com/gin-gonic/gin"
@@ -39,8 +41,8 @@ func (m *mockUserService) GetAllUsers(ctx context.Context, page, limit int, filt
 	return args.Get(0).([]user_model.User), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *mockUserService) UpdateUser(ctx context.Context, id uint, req user_model.UpdateUserRequest) (*user_model.User, error) {
-	args := m.Called(ctx, id, req)
+func (m *mockUserService) UpdateUser(ctx context.Context, id, version uint, req user_model.UpdateUserRequest) (*user_model.User, error) {
+	args := m.Called(ctx, id, version, req)
 	user, _ := args.Get(0).(*user_model.User)
 	return user, args.Error(1)
 }
@@ -50,9 +52,30 @@ func (m *mockUserService) DeleteUser(ctx context.Context, id uint) error {
 	return args.Error(0)
 }
 
-func (m *mockUserService) LoginUser(ctx context.Context, req user_model.LoginRequest) (string, error) {
-	args := m.Called(ctx, req)
-	return args.String(0), args.Error(1)
+func (m *mockUserService) LoginUser(
+	ctx context.Context,
+	req user_model.LoginRequest,
+	client login_attempt_model.ClientInfo,
+) (*user_model.LoginResponse, error) {
+	args := m.Called(ctx, req, client)
+	tokens, _ := args.Get(0).(*user_model.LoginResponse)
+	return tokens, args.Error(1)
+}
+
+func (m *mockUserService) RefreshTokens(ctx context.Context, refreshToken string) (*user_model.LoginResponse, error) {
+	args := m.Called(ctx, refreshToken)
+	tokens, _ := args.Get(0).(*user_model.LoginResponse)
+	return tokens, args.Error(1)
+}
+
+func (m *mockUserService) LogoutUser(ctx context.Context, userID uint, refreshToken, accessTokenID string, accessExpiresAt time.Time) error {
+	args := m.Called(ctx, userID, refreshToken, accessTokenID, accessExpiresAt)
+	return args.Error(0)
+}
+
+func (m *mockUserService) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	args := m.Called(ctx, tokenID)
+	return args.Bool(0), args.Error(1)
 }
 
 func (m *mockUserService) GetUserByEmail(ctx context.Context, email string) (*user_model.User, error) {
@@ -61,6 +84,13 @@ func (m *mockUserService) GetUserByEmail(ctx context.Context, email string) (*us
 	return user, args.Error(1)
 }
 
+func (m *mockUserService) GetLoginHistory(ctx context.Context, userID uint, page, limit int) ([]login_attempt_model.LoginAttempt, int64, error) {
+	args := m.Called(ctx, userID, page, limit)
+	attempts, _ := args.Get(0).([]login_attempt_model.LoginAttempt)
+	total, _ := args.Get(1).(int64)
+	return attempts, total, args.Error(2)
+}
+
 type mockCommonHandler struct {
 	mock.Mock
 }
@@ -158,3 +188,347 @@ func TestCreateUser_ServiceError(t *testing.T) {
 	handler.CreateUser(c)
 	assert.Equal(t, http.StatusConflict, w.Code)
 }
+
+func TestLoginUser_Success(t *testing.T) {
+	mockSvc, _, handler, _ := setupUserHandlerTest()
+	w := httptest.NewRecorder()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(w)
+
+	reqBody := user_model.LoginRequest{Email: "test@example.com", Password: "password123"}
+	tokens := &user_model.LoginResponse{Token: "access", RefreshToken: "refresh", TokenType: "Bearer", ExpiresIn: 900}
+	client := login_attempt_model.ClientInfo{IP: "192.0.2.10", UserAgent: "test-agent"}
+	mockSvc.On("LoginUser", mock.Anything, reqBody, client).Return(tokens, nil)
+
+	body, _ := json.Marshal(reqBody)
+	c.Request, _ = http.NewRequest("POST", "/auth/login", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.Header.Set("User-Agent", "test-agent")
+	c.Request.RemoteAddr = "192.0.2.10:12345"
+
+	handler.LoginUser(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp user_model.LoginResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, *tokens, resp)
+}
+
+func TestLoginUser_Locked(t *testing.T) {
+	mockSvc, _, handler, _ := setupUserHandlerTest()
+	w := httptest.NewRecorder()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(w)
+
+	reqBody := user_model.LoginRequest{Email: "test@example.com", Password: "password123"}
+	blocked := &user_service.LoginBlockedError{RetryAfter: 90500 * time.Millisecond, Locked: true}
+	mockSvc.On("LoginUser", mock.Anything, reqBody, mock.Anything).Return(nil, blocked)
+
+	body, _ := json.Marshal(reqBody)
+	c.Request, _ = http.NewRequest("POST", "/auth/login", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handler.LoginUser(c)
+	assert.Equal(t, http.StatusLocked, w.Code)
+	assert.Equal(t, "91", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "account_locked")
+}
+
+func TestRefreshToken_Success(t *testing.T) {
+	mockSvc, _, handler, _ := setupUserHandlerTest()
+	w := httptest.NewRecorder()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(w)
+
+	tokens := &user_model.LoginResponse{Token: "new-access", RefreshToken: "new-refresh", TokenType: "Bearer", ExpiresIn: 900}
+	mockSvc.On("RefreshTokens", mock.Anything, "old-refresh").Return(tokens, nil)
+
+	body, _ := json.Marshal(user_model.RefreshRequest{RefreshToken: "old-refresh"})
+	c.Request, _ = http.NewRequest("POST", "/auth/refresh", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handler.RefreshToken(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp user_model.LoginResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "new-refresh", resp.RefreshToken)
+}
+
+func TestRefreshToken_Reused(t *testing.T) {
+	mockSvc, _, handler, _ := setupUserHandlerTest()
+	w := httptest.NewRecorder()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(w)
+
+	mockSvc.On("RefreshTokens", mock.Anything, "stolen").Return(nil, user_service.ErrRefreshTokenReused)
+
+	body, _ := json.Marshal(user_model.RefreshRequest{RefreshToken: "stolen"})
+	c.Request, _ = http.NewRequest("POST", "/auth/refresh", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handler.RefreshToken(c)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestLogoutUser_Success(t *testing.T) {
+	mockSvc, _, handler, _ := setupUserHandlerTest()
+	w := httptest.NewRecorder()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(w)
+
+	expiresAt := time.Now().Add(time.Minute)
+	mockSvc.On("LogoutUser", mock.Anything, uint(1), "refresh", "jti-1", expiresAt).Return(nil)
+
+	body, _ := json.Marshal(user_model.LogoutRequest{RefreshToken: "refresh"})
+	c.Request, _ = http.NewRequest("POST", "/auth/logout", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	addAuthUserID(c, 1)
+	c.Set("tokenID", "jti-1")
+	c.Set("tokenExpiresAt", expiresAt)
+
+	handler.LogoutUser(c)
+	assert.Equal(t, http.StatusNoContent, c.Writer.Status())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestGetUserByID_ForbiddenForOtherUser(t *testing.T) {
+	mockSvc, _, handler, _ := setupUserHandlerTest()
+	w := httptest.NewRecorder()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(w)
+
+	c.Request, _ = http.NewRequest("GET", "/api/users/2", nil)
+	c.Params = gin.Params{{Key: "id", Value: "2"}}
+	addAuthUserID(c, 1)
+
+	handler.GetUserByID(c)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockSvc.AssertNotCalled(t, "GetUserByID", mock.Anything, mock.Anything)
+}
+
+func TestGetUserByID_SupportReadsOtherUser(t *testing.T) {
+	mockSvc, _, handler, _ := setupUserHandlerTest()
+	w := httptest.NewRecorder()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(w)
+
+	user := &user_model.User{ID: 2, Name: "Other", Email: "other@example.com", Age: 30, Role: user_model.RoleUser, Version: 4}
+	mockSvc.On("GetUserByID", mock.Anything, uint(2)).Return(user, nil)
+
+	c.Request, _ = http.NewRequest("GET", "/api/users/2", nil)
+	c.Params = gin.Params{{Key: "id", Value: "2"}}
+	addAuthUserID(c, 1)
+	c.Set("userRole", user_model.RoleSupport)
+
+	handler.GetUserByID(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp user_model.UserResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, user_model.RoleUser, resp.Role)
+	assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+}
+
+func TestGetLoginHistory_ForbiddenForOtherUser(t *testing.T) {
+	mockSvc, _, handler, _ := setupUserHandlerTest()
+	w := httptest.NewRecorder()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(w)
+
+	c.Request, _ = http.NewRequest("GET", "/api/users/2/logins", nil)
+	c.Params = gin.Params{{Key: "id", Value: "2"}}
+	addAuthUserID(c, 1)
+
+	handler.GetLoginHistory(c)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockSvc.AssertNotCalled(t, "GetLoginHistory", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetLoginHistory_Own(t *testing.T) {
+	mockSvc, mockCommon, handler, _ := setupUserHandlerTest()
+	w := httptest.NewRecorder()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(w)
+
+	userID := uint(1)
+	at := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	attempts := []login_attempt_model.LoginAttempt{{
+		ID: 7, UserID: &userID, Email: "me@example.com", IP: "192.0.2.10",
+		UserAgent: "test-agent", Outcome: login_attempt_model.OutcomeSuccess, CreatedAt: at,
+	}}
+	mockCommon.On("GetPaginationParams", mock.Anything).Return(1, 10, nil)
+	mockSvc.On("GetLoginHistory", mock.Anything, userID, 1, 10).Return(attempts, int64(1), nil)
+
+	c.Request, _ = http.NewRequest("GET", "/api/users/1/logins", nil)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	addAuthUserID(c, userID)
+
+	handler.GetLoginHistory(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp login_attempt_model.PaginatedLoginAttemptsResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, int64(1), resp.Total)
+	assert.Equal(t, []login_attempt_model.LoginAttemptResponse{{
+		ID: 7, IP: "192.0.2.10", UserAgent: "test-agent", Outcome: login_attempt_model.OutcomeSuccess, CreatedAt: at,
+	}}, resp.Logins)
+	assert.NotContains(t, w.Body.String(), "me@example.com")
+}
+
+func TestGetAllUsers_RegularUserSeesOnlySelf(t *testing.T) {
+	mockSvc, mockCommon, handler, _ := setupUserHandlerTest()
+	w := httptest.NewRecorder()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(w)
+
+	self := &user_model.User{ID: 1, Name: "Self", Email: "self@example.com", Age: 20, Role: user_model.RoleUser}
+	mockCommon.On("GetPaginationParams", mock.Anything).Return(1, 10, nil)
+	mockCommon.On("GetFilteringParams", mock.Anything).Return(map[string]any{}, nil)
+	mockSvc.On("GetUserByID", mock.Anything, uint(1)).Return(self, nil)
+
+	c.Request, _ = http.NewRequest("GET", "/api/users", nil)
+	addAuthUserID(c, 1)
+
+	handler.GetAllUsers(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp user_model.PaginatedUsersResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, int64(1), resp.Total)
+	if assert.Len(t, resp.Users, 1) {
+		assert.Equal(t, uint(1), resp.Users[0].ID)
+	}
+	mockSvc.AssertNotCalled(t, "GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetAllUsers_AdminSeesEveryone(t *testing.T) {
+	mockSvc, mockCommon, handler, _ := setupUserHandlerTest()
+	w := httptest.NewRecorder()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(w)
+
+	users := []user_model.User{{ID: 1, Name: "A"}, {ID: 2, Name: "B"}}
+	filters := map[string]any{}
+	mockCommon.On("GetPaginationParams", mock.Anything).Return(1, 10, nil)
+	mockCommon.On("GetFilteringParams", mock.Anything).Return(filters, nil)
+	mockSvc.On("GetAllUsers", mock.Anything, 1, 10, filters).Return(users, int64(2), nil)
+
+	c.Request, _ = http.NewRequest("GET", "/api/users", nil)
+	addAuthUserID(c, 1)
+	c.Set("userRole", user_model.RoleAdmin)
+
+	handler.GetAllUsers(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp user_model.PaginatedUsersResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, int64(2), resp.Total)
+	assert.Len(t, resp.Users, 2)
+}
+
+func TestUpdateUser_RoleChangeRequiresAdmin(t *testing.T) {
+	mockSvc, _, handler, _ := setupUserHandlerTest()
+	w := httptest.NewRecorder()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(w)
+
+	body, _ := json.Marshal(user_model.UpdateUserRequest{Role: user_model.RoleAdmin})
+	c.Request, _ = http.NewRequest("PUT", "/api/users/1", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.Header.Set("If-Match", `"1"`)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	addAuthUserID(c, 1)
+
+	handler.UpdateUser(c)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockSvc.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateUser_ReturnsNewETag(t *testing.T) {
+	mockSvc, _, handler, _ := setupUserHandlerTest()
+	w := httptest.NewRecorder()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(w)
+
+	req := user_model.UpdateUserRequest{Name: "Renamed"}
+	updated := &user_model.User{ID: 1, Name: "Renamed", Email: "a@example.com", Age: 30, Role: user_model.RoleUser, Version: 3}
+	mockSvc.On("UpdateUser", mock.Anything, uint(1), uint(2), req).Return(updated, nil)
+
+	body, _ := json.Marshal(req)
+	c.Request, _ = http.NewRequest("PUT", "/api/users/1", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.Header.Set("If-Match", `"2"`)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	addAuthUserID(c, 1)
+
+	handler.UpdateUser(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+	mockSvc.AssertExpectations(t)
+}
+
+func TestUpdateUser_MissingIfMatch(t *testing.T) {
+	mockSvc, _, handler, _ := setupUserHandlerTest()
+	w := httptest.NewRecorder()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(w)
+
+	body, _ := json.Marshal(user_model.UpdateUserRequest{Name: "Renamed"})
+	c.Request, _ = http.NewRequest("PUT", "/api/users/1", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	addAuthUserID(c, 1)
+
+	handler.UpdateUser(c)
+	assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+	mockSvc.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateUser_VersionMismatch(t *testing.T) {
+	mockSvc, _, handler, _ := setupUserHandlerTest()
+	w := httptest.NewRecorder()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(w)
+
+	req := user_model.UpdateUserRequest{Name: "Renamed"}
+	mockSvc.On("UpdateUser", mock.Anything, uint(1), uint(1), req).Return(nil, user_service.ErrVersionMismatch)
+
+	body, _ := json.Marshal(req)
+	c.Request, _ = http.NewRequest("PUT", "/api/users/1", bytes.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.Header.Set("If-Match", `"1"`)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	addAuthUserID(c, 1)
+
+	handler.UpdateUser(c)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+}
+
+func TestDeleteUser_AdminDeletesOtherUser(t *testing.T) {
+	mockSvc, _, handler, _ := setupUserHandlerTest()
+	w := httptest.NewRecorder()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(w)
+
+	mockSvc.On("DeleteUser", mock.Anything, uint(2)).Return(nil)
+
+	c.Request, _ = http.NewRequest("DELETE", "/api/users/2", nil)
+	c.Params = gin.Params{{Key: "id", Value: "2"}}
+	addAuthUserID(c, 1)
+	c.Set("userRole", user_model.RoleAdmin)
+
+	handler.DeleteUser(c)
+	assert.Equal(t, http.StatusNoContent, c.Writer.Status())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestDeleteUser_SupportForbidden(t *testing.T) {
+	mockSvc, _, handler, _ := setupUserHandlerTest()
+	w := httptest.NewRecorder()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(w)
+
+	c.Request, _ = http.NewRequest("DELETE", "/api/users/2", nil)
+	c.Params = gin.Params{{Key: "id", Value: "2"}}
+	addAuthUserID(c, 1)
+	c.Set("userRole", user_model.RoleSupport)
+
+	handler.DeleteUser(c)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockSvc.AssertNotCalled(t, "DeleteUser", mock.Anything, mock.Anything)
+}
@@ -1,24 +1,39 @@
 package auth_middleware
 
 import (
+	"context"
 	"errors"
 	"net/http"
 
+	"github.com/IlyushinDM/user-order-api/internal/utils/i18n_util"
 	"github.com/IlyushinDM/user-order-api/internal/utils/jwt_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/problem_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/tracing_util"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// RevocationChecker сообщает, отозван ли access токен с указанным jti
+type RevocationChecker func(ctx context.Context, tokenID string) (bool, error)
+
 // AuthMiddleware создает middleware для аутентификации JWT токенов
 func AuthMiddleware(log *logrus.Logger, jwtSecret string) gin.HandlerFunc {
-	return AuthMiddlewareWithValidator(log, jwtSecret, jwt_util.ValidateJWT)
+	return AuthMiddlewareWithValidator(log, jwtSecret, jwt_util.ValidateJWT, nil)
+}
+
+// AuthMiddlewareWithRevocation создает middleware, которое дополнительно
+// отклоняет access токены, находящиеся в списке отзыва
+func AuthMiddlewareWithRevocation(log *logrus.Logger, jwtSecret string, isRevoked RevocationChecker) gin.HandlerFunc {
+	return AuthMiddlewareWithValidator(log, jwtSecret, jwt_util.ValidateJWT, isRevoked)
 }
 
 func AuthMiddlewareWithValidator(
 	log *logrus.Logger,
 	jwtSecret string,
 	validateJWT func(tokenString, secret string) (*jwt_util.Claims, error),
+	isRevoked RevocationChecker,
 ) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if c.Request.Method == http.MethodPost && c.Request.URL.Path == "/api/users" {
@@ -28,39 +43,58 @@ func AuthMiddlewareWithValidator(
 
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.String(http.StatusUnauthorized, "Требуется заголовок Authorization")
-			c.Abort()
+			problem_util.Respond(c, problem_util.Unauthorized, i18n_util.MsgAuthHeaderRequired)
 			return
 		}
 
 		const prefix = "Bearer "
 		if len(authHeader) < len(prefix) || authHeader[:len(prefix)] != prefix {
-			c.String(http.StatusUnauthorized, "Формат заголовка должен быть Bearer {token}")
-			c.Abort()
+			problem_util.Respond(c, problem_util.Unauthorized, i18n_util.MsgAuthHeaderFormat)
 			return
 		}
 
 		tokenString := authHeader[len(prefix):]
+		logger := log.WithContext(c.Request.Context())
 		if jwtSecret == "" {
-			c.String(http.StatusInternalServerError, "Ошибка конфигурации сервера")
-			c.Abort()
+			logger.Error("JWT секрет не задан")
+			problem_util.Respond(c, problem_util.Internal, "")
 			return
 		}
 
-		claims, err := jwt_util.ValidateJWT(tokenString, jwtSecret)
+		claims, err := validateJWT(tokenString, jwtSecret)
 		if err != nil {
-			log.Warnf("Не удалось выполнить проверку JWT: %v", err)
+			logger.Warnf("Не удалось выполнить проверку JWT: %v", err)
 			if errors.Is(err, jwt.ErrTokenExpired) {
-				c.String(http.StatusUnauthorized, "Токен просрочен")
+				problem_util.Respond(c, problem_util.TokenExpired, "")
 			} else {
-				c.String(http.StatusUnauthorized, "Неверный или просроченный токен")
+				problem_util.Respond(c, problem_util.TokenInvalid, "")
 			}
-			c.Abort()
 			return
 		}
 
+		if isRevoked != nil {
+			revoked, err := isRevoked(c.Request.Context(), claims.ID)
+			if err != nil {
+				logger.WithError(err).Error("Не удалось проверить список отзыва токенов")
+				problem_util.Respond(c, problem_util.Internal, "")
+				return
+			}
+			if revoked {
+				logger.WithField("user_id", claims.UserID).Warn("Предъявлен отозванный access токен")
+				problem_util.Respond(c, problem_util.TokenRevoked, "")
+				return
+			}
+		}
+
+		// Пользователь запроса виден в спане запроса
+		trace.SpanFromContext(c.Request.Context()).SetAttributes(tracing_util.UserID(claims.UserID))
 		c.Set("userID", claims.UserID)
 		c.Set("userEmail", claims.Email)
+		c.Set("userRole", claims.Role)
+		c.Set("tokenID", claims.ID)
+		if claims.ExpiresAt != nil {
+			c.Set("tokenExpiresAt", claims.ExpiresAt.Time)
+		}
 		c.Next()
 	}
 }
@@ -1,6 +1,8 @@
 package auth_middleware_test
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -8,6 +10,7 @@ import (
 
 	"github.com/IlyushinDM/user-order-api/internal/middleware/auth_middleware"
 	"github.com/IlyushinDM/user-order-api/internal/utils/jwt_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/problem_util"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/sirupsen/logrus"
@@ -20,6 +23,18 @@ type mockClaims struct {
 	Email  string
 }
 
+// assertProblem проверяет, что ответ - ошибка в формате problem+json с указанными статусом и кодом
+func assertProblem(t *testing.T, w *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	assert.Equal(t, status, w.Code)
+	assert.Equal(t, problem_util.ContentType, w.Header().Get("Content-Type"))
+	var problem problem_util.Problem
+	if assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem)) {
+		assert.Equal(t, code, problem.Code)
+		assert.Equal(t, status, problem.Status)
+	}
+}
+
 func TestAuthMiddleware_RegistrationBypass(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
@@ -50,8 +65,7 @@ func TestAuthMiddleware_MissingAuthorizationHeader(t *testing.T) {
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusUnauthorized, w.Code)
-	assert.Contains(t, w.Body.String(), "Требуется заголовок Authorization")
+	assertProblem(t, w, http.StatusUnauthorized, "unauthorized")
 }
 
 func TestAuthMiddleware_InvalidAuthorizationFormat(t *testing.T) {
@@ -68,8 +82,7 @@ func TestAuthMiddleware_InvalidAuthorizationFormat(t *testing.T) {
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusUnauthorized, w.Code)
-	assert.Contains(t, w.Body.String(), "Формат заголовка должен быть Bearer {token}")
+	assertProblem(t, w, http.StatusUnauthorized, "unauthorized")
 }
 
 func TestAuthMiddleware_MissingJWTSecret(t *testing.T) {
@@ -86,14 +99,18 @@ func TestAuthMiddleware_MissingJWTSecret(t *testing.T) {
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusInternalServerError, w.Code)
-	assert.Contains(t, w.Body.String(), "Ошибка конфигурации сервера")
+	assertProblem(t, w, http.StatusInternalServerError, "internal_error")
 }
 
 // mockValidateJWT replaces jwt_util.ValidateJWT for testing
 func mockValidateJWT(tokenString, secret string) (*jwt_util.Claims, error) {
 	if tokenString == "validtoken" {
-		return &jwt_util.Claims{UserID: 123, Email: "test@example.com"}, nil
+		return &jwt_util.Claims{
+			UserID:           123,
+			Email:            "test@example.com",
+			Role:             "support",
+			RegisteredClaims: jwt.RegisteredClaims{ID: "jti-valid"},
+		}, nil
 	}
 	if tokenString == "expiredtoken" {
 		return nil, jwt.ErrTokenExpired
@@ -115,8 +132,7 @@ func TestAuthMiddleware_InvalidJWTToken(t *testing.T) {
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusUnauthorized, w.Code)
-	assert.Contains(t, w.Body.String(), "Неверный или просроченный токен")
+	assertProblem(t, w, http.StatusUnauthorized, "token_invalid")
 }
 
 func TestAuthMiddleware_ExpiredJWTToken(t *testing.T) {
@@ -133,15 +149,14 @@ func TestAuthMiddleware_ExpiredJWTToken(t *testing.T) {
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusUnauthorized, w.Code)
-	assert.Contains(t, w.Body.String(), "Неверный или просроченный токен")
+	assertProblem(t, w, http.StatusUnauthorized, "token_invalid")
 }
 
 func TestAuthMiddleware_InvalidJWTToken_CustomValidator(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 	log := logrus.New()
-	router.Use(auth_middleware.AuthMiddlewareWithValidator(log, "secret", mockValidateJWT))
+	router.Use(auth_middleware.AuthMiddlewareWithValidator(log, "secret", mockValidateJWT, nil))
 	router.GET("/protected", func(c *gin.Context) {
 		c.String(200, "ok")
 	})
@@ -151,8 +166,7 @@ func TestAuthMiddleware_InvalidJWTToken_CustomValidator(t *testing.T) {
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusUnauthorized, w.Code)
-	assert.Contains(t, w.Body.String(), "Неверный или просроченный токен")
+	assertProblem(t, w, http.StatusUnauthorized, "token_invalid")
 }
 
 func TestAuthMiddleware_NextHandlerNotCalledOnFailure(t *testing.T) {
@@ -160,7 +174,7 @@ func TestAuthMiddleware_NextHandlerNotCalledOnFailure(t *testing.T) {
 	router := gin.New()
 	log := logrus.New()
 	called := false
-	router.Use(auth_middleware.AuthMiddlewareWithValidator(log, "secret", mockValidateJWT))
+	router.Use(auth_middleware.AuthMiddlewareWithValidator(log, "secret", mockValidateJWT, nil))
 	router.GET("/protected", func(c *gin.Context) {
 		called = true
 		c.String(200, "ok")
@@ -174,3 +188,68 @@ func TestAuthMiddleware_NextHandlerNotCalledOnFailure(t *testing.T) {
 	assert.False(t, called, "Handler should not be called on auth failure")
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 }
+
+func TestAuthMiddleware_ValidToken_SetsContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	log := logrus.New()
+	router.Use(auth_middleware.AuthMiddlewareWithValidator(log, "secret", mockValidateJWT, nil))
+	router.GET("/protected", func(c *gin.Context) {
+		userID, _ := c.Get("userID")
+		tokenID, _ := c.Get("tokenID")
+		assert.Equal(t, uint(123), userID)
+		assert.Equal(t, "jti-valid", tokenID)
+		assert.Equal(t, "support", c.GetString("userRole"))
+		c.String(200, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("Authorization", "Bearer validtoken")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAuthMiddleware_RevokedToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	log := logrus.New()
+	called := false
+	isRevoked := func(ctx context.Context, tokenID string) (bool, error) {
+		return tokenID == "jti-valid", nil
+	}
+	router.Use(auth_middleware.AuthMiddlewareWithValidator(log, "secret", mockValidateJWT, isRevoked))
+	router.GET("/protected", func(c *gin.Context) {
+		called = true
+		c.String(200, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("Authorization", "Bearer validtoken")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.False(t, called, "Handler should not be called for revoked token")
+	assertProblem(t, w, http.StatusUnauthorized, "token_revoked")
+}
+
+func TestAuthMiddleware_RevocationCheckError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	log := logrus.New()
+	isRevoked := func(ctx context.Context, tokenID string) (bool, error) {
+		return false, errors.New("db down")
+	}
+	router.Use(auth_middleware.AuthMiddlewareWithValidator(log, "secret", mockValidateJWT, isRevoked))
+	router.GET("/protected", func(c *gin.Context) {
+		c.String(200, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("Authorization", "Bearer validtoken")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
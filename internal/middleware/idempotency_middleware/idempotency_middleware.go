@@ -0,0 +1,176 @@
+package idempotency_middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/idempotency_model"
+	"github.com/IlyushinDM/user-order-api/internal/policy/access_policy"
+	"github.com/IlyushinDM/user-order-api/internal/repository/idempotency_rep"
+	"github.com/IlyushinDM/user-order-api/internal/utils/i18n_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/problem_util"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// HeaderIdempotencyKey - заголовок запроса с ключом идемпотентности
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed - заголовок ответа, которым помечается повтор сохраненного ответа
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+// responseRecorder передает ответ клиенту и одновременно запоминает его тело
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware создает middleware, которое обрабатывает заголовок Idempotency-Key.
+// Первый запрос с ключом выполняется, и его код ответа, тело и ETag сохраняются на время ttl.
+// Пока первый запрос выполняется, ключ захвачен на время lease: если процесс остановится
+// до сохранения ответа, ключ освободится по истечении lease, а не ttl.
+// Повторы с тем же ключом и тем же запросом получают сохраненный ответ без повторного выполнения.
+// Пока первый запрос выполняется, повтор получает 409. Запросы без заголовка не затрагиваются.
+// Middleware должно подключаться после AuthMiddleware: ключи изолированы по пользователям.
+func IdempotencyMiddleware(store idempotency_rep.IdempotencyRepository, ttl, lease time.Duration, log *logrus.Logger) gin.HandlerFunc {
+	if store == nil {
+		logrus.Panic("Хранилище ключей идемпотентности равно nil в IdempotencyMiddleware")
+	}
+	if log == nil {
+		defaultLog := logrus.New()
+		defaultLog.SetLevel(logrus.InfoLevel)
+		defaultLog.Warn("Экземпляр логгера Logrus равен nil в IdempotencyMiddleware, используется логгер по умолчанию")
+		log = defaultLog
+	}
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderIdempotencyKey)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxKeyLength {
+			problem_util.Respond(c, problem_util.ValidationFailed, i18n_util.MsgIdempotencyKeyTooLong)
+			return
+		}
+
+		requestLog := log.WithContext(c.Request.Context())
+		subject, exists := access_policy.CurrentSubject(c)
+		if !exists {
+			requestLog.Error("Ошибка аутентификации: userID не найден в контексте")
+			problem_util.Respond(c, problem_util.Internal, "")
+			return
+		}
+
+		requestHash, err := hashRequest(c)
+		if err != nil {
+			requestLog.WithError(err).Warn("Не удалось прочитать тело запроса")
+			problem_util.Respond(c, problem_util.ValidationFailed, i18n_util.MsgBodyUnreadable)
+			return
+		}
+
+		logger := requestLog.WithFields(logrus.Fields{"user_id": subject.UserID, "path": c.Request.URL.Path})
+		ctx := c.Request.Context()
+
+		stored, err := store.Begin(ctx, subject.UserID, key, requestHash, lease)
+		switch {
+		case errors.Is(err, idempotency_rep.ErrRequestInProgress):
+			logger.Warn("Повторный запрос с ключом идемпотентности, пока первый еще выполняется")
+			problem_util.Respond(c, problem_util.IdempotencyInFlight, "")
+			return
+		case errors.Is(err, idempotency_rep.ErrKeyReused):
+			logger.Warn("Ключ идемпотентности повторно использован с другим запросом")
+			problem_util.Respond(c, problem_util.IdempotencyKeyReused, "")
+			return
+		case err != nil:
+			logger.WithError(err).Error("Не удалось проверить ключ идемпотентности")
+			problem_util.Respond(c, problem_util.Internal, "")
+			return
+		}
+
+		if stored != nil {
+			logger.WithField("status", stored.StatusCode).Info("Возвращен сохраненный ответ по ключу идемпотентности")
+			c.Header(HeaderIdempotentReplayed, "true")
+			if stored.ETag != "" {
+				c.Header("ETag", stored.ETag)
+			}
+			c.Data(stored.StatusCode, stored.ContentType, stored.Body)
+			c.Abort()
+			return
+		}
+
+		// Ответ сохраняется и после отключения клиента, иначе ключ останется занятым до истечения ttl
+		saveCtx := context.WithoutCancel(ctx)
+		completed := false
+		defer func() {
+			if !completed {
+				if err := store.Release(saveCtx, subject.UserID, key); err != nil {
+					logger.WithError(err).Error("Не удалось освободить ключ идемпотентности")
+				}
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+		c.Writer = recorder.ResponseWriter
+
+		// Ответы с ошибкой сервера не сохраняются: клиент может повторить запрос с тем же ключом
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		response := idempotency_model.StoredResponse{
+			StatusCode:  status,
+			ContentType: recorder.Header().Get("Content-Type"),
+			ETag:        recorder.Header().Get("ETag"),
+			Body:        recorder.body.Bytes(),
+		}
+		if err := store.Complete(saveCtx, subject.UserID, key, response, ttl); err != nil {
+			logger.WithError(err).Error("Не удалось сохранить ответ для ключа идемпотентности")
+			return
+		}
+		completed = true
+	}
+}
+
+// hashRequest вычисляет отпечаток запроса по методу, пути и телу.
+// Тело запроса восстанавливается для следующих обработчиков.
+func hashRequest(c *gin.Context) (string, error) {
+	var body []byte
+	if c.Request.Body != nil {
+		var err error
+		body, err = io.ReadAll(c.Request.Body)
+		if err != nil {
+			return "", err
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(c.Request.URL.Path))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
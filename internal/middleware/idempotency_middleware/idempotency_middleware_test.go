@@ -0,0 +1,167 @@
+package idempotency_middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/middleware/idempotency_middleware"
+	"github.com/IlyushinDM/user-order-api/internal/repository/idempotency_rep"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestRouter собирает роутер, в котором обработчик создания считает свои вызовы
+func newTestRouter(store idempotency_rep.IdempotencyRepository, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", uint(1))
+		c.Set("userRole", "user")
+		c.Next()
+	})
+	router.POST("/orders", idempotency_middleware.IdempotencyMiddleware(store, time.Hour, time.Minute, log), handler)
+	return router
+}
+
+func doRequest(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(idempotency_middleware.HeaderIdempotencyKey, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyMiddleware_ReplaysStoredResponse(t *testing.T) {
+	var calls int32
+	router := newTestRouter(idempotency_rep.NewMemoryIdempotencyRepository(nil), func(c *gin.Context) {
+		n := atomic.AddInt32(&calls, 1)
+		c.JSON(http.StatusCreated, gin.H{"id": n})
+	})
+
+	first := doRequest(router, "key-1", `{"a":1}`)
+	second := doRequest(router, "key-1", `{"a":1}`)
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, first.Header().Get("Content-Type"), second.Header().Get("Content-Type"))
+	assert.Empty(t, first.Header().Get(idempotency_middleware.HeaderIdempotentReplayed))
+	assert.Equal(t, "true", second.Header().Get(idempotency_middleware.HeaderIdempotentReplayed))
+}
+
+func TestIdempotencyMiddleware_ReplaysETag(t *testing.T) {
+	router := newTestRouter(idempotency_rep.NewMemoryIdempotencyRepository(nil), func(c *gin.Context) {
+		c.Header("ETag", `"1"`)
+		c.JSON(http.StatusCreated, gin.H{"id": 1})
+	})
+
+	first := doRequest(router, "key-1", `{"a":1}`)
+	second := doRequest(router, "key-1", `{"a":1}`)
+
+	assert.Equal(t, `"1"`, first.Header().Get("ETag"))
+	assert.Equal(t, "true", second.Header().Get(idempotency_middleware.HeaderIdempotentReplayed))
+	assert.Equal(t, first.Header().Get("ETag"), second.Header().Get("ETag"))
+}
+
+func TestIdempotencyMiddleware_WithoutKeyPassesThrough(t *testing.T) {
+	var calls int32
+	router := newTestRouter(idempotency_rep.NewMemoryIdempotencyRepository(nil), func(c *gin.Context) {
+		atomic.AddInt32(&calls, 1)
+		c.Status(http.StatusCreated)
+	})
+
+	doRequest(router, "", `{}`)
+	doRequest(router, "", `{}`)
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestIdempotencyMiddleware_ConcurrentDuplicateGetsConflict(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	router := newTestRouter(idempotency_rep.NewMemoryIdempotencyRepository(nil), func(c *gin.Context) {
+		close(started)
+		<-release
+		c.JSON(http.StatusCreated, gin.H{"id": 1})
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- doRequest(router, "key-1", `{}`) }()
+	<-started
+
+	duplicate := doRequest(router, "key-1", `{}`)
+	assert.Equal(t, http.StatusConflict, duplicate.Code)
+
+	close(release)
+	first := <-done
+	assert.Equal(t, http.StatusCreated, first.Code)
+}
+
+func TestIdempotencyMiddleware_KeyReusedWithDifferentBody(t *testing.T) {
+	router := newTestRouter(idempotency_rep.NewMemoryIdempotencyRepository(nil), func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"id": 1})
+	})
+
+	doRequest(router, "key-1", `{"a":1}`)
+	w := doRequest(router, "key-1", `{"a":2}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestIdempotencyMiddleware_ServerErrorIsNotStored(t *testing.T) {
+	var calls int32
+	router := newTestRouter(idempotency_rep.NewMemoryIdempotencyRepository(nil), func(c *gin.Context) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "fail"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": 1})
+	})
+
+	first := doRequest(router, "key-1", `{}`)
+	second := doRequest(router, "key-1", `{}`)
+
+	assert.Equal(t, http.StatusInternalServerError, first.Code)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestIdempotencyMiddleware_HandlerReadsOriginalBody(t *testing.T) {
+	router := newTestRouter(idempotency_rep.NewMemoryIdempotencyRepository(nil), func(c *gin.Context) {
+		var req struct {
+			A int `json:"a"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"a": req.A})
+	})
+
+	w := doRequest(router, "key-1", `{"a":5}`)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, `{"a":5}`, w.Body.String())
+}
+
+func TestIdempotencyMiddleware_KeyTooLong(t *testing.T) {
+	router := newTestRouter(idempotency_rep.NewMemoryIdempotencyRepository(nil), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	w := doRequest(router, strings.Repeat("k", 256), `{}`)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,20 @@
+package locale_middleware
+
+import (
+	"github.com/IlyushinDM/user-order-api/internal/utils/i18n_util"
+	"github.com/gin-gonic/gin"
+)
+
+// LocaleMiddleware выбирает язык сообщений ответа по заголовку Accept-Language.
+// Если клиент не указал поддерживаемый язык, используется defaultLang.
+func LocaleMiddleware(defaultLang i18n_util.Lang) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lang := i18n_util.Negotiate(c.GetHeader("Accept-Language"), defaultLang)
+		i18n_util.SetLang(c, lang)
+
+		// Ответ зависит от Accept-Language, что важно для кэширующих прокси
+		c.Header("Content-Language", string(lang))
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Next()
+	}
+}
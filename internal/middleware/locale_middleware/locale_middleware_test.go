@@ -0,0 +1,47 @@
+package locale_middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IlyushinDM/user-order-api/internal/middleware/locale_middleware"
+	"github.com/IlyushinDM/user-order-api/internal/utils/i18n_util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocaleMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(locale_middleware.LocaleMiddleware(i18n_util.Ru))
+	router.GET("/lang", func(c *gin.Context) {
+		c.String(http.StatusOK, string(i18n_util.FromContext(c)))
+	})
+
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		want           string
+	}{
+		{"Заголовок отсутствует", "", "ru"},
+		{"Английский", "en", "en"},
+		{"Региональный вариант", "en-US,en;q=0.9", "en"},
+		{"Приоритет по весу", "ru;q=0.5, en;q=0.8", "en"},
+		{"Неподдерживаемый язык", "de-DE, fr;q=0.9", "ru"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/lang", nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want, w.Body.String())
+			assert.Equal(t, tt.want, w.Header().Get("Content-Language"))
+			assert.Equal(t, "Accept-Language", w.Header().Get("Vary"))
+		})
+	}
+}
@@ -34,7 +34,8 @@ func LoggerMiddleware(log *logrus.Logger) gin.HandlerFunc {
 		}
 
 		// Создаем log entry с дополнительными полями, специфичными для HTTP запроса.
-		entry := log.WithFields(logrus.Fields{
+		// Контекст запроса добавляет в запись идентификаторы трассировки.
+		entry := log.WithContext(c.Request.Context()).WithFields(logrus.Fields{
 			"statusCode": statusCode, // Статусный код ответа
 			"latency":    latency,    // Задержка обработки запроса
 			"clientIP":   clientIP,   // IP-адрес клиента
@@ -0,0 +1,33 @@
+package metrics_middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/utils/metrics_util"
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute - значение метки route для запросов к несуществующим маршрутам.
+// Путь таких запросов не используется как метка, чтобы число рядов не росло неограниченно.
+const unmatchedRoute = "unmatched"
+
+// MetricsMiddleware измеряет длительность обработки запросов и количество запросов в обработке.
+// Метка route содержит шаблон маршрута (например, /api/users/:id), а не фактический путь.
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		metrics_util.HTTPRequestsInFlight.Inc()
+		defer metrics_util.HTTPRequestsInFlight.Dec()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		metrics_util.HTTPRequestDuration.
+			WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,45 @@
+package metrics_middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IlyushinDM/user-order-api/internal/middleware/metrics_middleware"
+	"github.com/IlyushinDM/user-order-api/internal/utils/metrics_util"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// observations возвращает количество измерений длительности запросов в ряду с указанными метками
+func observations(t *testing.T, method, route, status string) uint64 {
+	t.Helper()
+	var metric dto.Metric
+	observer := metrics_util.HTTPRequestDuration.WithLabelValues(method, route, status)
+	require.NoError(t, observer.(prometheus.Metric).Write(&metric))
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(metrics_middleware.MetricsMiddleware())
+	router.GET("/api/users/:id", func(c *gin.Context) {
+		// Запрос учитывается как находящийся в обработке
+		assert.Equal(t, 1.0, testutil.ToFloat64(metrics_util.HTTPRequestsInFlight))
+		c.Status(http.StatusNoContent)
+	})
+
+	for _, path := range []string{"/api/users/1", "/api/users/2", "/missing/42"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Zero(t, testutil.ToFloat64(metrics_util.HTTPRequestsInFlight))
+	// Запросы к разным ID учитываются в ряду шаблона маршрута, неизвестные пути - в ряду unmatched
+	assert.EqualValues(t, 2, observations(t, http.MethodGet, "/api/users/:id", "204"))
+	assert.EqualValues(t, 1, observations(t, http.MethodGet, "unmatched", "404"))
+}
@@ -0,0 +1,97 @@
+package rate_limit_middleware
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/rate_limit_model"
+	"github.com/IlyushinDM/user-order-api/internal/policy/access_policy"
+	"github.com/IlyushinDM/user-order-api/internal/repository/rate_limit_rep"
+	"github.com/IlyushinDM/user-order-api/internal/utils/i18n_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/metrics_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/problem_util"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// Заголовки ответа с состоянием лимита (draft-ietf-httpapi-ratelimit-headers)
+const (
+	HeaderLimit      = "RateLimit-Limit"
+	HeaderRemaining  = "RateLimit-Remaining"
+	HeaderReset      = "RateLimit-Reset"
+	HeaderPolicy     = "RateLimit-Policy"
+	HeaderRetryAfter = "Retry-After"
+)
+
+// KeyFunc возвращает ключ клиента, для которого считается лимит
+type KeyFunc func(c *gin.Context) string
+
+// ByClientIP считает лимит по IP адресу клиента. Адрес из X-Forwarded-For учитывается
+// только для доверенных прокси (TRUSTED_PROXIES), иначе берется адрес соединения.
+func ByClientIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ByUserID считает лимит по пользователю из JWT. Middleware должно подключаться после AuthMiddleware;
+// без аутентифицированного пользователя лимит считается по IP адресу.
+func ByUserID(c *gin.Context) string {
+	if subject, ok := access_policy.CurrentSubject(c); ok {
+		return "user:" + strconv.FormatUint(uint64(subject.UserID), 10)
+	}
+	return ByClientIP(c)
+}
+
+// RateLimitMiddleware ограничивает частоту запросов клиента алгоритмом корзины токенов.
+// name отделяет корзины разных лимитов одного клиента и попадает в метрики. Каждый ответ получает
+// заголовки RateLimit-*; при превышении лимита клиент получает 429 с Retry-After.
+// Если хранилище лимитов недоступно, запрос пропускается: ограничитель не должен останавливать API.
+func RateLimitMiddleware(store rate_limit_rep.RateLimitRepository, name string, limit rate_limit_model.Limit, key KeyFunc, log *logrus.Logger) gin.HandlerFunc {
+	if store == nil {
+		logrus.Panic("Хранилище лимитов запросов равно nil в RateLimitMiddleware")
+	}
+	if key == nil {
+		logrus.Panic("Функция ключа клиента равна nil в RateLimitMiddleware")
+	}
+	if log == nil {
+		defaultLog := logrus.New()
+		defaultLog.SetLevel(logrus.InfoLevel)
+		defaultLog.Warn("Экземпляр логгера Logrus равен nil в RateLimitMiddleware, используется логгер по умолчанию")
+		log = defaultLog
+	}
+	metrics_util.RateLimitRejected.WithLabelValues(name)
+	policy := strconv.Itoa(limit.Requests) + ";w=" + strconv.Itoa(ceilSeconds(limit.Period))
+
+	return func(c *gin.Context) {
+		result, err := store.Take(c.Request.Context(), name+"|"+key(c), limit)
+		if err != nil {
+			log.WithContext(c.Request.Context()).WithError(err).WithField("limit", name).Error("Не удалось проверить лимит запросов, запрос пропущен")
+			c.Next()
+			return
+		}
+
+		c.Header(HeaderLimit, strconv.Itoa(result.Limit))
+		c.Header(HeaderRemaining, strconv.Itoa(result.Remaining))
+		c.Header(HeaderReset, strconv.Itoa(ceilSeconds(result.Reset)))
+		c.Header(HeaderPolicy, policy)
+		if result.Allowed {
+			c.Next()
+			return
+		}
+
+		retryAfter := max(ceilSeconds(result.RetryAfter), 1)
+		metrics_util.RateLimitRejected.WithLabelValues(name).Inc()
+		log.WithContext(c.Request.Context()).WithFields(logrus.Fields{
+			"limit":     name,
+			"client_ip": c.ClientIP(),
+			"path":      c.Request.URL.Path,
+		}).Warn("Превышен лимит запросов")
+		c.Header(HeaderRetryAfter, strconv.Itoa(retryAfter))
+		problem_util.Respond(c, problem_util.TooManyRequests, i18n_util.MsgRateLimitExceeded, retryAfter)
+	}
+}
+
+// ceilSeconds округляет длительность вверх до целых секунд
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,99 @@
+package rate_limit_middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/middleware/rate_limit_middleware"
+	"github.com/IlyushinDM/user-order-api/internal/models/rate_limit_model"
+	"github.com/IlyushinDM/user-order-api/internal/repository/rate_limit_rep"
+	"github.com/IlyushinDM/user-order-api/internal/utils/problem_util"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRouter(store rate_limit_rep.RateLimitRepository, key rate_limit_middleware.KeyFunc, userID uint) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	log := logrus.New()
+	log.SetLevel(logrus.PanicLevel)
+
+	router := gin.New()
+	if userID != 0 {
+		router.Use(func(c *gin.Context) {
+			c.Set("userID", userID)
+			c.Next()
+		})
+	}
+	limit := rate_limit_model.Limit{Requests: 2, Period: time.Minute}
+	router.POST("/login", rate_limit_middleware.RateLimitMiddleware(store, "login", limit, key, log), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	return router
+}
+
+func doRequest(router *gin.Engine, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitMiddleware_RejectsWithHeaders(t *testing.T) {
+	router := newTestRouter(rate_limit_rep.NewMemoryRateLimitRepository(nil), rate_limit_middleware.ByClientIP, 0)
+
+	first := doRequest(router, "203.0.113.1:1000")
+	assert.Equal(t, http.StatusNoContent, first.Code)
+	assert.Equal(t, "2", first.Header().Get(rate_limit_middleware.HeaderLimit))
+	assert.Equal(t, "1", first.Header().Get(rate_limit_middleware.HeaderRemaining))
+	assert.Equal(t, "30", first.Header().Get(rate_limit_middleware.HeaderReset))
+	assert.Equal(t, "2;w=60", first.Header().Get(rate_limit_middleware.HeaderPolicy))
+	assert.Empty(t, first.Header().Get(rate_limit_middleware.HeaderRetryAfter))
+
+	doRequest(router, "203.0.113.1:1001")
+	rejected := doRequest(router, "203.0.113.1:1002")
+	assert.Equal(t, http.StatusTooManyRequests, rejected.Code)
+	assert.Equal(t, problem_util.ContentType, rejected.Header().Get("Content-Type"))
+	assert.Contains(t, rejected.Body.String(), `"code":"too_many_requests"`)
+	assert.Equal(t, "0", rejected.Header().Get(rate_limit_middleware.HeaderRemaining))
+	assert.Equal(t, "30", rejected.Header().Get(rate_limit_middleware.HeaderRetryAfter))
+
+	// Другой IP адрес получает свою корзину
+	assert.Equal(t, http.StatusNoContent, doRequest(router, "203.0.113.2:1000").Code)
+}
+
+func TestRateLimitMiddleware_ByUserID(t *testing.T) {
+	store := rate_limit_rep.NewMemoryRateLimitRepository(nil)
+	first := newTestRouter(store, rate_limit_middleware.ByUserID, 1)
+	second := newTestRouter(store, rate_limit_middleware.ByUserID, 2)
+
+	doRequest(first, "203.0.113.1:1000")
+	doRequest(first, "203.0.113.2:1000")
+	// Лимит пользователя не зависит от его адреса
+	assert.Equal(t, http.StatusTooManyRequests, doRequest(first, "203.0.113.3:1000").Code)
+	// Другой пользователь с того же адреса не ограничен
+	assert.Equal(t, http.StatusNoContent, doRequest(second, "203.0.113.1:1000").Code)
+}
+
+// failingStore всегда возвращает ошибку хранилища
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit rate_limit_model.Limit) (rate_limit_model.Result, error) {
+	return rate_limit_model.Result{}, errors.New("store unavailable")
+}
+
+func TestRateLimitMiddleware_FailsOpen(t *testing.T) {
+	router := newTestRouter(failingStore{}, rate_limit_middleware.ByClientIP, 0)
+
+	for i := 0; i < 5; i++ {
+		w := doRequest(router, "203.0.113.1:1000")
+		require.Equal(t, http.StatusNoContent, w.Code)
+		assert.Empty(t, w.Header().Get(rate_limit_middleware.HeaderLimit))
+	}
+}
@@ -0,0 +1,22 @@
+package request_id_middleware
+
+import (
+	"github.com/IlyushinDM/user-order-api/internal/utils/request_id_util"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDMiddleware принимает идентификатор запроса из заголовка X-Request-ID или генерирует новый,
+// если заголовка нет или его значение недопустимо. Идентификатор возвращается в заголовке ответа
+// и сохраняется в контексте запроса, откуда LogHook добавляет его в записи лога.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(request_id_util.Header)
+		if !request_id_util.IsValid(id) {
+			id = request_id_util.New()
+		}
+
+		c.Request = c.Request.WithContext(request_id_util.WithRequestID(c.Request.Context(), id))
+		c.Header(request_id_util.Header, id)
+		c.Next()
+	}
+}
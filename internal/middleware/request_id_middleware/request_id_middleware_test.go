@@ -0,0 +1,50 @@
+package request_id_middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IlyushinDM/user-order-api/internal/middleware/request_id_middleware"
+	"github.com/IlyushinDM/user-order-api/internal/utils/request_id_util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func serve(t *testing.T, header string) (responseID, contextID string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(request_id_middleware.RequestIDMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		contextID = request_id_util.FromContext(c.Request.Context())
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if header != "" {
+		req.Header.Set(request_id_util.Header, header)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Header().Get(request_id_util.Header), contextID
+}
+
+func TestRequestIDMiddleware_GeneratesID(t *testing.T) {
+	responseID, contextID := serve(t, "")
+	assert.Len(t, responseID, 32)
+	assert.Equal(t, responseID, contextID)
+}
+
+func TestRequestIDMiddleware_EchoesValidID(t *testing.T) {
+	responseID, contextID := serve(t, "client-req-42")
+	assert.Equal(t, "client-req-42", responseID)
+	assert.Equal(t, "client-req-42", contextID)
+}
+
+func TestRequestIDMiddleware_ReplacesInvalidID(t *testing.T) {
+	responseID, contextID := serve(t, "bad id\tlevel=error")
+	assert.NotEqual(t, "bad id\tlevel=error", responseID)
+	assert.True(t, request_id_util.IsValid(responseID))
+	assert.Equal(t, responseID, contextID)
+}
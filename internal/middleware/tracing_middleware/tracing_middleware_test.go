@@ -0,0 +1,89 @@
+package tracing_middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IlyushinDM/user-order-api/internal/middleware/tracing_middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	parentTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentSpanID  = "00f067aa0ba902b7"
+)
+
+func setupTracing(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	return recorder
+}
+
+func TestTracingMiddleware_ContinuesIncomingTrace(t *testing.T) {
+	recorder := setupTracing(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(tracing_middleware.TracingMiddleware())
+
+	var handlerTraceID string
+	router.GET("/api/users/:id", func(c *gin.Context) {
+		// Обработчик получает спан запроса через контекст
+		handlerTraceID = trace.SpanContextFromContext(c.Request.Context()).TraceID().String()
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/5", nil)
+	req.Header.Set("traceparent", "00-"+parentTraceID+"-"+parentSpanID+"-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /api/users/:id", span.Name())
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, parentTraceID, span.SpanContext().TraceID().String())
+	assert.Equal(t, parentSpanID, span.Parent().SpanID().String())
+	assert.Equal(t, parentTraceID, handlerTraceID)
+	assert.Contains(t, span.Attributes(), semconv.HTTPRoute("/api/users/:id"))
+	assert.Contains(t, span.Attributes(), semconv.HTTPResponseStatusCode(http.StatusNoContent))
+	assert.Equal(t, codes.Unset, span.Status().Code)
+}
+
+func TestTracingMiddleware_ServerErrorAndUnmatchedRoute(t *testing.T) {
+	recorder := setupTracing(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(tracing_middleware.TracingMiddleware())
+	router.GET("/fail", func(c *gin.Context) {
+		c.Status(http.StatusInternalServerError)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing/42", nil))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	// Без traceparent начинается новая трасса
+	assert.False(t, spans[0].Parent().IsValid())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	// Ответ 404 не считается ошибкой сервера, путь не попадает в имя спана
+	assert.Equal(t, "GET unmatched", spans[1].Name())
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+}
@@ -0,0 +1,27 @@
+package health_model
+
+// Значения статуса проверок готовности
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// LivenessResponse - ответ проверки живости процесса
+type LivenessResponse struct {
+	Status string `json:"status" example:"ok"` // Всегда ok, если процесс отвечает на запросы
+}
+
+// CheckResult описывает результат одной проверки готовности
+type CheckResult struct {
+	Status     string `json:"status" example:"ok"`             // ok или fail
+	DurationMS int64  `json:"duration_ms" example:"3"`         // Длительность проверки в миллисекундах
+	Error      string `json:"error,omitempty"`                 // Причина неуспешной проверки
+	Version    *uint  `json:"version,omitempty" example:"6"`   // Примененная версия схемы (только для migrations)
+	Pending    []uint `json:"pending,omitempty" example:"7,8"` // Не примененные миграции (только для migrations)
+}
+
+// ReadinessResponse - ответ проверки готовности принимать запросы
+type ReadinessResponse struct {
+	Status string                 `json:"status" example:"ok"` // ok, если все проверки успешны, иначе fail
+	Checks map[string]CheckResult `json:"checks"`              // Результаты проверок по имени: shutdown, database, migrations
+}
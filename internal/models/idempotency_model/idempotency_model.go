@@ -0,0 +1,46 @@
+package idempotency_model
+
+import (
+	"time"
+)
+
+// IdempotencyRecord хранит результат запроса, выполненного с заголовком Idempotency-Key.
+// Ключ уникален в пределах пользователя. Пока StatusCode равен нулю, первый запрос
+// с этим ключом еще выполняется, а ExpiresAt - конец короткой аренды ключа; после сохранения
+// ответа ExpiresAt - конец срока его хранения.
+type IdempotencyRecord struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID      uint      `gorm:"not null;uniqueIndex:idx_idempotency_keys_user_key" json:"user_id"`
+	Key         string    `gorm:"column:idempotency_key;not null;size:255;uniqueIndex:idx_idempotency_keys_user_key" json:"key"`
+	RequestHash string    `gorm:"not null;size:64" json:"-"`
+	StatusCode  int       `gorm:"not null;default:0" json:"status_code"`
+	ContentType string    `gorm:"size:255" json:"content_type,omitempty"`
+	ETag        string    `gorm:"column:etag;size:255" json:"etag,omitempty"`
+	Body        []byte    `json:"-"`
+	ExpiresAt   time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// StoredResponse описывает ответ на первый запрос, который возвращается при повторах
+type StoredResponse struct {
+	StatusCode  int
+	ContentType string
+	ETag        string
+	Body        []byte
+}
+
+// TableName задает имя таблицы ключей идемпотентности
+func (IdempotencyRecord) TableName() string {
+	return "idempotency_keys"
+}
+
+// IsCompleted сообщает, сохранен ли уже ответ на первый запрос
+func (r *IdempotencyRecord) IsCompleted() bool {
+	return r.StatusCode != 0
+}
+
+// IsExpired сообщает, истек ли срок хранения записи
+func (r *IdempotencyRecord) IsExpired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
@@ -0,0 +1,118 @@
+package login_attempt_model
+
+import (
+	"strings"
+	"time"
+)
+
+// Исходы попытки входа
+const (
+	OutcomeSuccess         = "success"          // Вход выполнен
+	OutcomeInvalidPassword = "invalid_password" // Пользователь найден, пароль неверный
+	OutcomeUnknownUser     = "unknown_user"     // Пользователь с таким email не найден
+	OutcomeThrottled       = "throttled"        // Вход отклонен без проверки пароля: не истекла задержка после неудачи
+	OutcomeLocked          = "locked"           // Вход отклонен без проверки пароля: учетная запись временно заблокирована
+)
+
+// FailureOutcomes - исходы, которые считаются неудачными попытками при расчете блокировки.
+// Отклоненные из-за задержки или блокировки попытки не учитываются, иначе блокировка продлевалась бы сама собой.
+var FailureOutcomes = []string{OutcomeInvalidPassword, OutcomeUnknownUser}
+
+// LoginAttempt представляет запись журнала попыток входа.
+// UserID заполнен, если email принадлежит существующему пользователю.
+type LoginAttempt struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    *uint     `gorm:"index" json:"user_id,omitempty"`
+	Email     string    `gorm:"not null;size:255" json:"email"`
+	IP        string    `gorm:"column:ip;not null;size:64" json:"ip"`
+	UserAgent string    `gorm:"not null;size:512" json:"user_agent"`
+	Outcome   string    `gorm:"not null;size:32" json:"outcome"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+}
+
+// TableName задает имя таблицы журнала попыток входа
+func (LoginAttempt) TableName() string {
+	return "login_attempts"
+}
+
+// ClientInfo содержит сведения о клиенте, выполняющем вход
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// NormalizeEmail приводит email к виду, по которому считаются неудачные попытки
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// LoginAttemptResponse определяет запись истории входов, возвращаемую пользователю
+type LoginAttemptResponse struct {
+	ID        uint      `json:"id"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	Outcome   string    `json:"outcome"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PaginatedLoginAttemptsResponse определяет структуру постраничной истории входов
+type PaginatedLoginAttemptsResponse struct {
+	Page   int                    `json:"page"`
+	Limit  int                    `json:"limit"`
+	Total  int64                  `json:"total"`
+	Logins []LoginAttemptResponse `json:"logins"`
+}
+
+// LockoutPolicy задает правила задержки и блокировки входа после неудачных попыток.
+// После каждой неудачной попытки следующая возможна не раньше чем через BaseDelay,
+// удваиваемую с каждой неудачей в окне Window. MaxFailures неудач в пределах Window
+// блокируют вход на LockoutDuration. Нулевой MaxFailures отключает политику.
+type LockoutPolicy struct {
+	MaxFailures     int
+	Window          time.Duration
+	LockoutDuration time.Duration
+	BaseDelay       time.Duration
+}
+
+// maxDelayShift ограничивает число удвоений задержки, чтобы исключить переполнение
+const maxDelayShift = 20
+
+// Enabled сообщает, включена ли политика
+func (p LockoutPolicy) Enabled() bool {
+	return p.MaxFailures > 0
+}
+
+// BlockedUntil возвращает момент, до которого вход запрещен, или нулевое время, если вход разрешен.
+// locked сообщает, что запрет вызван блокировкой, а не задержкой после неудачной попытки.
+// failures - время последних неудачных попыток (не более MaxFailures) от новых к старым.
+func (p LockoutPolicy) BlockedUntil(failures []time.Time, now time.Time) (until time.Time, locked bool) {
+	if !p.Enabled() || len(failures) == 0 {
+		return time.Time{}, false
+	}
+	last := failures[0]
+
+	if len(failures) >= p.MaxFailures && last.Sub(failures[p.MaxFailures-1]) <= p.Window {
+		if until := last.Add(p.LockoutDuration); now.Before(until) {
+			return until, true
+		}
+		return time.Time{}, false
+	}
+
+	if p.BaseDelay <= 0 {
+		return time.Time{}, false
+	}
+	recent := 0
+	for _, failure := range failures {
+		if now.Sub(failure) <= p.Window {
+			recent++
+		}
+	}
+	if recent == 0 {
+		return time.Time{}, false
+	}
+	delay := p.BaseDelay << min(recent-1, maxDelayShift)
+	if until := last.Add(delay); now.Before(until) {
+		return until, false
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,70 @@
+package login_attempt_model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testPolicy = LockoutPolicy{
+	MaxFailures:     3,
+	Window:          15 * time.Minute,
+	LockoutDuration: 10 * time.Minute,
+	BaseDelay:       time.Second,
+}
+
+// blockedUntil возвращает только момент окончания запрета
+func blockedUntil(p LockoutPolicy, failures []time.Time, now time.Time) time.Time {
+	until, _ := p.BlockedUntil(failures, now)
+	return until
+}
+
+func TestLockoutPolicy_ProgressiveDelay(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.True(t, blockedUntil(testPolicy, nil, now).IsZero())
+
+	// Первая неудача - задержка BaseDelay, вторая - вдвое больше
+	failures := []time.Time{now}
+	assert.Equal(t, now.Add(time.Second), blockedUntil(testPolicy, failures, now))
+	assert.True(t, blockedUntil(testPolicy, failures, now.Add(time.Second)).IsZero())
+
+	failures = []time.Time{now, now.Add(-time.Minute)}
+	until, locked := testPolicy.BlockedUntil(failures, now)
+	assert.Equal(t, now.Add(2*time.Second), until)
+	assert.False(t, locked)
+
+	// Неудачи за пределами окна не увеличивают задержку
+	failures = []time.Time{now, now.Add(-time.Hour)}
+	assert.Equal(t, now.Add(time.Second), blockedUntil(testPolicy, failures, now))
+}
+
+func TestLockoutPolicy_Lockout(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	failures := []time.Time{now, now.Add(-time.Minute), now.Add(-2 * time.Minute)}
+
+	until, locked := testPolicy.BlockedUntil(failures, now)
+	assert.Equal(t, now.Add(10*time.Minute), until)
+	assert.True(t, locked)
+	assert.Equal(t, now.Add(10*time.Minute), blockedUntil(testPolicy, failures, now.Add(9*time.Minute)))
+	assert.True(t, blockedUntil(testPolicy, failures, now.Add(10*time.Minute)).IsZero())
+
+	// Неудачи, разнесенные шире окна, не блокируют вход
+	spread := []time.Time{now, now.Add(-10 * time.Minute), now.Add(-20 * time.Minute)}
+	assert.Equal(t, now.Add(2*time.Second), blockedUntil(testPolicy, spread, now))
+}
+
+func TestLockoutPolicy_Disabled(t *testing.T) {
+	now := time.Now()
+	failures := []time.Time{now, now, now, now}
+	assert.True(t, blockedUntil(LockoutPolicy{}, failures, now).IsZero())
+
+	noDelay := testPolicy
+	noDelay.BaseDelay = 0
+	assert.True(t, blockedUntil(noDelay, failures[:1], now).IsZero())
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	assert.Equal(t, "alice@example.com", NormalizeEmail("  Alice@Example.COM "))
+}
@@ -0,0 +1,164 @@
+package money_model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency - валюта, используемая, если она не указана явно
+const DefaultCurrency = "RUB"
+
+// minorUnitsPerMajor - количество минимальных единиц в основной (копеек в рубле, центов в долларе)
+const minorUnitsPerMajor = 100
+
+// supportedCurrencies перечисляет валюты с двумя знаками после запятой, которые принимает API
+var supportedCurrencies = map[string]bool{
+	"RUB": true, "USD": true, "EUR": true, "GBP": true, "CNY": true, "KZT": true, "BYN": true,
+}
+
+// ErrInvalidAmount возвращается при разборе некорректной денежной суммы
+var ErrInvalidAmount = errors.New("некорректная денежная сумма")
+
+// IsSupportedCurrency сообщает, поддерживается ли валюта с кодом ISO 4217
+func IsSupportedCurrency(code string) bool {
+	return supportedCurrencies[code]
+}
+
+// Amount - точная денежная сумма в минимальных единицах валюты (копейках, центах).
+// В JSON передается строкой с двумя знаками после запятой ("12.50"), чтобы клиенты
+// не теряли точность, в базе данных хранится как DECIMAL.
+type Amount int64
+
+// NewAmount создает сумму из основной и дробной части, например NewAmount(12, 50) = 12.50
+func NewAmount(major int64, minor int64) Amount {
+	if major < 0 {
+		return Amount(major*minorUnitsPerMajor - minor)
+	}
+	return Amount(major*minorUnitsPerMajor + minor)
+}
+
+// ParseAmount разбирает строку вида "12", "12.5" или "-12.50".
+// Более двух знаков после запятой считается ошибкой, а не округляется.
+func ParseAmount(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	digits := strings.TrimPrefix(s, "-")
+
+	majorStr, minorStr, hasPoint := strings.Cut(digits, ".")
+	if majorStr == "" || !isDigits(majorStr) || (hasPoint && (minorStr == "" || len(minorStr) > 2 || !isDigits(minorStr))) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	for len(minorStr) < 2 {
+		minorStr += "0"
+	}
+
+	major, err := strconv.ParseInt(majorStr, 10, 64)
+	if err != nil || major > math.MaxInt64/minorUnitsPerMajor-1 {
+		return 0, fmt.Errorf("%w: %q слишком велика", ErrInvalidAmount, s)
+	}
+	minor, _ := strconv.ParseInt(minorStr, 10, 64)
+
+	amount := Amount(major*minorUnitsPerMajor + minor)
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// MinorUnits возвращает сумму в минимальных единицах валюты
+func (a Amount) MinorUnits() int64 {
+	return int64(a)
+}
+
+// Mul умножает сумму на количество (стоимость позиции)
+func (a Amount) Mul(quantity int) Amount {
+	return a * Amount(quantity)
+}
+
+// String форматирует сумму с двумя знаками после запятой
+func (a Amount) String() string {
+	sign := ""
+	v := int64(a)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/minorUnitsPerMajor, v%minorUnitsPerMajor)
+}
+
+// MarshalJSON сериализует сумму строкой
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON принимает сумму только в виде строки
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("%w: сумма должна передаваться строкой, например \"12.50\"", ErrInvalidAmount)
+	}
+	parsed, err := ParseAmount(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Value сохраняет сумму в DECIMAL-колонку строкой, без преобразования через float
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// Scan читает сумму из DECIMAL-колонки. Postgres возвращает текст и разбирается точно;
+// SQLite может вернуть число с плавающей точкой, которое округляется до минимальных единиц.
+func (a *Amount) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*a = 0
+		return nil
+	case []byte:
+		return a.scanString(string(v))
+	case string:
+		return a.scanString(v)
+	case int64:
+		*a = Amount(v * minorUnitsPerMajor)
+		return nil
+	case float64:
+		*a = Amount(math.Round(v * minorUnitsPerMajor))
+		return nil
+	default:
+		return fmt.Errorf("%w: неподдерживаемый тип %T", ErrInvalidAmount, value)
+	}
+}
+
+func (a *Amount) scanString(s string) error {
+	// DECIMAL может вернуть больше двух знаков (например, "12.500"), незначащие нули отбрасываются
+	if majorStr, minorStr, ok := strings.Cut(s, "."); ok && len(minorStr) > 2 {
+		minorStr = strings.TrimRight(minorStr, "0")
+		s = majorStr
+		if minorStr != "" {
+			s += "." + minorStr
+		}
+	}
+	parsed, err := ParseAmount(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
@@ -0,0 +1,97 @@
+package money_model
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Amount
+		wantErr bool
+	}{
+		{"12", 1200, false},
+		{"12.5", 1250, false},
+		{"12.05", 1205, false},
+		{"0.01", 1, false},
+		{"-3.10", -310, false},
+		{"12.345", 0, true},
+		{"12.", 0, true},
+		{".5", 0, true},
+		{"1e3", 0, true},
+		{"", 0, true},
+		{"99999999999999999999", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseAmount(tt.in)
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, ErrInvalidAmount), "ожидалась ErrInvalidAmount, получено %v", err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAmount_String(t *testing.T) {
+	assert.Equal(t, "12.50", Amount(1250).String())
+	assert.Equal(t, "0.07", Amount(7).String())
+	assert.Equal(t, "-0.07", Amount(-7).String())
+	assert.Equal(t, "12.50", NewAmount(12, 50).String())
+}
+
+func TestAmount_NoFloatDrift(t *testing.T) {
+	// 0.1 + 0.2 во float64 дает 0.30000000000000004
+	var total Amount
+	for i := 0; i < 10; i++ {
+		total += Amount(10).Mul(3)
+	}
+	assert.Equal(t, "3.00", total.String())
+}
+
+func TestAmount_JSON(t *testing.T) {
+	data, err := json.Marshal(struct {
+		Price Amount `json:"price"`
+	}{Price: 1999})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"price":"19.99"}`, string(data))
+
+	var parsed struct {
+		Price Amount `json:"price"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"price":"19.99"}`), &parsed))
+	assert.Equal(t, Amount(1999), parsed.Price)
+
+	err = json.Unmarshal([]byte(`{"price":19.99}`), &parsed)
+	assert.True(t, errors.Is(err, ErrInvalidAmount), "число вместо строки должно отклоняться, получено %v", err)
+}
+
+func TestAmount_Scan(t *testing.T) {
+	var a Amount
+	assert.NoError(t, a.Scan([]byte("12.30")))
+	assert.Equal(t, Amount(1230), a)
+	assert.NoError(t, a.Scan("7.500"))
+	assert.Equal(t, Amount(750), a)
+	assert.NoError(t, a.Scan(0.29))
+	assert.Equal(t, Amount(29), a)
+	assert.NoError(t, a.Scan(int64(3)))
+	assert.Equal(t, Amount(300), a)
+	assert.Error(t, a.Scan(true))
+
+	v, err := Amount(1230).Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "12.30", v)
+}
+
+func TestIsSupportedCurrency(t *testing.T) {
+	assert.True(t, IsSupportedCurrency("RUB"))
+	assert.True(t, IsSupportedCurrency("USD"))
+	assert.False(t, IsSupportedCurrency("JPY"))
+	assert.False(t, IsSupportedCurrency("rub"))
+}
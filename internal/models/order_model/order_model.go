@@ -3,42 +3,154 @@ package order_model
 import (
 	"time"
 
+	"github.com/IlyushinDM/user-order-api/internal/models/money_model"
 	"gorm.io/gorm"
 )
 
-// Order представляет модель заказа в базе данных
+// OrderStatus описывает состояние заказа в его жизненном цикле
+type OrderStatus string
+
+const (
+	StatusPending   OrderStatus = "pending"   // Создан, ожидает подтверждения
+	StatusConfirmed OrderStatus = "confirmed" // Подтвержден, ожидает оплаты
+	StatusPaid      OrderStatus = "paid"      // Оплачен, ожидает отправки
+	StatusShipped   OrderStatus = "shipped"   // Передан в доставку
+	StatusDelivered OrderStatus = "delivered" // Доставлен покупателю
+	StatusCancelled OrderStatus = "cancelled" // Отменен до оплаты (конечное состояние)
+	StatusRefunded  OrderStatus = "refunded"  // Деньги возвращены покупателю (конечное состояние)
+)
+
+// orderTransitions задает допустимые переходы между состояниями заказа.
+// Состояния, отсутствующие в ключах, являются конечными.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	StatusPending:   {StatusConfirmed, StatusCancelled},
+	StatusConfirmed: {StatusPaid, StatusCancelled},
+	StatusPaid:      {StatusShipped, StatusRefunded},
+	StatusShipped:   {StatusDelivered},
+	StatusDelivered: {StatusRefunded},
+}
+
+// IsValid проверяет, является ли значение известным состоянием заказа
+func (s OrderStatus) IsValid() bool {
+	switch s {
+	case StatusPending, StatusConfirmed, StatusPaid, StatusShipped,
+		StatusDelivered, StatusCancelled, StatusRefunded:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo сообщает, допустим ли переход из текущего состояния в next
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEditable сообщает, можно ли в этом состоянии менять содержимое заказа
+func (s OrderStatus) IsEditable() bool {
+	return s == StatusPending
+}
+
+// HoldsStock сообщает, удерживает ли заказ в этом состоянии резерв товара на складе.
+// После отправки товар считается списанным, после отмены или возврата резерв снят.
+func (s OrderStatus) HoldsStock() bool {
+	return s == StatusPending || s == StatusConfirmed || s == StatusPaid
+}
+
+// ReleasesStockOn сообщает, снимается ли резерв товара при переходе в состояние next:
+// заказ удерживал резерв и был отменен или возвращен до отправки.
+func (s OrderStatus) ReleasesStockOn(next OrderStatus) bool {
+	return s.HoldsStock() && (next == StatusCancelled || next == StatusRefunded)
+}
+
+// Order представляет модель заказа в базе данных.
+// Состав заказа хранится в позициях OrderItem.
 type Order struct {
-	ID          uint           `gorm:"primaryKey;autoIncrement" json:"id"`
-	UserID      uint           `gorm:"not null" json:"user_id"`
-	ProductName string         `gorm:"not null;size:255" json:"product_name" binding:"required"`
-	Quantity    int            `gorm:"not null" json:"quantity" binding:"required,gt=0"`
-	Price       float64        `gorm:"not null" json:"price" binding:"required,gt=0"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID        uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint           `gorm:"not null" json:"user_id"`
+	Status    OrderStatus    `gorm:"not null;size:32;default:pending;index" json:"status"`
+	Currency  string         `gorm:"not null;size:3;default:RUB" json:"currency"` // Код валюты ISO 4217, общий для всех позиций
+	Items     []OrderItem    `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"items"`
+	Version   uint           `gorm:"not null;default:1" json:"-"` // Версия для оптимистичной блокировки, передается в ETag
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// Total вычисляет итоговую сумму заказа по его позициям
+func (o *Order) Total() money_model.Amount {
+	var total money_model.Amount
+	for _, item := range o.Items {
+		total += item.LineTotal()
+	}
+	return total
+}
+
+// OrderItem представляет позицию заказа: продукт, количество и цену за единицу.
+// Название и цена копируются из каталога в момент оформления и не меняются вместе с продуктом.
+type OrderItem struct {
+	ID          uint               `gorm:"primaryKey;autoIncrement" json:"id"`
+	OrderID     uint               `gorm:"not null;index" json:"order_id"`
+	ProductID   *uint              `gorm:"index" json:"product_id"` // nil для позиций, созданных до появления каталога
+	ProductName string             `gorm:"not null;size:255" json:"product_name"`
+	Quantity    int                `gorm:"not null" json:"quantity"`
+	UnitPrice   money_model.Amount `gorm:"type:decimal(10,2);not null" json:"unit_price"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+// LineTotal вычисляет стоимость позиции
+func (i *OrderItem) LineTotal() money_model.Amount {
+	return i.UnitPrice.Mul(i.Quantity)
+}
+
+// OrderItemResponse определяет структуру ответа с данными позиции заказа
+type OrderItemResponse struct {
+	ID          uint               `json:"id"`
+	ProductID   *uint              `json:"product_id"`
+	ProductName string             `json:"product_name"`
+	Quantity    int                `json:"quantity"`
+	UnitPrice   money_model.Amount `json:"unit_price" swaggertype:"string" example:"199.90"`
+	LineTotal   money_model.Amount `json:"line_total" swaggertype:"string" example:"399.80"` // Стоимость позиции, вычисленная сервером
 }
 
 // OrderResponse определяет структуру ответа с данными заказа
 type OrderResponse struct {
-	ID          uint    `json:"id"`
-	UserID      uint    `json:"user_id"`
-	ProductName string  `json:"product_name"`
-	Quantity    int     `json:"quantity"`
-	Price       float64 `json:"price"`
+	ID       uint                `json:"id"`
+	UserID   uint                `json:"user_id"`
+	Status   OrderStatus         `json:"status"`
+	Currency string              `json:"currency" example:"RUB"`
+	Items    []OrderItemResponse `json:"items"`
+	Total    money_model.Amount  `json:"total" swaggertype:"string" example:"399.80"` // Итоговая сумма заказа, вычисленная сервером
+}
+
+// OrderItemRequest определяет структуру позиции в запросах создания и обновления заказа.
+// Цена берется из каталога продуктов и не передается клиентом.
+type OrderItemRequest struct {
+	ProductID uint `json:"product_id" binding:"required,gt=0"` // ID продукта из каталога (обязательно)
+	Quantity  int  `json:"quantity" binding:"required,gt=0"`   // Количество (положительное число)
 }
 
 // CreateOrderRequest определяет структуру запроса для создания заказа
 type CreateOrderRequest struct {
-	ProductName string  `json:"product_name" binding:"required"`  // Название продукта (обязательно)
-	Quantity    int     `json:"quantity" binding:"required,gt=0"` // Количество (положительное число)
-	Price       float64 `json:"price" binding:"required,gt=0"`    // Цена за единицу (положительное число)
+	Currency string             `json:"currency" binding:"required,iso4217" example:"RUB"` // Валюта заказа; должна совпадать с валютой продуктов
+	Items    []OrderItemRequest `json:"items" binding:"required,min=1,dive"`               // Позиции заказа (хотя бы одна)
 }
 
-// UpdateOrderRequest определяет структуру запроса для обновления заказа
+// UpdateOrderRequest определяет структуру запроса для обновления заказа.
+// Переданный список позиций полностью заменяет текущий состав заказа.
 type UpdateOrderRequest struct {
-	ProductName string  `json:"product_name"`                      // Новое название продукта
-	Quantity    int     `json:"quantity" binding:"omitempty,gt=0"` // Новое количество (опционально)
-	Price       float64 `json:"price" binding:"omitempty,gt=0"`    // Новая цена (опционально)
+	Currency string             `json:"currency" binding:"omitempty,iso4217" example:"RUB"` // Новая валюта заказа (опционально, только вместе с позициями)
+	Items    []OrderItemRequest `json:"items" binding:"omitempty,min=1,dive"`               // Новый состав заказа (опционально)
+}
+
+// UpdateOrderStatusRequest определяет структуру запроса на смену состояния заказа
+type UpdateOrderStatusRequest struct {
+	Status OrderStatus `json:"status" binding:"required,oneof=pending confirmed paid shipped delivered cancelled refunded"` // Целевое состояние
 }
 
 // PaginatedOrdersResponse определяет структуру для пагинированного списка заказов
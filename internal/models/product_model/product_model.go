@@ -0,0 +1,59 @@
+package product_model
+
+import (
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/money_model"
+	"gorm.io/gorm"
+)
+
+// Product представляет модель продукта каталога в базе данных.
+// Цена продукта является источником истины при оформлении заказов.
+type Product struct {
+	ID          uint               `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name        string             `gorm:"not null;size:255;index" json:"name"`
+	Description string             `gorm:"type:text" json:"description"`
+	Price       money_model.Amount `gorm:"type:decimal(10,2);not null" json:"price"`
+	Currency    string             `gorm:"not null;size:3;default:RUB" json:"currency"` // Код валюты ISO 4217
+	Stock       int                `gorm:"not null;default:0" json:"stock"`             // Свободный остаток; резервируется при оформлении заказа
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt     `gorm:"index" json:"-"`
+}
+
+// ProductResponse определяет структуру ответа с данными продукта
+type ProductResponse struct {
+	ID          uint               `json:"id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Price       money_model.Amount `json:"price" swaggertype:"string" example:"199.90"`
+	Currency    string             `json:"currency" example:"RUB"`
+	Stock       int                `json:"stock"`
+}
+
+// CreateProductRequest определяет структуру запроса для создания продукта
+type CreateProductRequest struct {
+	Name        string             `json:"name" binding:"required,product_name"`                                // Название продукта (обязательно)
+	Description string             `json:"description"`                                                         // Описание продукта (опционально)
+	Price       money_model.Amount `json:"price" binding:"required,gt=0" swaggertype:"string" example:"199.90"` // Цена за единицу (положительная, строкой)
+	Currency    string             `json:"currency" binding:"omitempty,iso4217" example:"RUB"`                  // Код валюты ISO 4217 (по умолчанию RUB)
+	Stock       int                `json:"stock" binding:"gte=0"`                                               // Начальный остаток на складе (по умолчанию 0)
+}
+
+// UpdateProductRequest определяет структуру запроса для обновления продукта.
+// Незаданные поля не изменяются.
+type UpdateProductRequest struct {
+	Name        *string             `json:"name" binding:"omitempty,product_name"`                                // Новое название (опционально)
+	Description *string             `json:"description"`                                                          // Новое описание (опционально)
+	Price       *money_model.Amount `json:"price" binding:"omitempty,gt=0" swaggertype:"string" example:"199.90"` // Новая цена (опционально)
+	Currency    *string             `json:"currency" binding:"omitempty,iso4217" example:"RUB"`                   // Новая валюта (опционально)
+	Stock       *int                `json:"stock" binding:"omitempty,gte=0"`                                      // Новый свободный остаток (опционально)
+}
+
+// PaginatedProductsResponse определяет структуру для пагинированного списка продуктов
+type PaginatedProductsResponse struct {
+	Page     int               `json:"page"`     // Текущая страница
+	Limit    int               `json:"limit"`    // Количество элементов на странице
+	Total    int64             `json:"total"`    // Общее количество продуктов
+	Products []ProductResponse `json:"products"` // Список продуктов
+}
@@ -0,0 +1,55 @@
+package rate_limit_model
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidLimit - некорректная запись лимита запросов
+var ErrInvalidLimit = errors.New("некорректный лимит запросов")
+
+// Limit задает лимит корзины токенов: не более Requests запросов подряд,
+// после чего корзина пополняется со скоростью Requests токенов за Period
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit разбирает лимит в формате "запросов/период", например "5/1m" или "300/1h"
+func ParseLimit(value string) (Limit, error) {
+	requestsPart, periodPart, ok := strings.Cut(strings.TrimSpace(value), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("%w: %q, ожидается формат \"запросов/период\", например \"5/1m\"", ErrInvalidLimit, value)
+	}
+	requests, err := strconv.Atoi(strings.TrimSpace(requestsPart))
+	if err != nil || requests <= 0 {
+		return Limit{}, fmt.Errorf("%w: %q, число запросов должно быть положительным целым", ErrInvalidLimit, value)
+	}
+	period, err := time.ParseDuration(strings.TrimSpace(periodPart))
+	if err != nil || period <= 0 {
+		return Limit{}, fmt.Errorf("%w: %q, период должен быть положительной длительностью", ErrInvalidLimit, value)
+	}
+	return Limit{Requests: requests, Period: period}, nil
+}
+
+// String возвращает лимит в формате ParseLimit
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+// Interval возвращает время пополнения корзины на один токен
+func (l Limit) Interval() time.Duration {
+	return l.Period / time.Duration(l.Requests)
+}
+
+// Result - результат попытки взять токен из корзины
+type Result struct {
+	Allowed    bool          // Запрос укладывается в лимит
+	Limit      int           // Емкость корзины
+	Remaining  int           // Токенов осталось после запроса
+	RetryAfter time.Duration // Через сколько появится токен, если запрос отклонен
+	Reset      time.Duration // Через сколько корзина заполнится полностью
+}
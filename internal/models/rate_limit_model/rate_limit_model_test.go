@@ -0,0 +1,22 @@
+package rate_limit_model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit(" 5 / 1m ")
+	require.NoError(t, err)
+	assert.Equal(t, Limit{Requests: 5, Period: time.Minute}, limit)
+	assert.Equal(t, 12*time.Second, limit.Interval())
+	assert.Equal(t, "5/1m0s", limit.String())
+
+	for _, value := range []string{"", "5", "5/", "/1m", "0/1m", "-1/1m", "5/0s", "5/minute", "five/1m"} {
+		_, err := ParseLimit(value)
+		assert.ErrorIs(t, err, ErrInvalidLimit, value)
+	}
+}
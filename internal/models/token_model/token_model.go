@@ -0,0 +1,33 @@
+package token_model
+
+import (
+	"time"
+)
+
+// RefreshToken представляет сохраненный на сервере refresh токен.
+// Сам токен не хранится, только его хеш. Все токены, выпущенные в рамках
+// одной сессии входа, объединены общим FamilyID.
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	FamilyID  string     `gorm:"not null;size:64;index" json:"family_id"`
+	TokenHash string     `gorm:"not null;size:64;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// IsExpired сообщает, истек ли срок действия refresh токена
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// RevokedAccessToken представляет запись списка отзыва access токенов по jti.
+// Запись хранится до истечения срока действия самого токена.
+type RevokedAccessToken struct {
+	JTI       string    `gorm:"primaryKey;size:64" json:"jti"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
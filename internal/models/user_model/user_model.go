@@ -4,13 +4,31 @@ import (
 	"github.com/IlyushinDM/user-order-api/internal/models/order_model"
 )
 
+// Роли пользователей, определяющие уровень доступа
+const (
+	RoleUser    = "user"    // Обычный пользователь: доступ только к своим данным
+	RoleSupport = "support" // Сотрудник поддержки: просмотр и исправление данных любых пользователей
+	RoleAdmin   = "admin"   // Администратор: полный доступ, включая удаление и назначение ролей
+)
+
+// IsValidRole проверяет, является ли строка известной ролью
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleUser, RoleSupport, RoleAdmin:
+		return true
+	}
+	return false
+}
+
 // User представляет собой модель пользователя в базе данных
 type User struct {
 	ID           uint                `gorm:"primaryKey;autoIncrement" json:"id"`
-	Name         string              `gorm:"not null;size:255" json:"name" binding:"required"`
+	Name         string              `gorm:"not null;size:255" json:"name" binding:"required,person_name"`
 	Email        string              `gorm:"unique;not null;size:255" json:"email" binding:"required,email"`
-	Age          int                 `gorm:"not null" json:"age" binding:"required,gt=0"`
+	Age          int                 `gorm:"not null" json:"age" binding:"required,age"`
 	PasswordHash string              `gorm:"not null" json:"-"`
+	Role         string              `gorm:"not null;size:32;default:user" json:"role"`
+	Version      uint                `gorm:"not null;default:1" json:"-"` // Версия для оптимистичной блокировки, передается в ETag
 	Orders       []order_model.Order `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"orders,omitempty"`
 }
 
@@ -20,21 +38,23 @@ type UserResponse struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
 	Age   int    `json:"age"`
+	Role  string `json:"role"`
 }
 
 // CreateUserRequest определяет структуру для создания нового пользователя
 type CreateUserRequest struct {
-	Name     string `json:"name" binding:"required"`
+	Name     string `json:"name" binding:"required,person_name"`
 	Email    string `json:"email" binding:"required,email"`
-	Age      int    `json:"age" binding:"required,gt=0"`
+	Age      int    `json:"age" binding:"required,age"`
 	Password string `json:"password" binding:"required,min=6"`
 }
 
 // UpdateUserRequest определяет структуру для обновления существующего пользователя
 type UpdateUserRequest struct {
-	Name  string `json:"name"`
+	Name  string `json:"name" binding:"omitempty,person_name"`
 	Email string `json:"email" binding:"omitempty,email"`
-	Age   int    `json:"age" binding:"omitempty,gt=0"`
+	Age   int    `json:"age" binding:"omitempty,age"`
+	Role  string `json:"role" binding:"omitempty,oneof=user support admin"` // Изменять роль может только администратор
 }
 
 // PaginatedUsersResponse определяет структуру постраничных списков пользователей
@@ -51,7 +71,20 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
-// LoginResponse определяет структуру ответа на вход в систему (токен JWT)
+// LoginResponse определяет структуру ответа на вход в систему и обновление токенов
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`         // Короткоживущий access токен (JWT)
+	RefreshToken string `json:"refresh_token"` // Одноразовый refresh токен для получения новой пары
+	TokenType    string `json:"token_type"`    // Тип токена для заголовка Authorization
+	ExpiresIn    int    `json:"expires_in"`    // Время жизни access токена в секундах
+}
+
+// RefreshRequest определяет структуру запроса на обновление пары токенов
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LogoutRequest определяет структуру запроса на выход из системы
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
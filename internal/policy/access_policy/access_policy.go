@@ -0,0 +1,109 @@
+package access_policy
+
+import (
+	"github.com/IlyushinDM/user-order-api/internal/models/user_model"
+	"github.com/gin-gonic/gin"
+)
+
+// Action описывает действие, которое субъект выполняет над ресурсом
+type Action string
+
+const (
+	ActionRead         Action = "read"          // Чтение одного ресурса
+	ActionList         Action = "list"          // Просмотр списка ресурсов всех пользователей
+	ActionCreate       Action = "create"        // Создание ресурса
+	ActionUpdate       Action = "update"        // Изменение ресурса
+	ActionDelete       Action = "delete"        // Удаление ресурса
+	ActionManageRoles  Action = "manage_roles"  // Назначение ролей пользователям
+	ActionChangeStatus Action = "change_status" // Произвольная смена состояния заказа
+)
+
+// Resource описывает тип ресурса, к которому запрашивается доступ
+type Resource string
+
+const (
+	ResourceUser    Resource = "user"
+	ResourceOrder   Resource = "order"
+	ResourceProduct Resource = "product"
+)
+
+// Subject - аутентифицированный пользователь, выполняющий запрос
+type Subject struct {
+	UserID uint
+	Role   string
+}
+
+// IsStaff сообщает, является ли субъект сотрудником (поддержка или администратор)
+func (s Subject) IsStaff() bool {
+	return s.Role == user_model.RoleAdmin || s.Role == user_model.RoleSupport
+}
+
+// rolePermissions перечисляет действия, разрешенные роли над ресурсами любых пользователей.
+// Действия над собственными ресурсами разрешены всем ролям (см. Can).
+var rolePermissions = map[string]map[Resource][]Action{
+	user_model.RoleAdmin: {
+		ResourceUser:    {ActionRead, ActionList, ActionCreate, ActionUpdate, ActionDelete, ActionManageRoles},
+		ResourceOrder:   {ActionRead, ActionList, ActionCreate, ActionUpdate, ActionDelete, ActionChangeStatus},
+		ResourceProduct: {ActionRead, ActionList, ActionCreate, ActionUpdate, ActionDelete},
+	},
+	user_model.RoleSupport: {
+		ResourceUser:  {ActionRead, ActionList, ActionUpdate},
+		ResourceOrder: {ActionRead, ActionList, ActionUpdate, ActionChangeStatus},
+	},
+}
+
+// ownerActions перечисляет действия, которые любой пользователь может выполнять над своими ресурсами
+var ownerActions = map[Resource][]Action{
+	ResourceUser:  {ActionRead, ActionUpdate, ActionDelete},
+	ResourceOrder: {ActionRead, ActionCreate, ActionUpdate, ActionDelete},
+}
+
+// publicActions перечисляет действия, доступные любому аутентифицированному пользователю
+// независимо от владельца ресурса (например, просмотр каталога продуктов)
+var publicActions = map[Resource][]Action{
+	ResourceProduct: {ActionRead, ActionList},
+}
+
+// Can проверяет, может ли субъект выполнить действие над ресурсом, принадлежащим ownerID.
+// Для ActionList, ActionManageRoles и ActionChangeStatus ownerID не учитывается:
+// эти действия доступны только ролям с соответствующими правами.
+func Can(subject Subject, action Action, resource Resource, ownerID uint) bool {
+	if subject.UserID == 0 {
+		return false
+	}
+	if contains(rolePermissions[subject.Role][resource], action) || contains(publicActions[resource], action) {
+		return true
+	}
+	if action == ActionList || action == ActionManageRoles || action == ActionChangeStatus {
+		return false
+	}
+	return ownerID == subject.UserID && contains(ownerActions[resource], action)
+}
+
+// CurrentSubject извлекает аутентифицированного пользователя из контекста Gin.
+// Значения устанавливаются AuthMiddleware; отсутствие роли трактуется как обычный пользователь.
+func CurrentSubject(c *gin.Context) (Subject, bool) {
+	rawUserID, exists := c.Get("userID")
+	if !exists {
+		return Subject{}, false
+	}
+	userID, ok := rawUserID.(uint)
+	if !ok {
+		return Subject{}, false
+	}
+
+	role := c.GetString("userRole")
+	if !user_model.IsValidRole(role) {
+		role = user_model.RoleUser
+	}
+	return Subject{UserID: userID, Role: role}, true
+}
+
+func contains(actions []Action, action Action) bool {
+	for _, a := range actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package access_policy
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/user_model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCan(t *testing.T) {
+	user := Subject{UserID: 1, Role: user_model.RoleUser}
+	support := Subject{UserID: 2, Role: user_model.RoleSupport}
+	admin := Subject{UserID: 3, Role: user_model.RoleAdmin}
+
+	tests := []struct {
+		name     string
+		subject  Subject
+		action   Action
+		resource Resource
+		ownerID  uint
+		want     bool
+	}{
+		{"пользователь читает себя", user, ActionRead, ResourceUser, 1, true},
+		{"пользователь обновляет себя", user, ActionUpdate, ResourceUser, 1, true},
+		{"пользователь удаляет себя", user, ActionDelete, ResourceUser, 1, true},
+		{"пользователь читает другого", user, ActionRead, ResourceUser, 5, false},
+		{"пользователь обновляет другого", user, ActionUpdate, ResourceUser, 5, false},
+		{"пользователь видит список всех", user, ActionList, ResourceUser, 1, false},
+		{"пользователь меняет роли", user, ActionManageRoles, ResourceUser, 1, false},
+		{"пользователь создает свой заказ", user, ActionCreate, ResourceOrder, 1, true},
+		{"пользователь читает чужой заказ", user, ActionRead, ResourceOrder, 5, false},
+		{"поддержка читает другого", support, ActionRead, ResourceUser, 5, true},
+		{"поддержка видит список всех", support, ActionList, ResourceUser, 0, true},
+		{"поддержка исправляет чужой заказ", support, ActionUpdate, ResourceOrder, 5, true},
+		{"поддержка удаляет другого", support, ActionDelete, ResourceUser, 5, false},
+		{"поддержка удаляет чужой заказ", support, ActionDelete, ResourceOrder, 5, false},
+		{"поддержка меняет роли", support, ActionManageRoles, ResourceUser, 5, false},
+		{"администратор удаляет другого", admin, ActionDelete, ResourceUser, 5, true},
+		{"администратор удаляет чужой заказ", admin, ActionDelete, ResourceOrder, 5, true},
+		{"администратор меняет роли", admin, ActionManageRoles, ResourceUser, 5, true},
+		{"пользователь меняет состояние своего заказа", user, ActionChangeStatus, ResourceOrder, 1, false},
+		{"поддержка меняет состояние чужого заказа", support, ActionChangeStatus, ResourceOrder, 5, true},
+		{"пользователь просматривает каталог", user, ActionList, ResourceProduct, 0, true},
+		{"пользователь читает продукт", user, ActionRead, ResourceProduct, 0, true},
+		{"пользователь создает продукт", user, ActionCreate, ResourceProduct, 0, false},
+		{"поддержка изменяет продукт", support, ActionUpdate, ResourceProduct, 0, false},
+		{"администратор удаляет продукт", admin, ActionDelete, ResourceProduct, 0, true},
+		{"анонимный субъект", Subject{}, ActionRead, ResourceUser, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Can(tt.subject, tt.action, tt.resource, tt.ownerID))
+		})
+	}
+}
+
+func TestCurrentSubject(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("нет пользователя в контексте", func(t *testing.T) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		_, ok := CurrentSubject(c)
+		assert.False(t, ok)
+	})
+
+	t.Run("роль по умолчанию", func(t *testing.T) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Set("userID", uint(7))
+		subject, ok := CurrentSubject(c)
+		assert.True(t, ok)
+		assert.Equal(t, Subject{UserID: 7, Role: user_model.RoleUser}, subject)
+	})
+
+	t.Run("роль из токена", func(t *testing.T) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Set("userID", uint(7))
+		c.Set("userRole", user_model.RoleAdmin)
+		subject, ok := CurrentSubject(c)
+		assert.True(t, ok)
+		assert.Equal(t, user_model.RoleAdmin, subject.Role)
+	})
+}
@@ -1,21 +1,24 @@
 package database
 
 import (
+	"context"
 	"errors" // Импортируем пакет errors для создания простых ошибок, если нужно
 	"fmt"
 	"time"
 
-	"github.com/IlyushinDM/user-order-api/internal/models/order_model"
-	"github.com/IlyushinDM/user-order-api/internal/models/user_model"
 	"github.com/IlyushinDM/user-order-api/internal/utils/config_util"
 	"github.com/IlyushinDM/user-order-api/internal/utils/logger_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/metrics_util"
+	"github.com/IlyushinDM/user-order-api/migrations"
 	"github.com/sirupsen/logrus"
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	gormlogger "gorm.io/gorm/logger"
 )
 
 // InitDB инициализирует подключение к базе данных через GORM и настраивает пул соединений.
+// СУБД выбирается настройкой DB_DRIVER: PostgreSQL или SQLite (файл или база в памяти).
 // Эта функция НЕ выполняет миграции.
 func InitDB(cfg *config_util.Config, log *logrus.Logger) (*gorm.DB, error) {
 	// Простая проверка входных параметров
@@ -27,10 +30,11 @@ func InitDB(cfg *config_util.Config, log *logrus.Logger) (*gorm.DB, error) {
 		return nil, errors.New("логгер не предоставлен (log is nil)")
 	}
 
-	dsn := fmt.Sprintf(
-		"host=%s port=%s user=%s dbname=%s password=%s sslmode=disable TimeZone=UTC",
-		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBName, cfg.DBPassword,
-	)
+	dialector, err := openDialector(cfg)
+	if err != nil {
+		log.WithError(err).Error("Не удалось выбрать драйвер базы данных")
+		return nil, err
+	}
 
 	// Настройка уровня логирования GORM в зависимости от уровня Logrus
 	gormLogLevel := gormlogger.Silent // gormlogger.LogLevel по умолчанию - тихий
@@ -42,10 +46,9 @@ func InitDB(cfg *config_util.Config, log *logrus.Logger) (*gorm.DB, error) {
 	// Поэтому уровни Debug и Trace Logrus будут также маппиться на gormlogger.Info
 	// для включения подробных логов GORM.
 
-	gormWriter := &logger_util.LogrusGormWriter{Logger: log}
-
-	newLogger := gormlogger.New(
-		gormWriter,
+	// Записи о запросах, выполненных с контекстом запроса API, содержат его request_id
+	newLogger := logger_util.NewGormLogger(
+		log,
 		gormlogger.Config{
 			SlowThreshold:             200 * time.Millisecond, // Порог для "медленных" запросов
 			LogLevel:                  gormLogLevel,           // Установленный уровень логирования GORM
@@ -56,7 +59,7 @@ func InitDB(cfg *config_util.Config, log *logrus.Logger) (*gorm.DB, error) {
 	)
 
 	// Открываем соединение с базой данных с настроенным логированием
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: newLogger,
 	})
 	if err != nil {
@@ -73,12 +76,21 @@ func InitDB(cfg *config_util.Config, log *logrus.Logger) (*gorm.DB, error) {
 		return nil, fmt.Errorf("ошибка получения sql.DB: %w", err)
 	}
 
-	// Устанавливаем настройки пула соединений из конфигурации или дефолтов
-	// Использование значений из конфига предпочтительнее хардкода
-	sqlDB.SetMaxIdleConns(cfg.DBMaxIdleConns)       // Макс. количество простаивающих соединений
-	sqlDB.SetMaxOpenConns(cfg.DBMaxOpenConns)       // Макс. количество открытых соединений
-	sqlDB.SetConnMaxLifetime(cfg.DBConnMaxLifetime) // Время жизни соединения
-	sqlDB.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime) // Время простоя соединения перед закрытием (добавлено)
+	if cfg.DBDriver == config_util.DBDriverSQLite {
+		// SQLite допускает одного писателя, а база в памяти живет, пока открыто ее соединение.
+		// Единственное бессрочное соединение сериализует запросы и сохраняет данные базы в памяти.
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetMaxIdleConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+		sqlDB.SetConnMaxIdleTime(0)
+	} else {
+		// Устанавливаем настройки пула соединений из конфигурации или дефолтов
+		// Использование значений из конфига предпочтительнее хардкода
+		sqlDB.SetMaxIdleConns(cfg.DBMaxIdleConns)       // Макс. количество простаивающих соединений
+		sqlDB.SetMaxOpenConns(cfg.DBMaxOpenConns)       // Макс. количество открытых соединений
+		sqlDB.SetConnMaxLifetime(cfg.DBConnMaxLifetime) // Время жизни соединения
+		sqlDB.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime) // Время простоя соединения перед закрытием (добавлено)
+	}
 
 	// Проверка "живости" соединения (ping) - опционально, но полезно при старте
 	if err := sqlDB.Ping(); err != nil {
@@ -86,13 +98,55 @@ func InitDB(cfg *config_util.Config, log *logrus.Logger) (*gorm.DB, error) {
 		return nil, fmt.Errorf("ошибка проверки соединения с базой данных: %w", err)
 	}
 
+	// Статистика пула соединений публикуется в метриках; без нее приложение продолжает работать
+	if err := metrics_util.RegisterDBStats(sqlDB); err != nil {
+		log.WithError(err).Warn("Не удалось зарегистрировать метрики пула соединений")
+	}
+
+	// SQL запросы внутри трассируемых операций попадают в трассировку отдельными спанами
+	if err := RegisterTracing(db); err != nil {
+		log.WithError(err).Warn("Не удалось зарегистрировать трассировку запросов GORM")
+	}
+
 	log.Info("Подключение к базе данных установлено и пул соединений настроен успешно.")
 	return db, nil
 }
 
-// RunMigrations выполняет автоматическую миграцию базы данных для заданных моделей.
-// Эту функцию следует вызывать отдельно после успешной инициализации DB,
-// обычно только в среде разработки или при явном сценарии миграции.
+// openDialector создает диалектор GORM для драйвера из конфигурации
+func openDialector(cfg *config_util.Config) (gorm.Dialector, error) {
+	switch cfg.DBDriver {
+	case config_util.DBDriverPostgres, "":
+		dsn := fmt.Sprintf(
+			"host=%s port=%s user=%s dbname=%s password=%s sslmode=disable TimeZone=UTC",
+			cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBName, cfg.DBPassword,
+		)
+		return postgres.Open(dsn), nil
+	case config_util.DBDriverSQLite:
+		if cfg.DBPath == "" {
+			return nil, errors.New("не задан путь к файлу SQLite (DB_PATH)")
+		}
+		// Внешние ключи в SQLite по умолчанию не проверяются; каскадное удаление заказов на них опирается
+		return sqlite.Open(cfg.DBPath + "?_foreign_keys=1&_busy_timeout=5000"), nil
+	default:
+		return nil, fmt.Errorf("неизвестный драйвер базы данных '%s'", cfg.DBDriver)
+	}
+}
+
+// NewEmbeddedMigrator создает мигратор для SQL миграций, встроенных в бинарный файл,
+// выбирая набор миграций по СУБД подключения
+func NewEmbeddedMigrator(db *gorm.DB, log *logrus.Logger) (*Migrator, error) {
+	if db == nil {
+		return nil, errors.New("экземпляр *gorm.DB не предоставлен для выполнения миграций")
+	}
+	fsys, err := migrations.ForDialect(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	return NewMigrator(db, fsys, log)
+}
+
+// RunMigrations применяет к базе данных все еще не примененные SQL миграции из каталога migrations.
+// Миграции встроены в бинарный файл, примененные версии записываются в таблицу schema_migrations.
 func RunMigrations(db *gorm.DB, log *logrus.Logger) error {
 	// Проверка на nil DB
 	if db == nil {
@@ -104,15 +158,25 @@ func RunMigrations(db *gorm.DB, log *logrus.Logger) error {
 		return errors.New("логгер не предоставлен для выполнения миграций")
 	}
 
-	log.Info("Запуск автомиграций базы данных для User и Order моделей.")
-	// Выполняем автомиграцию. GORM создаст таблицы, если они не существуют,
-	// и добавит недостающие колонки. Он НЕ удалит колонки и НЕ изменит их тип.
-	err := db.AutoMigrate(&user_model.User{}, &order_model.Order{})
+	migrator, err := NewEmbeddedMigrator(db, log)
 	if err != nil {
-		// Логируем и возвращаем ошибку миграции
-		log.WithError(err).Errorf("ошибка выполнения автомиграции базы данных: %v", err)
-		return fmt.Errorf("ошибка автомиграции базы данных: %w", err)
+		return err
 	}
-	log.Info("Автомиграции завершены успешно.")
+
+	log.Info("Запуск миграций базы данных.")
+	if _, err := migrator.Up(context.Background()); err != nil {
+		return fmt.Errorf("ошибка миграции базы данных: %w", err)
+	}
+	log.Info("Миграции завершены успешно.")
 	return nil
 }
+
+// CheckSchema проверяет, что схема базы данных соответствует встроенным миграциям.
+// Возвращает ErrSchemaOutdated, если часть миграций не применена.
+func CheckSchema(db *gorm.DB, log *logrus.Logger) error {
+	migrator, err := NewEmbeddedMigrator(db, log)
+	if err != nil {
+		return err
+	}
+	return migrator.CheckSchema(context.Background())
+}
@@ -1,11 +1,13 @@
 package database
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
 
 	"github.com/IlyushinDM/user-order-api/internal/utils/config_util"
+	"github.com/IlyushinDM/user-order-api/migrations"
 	"github.com/sirupsen/logrus"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -63,14 +65,76 @@ func TestRunMigrations_NilLogger(t *testing.T) {
 	}
 }
 
-// This test checks that RunMigrations works with a valid DB and logger.
-// It does not check actual DB schema, just that no error is returned.
-func TestRunMigrations_Success(t *testing.T) {
+// Наборы миграций PostgreSQL и SQLite должны содержать одни и те же версии с одинаковыми именами:
+// версии идут подряд с 1 и у каждой есть файлы up и down.
+func TestEmbeddedMigrations_Valid(t *testing.T) {
 	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	sqliteFS, err := migrations.ForDialect("sqlite")
+	if err != nil {
+		t.Fatalf("ForDialect: %v", err)
+	}
+	pgMigrator, err := NewMigrator(db, migrations.FS, mockLogger())
+	if err != nil {
+		t.Fatalf("не удалось загрузить миграции PostgreSQL: %v", err)
+	}
+	sqliteMigrator, err := NewMigrator(db, sqliteFS, mockLogger())
+	if err != nil {
+		t.Fatalf("не удалось загрузить миграции SQLite: %v", err)
+	}
+
+	pg, lite := pgMigrator.Migrations(), sqliteMigrator.Migrations()
+	if len(pg) == 0 || len(pg) != len(lite) {
+		t.Fatalf("количество миграций различается: PostgreSQL %d, SQLite %d", len(pg), len(lite))
+	}
+	for i, m := range pg {
+		if m.Version != uint(i+1) {
+			t.Errorf("ожидалась версия %d, получена %d (%s)", i+1, m.Version, m.Name)
+		}
+		if m.DownSQL == "" || lite[i].DownSQL == "" {
+			t.Errorf("у миграции %d_%s нет файла down", m.Version, m.Name)
+		}
+		if lite[i].Version != m.Version || lite[i].Name != m.Name {
+			t.Errorf("миграция SQLite %d_%s не соответствует %d_%s", lite[i].Version, lite[i].Name, m.Version, m.Name)
+		}
+	}
+}
+
+// Миграции SQLite применяются и откатываются полностью, а повторное применение восстанавливает схему
+func TestRunMigrations_SQLite(t *testing.T) {
+	cfg := &config_util.Config{DBDriver: config_util.DBDriverSQLite, DBPath: ":memory:"}
 	log := mockLogger()
-	err := RunMigrations(db, log)
+	db, err := InitDB(cfg, log)
 	if err != nil {
-		t.Errorf("expected no error, got: %v", err)
+		t.Fatalf("InitDB: %v", err)
+	}
+	if err := RunMigrations(db, log); err != nil {
+		t.Fatalf("RunMigrations: %v", err)
+	}
+	if err := CheckSchema(db, log); err != nil {
+		t.Fatalf("CheckSchema: %v", err)
+	}
+
+	migrator, err := NewEmbeddedMigrator(db, log)
+	if err != nil {
+		t.Fatalf("NewEmbeddedMigrator: %v", err)
+	}
+	if _, err := migrator.Down(context.Background(), 0); err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if db.Migrator().HasTable("users") {
+		t.Error("после полного отката таблица users осталась")
+	}
+	if err := RunMigrations(db, log); err != nil {
+		t.Fatalf("повторный RunMigrations: %v", err)
+	}
+}
+
+func TestInitDB_UnknownDriver(t *testing.T) {
+	cfg := validConfig()
+	cfg.DBDriver = "mysql"
+	db, err := InitDB(cfg, mockLogger())
+	if db != nil || err == nil {
+		t.Errorf("ожидалась ошибка для неизвестного драйвера, получено db=%v err=%v", db, err)
 	}
 }
 
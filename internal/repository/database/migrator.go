@@ -0,0 +1,427 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Определение ошибок миграций
+var (
+	ErrInvalidMigration  = errors.New("некорректный набор миграций")
+	ErrSchemaOutdated    = errors.New("схема базы данных отстает от версии приложения")
+	ErrDirtyLegacySchema = errors.New("предыдущий инструмент миграций оставил схему в незавершенном состоянии")
+)
+
+// schemaMigrationsTable - таблица, в которой записываются примененные миграции
+const schemaMigrationsTable = "schema_migrations"
+
+// migrationLockKey - ключ advisory lock PostgreSQL, под которым выполняются миграции.
+// Пока одна реплика применяет миграции, остальные ждут освобождения блокировки.
+const migrationLockKey int64 = 7_241_934_502
+
+// migrationFilePattern описывает имя файла миграции: NNN_описание.up.sql или NNN_описание.down.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([A-Za-z0-9_]+)\.(up|down)\.sql$`)
+
+// conditionalColumnPattern описывает ALTER TABLE t ADD COLUMN IF NOT EXISTS c ... и
+// ALTER TABLE t DROP COLUMN IF EXISTS c. SQLite такие команды не поддерживает, поэтому
+// для него мигратор выполняет их сам в зависимости от наличия колонки.
+var conditionalColumnPattern = regexp.MustCompile(
+	`(?i)ALTER\s+TABLE\s+(\w+)\s+(ADD|DROP)\s+COLUMN\s+(IF\s+(?:NOT\s+)?EXISTS\s+)(\w+)[^;]*;`)
+
+// Migration описывает одну версию схемы
+type Migration struct {
+	Version uint
+	Name    string
+	UpSQL   string
+	DownSQL string
+}
+
+// appliedMigration - запись таблицы schema_migrations
+type appliedMigration struct {
+	Version   uint
+	Name      string
+	AppliedAt time.Time
+}
+
+// MigrationStatus описывает состояние миграции в конкретной базе данных
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// Migrator применяет и откатывает версионированные SQL миграции.
+// Каждая миграция выполняется в отдельной транзакции вместе с записью в schema_migrations,
+// поэтому прерванная миграция не оставляет схему в промежуточном состоянии.
+type Migrator struct {
+	db         *gorm.DB
+	log        *logrus.Logger
+	migrations []Migration
+}
+
+// NewMigrator создает мигратор для миграций из файловой системы fsys
+func NewMigrator(db *gorm.DB, fsys fs.FS, log *logrus.Logger) (*Migrator, error) {
+	if db == nil {
+		return nil, errors.New("экземпляр *gorm.DB не предоставлен для выполнения миграций")
+	}
+	if log == nil {
+		return nil, errors.New("логгер не предоставлен для выполнения миграций")
+	}
+
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, log: log, migrations: migrations}, nil
+}
+
+// loadMigrations читает и проверяет файлы миграций. Версии должны быть уникальны,
+// у каждой версии должен быть файл up.
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("%w: не удалось прочитать каталог миграций: %v", ErrInvalidMigration, err)
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 32)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("%w: недопустимая версия в имени файла %s", ErrInvalidMigration, entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("%w: не удалось прочитать %s: %v", ErrInvalidMigration, entry.Name(), err)
+		}
+
+		migration, ok := byVersion[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("%w: у версии %d несколько имен: %s и %s",
+				ErrInvalidMigration, version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.UpSQL = string(content)
+		} else {
+			migration.DownSQL = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.UpSQL == "" {
+			return nil, fmt.Errorf("%w: у версии %d нет файла up", ErrInvalidMigration, migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrations возвращает известные приложению миграции в порядке версий
+func (m *Migrator) Migrations() []Migration {
+	return append([]Migration(nil), m.migrations...)
+}
+
+// LatestVersion возвращает последнюю известную приложению версию схемы
+func (m *Migrator) LatestVersion() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up применяет все еще не примененные миграции по возрастанию версий
+// и возвращает количество примененных миграций.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	logger := m.log.WithContext(ctx).WithField("method", "Migrator.Up")
+
+	applied := 0
+	err := m.withLock(ctx, func(conn *gorm.DB) error {
+		done, err := m.appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			logger.WithField("version", migration.Version).Infof("Применение миграции %s", migration.Name)
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := execMigrationSQL(tx, migration.UpSQL); err != nil {
+					return err
+				}
+				return tx.Exec("INSERT INTO "+schemaMigrationsTable+" (version, name, applied_at) VALUES (?, ?, ?)",
+					migration.Version, migration.Name, time.Now().UTC()).Error
+			})
+			if err != nil {
+				return fmt.Errorf("миграция %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied++
+		}
+		return nil
+	})
+	if err != nil {
+		logger.WithError(err).Error("Не удалось применить миграции")
+		return applied, err
+	}
+
+	logger.WithField("applied", applied).Info("Схема базы данных актуальна")
+	return applied, nil
+}
+
+// Down откатывает примененные миграции с версией больше target по убыванию версий
+// и возвращает количество откаченных миграций. Down(ctx, 0) откатывает все миграции.
+func (m *Migrator) Down(ctx context.Context, target uint) (int, error) {
+	logger := m.log.WithContext(ctx).WithField("method", "Migrator.Down").WithField("target", target)
+
+	reverted := 0
+	err := m.withLock(ctx, func(conn *gorm.DB) error {
+		done, err := m.appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if migration.Version <= target {
+				break
+			}
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+			if migration.DownSQL == "" {
+				return fmt.Errorf("%w: у версии %d нет файла down", ErrInvalidMigration, migration.Version)
+			}
+			logger.WithField("version", migration.Version).Infof("Откат миграции %s", migration.Name)
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := execMigrationSQL(tx, migration.DownSQL); err != nil {
+					return err
+				}
+				return tx.Exec("DELETE FROM "+schemaMigrationsTable+" WHERE version = ?", migration.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("откат миграции %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted++
+		}
+		return nil
+	})
+	if err != nil {
+		logger.WithError(err).Error("Не удалось откатить миграции")
+		return reverted, err
+	}
+
+	logger.WithField("reverted", reverted).Info("Откат миграций завершен")
+	return reverted, nil
+}
+
+// Status возвращает состояние всех известных приложению миграций
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn := m.db.WithContext(ctx)
+	if !conn.Migrator().HasTable(schemaMigrationsTable) {
+		statuses := make([]MigrationStatus, len(m.migrations))
+		for i, migration := range m.migrations {
+			statuses[i] = MigrationStatus{Migration: migration}
+		}
+		return statuses, nil
+	}
+
+	done, err := m.appliedVersions(conn)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = MigrationStatus{Migration: migration}
+		if record, ok := done[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			statuses[i].Applied = true
+			statuses[i].AppliedAt = &appliedAt
+		}
+	}
+	return statuses, nil
+}
+
+// CurrentVersion возвращает наибольшую примененную версию схемы или 0, если миграции не применялись
+func (m *Migrator) CurrentVersion(ctx context.Context) (uint, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var current uint
+	for _, status := range statuses {
+		if status.Applied {
+			current = status.Version
+		}
+	}
+	return current, nil
+}
+
+// CheckSchema проверяет, что в базе данных применены все известные приложению миграции.
+// Если схема отстает, возвращает ErrSchemaOutdated: обслуживать запросы с такой схемой нельзя.
+func (m *Migrator) CheckSchema(ctx context.Context) error {
+	logger := m.log.WithContext(ctx).WithField("method", "Migrator.CheckSchema")
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	var pending []uint
+	for _, status := range statuses {
+		if !status.Applied {
+			pending = append(pending, status.Version)
+		}
+	}
+	if len(pending) > 0 {
+		logger.WithField("pending", pending).Error("Схема базы данных отстает от версии приложения")
+		return fmt.Errorf("%w: не применены миграции %v", ErrSchemaOutdated, pending)
+	}
+
+	logger.WithField("version", m.LatestVersion()).Debug("Схема базы данных соответствует версии приложения")
+	return nil
+}
+
+// execMigrationSQL выполняет SQL миграции. В SQLite условные ADD COLUMN и DROP COLUMN
+// выполняются по очереди с остальными командами: каждая проверяет колонку в текущей схеме
+// и выполняется без IF [NOT] EXISTS, только если колонку действительно нужно добавить или удалить.
+func execMigrationSQL(tx *gorm.DB, sql string) error {
+	if tx.Dialector.Name() != "sqlite" {
+		return tx.Exec(sql).Error
+	}
+
+	for {
+		loc := conditionalColumnPattern.FindStringSubmatchIndex(sql)
+		if loc == nil {
+			break
+		}
+		if before := strings.TrimSpace(sql[:loc[0]]); before != "" {
+			if err := tx.Exec(before).Error; err != nil {
+				return err
+			}
+		}
+
+		table, action, column := sql[loc[2]:loc[3]], strings.ToUpper(sql[loc[4]:loc[5]]), sql[loc[8]:loc[9]]
+		if exists := tx.Migrator().HasColumn(table, column); (action == "ADD") != exists {
+			statement := sql[loc[0]:loc[6]] + sql[loc[7]:loc[1]]
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		sql = sql[loc[1]:]
+	}
+
+	if strings.TrimSpace(sql) == "" {
+		return nil
+	}
+	return tx.Exec(sql).Error
+}
+
+// withLock выполняет fn на одном соединении под advisory lock.
+// Для СУБД без advisory lock (SQLite) блокировка не требуется: запись в них сериализуется самой СУБД.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *gorm.DB) error) error {
+	return m.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		if conn.Dialector.Name() == "postgres" {
+			m.log.WithContext(ctx).Debug("Ожидание блокировки миграций")
+			if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockKey).Error; err != nil {
+				return fmt.Errorf("не удалось получить блокировку миграций: %w", err)
+			}
+			defer func() {
+				// Блокировка принадлежит соединению из пула, поэтому снимается даже при отмене контекста
+				unlock := conn.WithContext(context.WithoutCancel(ctx))
+				if err := unlock.Exec("SELECT pg_advisory_unlock(?)", migrationLockKey).Error; err != nil {
+					m.log.WithError(err).Error("Не удалось снять блокировку миграций")
+				}
+			}()
+		}
+
+		if err := m.ensureTable(conn); err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+// ensureTable создает таблицу schema_migrations. Если в базе осталась таблица golang-migrate
+// (одна строка с version и dirty), ее версия переносится в новую таблицу.
+func (m *Migrator) ensureTable(conn *gorm.DB) error {
+	if conn.Migrator().HasTable(schemaMigrationsTable) && conn.Migrator().HasColumn(schemaMigrationsTable, "dirty") {
+		return m.adoptLegacyTable(conn)
+	}
+	return conn.Exec(createSchemaMigrationsSQL).Error
+}
+
+const createSchemaMigrationsSQL = `CREATE TABLE IF NOT EXISTS ` + schemaMigrationsTable + ` (
+  version BIGINT PRIMARY KEY,
+  name VARCHAR(255) NOT NULL,
+  applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`
+
+// adoptLegacyTable переносит состояние golang-migrate: все миграции до его версии
+// считаются примененными.
+func (m *Migrator) adoptLegacyTable(conn *gorm.DB) error {
+	var legacy struct {
+		Version int64
+		Dirty   bool
+	}
+	if err := conn.Raw("SELECT version, dirty FROM " + schemaMigrationsTable + " LIMIT 1").Scan(&legacy).Error; err != nil {
+		return fmt.Errorf("не удалось прочитать таблицу golang-migrate: %w", err)
+	}
+	if legacy.Dirty {
+		return fmt.Errorf("%w: версия %d", ErrDirtyLegacySchema, legacy.Version)
+	}
+
+	m.log.WithField("version", legacy.Version).Info("Перенос состояния миграций из таблицы golang-migrate")
+	return conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DROP TABLE " + schemaMigrationsTable).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(createSchemaMigrationsSQL).Error; err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		for _, migration := range m.migrations {
+			if int64(migration.Version) > legacy.Version {
+				break
+			}
+			if err := tx.Exec("INSERT INTO "+schemaMigrationsTable+" (version, name, applied_at) VALUES (?, ?, ?)",
+				migration.Version, migration.Name, now).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// appliedVersions возвращает примененные миграции по версиям
+func (m *Migrator) appliedVersions(conn *gorm.DB) (map[uint]appliedMigration, error) {
+	var records []appliedMigration
+	err := conn.Table(schemaMigrationsTable).Select("version", "name", "applied_at").Order("version").Find(&records).Error
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать %s: %w", schemaMigrationsTable, err)
+	}
+	done := make(map[uint]appliedMigration, len(records))
+	for _, record := range records {
+		done[record.Version] = record
+	}
+	return done, nil
+}
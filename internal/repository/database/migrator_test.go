@@ -0,0 +1,292 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/migrations"
+	"github.com/sirupsen/logrus"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testMigrations - переносимые миграции, которые выполняются и в SQLite
+func testMigrations() fstest.MapFS {
+	return fstest.MapFS{
+		"001_users.up.sql":        {Data: []byte("CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL);")},
+		"001_users.down.sql":      {Data: []byte("DROP TABLE users;")},
+		"002_orders.up.sql":       {Data: []byte("CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL);")},
+		"002_orders.down.sql":     {Data: []byte("DROP TABLE orders;")},
+		"003_orders_idx.up.sql":   {Data: []byte("CREATE INDEX idx_orders_user_id ON orders (user_id);")},
+		"003_orders_idx.down.sql": {Data: []byte("DROP INDEX idx_orders_user_id;")},
+		"README.md":               {Data: []byte("не миграция")},
+	}
+}
+
+func newMigratorTestDB(t *testing.T) *gorm.DB {
+	dsn := filepath.Join(t.TempDir(), "migrations.db")
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	return db
+}
+
+func newTestMigrator(t *testing.T, db *gorm.DB, fsys fs.FS) *Migrator {
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	migrator, err := NewMigrator(db, fsys, log)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	return migrator
+}
+
+func TestMigrator_UpAppliesInOrderAndIsIdempotent(t *testing.T) {
+	db := newMigratorTestDB(t)
+	migrator := newTestMigrator(t, db, testMigrations())
+	ctx := context.Background()
+
+	applied, err := migrator.Up(ctx)
+	if err != nil || applied != 3 {
+		t.Fatalf("Up: applied=%d err=%v", applied, err)
+	}
+	if !db.Migrator().HasTable("users") || !db.Migrator().HasIndex("orders", "idx_orders_user_id") {
+		t.Fatal("миграции не применены")
+	}
+
+	applied, err = migrator.Up(ctx)
+	if err != nil || applied != 0 {
+		t.Fatalf("повторный Up: applied=%d err=%v", applied, err)
+	}
+	if err := migrator.CheckSchema(ctx); err != nil {
+		t.Fatalf("CheckSchema: %v", err)
+	}
+}
+
+func TestMigrator_DownToTarget(t *testing.T) {
+	db := newMigratorTestDB(t)
+	migrator := newTestMigrator(t, db, testMigrations())
+	ctx := context.Background()
+
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	reverted, err := migrator.Down(ctx, 1)
+	if err != nil || reverted != 2 {
+		t.Fatalf("Down: reverted=%d err=%v", reverted, err)
+	}
+	if db.Migrator().HasTable("orders") || !db.Migrator().HasTable("users") {
+		t.Fatal("откат выполнен не до целевой версии")
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if !statuses[0].Applied || statuses[1].Applied || statuses[2].Applied {
+		t.Errorf("непредвиденное состояние миграций: %+v", statuses)
+	}
+	if current, err := migrator.CurrentVersion(ctx); err != nil || current != 1 {
+		t.Errorf("ожидалась текущая версия 1, получено %d (%v)", current, err)
+	}
+	if err := migrator.CheckSchema(ctx); !errors.Is(err, ErrSchemaOutdated) {
+		t.Errorf("ожидалась ErrSchemaOutdated, получено %v", err)
+	}
+}
+
+func TestMigrator_CheckSchemaOnEmptyDatabase(t *testing.T) {
+	migrator := newTestMigrator(t, newMigratorTestDB(t), testMigrations())
+	if err := migrator.CheckSchema(context.Background()); !errors.Is(err, ErrSchemaOutdated) {
+		t.Errorf("ожидалась ErrSchemaOutdated, получено %v", err)
+	}
+}
+
+func TestMigrator_FailedMigrationIsRolledBack(t *testing.T) {
+	db := newMigratorTestDB(t)
+	fsys := testMigrations()
+	fsys["002_orders.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE orders (id INTEGER PRIMARY KEY); SELECT * FROM missing_table;")}
+	migrator := newTestMigrator(t, db, fsys)
+
+	applied, err := migrator.Up(context.Background())
+	if err == nil || applied != 1 {
+		t.Fatalf("ожидалась ошибка после первой миграции: applied=%d err=%v", applied, err)
+	}
+	if db.Migrator().HasTable("orders") {
+		t.Error("частично примененная миграция не откатилась")
+	}
+	statuses, _ := migrator.Status(context.Background())
+	if !statuses[0].Applied || statuses[1].Applied {
+		t.Errorf("непредвиденное состояние миграций: %+v", statuses)
+	}
+}
+
+func TestMigrator_AdoptsLegacyTable(t *testing.T) {
+	db := newMigratorTestDB(t)
+	ctx := context.Background()
+	// Состояние, оставленное golang-migrate после применения первых двух миграций
+	for _, stmt := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL)",
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL)",
+		"CREATE TABLE schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)",
+		"INSERT INTO schema_migrations (version, dirty) VALUES (2, false)",
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("подготовка: %v", err)
+		}
+	}
+
+	migrator := newTestMigrator(t, db, testMigrations())
+	applied, err := migrator.Up(ctx)
+	if err != nil || applied != 1 {
+		t.Fatalf("Up: applied=%d err=%v", applied, err)
+	}
+	if err := migrator.CheckSchema(ctx); err != nil {
+		t.Fatalf("CheckSchema: %v", err)
+	}
+}
+
+// baselineUser и baselineOrder повторяют модели, которые до перехода на миграции
+// дополняли схему автомиграцией GORM при запуске приложения
+type baselineUser struct {
+	ID           uint   `gorm:"primaryKey;autoIncrement"`
+	Name         string `gorm:"not null;size:255"`
+	Email        string `gorm:"unique;not null;size:255"`
+	Age          int    `gorm:"not null"`
+	PasswordHash string `gorm:"not null"`
+}
+
+func (baselineUser) TableName() string { return "users" }
+
+type baselineOrder struct {
+	ID     uint `gorm:"primaryKey;autoIncrement"`
+	UserID uint `gorm:"not null"`
+	// PostgreSQL добавлял колонку как NOT NULL без значения по умолчанию; SQLite так не умеет
+	ProductName string  `gorm:"not null;size:255;default:''"`
+	Quantity    int     `gorm:"not null"`
+	Price       float64 `gorm:"not null"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineOrder) TableName() string { return "orders" }
+
+func TestMigrator_UpgradesLegacyAutoMigrateSchema(t *testing.T) {
+	db := newMigratorTestDB(t)
+	ctx := context.Background()
+	fsys, err := migrations.ForDialect("sqlite")
+	if err != nil {
+		t.Fatalf("ForDialect: %v", err)
+	}
+
+	// Состояние развернутой базы: golang-migrate применил версии 1-2, затем автомиграция GORM
+	// добавила в orders product_name, updated_at и deleted_at
+	for _, name := range []string{"001_users_table.up.sql", "002_orders_table.up.sql"} {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			t.Fatalf("чтение %s: %v", name, err)
+		}
+		if err := db.Exec(string(data)).Error; err != nil {
+			t.Fatalf("миграция %s: %v", name, err)
+		}
+	}
+	if err := db.AutoMigrate(&baselineUser{}, &baselineOrder{}); err != nil {
+		t.Fatalf("автомиграция: %v", err)
+	}
+	updatedAt := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, stmt := range []string{
+		"CREATE TABLE schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)",
+		"INSERT INTO schema_migrations (version, dirty) VALUES (2, false)",
+		"INSERT INTO users (id, name, email, age, password_hash) VALUES (1, 'Alice', 'alice@example.com', 30, 'hash')",
+		"INSERT INTO orders (id, user_id, product, product_name, quantity, price, created_at) " +
+			"VALUES (1, 1, 'old', 'Widget', 2, 9.50, '2025-01-01 10:00:00')",
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("подготовка: %v", err)
+		}
+	}
+	if err := db.Exec("UPDATE orders SET updated_at = ?", updatedAt).Error; err != nil {
+		t.Fatalf("подготовка: %v", err)
+	}
+
+	migrator := newTestMigrator(t, db, fsys)
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if err := migrator.CheckSchema(ctx); err != nil {
+		t.Fatalf("CheckSchema: %v", err)
+	}
+
+	for _, column := range []string{"product", "product_name", "quantity", "price"} {
+		if db.Migrator().HasColumn("orders", column) {
+			t.Errorf("в orders осталась колонка %s", column)
+		}
+	}
+	var productName string
+	if err := db.Raw("SELECT product_name FROM order_items WHERE order_id = 1").Scan(&productName).Error; err != nil {
+		t.Fatalf("чтение позиции: %v", err)
+	}
+	if productName != "Widget" {
+		t.Errorf("ожидалось название из product_name, получено %q", productName)
+	}
+	var stored time.Time
+	if err := db.Raw("SELECT updated_at FROM orders WHERE id = 1").Scan(&stored).Error; err != nil {
+		t.Fatalf("чтение заказа: %v", err)
+	}
+	if !stored.Equal(updatedAt) {
+		t.Errorf("updated_at перезаписан: %v", stored)
+	}
+	if err := db.Exec("INSERT INTO orders (user_id, status, currency) VALUES (1, 'pending', 'RUB')").Error; err != nil {
+		t.Errorf("новый заказ не вставляется: %v", err)
+	}
+}
+
+func TestMigrator_RefusesDirtyLegacyTable(t *testing.T) {
+	db := newMigratorTestDB(t)
+	for _, stmt := range []string{
+		"CREATE TABLE schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)",
+		"INSERT INTO schema_migrations (version, dirty) VALUES (2, true)",
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("подготовка: %v", err)
+		}
+	}
+
+	migrator := newTestMigrator(t, db, testMigrations())
+	if _, err := migrator.Up(context.Background()); !errors.Is(err, ErrDirtyLegacySchema) {
+		t.Errorf("ожидалась ErrDirtyLegacySchema, получено %v", err)
+	}
+}
+
+func TestNewMigrator_InvalidSets(t *testing.T) {
+	db := newMigratorTestDB(t)
+	log := logrus.New()
+	cases := map[string]fstest.MapFS{
+		"нет up": {
+			"001_users.down.sql": {Data: []byte("DROP TABLE users;")},
+		},
+		"два имени у версии": {
+			"001_users.up.sql":    {Data: []byte("SELECT 1;")},
+			"001_accounts.up.sql": {Data: []byte("SELECT 1;")},
+		},
+		"нулевая версия": {
+			"000_init.up.sql": {Data: []byte("SELECT 1;")},
+		},
+	}
+	for name, fsys := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewMigrator(db, fsys, log); !errors.Is(err, ErrInvalidMigration) {
+				t.Errorf("ожидалась ErrInvalidMigration, получено %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"gorm.io/gorm"
+)
+
+func newTracingTestDB(t *testing.T) (*gorm.DB, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	db := newMigratorTestDB(t)
+	if err := db.AutoMigrate(&txTestRow{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	if err := RegisterTracing(db); err != nil {
+		t.Fatalf("RegisterTracing failed: %v", err)
+	}
+	return db, recorder
+}
+
+func TestRegisterTracing_CreatesChildSpans(t *testing.T) {
+	db, recorder := newTracingTestDB(t)
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	if err := db.WithContext(ctx).Create(&txTestRow{Name: "traced"}).Error; err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	var row txTestRow
+	err := db.WithContext(ctx).Where("name = ?", "missing").First(&row).Error
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("expected ErrRecordNotFound, got %v", err)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+	create, query := spans[0], spans[1]
+	if create.Name() != "gorm.create" || query.Name() != "gorm.query" {
+		t.Fatalf("unexpected span names: %q, %q", create.Name(), query.Name())
+	}
+	if create.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("gorm span is not a child of the operation span")
+	}
+
+	attrs := map[string]string{}
+	for _, attr := range create.Attributes() {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	if attrs[string(semconv.DBSystemKey)] != "sqlite" {
+		t.Errorf("expected db.system sqlite, got %q", attrs[string(semconv.DBSystemKey)])
+	}
+	if attrs["db.collection.name"] != "tx_test_rows" {
+		t.Errorf("expected db.collection.name tx_test_rows, got %q", attrs["db.collection.name"])
+	}
+	if attrs["db.response.rows_affected"] != "1" {
+		t.Errorf("expected rows_affected 1, got %q", attrs["db.response.rows_affected"])
+	}
+	// В текст запроса попадают только плейсхолдеры, значения параметров не записываются
+	for _, span := range []sdktrace.ReadOnlySpan{create, query} {
+		for _, attr := range span.Attributes() {
+			if attr.Key == "db.query.text" && (strings.Contains(attr.Value.AsString(), "traced") || strings.Contains(attr.Value.AsString(), "missing")) {
+				t.Errorf("query text contains parameter values: %s", attr.Value.AsString())
+			}
+		}
+	}
+	// Отсутствие записи не считается ошибкой
+	if query.Status().Code == codes.Error {
+		t.Errorf("ErrRecordNotFound must not mark the span as failed")
+	}
+}
+
+func TestRegisterTracing_SkipsUntracedQueries(t *testing.T) {
+	db, recorder := newTracingTestDB(t)
+
+	if err := db.Create(&txTestRow{Name: "untraced"}).Error; err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if n := len(recorder.Ended()); n != 0 {
+		t.Errorf("expected no spans without parent span, got %d", n)
+	}
+}
+
+func TestRegisterTracing_RecordsErrors(t *testing.T) {
+	db, recorder := newTracingTestDB(t)
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	if err := db.WithContext(ctx).Exec("SELECT * FROM missing_table").Error; err == nil {
+		t.Fatalf("expected error for missing table")
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 || spans[0].Name() != "gorm.raw" {
+		t.Fatalf("expected gorm.raw span and parent, got %d spans", len(spans))
+	}
+	if spans[0].Status().Code != codes.Error {
+		t.Errorf("expected error status, got %v", spans[0].Status().Code)
+	}
+}
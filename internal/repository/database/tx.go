@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// txKey - ключ контекста, под которым хранится открытая транзакция
+type txKey struct{}
+
+// TxManager выполняет несколько вызовов репозиториев атомарно.
+// fn получает контекст с открытой транзакцией: GORM репозитории пользователей и заказов,
+// вызванные с этим контекстом, выполняют запросы в ней. Если fn возвращает ошибку или
+// паникует, транзакция откатывается; паника после отката передается дальше.
+// Вложенный вызов WithinTx создает точку сохранения внутри внешней транзакции.
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// gormTxManager реализует TxManager поверх транзакций GORM
+type gormTxManager struct {
+	db  *gorm.DB
+	log *logrus.Logger
+}
+
+// NewGormTxManager создает менеджер транзакций поверх db
+func NewGormTxManager(db *gorm.DB, log *logrus.Logger) TxManager {
+	if db == nil {
+		logrus.Fatal("Экземпляр GORM DB равен nil в NewGormTxManager")
+	}
+	if log == nil {
+		defaultLog := logrus.New()
+		defaultLog.SetLevel(logrus.InfoLevel)
+		defaultLog.Warn("Экземпляр Logrus logger равен nil в NewGormTxManager, используется логгер по умолчанию")
+		log = defaultLog
+	}
+	return &gormTxManager{db: db, log: log}
+}
+
+// WithinTx выполняет fn в транзакции и фиксирует ее, если fn завершилась без ошибки
+func (m *gormTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := Conn(ctx, m.db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+	if err != nil {
+		m.log.WithContext(ctx).WithError(err).Debug("Транзакция отменена")
+	}
+	return err
+}
+
+// Conn возвращает соединение для запросов репозитория: транзакцию из контекста,
+// если она открыта через TxManager, иначе db. Контекст запроса устанавливается в обоих случаях.
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type txTestRow struct {
+	ID   uint `gorm:"primaryKey"`
+	Name string
+}
+
+func newTxTestManager(t *testing.T) (TxManager, *gorm.DB) {
+	db := newMigratorTestDB(t)
+	if err := db.AutoMigrate(&txTestRow{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	return NewGormTxManager(db, log), db
+}
+
+func insertRow(ctx context.Context, db *gorm.DB, name string) error {
+	return Conn(ctx, db).Create(&txTestRow{Name: name}).Error
+}
+
+func countRows(t *testing.T, db *gorm.DB) int64 {
+	var count int64
+	if err := db.Model(&txTestRow{}).Count(&count).Error; err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	return count
+}
+
+func TestWithinTx_Commits(t *testing.T) {
+	tm, db := newTxTestManager(t)
+
+	err := tm.WithinTx(context.Background(), func(ctx context.Context) error {
+		if err := insertRow(ctx, db, "first"); err != nil {
+			return err
+		}
+		return insertRow(ctx, db, "second")
+	})
+	if err != nil {
+		t.Fatalf("WithinTx: %v", err)
+	}
+	if got := countRows(t, db); got != 2 {
+		t.Errorf("ожидалось 2 строки, получено %d", got)
+	}
+}
+
+func TestWithinTx_RollsBackOnError(t *testing.T) {
+	tm, db := newTxTestManager(t)
+	errBoom := errors.New("boom")
+
+	err := tm.WithinTx(context.Background(), func(ctx context.Context) error {
+		if err := insertRow(ctx, db, "first"); err != nil {
+			return err
+		}
+		return errBoom
+	})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("ожидалась ошибка fn, получено %v", err)
+	}
+	if got := countRows(t, db); got != 0 {
+		t.Errorf("транзакция не откачена: %d строк", got)
+	}
+}
+
+func TestWithinTx_RollsBackOnPanic(t *testing.T) {
+	tm, db := newTxTestManager(t)
+
+	func() {
+		defer func() {
+			if recovered := recover(); recovered != "boom" {
+				t.Errorf("паника не передана дальше: %v", recovered)
+			}
+		}()
+		_ = tm.WithinTx(context.Background(), func(ctx context.Context) error {
+			if err := insertRow(ctx, db, "first"); err != nil {
+				return err
+			}
+			panic("boom")
+		})
+	}()
+
+	if got := countRows(t, db); got != 0 {
+		t.Errorf("транзакция не откачена: %d строк", got)
+	}
+}
+
+func TestWithinTx_NestedRollsBackToSavepoint(t *testing.T) {
+	tm, db := newTxTestManager(t)
+
+	err := tm.WithinTx(context.Background(), func(ctx context.Context) error {
+		if err := insertRow(ctx, db, "outer"); err != nil {
+			return err
+		}
+		nestedErr := tm.WithinTx(ctx, func(ctx context.Context) error {
+			if err := insertRow(ctx, db, "inner"); err != nil {
+				return err
+			}
+			return errors.New("inner failed")
+		})
+		if nestedErr == nil {
+			t.Error("ожидалась ошибка вложенной транзакции")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithinTx: %v", err)
+	}
+
+	var rows []txTestRow
+	if err := db.Find(&rows).Error; err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	if len(rows) != 1 || rows[0].Name != "outer" {
+		t.Errorf("ожидалась только строка внешней транзакции, получено %+v", rows)
+	}
+}
+
+func TestConn_WithoutTxUsesDB(t *testing.T) {
+	_, db := newTxTestManager(t)
+
+	if err := insertRow(context.Background(), db, "plain"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if got := countRows(t, db); got != 1 {
+		t.Errorf("ожидалась 1 строка, получено %d", got)
+	}
+}
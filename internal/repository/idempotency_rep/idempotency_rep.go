@@ -0,0 +1,183 @@
+package idempotency_rep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/idempotency_model"
+	"github.com/IlyushinDM/user-order-api/internal/repository/database"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Определение ошибок хранилища ключей идемпотентности
+var (
+	ErrRequestInProgress = errors.New("запрос с этим ключом идемпотентности еще выполняется")
+	ErrKeyReused         = errors.New("ключ идемпотентности уже использован для другого запроса")
+	ErrDatabaseError     = errors.New("ошибка базы данных")
+	ErrInvalidInput      = errors.New("неверные входные данные ключа идемпотентности")
+)
+
+// IdempotencyRepository определяет интерфейс хранилища ключей идемпотентности.
+// Ключи изолированы по пользователям: один и тот же ключ разных пользователей не пересекается.
+type IdempotencyRepository interface {
+	// Begin захватывает ключ для нового запроса на время lease. Если ключ свободен или его запись
+	// просрочена, возвращает (nil, nil). Если ответ на запрос с этим ключом уже
+	// сохранен, возвращает его запись. Если первый запрос еще выполняется,
+	// возвращает ErrRequestInProgress, если ключ использован с другим телом - ErrKeyReused.
+	// Захват, который не завершили Complete или Release (например, процесс аварийно остановился),
+	// освобождается по истечении lease.
+	Begin(ctx context.Context, userID uint, key, requestHash string, lease time.Duration) (*idempotency_model.IdempotencyRecord, error)
+	// Complete сохраняет ответ на запрос, захвативший ключ, на время ttl
+	Complete(ctx context.Context, userID uint, key string, response idempotency_model.StoredResponse, ttl time.Duration) error
+	// Release освобождает ключ, если ответ сохранять не нужно
+	Release(ctx context.Context, userID uint, key string) error
+}
+
+func validateKey(userID uint, key string) error {
+	if userID == 0 || key == "" {
+		return fmt.Errorf("%w: требуются пользователь и ключ", ErrInvalidInput)
+	}
+	return nil
+}
+
+// checkExisting решает, что делать с уже существующей действующей записью ключа
+func checkExisting(record *idempotency_model.IdempotencyRecord, requestHash string) (*idempotency_model.IdempotencyRecord, error) {
+	if record.RequestHash != requestHash {
+		return nil, ErrKeyReused
+	}
+	if !record.IsCompleted() {
+		return nil, ErrRequestInProgress
+	}
+	return record, nil
+}
+
+// gormIdempotencyRepository реализует IdempotencyRepository с использованием GORM
+type gormIdempotencyRepository struct {
+	db  *gorm.DB
+	log *logrus.Logger
+}
+
+// NewGormIdempotencyRepository создает хранилище ключей идемпотентности в базе данных
+func NewGormIdempotencyRepository(db *gorm.DB, log *logrus.Logger) IdempotencyRepository {
+	if db == nil {
+		logrus.Fatal("Экземпляр GORM DB равен nil в NewGormIdempotencyRepository")
+	}
+	if log == nil {
+		defaultLog := logrus.New()
+		defaultLog.SetLevel(logrus.InfoLevel)
+		defaultLog.Warn("Экземпляр логгера Logrus равен nil в NewGormIdempotencyRepository, используется логгер по умолчанию")
+		log = defaultLog
+	}
+	return &gormIdempotencyRepository{db: db, log: log}
+}
+
+// conn возвращает транзакцию из контекста, если она открыта через database.TxManager, иначе r.db
+func (r *gormIdempotencyRepository) conn(ctx context.Context) *gorm.DB {
+	return database.Conn(ctx, r.db)
+}
+
+// Begin захватывает ключ вставкой записи. Уникальный индекс (user_id, idempotency_key)
+// гарантирует, что из параллельных запросов с одним ключом запись вставит только один.
+func (r *gormIdempotencyRepository) Begin(ctx context.Context, userID uint, key, requestHash string, lease time.Duration) (*idempotency_model.IdempotencyRecord, error) {
+	logger := r.log.WithContext(ctx).WithField("method", "IdempotencyRepository.Begin").WithField("user_id", userID)
+
+	if err := validateKey(userID, key); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	// Просроченные записи пользователя, в том числе брошенные захваты, удаляются,
+	// чтобы их ключи можно было использовать снова
+	if err := r.conn(ctx).
+		Where("user_id = ? AND expires_at <= ?", userID, now).
+		Delete(&idempotency_model.IdempotencyRecord{}).Error; err != nil {
+		logger.WithError(err).Error("Не удалось удалить просроченные ключи идемпотентности")
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	record := idempotency_model.IdempotencyRecord{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		ExpiresAt:   now.Add(lease),
+	}
+	result := r.conn(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+	if result.Error != nil {
+		logger.WithError(result.Error).Error("Не удалось сохранить ключ идемпотентности")
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, result.Error)
+	}
+	if result.RowsAffected == 1 {
+		logger.Debug("Ключ идемпотентности захвачен")
+		return nil, nil
+	}
+
+	var existing idempotency_model.IdempotencyRecord
+	err := r.conn(ctx).
+		Where("user_id = ? AND idempotency_key = ?", userID, key).
+		First(&existing).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Запись освобождена между вставкой и чтением: первый запрос завершился ошибкой
+			return nil, ErrRequestInProgress
+		}
+		logger.WithError(err).Error("Не удалось получить ключ идемпотентности")
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	return checkExisting(&existing, requestHash)
+}
+
+// Complete сохраняет ответ в записи, захваченной вызовом Begin, и продлевает ее до now + ttl
+func (r *gormIdempotencyRepository) Complete(ctx context.Context, userID uint, key string, response idempotency_model.StoredResponse, ttl time.Duration) error {
+	logger := r.log.WithContext(ctx).WithField("method", "IdempotencyRepository.Complete").WithField("user_id", userID)
+
+	if err := validateKey(userID, key); err != nil {
+		return err
+	}
+	if response.StatusCode <= 0 {
+		return fmt.Errorf("%w: некорректный код ответа %d", ErrInvalidInput, response.StatusCode)
+	}
+
+	now := time.Now().UTC()
+	err := r.conn(ctx).Model(&idempotency_model.IdempotencyRecord{}).
+		Where("user_id = ? AND idempotency_key = ? AND status_code = 0", userID, key).
+		Updates(map[string]interface{}{
+			"status_code":  response.StatusCode,
+			"content_type": response.ContentType,
+			"etag":         response.ETag,
+			"body":         response.Body,
+			"expires_at":   now.Add(ttl),
+			"updated_at":   now,
+		}).Error
+	if err != nil {
+		logger.WithError(err).Error("Не удалось сохранить ответ для ключа идемпотентности")
+		return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	logger.WithField("status", response.StatusCode).Debug("Ответ для ключа идемпотентности сохранен")
+	return nil
+}
+
+// Release удаляет незавершенную запись, чтобы запрос можно было повторить с тем же ключом
+func (r *gormIdempotencyRepository) Release(ctx context.Context, userID uint, key string) error {
+	logger := r.log.WithContext(ctx).WithField("method", "IdempotencyRepository.Release").WithField("user_id", userID)
+
+	if err := validateKey(userID, key); err != nil {
+		return err
+	}
+
+	err := r.conn(ctx).
+		Where("user_id = ? AND idempotency_key = ? AND status_code = 0", userID, key).
+		Delete(&idempotency_model.IdempotencyRecord{}).Error
+	if err != nil {
+		logger.WithError(err).Error("Не удалось освободить ключ идемпотентности")
+		return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	logger.Debug("Ключ идемпотентности освобожден")
+	return nil
+}
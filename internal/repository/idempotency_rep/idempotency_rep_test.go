@@ -0,0 +1,284 @@
+package idempotency_rep
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/idempotency_model"
+	"github.com/sirupsen/logrus"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestLogger() *logrus.Logger {
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	return log
+}
+
+func newGormTestRepo(t *testing.T) IdempotencyRepository {
+	dsn := filepath.Join(t.TempDir(), "idempotency.db") + "?_busy_timeout=10000"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if err := db.AutoMigrate(&idempotency_model.IdempotencyRecord{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return &gormIdempotencyRepository{db: db, log: newTestLogger()}
+}
+
+func newMemoryTestRepo(t *testing.T) IdempotencyRepository {
+	return NewMemoryIdempotencyRepository(newTestLogger())
+}
+
+// completed - ответ, которым тесты завершают захваченный ключ
+var completed = idempotency_model.StoredResponse{StatusCode: 201, ContentType: "application/json", Body: []byte(`{}`)}
+
+var implementations = map[string]func(t *testing.T) IdempotencyRepository{
+	"gorm":   newGormTestRepo,
+	"memory": newMemoryTestRepo,
+}
+
+func TestBegin_ClaimsFreeKey(t *testing.T) {
+	for name, newRepo := range implementations {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			record, err := repo.Begin(context.Background(), 1, "key-1", "hash", time.Hour)
+			if err != nil || record != nil {
+				t.Fatalf("expected free key to be claimed, got record=%v err=%v", record, err)
+			}
+		})
+	}
+}
+
+func TestBegin_InProgress(t *testing.T) {
+	for name, newRepo := range implementations {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			ctx := context.Background()
+			if _, err := repo.Begin(ctx, 1, "key-1", "hash", time.Hour); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			_, err := repo.Begin(ctx, 1, "key-1", "hash", time.Hour)
+			if !errors.Is(err, ErrRequestInProgress) {
+				t.Fatalf("expected ErrRequestInProgress, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBegin_ReturnsCompletedResponse(t *testing.T) {
+	for name, newRepo := range implementations {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			ctx := context.Background()
+			if _, err := repo.Begin(ctx, 1, "key-1", "hash", time.Hour); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := repo.Complete(ctx, 1, "key-1", idempotency_model.StoredResponse{
+				StatusCode: 201, ContentType: "application/json", ETag: `"3"`, Body: []byte(`{"id":7}`),
+			}, time.Hour); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			record, err := repo.Begin(ctx, 1, "key-1", "hash", time.Hour)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if record == nil || record.StatusCode != 201 || string(record.Body) != `{"id":7}` || record.ContentType != "application/json" || record.ETag != `"3"` {
+				t.Fatalf("unexpected stored response: %+v", record)
+			}
+		})
+	}
+}
+
+func TestBegin_KeyReusedWithDifferentRequest(t *testing.T) {
+	for name, newRepo := range implementations {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			ctx := context.Background()
+			if _, err := repo.Begin(ctx, 1, "key-1", "hash-a", time.Hour); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := repo.Complete(ctx, 1, "key-1", completed, time.Hour); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			_, err := repo.Begin(ctx, 1, "key-1", "hash-b", time.Hour)
+			if !errors.Is(err, ErrKeyReused) {
+				t.Fatalf("expected ErrKeyReused, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBegin_KeysAreScopedPerUser(t *testing.T) {
+	for name, newRepo := range implementations {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			ctx := context.Background()
+			if _, err := repo.Begin(ctx, 1, "key-1", "hash", time.Hour); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			record, err := repo.Begin(ctx, 2, "key-1", "hash", time.Hour)
+			if err != nil || record != nil {
+				t.Fatalf("expected key of another user to be free, got record=%v err=%v", record, err)
+			}
+		})
+	}
+}
+
+func TestBegin_ExpiredRecordIsReplaced(t *testing.T) {
+	for name, newRepo := range implementations {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			ctx := context.Background()
+			if _, err := repo.Begin(ctx, 1, "key-1", "hash-a", time.Hour); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := repo.Complete(ctx, 1, "key-1", completed, -time.Second); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			record, err := repo.Begin(ctx, 1, "key-1", "hash-b", time.Hour)
+			if err != nil || record != nil {
+				t.Fatalf("expected expired key to be claimed again, got record=%v err=%v", record, err)
+			}
+		})
+	}
+}
+
+func TestBegin_AbandonedInFlightKeyIsFreedAfterLease(t *testing.T) {
+	for name, newRepo := range implementations {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			ctx := context.Background()
+			// Захват с истекшей арендой: запрос не дошел ни до Complete, ни до Release
+			if _, err := repo.Begin(ctx, 1, "key-1", "hash", -time.Second); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			record, err := repo.Begin(ctx, 1, "key-1", "hash", time.Minute)
+			if err != nil || record != nil {
+				t.Fatalf("expected abandoned key to be claimed again, got record=%v err=%v", record, err)
+			}
+		})
+	}
+}
+
+func TestComplete_KeepsResponseForTTL(t *testing.T) {
+	for name, newRepo := range implementations {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			ctx := context.Background()
+			// Аренда истекла к моменту сохранения ответа, но ответ хранится ttl с момента Complete
+			if _, err := repo.Begin(ctx, 1, "key-1", "hash", -time.Second); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := repo.Complete(ctx, 1, "key-1", completed, time.Hour); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			record, err := repo.Begin(ctx, 1, "key-1", "hash", time.Minute)
+			if err != nil || record == nil || record.StatusCode != 201 {
+				t.Fatalf("expected stored response to be replayed, got record=%v err=%v", record, err)
+			}
+		})
+	}
+}
+
+func TestRelease_FreesInFlightKey(t *testing.T) {
+	for name, newRepo := range implementations {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			ctx := context.Background()
+			if _, err := repo.Begin(ctx, 1, "key-1", "hash", time.Hour); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := repo.Release(ctx, 1, "key-1"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			record, err := repo.Begin(ctx, 1, "key-1", "hash", time.Hour)
+			if err != nil || record != nil {
+				t.Fatalf("expected released key to be claimed again, got record=%v err=%v", record, err)
+			}
+		})
+	}
+}
+
+func TestRelease_KeepsCompletedResponse(t *testing.T) {
+	for name, newRepo := range implementations {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			ctx := context.Background()
+			if _, err := repo.Begin(ctx, 1, "key-1", "hash", time.Hour); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := repo.Complete(ctx, 1, "key-1", completed, time.Hour); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := repo.Release(ctx, 1, "key-1"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			record, err := repo.Begin(ctx, 1, "key-1", "hash", time.Hour)
+			if err != nil || record == nil || record.StatusCode != 201 {
+				t.Fatalf("expected stored response to survive release, got record=%v err=%v", record, err)
+			}
+		})
+	}
+}
+
+func TestBegin_InvalidInput(t *testing.T) {
+	for name, newRepo := range implementations {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			_, err := repo.Begin(context.Background(), 0, "key-1", "hash", time.Hour)
+			if !errors.Is(err, ErrInvalidInput) {
+				t.Fatalf("expected ErrInvalidInput, got %v", err)
+			}
+			_, err = repo.Begin(context.Background(), 1, "", "hash", time.Hour)
+			if !errors.Is(err, ErrInvalidInput) {
+				t.Fatalf("expected ErrInvalidInput, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBegin_ConcurrentClaimsOnlyOnce(t *testing.T) {
+	for name, newRepo := range implementations {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			const workers = 8
+
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			claimed, inProgress := 0, 0
+			for i := 0; i < workers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					record, err := repo.Begin(context.Background(), 1, "key-1", "hash", time.Hour)
+					mu.Lock()
+					defer mu.Unlock()
+					switch {
+					case err == nil && record == nil:
+						claimed++
+					case errors.Is(err, ErrRequestInProgress):
+						inProgress++
+					default:
+						t.Errorf("unexpected result: record=%v err=%v", record, err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if claimed != 1 || inProgress != workers-1 {
+				t.Fatalf("expected exactly one claim, got claimed=%d inProgress=%d", claimed, inProgress)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package idempotency_rep
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/idempotency_model"
+	"github.com/sirupsen/logrus"
+)
+
+// memorySweepInterval задает, как часто хранилище в памяти удаляет просроченные записи
+const memorySweepInterval = time.Minute
+
+type memoryKey struct {
+	userID uint
+	key    string
+}
+
+// memoryIdempotencyRepository хранит ключи идемпотентности в памяти процесса.
+// Подходит для одного экземпляра приложения; записи теряются при перезапуске.
+type memoryIdempotencyRepository struct {
+	mu        sync.Mutex
+	records   map[memoryKey]*idempotency_model.IdempotencyRecord
+	lastSweep time.Time
+	now       func() time.Time
+	log       *logrus.Logger
+}
+
+// NewMemoryIdempotencyRepository создает хранилище ключей идемпотентности в памяти процесса
+func NewMemoryIdempotencyRepository(log *logrus.Logger) IdempotencyRepository {
+	if log == nil {
+		defaultLog := logrus.New()
+		defaultLog.SetLevel(logrus.InfoLevel)
+		defaultLog.Warn("Экземпляр логгера Logrus равен nil в NewMemoryIdempotencyRepository, используется логгер по умолчанию")
+		log = defaultLog
+	}
+	return &memoryIdempotencyRepository{
+		records: make(map[memoryKey]*idempotency_model.IdempotencyRecord),
+		now:     time.Now,
+		log:     log,
+	}
+}
+
+// Begin захватывает ключ или возвращает сохраненный ответ
+func (r *memoryIdempotencyRepository) Begin(ctx context.Context, userID uint, key, requestHash string, lease time.Duration) (*idempotency_model.IdempotencyRecord, error) {
+	if err := validateKey(userID, key); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now().UTC()
+	r.sweepLocked(now)
+
+	k := memoryKey{userID: userID, key: key}
+	if existing, ok := r.records[k]; ok && !existing.IsExpired(now) {
+		record, err := checkExisting(existing, requestHash)
+		if err != nil {
+			return nil, err
+		}
+		// Возвращаем копию, чтобы вызывающий код не изменил сохраненный ответ
+		replay := *record
+		replay.Body = append([]byte(nil), record.Body...)
+		return &replay, nil
+	}
+
+	r.records[k] = &idempotency_model.IdempotencyRecord{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		ExpiresAt:   now.Add(lease),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	r.log.WithContext(ctx).WithField("user_id", userID).Debug("Ключ идемпотентности захвачен")
+	return nil, nil
+}
+
+// Complete сохраняет ответ в записи, захваченной вызовом Begin, и продлевает ее до now + ttl
+func (r *memoryIdempotencyRepository) Complete(ctx context.Context, userID uint, key string, response idempotency_model.StoredResponse, ttl time.Duration) error {
+	if err := validateKey(userID, key); err != nil {
+		return err
+	}
+	if response.StatusCode <= 0 {
+		return fmt.Errorf("%w: некорректный код ответа %d", ErrInvalidInput, response.StatusCode)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, ok := r.records[memoryKey{userID: userID, key: key}]
+	if !ok || record.IsCompleted() {
+		return nil
+	}
+	record.StatusCode = response.StatusCode
+	record.ContentType = response.ContentType
+	record.ETag = response.ETag
+	record.Body = append([]byte(nil), response.Body...)
+	now := r.now().UTC()
+	record.ExpiresAt = now.Add(ttl)
+	record.UpdatedAt = now
+	return nil
+}
+
+// Release удаляет незавершенную запись, чтобы запрос можно было повторить с тем же ключом
+func (r *memoryIdempotencyRepository) Release(ctx context.Context, userID uint, key string) error {
+	if err := validateKey(userID, key); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := memoryKey{userID: userID, key: key}
+	if record, ok := r.records[k]; ok && !record.IsCompleted() {
+		delete(r.records, k)
+	}
+	return nil
+}
+
+// sweepLocked периодически удаляет просроченные записи. Вызывается под блокировкой.
+func (r *memoryIdempotencyRepository) sweepLocked(now time.Time) {
+	if now.Sub(r.lastSweep) < memorySweepInterval {
+		return
+	}
+	for k, record := range r.records {
+		if record.IsExpired(now) {
+			delete(r.records, k)
+		}
+	}
+	r.lastSweep = now
+}
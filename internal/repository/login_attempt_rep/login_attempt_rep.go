@@ -0,0 +1,141 @@
+package login_attempt_rep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/login_attempt_model"
+	"github.com/IlyushinDM/user-order-api/internal/repository/database"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Определение ошибок репозитория попыток входа
+var (
+	ErrDatabaseError = errors.New("ошибка базы данных")
+	ErrInvalidInput  = errors.New("неверные входные данные попытки входа")
+)
+
+// LoginAttemptRepository определяет интерфейс журнала попыток входа
+type LoginAttemptRepository interface {
+	Create(ctx context.Context, attempt *login_attempt_model.LoginAttempt) error
+	// RecentFailures возвращает время не более limit последних неудачных попыток входа с email,
+	// совершенных после последнего успешного входа, от новых к старым
+	RecentFailures(ctx context.Context, email string, limit int) ([]time.Time, error)
+	// GetByUserID возвращает страницу попыток входа пользователя от новых к старым и их общее число
+	GetByUserID(ctx context.Context, userID uint, page, limit int) ([]login_attempt_model.LoginAttempt, int64, error)
+}
+
+// gormLoginAttemptRepository реализует LoginAttemptRepository с использованием GORM
+type gormLoginAttemptRepository struct {
+	db  *gorm.DB
+	log *logrus.Logger
+}
+
+// NewGormLoginAttemptRepository создает новый репозиторий попыток входа с использованием GORM
+func NewGormLoginAttemptRepository(db *gorm.DB, log *logrus.Logger) LoginAttemptRepository {
+	if db == nil {
+		logrus.Fatal("Экземпляр GORM DB равен nil в NewGormLoginAttemptRepository")
+	}
+	if log == nil {
+		defaultLog := logrus.New()
+		defaultLog.SetLevel(logrus.InfoLevel)
+		defaultLog.Warn("Экземпляр логгера Logrus равен nil в NewGormLoginAttemptRepository, используется логгер по умолчанию")
+		log = defaultLog
+	}
+	return &gormLoginAttemptRepository{db: db, log: log}
+}
+
+// conn возвращает транзакцию из контекста, если она открыта через database.TxManager, иначе r.db
+func (r *gormLoginAttemptRepository) conn(ctx context.Context) *gorm.DB {
+	return database.Conn(ctx, r.db)
+}
+
+// Create сохраняет попытку входа
+func (r *gormLoginAttemptRepository) Create(ctx context.Context, attempt *login_attempt_model.LoginAttempt) error {
+	logger := r.log.WithContext(ctx).WithField("method", "LoginAttemptRepository.Create")
+
+	if attempt == nil || attempt.Email == "" || attempt.Outcome == "" {
+		logger.Warn("Попытка сохранить некорректную запись о входе")
+		return fmt.Errorf("%w: запись должна содержать email и исход", ErrInvalidInput)
+	}
+
+	if err := r.conn(ctx).Create(attempt).Error; err != nil {
+		logger.WithError(err).Error("Не удалось сохранить попытку входа")
+		return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	logger.WithFields(logrus.Fields{"attempt_id": attempt.ID, "outcome": attempt.Outcome}).Debug("Попытка входа сохранена")
+	return nil
+}
+
+// RecentFailures возвращает время последних неудачных попыток входа после последнего успешного.
+// Отклоненные из-за задержки или блокировки попытки не учитываются.
+func (r *gormLoginAttemptRepository) RecentFailures(ctx context.Context, email string, limit int) ([]time.Time, error) {
+	logger := r.log.WithContext(ctx).WithField("method", "LoginAttemptRepository.RecentFailures")
+
+	if email == "" || limit <= 0 {
+		return nil, nil
+	}
+
+	counted := append([]string{login_attempt_model.OutcomeSuccess}, login_attempt_model.FailureOutcomes...)
+	// Достаточно limit последних попыток: неудачи до ближайшего успешного входа идут первыми
+	var attempts []login_attempt_model.LoginAttempt
+	err := r.conn(ctx).
+		Select("outcome", "created_at").
+		Where("email = ? AND outcome IN ?", email, counted).
+		Order("created_at DESC").Order("id DESC").
+		Limit(limit).
+		Find(&attempts).Error
+	if err != nil {
+		logger.WithError(err).Error("Не удалось получить последние попытки входа")
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	failures := make([]time.Time, 0, len(attempts))
+	for _, attempt := range attempts {
+		if attempt.Outcome == login_attempt_model.OutcomeSuccess {
+			break
+		}
+		failures = append(failures, attempt.CreatedAt)
+	}
+	return failures, nil
+}
+
+// GetByUserID возвращает страницу истории входов пользователя
+func (r *gormLoginAttemptRepository) GetByUserID(
+	ctx context.Context,
+	userID uint,
+	page, limit int,
+) ([]login_attempt_model.LoginAttempt, int64, error) {
+	logger := r.log.WithContext(ctx).WithFields(logrus.Fields{
+		"method":  "LoginAttemptRepository.GetByUserID",
+		"user_id": userID,
+	})
+
+	if userID == 0 || page <= 0 || limit <= 0 {
+		return nil, 0, fmt.Errorf("%w: неверные параметры пагинации", ErrInvalidInput)
+	}
+
+	query := r.conn(ctx).Model(&login_attempt_model.LoginAttempt{}).Where("user_id = ?", userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.WithError(err).Error("Не удалось подсчитать попытки входа")
+		return nil, 0, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	var attempts []login_attempt_model.LoginAttempt
+	err := query.Order("created_at DESC").Order("id DESC").
+		Offset((page - 1) * limit).Limit(limit).
+		Find(&attempts).Error
+	if err != nil {
+		logger.WithError(err).Error("Не удалось получить историю входов")
+		return nil, 0, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	logger.WithFields(logrus.Fields{"count": len(attempts), "total": total}).Debug("История входов получена")
+	return attempts, total, nil
+}
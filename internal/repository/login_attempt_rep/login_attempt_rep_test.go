@@ -0,0 +1,119 @@
+package login_attempt_rep
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/login_attempt_model"
+	"github.com/sirupsen/logrus"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestRepo(t *testing.T) *gormLoginAttemptRepository {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if err := db.AutoMigrate(&login_attempt_model.LoginAttempt{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return &gormLoginAttemptRepository{db: db, log: logrus.New()}
+}
+
+func record(t *testing.T, repo *gormLoginAttemptRepository, userID *uint, email, outcome string, at time.Time) {
+	t.Helper()
+	attempt := &login_attempt_model.LoginAttempt{
+		UserID:    userID,
+		Email:     email,
+		IP:        "10.0.0.1",
+		UserAgent: "test-agent",
+		Outcome:   outcome,
+		CreatedAt: at,
+	}
+	if err := repo.Create(context.Background(), attempt); err != nil {
+		t.Fatalf("failed to record attempt: %v", err)
+	}
+}
+
+func TestCreate_InvalidInput(t *testing.T) {
+	repo := newTestRepo(t)
+	err := repo.Create(context.Background(), &login_attempt_model.LoginAttempt{Email: "a@example.com"})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput, got %v", err)
+	}
+}
+
+func TestRecentFailures_StopsAtLastSuccess(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	record(t, repo, nil, "a@example.com", login_attempt_model.OutcomeInvalidPassword, base)
+	record(t, repo, nil, "a@example.com", login_attempt_model.OutcomeSuccess, base.Add(time.Minute))
+	record(t, repo, nil, "a@example.com", login_attempt_model.OutcomeInvalidPassword, base.Add(2*time.Minute))
+	record(t, repo, nil, "a@example.com", login_attempt_model.OutcomeLocked, base.Add(3*time.Minute))
+	record(t, repo, nil, "a@example.com", login_attempt_model.OutcomeThrottled, base.Add(3*time.Minute))
+	record(t, repo, nil, "a@example.com", login_attempt_model.OutcomeUnknownUser, base.Add(4*time.Minute))
+	record(t, repo, nil, "b@example.com", login_attempt_model.OutcomeInvalidPassword, base.Add(5*time.Minute))
+
+	failures, err := repo.RecentFailures(ctx, "a@example.com", 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(failures) != 2 {
+		t.Fatalf("expected 2 failures after last success, got %d", len(failures))
+	}
+	if !failures[0].Equal(base.Add(4*time.Minute)) || !failures[1].Equal(base.Add(2*time.Minute)) {
+		t.Errorf("expected failures newest first, got %v", failures)
+	}
+
+	failures, err = repo.RecentFailures(ctx, "a@example.com", 1)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(failures) != 1 {
+		t.Errorf("expected limit to be applied, got %d failures", len(failures))
+	}
+}
+
+func TestGetByUserID_Paginates(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	userID, otherID := uint(1), uint(2)
+
+	for i := 0; i < 3; i++ {
+		record(t, repo, &userID, "a@example.com", login_attempt_model.OutcomeSuccess, base.Add(time.Duration(i)*time.Minute))
+	}
+	record(t, repo, &otherID, "b@example.com", login_attempt_model.OutcomeSuccess, base)
+	record(t, repo, nil, "a@example.com", login_attempt_model.OutcomeUnknownUser, base)
+
+	attempts, total, err := repo.GetByUserID(ctx, userID, 1, 2)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if total != 3 || len(attempts) != 2 {
+		t.Fatalf("expected 2 of 3 attempts, got %d of %d", len(attempts), total)
+	}
+	if !attempts[0].CreatedAt.Equal(base.Add(2 * time.Minute)) {
+		t.Errorf("expected newest attempt first, got %v", attempts[0].CreatedAt)
+	}
+
+	attempts, _, err = repo.GetByUserID(ctx, userID, 2, 2)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(attempts) != 1 {
+		t.Errorf("expected 1 attempt on second page, got %d", len(attempts))
+	}
+
+	if _, _, err := repo.GetByUserID(ctx, userID, 0, 2); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput, got %v", err)
+	}
+}
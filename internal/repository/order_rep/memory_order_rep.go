@@ -0,0 +1,298 @@
+package order_rep
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/order_model"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// memoryOrderRepository хранит заказы и остатки продуктов в памяти процесса.
+// Поведение совпадает с GormOrderRepository, что проверяет общий набор тестов:
+// резерв товара, оптимистичная блокировка по версии и мягкое удаление.
+type memoryOrderRepository struct {
+	mu         sync.Mutex
+	orders     map[uint]*order_model.Order
+	stock      map[uint]int // Свободный остаток по ID продукта; отсутствующий продукт имеет нулевой остаток
+	nextID     uint
+	nextItemID uint
+	log        *logrus.Logger
+}
+
+// NewMemoryOrderRepository создает репозиторий заказов в памяти процесса.
+// stock задает начальный свободный остаток продуктов по их ID; карта копируется.
+func NewMemoryOrderRepository(stock map[uint]int, log *logrus.Logger) OrderRepository {
+	if log == nil {
+		defaultLog := logrus.New()
+		defaultLog.SetLevel(logrus.InfoLevel)
+		defaultLog.Warn("Экземпляр Logrus logger равен nil в NewMemoryOrderRepository, используется логгер по умолчанию")
+		log = defaultLog
+	}
+	r := &memoryOrderRepository{
+		orders: make(map[uint]*order_model.Order),
+		stock:  make(map[uint]int, len(stock)),
+		log:    log,
+	}
+	for id, qty := range stock {
+		r.stock[id] = qty
+	}
+	return r
+}
+
+// copyOrder возвращает глубокую копию заказа, чтобы вызывающий код не менял хранимые данные
+func copyOrder(order *order_model.Order) *order_model.Order {
+	clone := *order
+	clone.Items = make([]order_model.OrderItem, len(order.Items))
+	for i, item := range order.Items {
+		if item.ProductID != nil {
+			productID := *item.ProductID
+			item.ProductID = &productID
+		}
+		clone.Items[i] = item
+	}
+	return &clone
+}
+
+// findLocked возвращает неудаленный заказ пользователя. Вызывается под блокировкой.
+func (r *memoryOrderRepository) findLocked(orderID, userID uint) (*order_model.Order, bool) {
+	order, ok := r.orders[orderID]
+	if !ok || order.UserID != userID || order.DeletedAt.Valid {
+		return nil, false
+	}
+	return order, true
+}
+
+// reserveLocked списывает количество позиций со свободного остатка. Если остатка
+// какого-либо продукта не хватает, остатки не меняются. Вызывается под блокировкой.
+func (r *memoryOrderRepository) reserveLocked(items []order_model.OrderItem) error {
+	ids, quantities := stockByProduct(items)
+	for _, id := range ids {
+		if r.stock[id] < quantities[id] {
+			return &InsufficientStockError{ProductID: id, Requested: quantities[id]}
+		}
+	}
+	for _, id := range ids {
+		r.stock[id] -= quantities[id]
+	}
+	return nil
+}
+
+// releaseLocked возвращает количество позиций в свободный остаток. Вызывается под блокировкой.
+func (r *memoryOrderRepository) releaseLocked(items []order_model.OrderItem) {
+	ids, quantities := stockByProduct(items)
+	for _, id := range ids {
+		r.stock[id] += quantities[id]
+	}
+}
+
+// storeItemsLocked назначает позициям ID и привязывает их к заказу. Вызывается под блокировкой.
+func (r *memoryOrderRepository) storeItemsLocked(order *order_model.Order, now time.Time) {
+	for i := range order.Items {
+		r.nextItemID++
+		order.Items[i].ID = r.nextItemID
+		order.Items[i].OrderID = order.ID
+		order.Items[i].CreatedAt = now
+		order.Items[i].UpdatedAt = now
+	}
+}
+
+// Create сохраняет новый заказ и резервирует товар по его позициям
+func (r *memoryOrderRepository) Create(ctx context.Context, order *order_model.Order) error {
+	logger := r.log.WithContext(ctx).WithField("method", "MemoryOrderRepository.Create")
+	if order == nil {
+		logger.Warn("Попытка создать nil заказ")
+		return fmt.Errorf("%w: невозможно создать nil заказ", ErrDatabaseError)
+	}
+	logger = logger.WithFields(logrus.Fields{"user_id": order.UserID, "items_count": len(order.Items)})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.reserveLocked(order.Items); err != nil {
+		logger.WithError(err).Warn("Заказ не создан: недостаточно товара на складе")
+		return err
+	}
+
+	// Значения по умолчанию те же, что в схеме базы данных
+	if order.Version == 0 {
+		order.Version = 1
+	}
+	if order.Status == "" {
+		order.Status = order_model.StatusPending
+	}
+	if order.Currency == "" {
+		order.Currency = "RUB"
+	}
+	now := time.Now()
+	r.nextID++
+	order.ID = r.nextID
+	order.CreatedAt, order.UpdatedAt = now, now
+	r.storeItemsLocked(order, now)
+	r.orders[order.ID] = copyOrder(order)
+
+	logger.WithField("order_id", order.ID).Info("Заказ успешно создан")
+	return nil
+}
+
+// GetByID возвращает копию заказа по его ID и ID пользователя для проверки владения
+func (r *memoryOrderRepository) GetByID(ctx context.Context, orderID uint, userID uint) (*order_model.Order, error) {
+	logger := r.log.WithContext(ctx).WithField(
+		"method", "MemoryOrderRepository.GetByID").WithFields(logrus.Fields{"order_id": orderID, "user_id": userID})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order, ok := r.findLocked(orderID, userID)
+	if !ok {
+		logger.Warn("Заказ не найден для данного ID и ID пользователя")
+		return nil, ErrOrderNotFound
+	}
+	return copyOrder(order), nil
+}
+
+// GetAllByUser возвращает страницу заказов пользователя, упорядоченных по ID
+func (r *memoryOrderRepository) GetAllByUser(
+	ctx context.Context, userID uint, params ListQueryParams,
+) ([]order_model.Order, int64, error) {
+	logger := r.log.WithContext(ctx).WithField("method", "MemoryOrderRepository.GetAllByUser").WithField(
+		"user_id", userID)
+	if userID == 0 {
+		logger.Warn("Попытка получить заказы для пользователя с нулевым ID")
+		return nil, 0, fmt.Errorf("%w: ID пользователя должен быть положительным", ErrDatabaseError)
+	}
+	offset, limit := max(params.Offset, 0), params.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+
+	r.mu.Lock()
+	var matched []order_model.Order
+	for _, order := range r.orders {
+		if order.UserID != userID || order.DeletedAt.Valid {
+			continue
+		}
+		if params.Status != nil && order.Status != *params.Status {
+			continue
+		}
+		matched = append(matched, *copyOrder(order))
+	}
+	r.mu.Unlock()
+
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID < matched[j].ID })
+	total := int64(len(matched))
+	offset = min(offset, len(matched))
+	page := matched[offset:min(offset+limit, len(matched))]
+
+	logger.WithField("count", len(page)).Debug("Заказы получены по ID пользователя")
+	return page, total, nil
+}
+
+// Update заменяет позиции и валюту заказа, если его версия равна order.Version.
+// Для заказа, удерживающего резерв, резерв пересчитывается по новым позициям.
+func (r *memoryOrderRepository) Update(ctx context.Context, order *order_model.Order) error {
+	if order == nil || order.ID == 0 || order.UserID == 0 {
+		r.log.WithContext(ctx).WithField("method", "MemoryOrderRepository.Update").
+			Warn("Попытка обновить nil заказ или заказ с нулевым ID/ID пользователя")
+		return fmt.Errorf("%w: неверный объект заказа для обновления", ErrDatabaseError)
+	}
+	logger := r.log.WithContext(ctx).WithField("method", "MemoryOrderRepository.Update").WithFields(
+		logrus.Fields{"order_id": order.ID, "user_id": order.UserID})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.findLocked(order.ID, order.UserID)
+	if !ok {
+		logger.Warn("Заказ для обновления не найден или не принадлежит пользователю")
+		return ErrOrderNotFound
+	}
+	if stored.Version != order.Version {
+		logger.WithField("version", order.Version).Warn("Заказ не обновлен: версия изменилась после чтения")
+		return ErrVersionMismatch
+	}
+
+	if stored.Status.HoldsStock() {
+		r.releaseLocked(stored.Items)
+		if err := r.reserveLocked(order.Items); err != nil {
+			// Возвращаем резерв старых позиций, как при откате транзакции
+			_ = r.reserveLocked(stored.Items)
+			logger.WithError(err).Warn("Заказ не обновлен: недостаточно товара на складе")
+			return err
+		}
+	}
+
+	now := time.Now()
+	r.storeItemsLocked(order, now)
+	updated := copyOrder(order)
+	stored.Items = updated.Items
+	if order.Currency != "" {
+		stored.Currency = order.Currency
+	}
+	stored.UpdatedAt = now
+	stored.Version++
+	order.Version++
+
+	logger.Info("Заказ успешно обновлен")
+	return nil
+}
+
+// UpdateStatus переводит заказ из состояния from в состояние to.
+// Если заказ находится в другом состоянии, возвращается ErrNoRowsAffected.
+func (r *memoryOrderRepository) UpdateStatus(
+	ctx context.Context, orderID uint, userID uint, from, to order_model.OrderStatus,
+) error {
+	logger := r.log.WithContext(ctx).WithField("method", "MemoryOrderRepository.UpdateStatus").WithFields(
+		logrus.Fields{"order_id": orderID, "user_id": userID, "from": from, "to": to})
+	if orderID == 0 || userID == 0 {
+		logger.Warn("Попытка изменить состояние заказа с нулевым ID или ID пользователя")
+		return fmt.Errorf("%w: неверный ID заказа или ID пользователя для смены состояния", ErrDatabaseError)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.findLocked(orderID, userID)
+	if !ok || stored.Status != from {
+		logger.Warn("Заказ не найден или его состояние уже изменилось")
+		return ErrNoRowsAffected
+	}
+	stored.Status = to
+	stored.Version++
+	if from.ReleasesStockOn(to) {
+		r.releaseLocked(stored.Items)
+	}
+
+	logger.Info("Состояние заказа успешно изменено")
+	return nil
+}
+
+// Delete мягко удаляет заказ; если заказ удерживал резерв товара, резерв снимается
+func (r *memoryOrderRepository) Delete(ctx context.Context, orderID uint, userID uint) error {
+	logger := r.log.WithContext(ctx).WithField(
+		"method", "MemoryOrderRepository.Delete").WithFields(logrus.Fields{"order_id": orderID, "user_id": userID})
+	if orderID == 0 || userID == 0 {
+		logger.Warn("Попытка удалить заказ с нулевым ID или ID пользователя")
+		return fmt.Errorf("%w: неверный ID заказа или ID пользователя для удаления", ErrDatabaseError)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.findLocked(orderID, userID)
+	if !ok {
+		logger.Warn("Заказ для удаления не найден или не принадлежит пользователю")
+		return ErrOrderNotFound
+	}
+	stored.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	if stored.Status.HoldsStock() {
+		r.releaseLocked(stored.Items)
+	}
+
+	logger.Info("Заказ успешно удален")
+	return nil
+}
@@ -4,8 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/IlyushinDM/user-order-api/internal/models/order_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/product_model"
+	"github.com/IlyushinDM/user-order-api/internal/repository/database"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
@@ -16,17 +20,45 @@ var (
 	ErrDatabaseError         = errors.New("ошибка базы данных")
 	ErrNoRowsAffected        = errors.New("ни одна запись не затронута")
 	ErrOrderNotBelongsToUser = errors.New("заказ не принадлежит пользователю")
+	ErrInsufficientStock     = errors.New("недостаточно товара на складе")
+	// ErrVersionMismatch означает, что заказ изменен другим запросом после чтения
+	ErrVersionMismatch = errors.New("версия заказа не совпадает")
 )
 
+// InsufficientStockError описывает продукт, остатка которого не хватило для резерва.
+// Сопоставляется с ErrInsufficientStock через errors.Is.
+type InsufficientStockError struct {
+	ProductID uint
+	Requested int
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("%s: продукт %d, запрошено %d", ErrInsufficientStock.Error(), e.ProductID, e.Requested)
+}
+
+// Is позволяет проверять ошибку через errors.Is(err, ErrInsufficientStock)
+func (e *InsufficientStockError) Is(target error) bool {
+	return target == ErrInsufficientStock
+}
+
 // OrderRepository определяет интерфейс для взаимодействия с данными заказов в базе данных.
 type OrderRepository interface {
 	Create(ctx context.Context, order *order_model.Order) error
 	GetByID(ctx context.Context, orderID uint, userID uint) (*order_model.Order, error)
-	GetAllByUser(ctx context.Context, userID uint, offset, limit int) ([]order_model.Order, int64, error)
+	GetAllByUser(ctx context.Context, userID uint, params ListQueryParams) ([]order_model.Order, int64, error)
 	Update(ctx context.Context, order *order_model.Order) error
+	UpdateStatus(ctx context.Context, orderID uint, userID uint, from, to order_model.OrderStatus) error
 	Delete(ctx context.Context, orderID uint, userID uint) error
 }
 
+// ListQueryParams содержит параметры пагинации и фильтры для GetAllByUser
+type ListQueryParams struct {
+	Offset int
+	Limit  int
+	// Фильтры: nil означает отсутствие фильтра
+	Status *order_model.OrderStatus
+}
+
 // orderRepository реализует интерфейс OrderRepository с использованием GORM.
 type orderRepository struct {
 	db  *gorm.DB
@@ -47,7 +79,13 @@ func NewGormOrderRepository(db *gorm.DB, log *logrus.Logger) OrderRepository {
 	return &orderRepository{db: db, log: log}
 }
 
-// Create вставляет новый заказ в базу данных.
+// conn возвращает транзакцию из контекста, если она открыта через database.TxManager, иначе r.db.
+// Собственные транзакции репозитория внутри внешней становятся точками сохранения.
+func (r *orderRepository) conn(ctx context.Context) *gorm.DB {
+	return database.Conn(ctx, r.db)
+}
+
+// Create вставляет новый заказ вместе с его позициями в одной транзакции.
 func (r *orderRepository) Create(ctx context.Context, order *order_model.Order) error {
 	logger := r.log.WithContext(ctx).WithField("method", "OrderRepository.Create")
 	if order == nil {
@@ -57,23 +95,121 @@ func (r *orderRepository) Create(ctx context.Context, order *order_model.Order)
 
 	// Добавление логов для отладки
 	logger = logger.WithFields(logrus.Fields{
-		"user_id":      order.UserID,
-		"product_name": order.ProductName,
-		"quantity":     order.Quantity,
-		"price":        order.Price,
+		"user_id":     order.UserID,
+		"items_count": len(order.Items),
 	})
 	logger.Debug("Создание нового заказа")
+	if order.Version == 0 {
+		order.Version = 1
+	}
 
-	result := r.db.WithContext(ctx).Create(order)
-	if result.Error != nil {
-		logger.WithError(result.Error).Error("Не удалось создать заказ в базе данных")
-		return fmt.Errorf("%w: %v", ErrDatabaseError, result.Error)
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Items").Create(order).Error; err != nil {
+			return err
+		}
+		if err := reserveStock(tx, order.Items); err != nil {
+			return err
+		}
+		return createItems(tx, order)
+	})
+	if errors.Is(err, ErrInsufficientStock) {
+		logger.WithError(err).Warn("Заказ не создан: недостаточно товара на складе")
+		return err
+	}
+	if err != nil {
+		logger.WithError(err).Error("Не удалось создать заказ в базе данных")
+		return fmt.Errorf("%w: %v", ErrDatabaseError, err)
 	}
 
 	logger.WithField("order_id", order.ID).Info("Заказ успешно создан")
 	return nil
 }
 
+// createItems сохраняет позиции заказа, привязывая их к order.ID
+func createItems(tx *gorm.DB, order *order_model.Order) error {
+	if len(order.Items) == 0 {
+		return nil
+	}
+	for i := range order.Items {
+		order.Items[i].ID = 0
+		order.Items[i].OrderID = order.ID
+	}
+	return tx.Create(&order.Items).Error
+}
+
+// stockByProduct суммирует количество по продуктам каталога; позиции без продукта пропускаются.
+// ID возвращаются по возрастанию, чтобы параллельные транзакции блокировали строки
+// продуктов в одном порядке и не попадали во взаимную блокировку.
+func stockByProduct(items []order_model.OrderItem) ([]uint, map[uint]int) {
+	quantities := make(map[uint]int)
+	for _, item := range items {
+		if item.ProductID != nil {
+			quantities[*item.ProductID] += item.Quantity
+		}
+	}
+	ids := make([]uint, 0, len(quantities))
+	for id := range quantities {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, quantities
+}
+
+// reserveStock списывает количество позиций со свободного остатка продуктов.
+// Условное обновление (stock >= ?) атомарно относительно параллельных заказов:
+// если остатка не хватает, ни одна строка не затрагивается и возвращается InsufficientStockError.
+func reserveStock(tx *gorm.DB, items []order_model.OrderItem) error {
+	ids, quantities := stockByProduct(items)
+	for _, id := range ids {
+		result := tx.Model(&product_model.Product{}).
+			Where("id = ? AND stock >= ?", id, quantities[id]).
+			UpdateColumn("stock", gorm.Expr("stock - ?", quantities[id]))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &InsufficientStockError{ProductID: id, Requested: quantities[id]}
+		}
+	}
+	return nil
+}
+
+// releaseStock возвращает количество позиций в свободный остаток продуктов.
+// Остаток возвращается и удаленным продуктам, чтобы их восстановление не теряло товар.
+func releaseStock(tx *gorm.DB, items []order_model.OrderItem) error {
+	ids, quantities := stockByProduct(items)
+	for _, id := range ids {
+		err := tx.Unscoped().Model(&product_model.Product{}).
+			Where("id = ?", id).
+			UpdateColumn("stock", gorm.Expr("stock + ?", quantities[id])).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockedOrderState возвращает состояние и позиции заказа. Вызывается после изменения строки
+// заказа в той же транзакции, поэтому видит данные, зафиксированные конкурирующими запросами.
+func lockedOrderState(tx *gorm.DB, orderID uint) (order_model.OrderStatus, []order_model.OrderItem, error) {
+	var current order_model.Order
+	if err := tx.Unscoped().Select("id", "status").First(&current, orderID).Error; err != nil {
+		return "", nil, err
+	}
+	var items []order_model.OrderItem
+	if err := tx.Where("order_id = ?", orderID).Find(&items).Error; err != nil {
+		return "", nil, err
+	}
+	return current.Status, items, nil
+}
+
+// preloadItems подгружает позиции заказа в порядке их добавления
+func preloadItems(db *gorm.DB) *gorm.DB {
+	return db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	})
+}
+
 // GetByID извлекает заказ по его ID и ID пользователя для проверки владения.
 func (r *orderRepository) GetByID(ctx context.Context, orderID uint, userID uint) (*order_model.Order, error) {
 	logger := r.log.WithContext(ctx).WithField(
@@ -85,7 +221,7 @@ func (r *orderRepository) GetByID(ctx context.Context, orderID uint, userID uint
 
 	logger.Debug("Получение заказа по ID и ID пользователя")
 	var order order_model.Order
-	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", orderID, userID).First(&order)
+	result := preloadItems(r.conn(ctx)).Where("id = ? AND user_id = ?", orderID, userID).First(&order)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -100,10 +236,11 @@ func (r *orderRepository) GetByID(ctx context.Context, orderID uint, userID uint
 	return &order, nil
 }
 
-// GetAllByUser извлекает все заказы для конкретного пользователя с пагинацией.
+// GetAllByUser извлекает все заказы для конкретного пользователя с пагинацией и фильтрами.
 func (r *orderRepository) GetAllByUser(
-	ctx context.Context, userID uint, offset, limit int,
+	ctx context.Context, userID uint, params ListQueryParams,
 ) ([]order_model.Order, int64, error) {
+	offset, limit := params.Offset, params.Limit
 	logger := r.log.WithContext(ctx).WithField("method", "OrderRepository.GetAllByUser").WithField(
 		"user_id", userID).WithFields(logrus.Fields{"offset": offset, "limit": limit})
 	if userID == 0 {
@@ -125,13 +262,19 @@ func (r *orderRepository) GetAllByUser(
 	var orders []order_model.Order
 	var total int64
 
-	countResult := r.db.WithContext(ctx).Model(&order_model.Order{}).Where("user_id = ?", userID).Count(&total)
+	query := r.conn(ctx).Model(&order_model.Order{}).Where("user_id = ?", userID)
+	if params.Status != nil {
+		query = query.Where("status = ?", *params.Status)
+		logger.Debugf("Применение фильтра: status = %s", *params.Status)
+	}
+
+	countResult := query.Session(&gorm.Session{}).Count(&total)
 	if countResult.Error != nil {
 		logger.WithError(countResult.Error).Error("Не удалось получить общее количество заказов для пользователя")
 		return nil, 0, fmt.Errorf("%w: не удалось подсчитать заказы пользователя", ErrDatabaseError)
 	}
 
-	result := r.db.WithContext(ctx).Where("user_id = ?", userID).Offset(offset).Limit(limit).Find(&orders)
+	result := preloadItems(query.Session(&gorm.Session{})).Order("id").Offset(offset).Limit(limit).Find(&orders)
 	if result.Error != nil {
 		// Если это не ErrRecordNotFound (который для Find просто означает пустой список, а не ошибку)
 		if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -144,33 +287,144 @@ func (r *orderRepository) GetAllByUser(
 	return orders, total, nil
 }
 
-// Обновление изменяет существующий порядок в базе данных
+// orderUpdateColumns возвращает изменяемые колонки заказа; пустая валюта не перезаписывается
+func orderUpdateColumns(order *order_model.Order) map[string]any {
+	columns := map[string]any{"updated_at": time.Now(), "version": gorm.Expr("version + 1")}
+	if order.Currency != "" {
+		columns["currency"] = order.Currency
+	}
+	return columns
+}
+
+// Update изменяет существующий заказ и заменяет его позиции в одной транзакции.
+// Обновление условное по order.Version: если заказ изменили после чтения,
+// возвращается ErrVersionMismatch. При успехе order.Version увеличивается.
 func (r *orderRepository) Update(ctx context.Context, order *order_model.Order) error {
-	logger := r.log.WithContext(ctx).WithField("method", "OrderRepository.Update").WithField(
-		"order_id", order.ID).WithField("user_id", order.UserID)
 	if order == nil || order.ID == 0 || order.UserID == 0 {
-		logger.Warn("Попытка обновить nil заказ или заказ с нулевым ID/ID пользователя")
+		r.log.WithContext(ctx).WithField("method", "OrderRepository.Update").
+			Warn("Попытка обновить nil заказ или заказ с нулевым ID/ID пользователя")
 		return fmt.Errorf("%w: неверный объект заказа для обновления", ErrDatabaseError)
 	}
+	logger := r.log.WithContext(ctx).WithField("method", "OrderRepository.Update").WithField(
+		"order_id", order.ID).WithField("user_id", order.UserID)
 
 	logger.Debug("Обновление заказа в базе данных")
-	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", order.ID, order.UserID).Save(order)
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		// Состояние заказа меняется только через UpdateStatus, чтобы переходы проверялись атомарно
+		result := tx.Model(&order_model.Order{}).
+			Where("id = ? AND user_id = ? AND version = ?", order.ID, order.UserID, order.Version).
+			Updates(orderUpdateColumns(order))
+		if result.Error != nil {
+			return result.Error
+		}
+		// Проверка rows affected полезна для операций обновления/удаления, чтобы понять, была ли запись найдена
+		if result.RowsAffected == 0 {
+			var count int64
+			if err := tx.Model(&order_model.Order{}).
+				Where("id = ? AND user_id = ?", order.ID, order.UserID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrVersionMismatch
+			}
+			return ErrOrderNotFound
+		}
 
-	if result.Error != nil {
-		logger.WithError(result.Error).Error("Не удалось обновить заказ в базе данных")
-		return fmt.Errorf("%w: %v", ErrDatabaseError, result.Error)
-	}
-	// Проверка rows affected полезна для операций обновления/удаления, чтобы понять, была ли запись найдена
-	if result.RowsAffected == 0 {
+		// Строка заказа заблокирована обновлением выше: резерв старых позиций снимается ровно один раз
+		status, oldItems, err := lockedOrderState(tx, order.ID)
+		if err != nil {
+			return err
+		}
+		if status.HoldsStock() {
+			if err := releaseStock(tx, oldItems); err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("order_id = ?", order.ID).Delete(&order_model.OrderItem{}).Error; err != nil {
+			return err
+		}
+		if status.HoldsStock() {
+			if err := reserveStock(tx, order.Items); err != nil {
+				return err
+			}
+		}
+		return createItems(tx, order)
+	})
+
+	if errors.Is(err, ErrOrderNotFound) {
 		logger.Warn("Операция обновления затронула 0 записей, заказ не найден или не принадлежит пользователю?")
 		return ErrOrderNotFound
 	}
+	if errors.Is(err, ErrVersionMismatch) {
+		logger.WithField("version", order.Version).Warn("Заказ не обновлен: версия изменилась после чтения")
+		return ErrVersionMismatch
+	}
+	if errors.Is(err, ErrInsufficientStock) {
+		logger.WithError(err).Warn("Заказ не обновлен: недостаточно товара на складе")
+		return err
+	}
+	if err != nil {
+		logger.WithError(err).Error("Не удалось обновить заказ в базе данных")
+		return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
 
+	order.Version++
 	logger.Info("Заказ успешно обновлен")
 	return nil
 }
 
-// Удаляет заказ из базы данных по ID и ID пользователя для проверки владения
+// UpdateStatus переводит заказ из состояния from в состояние to.
+// Обновление условное: если заказ уже находится в другом состоянии (например, его
+// параллельно изменил другой запрос), возвращается ErrNoRowsAffected.
+// При отмене или возврате неотправленного заказа резерв товара снимается в той же транзакции.
+// Смена состояния увеличивает версию заказа, поэтому ранее выданный ETag перестает совпадать.
+func (r *orderRepository) UpdateStatus(
+	ctx context.Context, orderID uint, userID uint, from, to order_model.OrderStatus,
+) error {
+	logger := r.log.WithContext(ctx).WithField("method", "OrderRepository.UpdateStatus").WithFields(
+		logrus.Fields{"order_id": orderID, "user_id": userID, "from": from, "to": to})
+	if orderID == 0 || userID == 0 {
+		logger.Warn("Попытка изменить состояние заказа с нулевым ID или ID пользователя")
+		return fmt.Errorf("%w: неверный ID заказа или ID пользователя для смены состояния", ErrDatabaseError)
+	}
+
+	logger.Debug("Смена состояния заказа в базе данных")
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&order_model.Order{}).
+			Where("id = ? AND user_id = ? AND status = ?", orderID, userID, from).
+			Updates(map[string]any{"status": to, "version": gorm.Expr("version + 1")})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNoRowsAffected
+		}
+
+		if !from.ReleasesStockOn(to) {
+			return nil
+		}
+		var items []order_model.OrderItem
+		if err := tx.Where("order_id = ?", orderID).Find(&items).Error; err != nil {
+			return err
+		}
+		return releaseStock(tx, items)
+	})
+
+	if errors.Is(err, ErrNoRowsAffected) {
+		logger.Warn("Смена состояния затронула 0 записей, заказ не найден или его состояние уже изменилось")
+		return ErrNoRowsAffected
+	}
+	if err != nil {
+		logger.WithError(err).Error("Не удалось изменить состояние заказа в базе данных")
+		return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	logger.Info("Состояние заказа успешно изменено")
+	return nil
+}
+
+// Удаляет заказ из базы данных по ID и ID пользователя для проверки владения.
+// Если заказ удерживал резерв товара, резерв снимается в той же транзакции.
 func (r *orderRepository) Delete(ctx context.Context, orderID uint, userID uint) error {
 	logger := r.log.WithContext(ctx).WithField(
 		"method", "OrderRepository.Delete").WithFields(logrus.Fields{"order_id": orderID, "user_id": userID})
@@ -180,18 +434,36 @@ func (r *orderRepository) Delete(ctx context.Context, orderID uint, userID uint)
 	}
 
 	logger.Debug("Удаление заказа из базы данных")
-	// Используем Delete и проверяем user_id для гарантии владения
-	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", orderID, userID).Delete(&order_model.Order{})
+	err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		// Используем Delete и проверяем user_id для гарантии владения.
+		// Мягкое удаление блокирует строку, поэтому повторное удаление затронет 0 записей
+		// и резерв не будет снят дважды.
+		result := tx.Where("id = ? AND user_id = ?", orderID, userID).Delete(&order_model.Order{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrOrderNotFound
+		}
 
-	if result.Error != nil {
-		logger.WithError(result.Error).Error("Не удалось удалить заказ из базы данных")
-		return fmt.Errorf("%w: %v", ErrDatabaseError, result.Error)
-	}
-	// Проверка rows affected
-	if result.RowsAffected == 0 {
+		status, items, err := lockedOrderState(tx, orderID)
+		if err != nil {
+			return err
+		}
+		if !status.HoldsStock() {
+			return nil
+		}
+		return releaseStock(tx, items)
+	})
+
+	if errors.Is(err, ErrOrderNotFound) {
 		logger.Warn("Операция удаления затронула 0 записей, заказ не найден или не принадлежит пользователю?")
 		return ErrOrderNotFound
 	}
+	if err != nil {
+		logger.WithError(err).Error("Не удалось удалить заказ из базы данных")
+		return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
 
 	logger.Info("Заказ успешно удален")
 	return nil
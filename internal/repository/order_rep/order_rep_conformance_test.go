@@ -0,0 +1,306 @@
+package order_rep
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/order_model"
+	"github.com/sirupsen/logrus"
+)
+
+// Общий набор тестов для всех реализаций OrderRepository: GORM и в памяти
+// должны одинаково резервировать товар, проверять версии и мягко удалять заказы.
+
+// conformanceFixture дает тесту репозиторий и доступ к остаткам продуктов его хранилища
+type conformanceFixture struct {
+	repo       OrderRepository
+	addProduct func(stock int) uint
+	stock      func(productID uint) int
+}
+
+func newGormFixture(t *testing.T) *conformanceFixture {
+	repo := newConcurrentTestRepo(t)
+	return &conformanceFixture{
+		repo:       repo,
+		addProduct: func(stock int) uint { return seedProduct(t, repo.db, stock).ID },
+		stock:      func(productID uint) int { return productStock(t, repo.db, productID) },
+	}
+}
+
+func newMemoryFixture(t *testing.T) *conformanceFixture {
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	repo := NewMemoryOrderRepository(nil, log).(*memoryOrderRepository)
+	var nextProductID uint
+	return &conformanceFixture{
+		repo: repo,
+		addProduct: func(stock int) uint {
+			repo.mu.Lock()
+			defer repo.mu.Unlock()
+			nextProductID++
+			repo.stock[nextProductID] = stock
+			return nextProductID
+		},
+		stock: func(productID uint) int {
+			repo.mu.Lock()
+			defer repo.mu.Unlock()
+			return repo.stock[productID]
+		},
+	}
+}
+
+var implementations = map[string]func(t *testing.T) *conformanceFixture{
+	"gorm":   newGormFixture,
+	"memory": newMemoryFixture,
+}
+
+func forEachImplementation(t *testing.T, test func(t *testing.T, f *conformanceFixture)) {
+	for name, newFixture := range implementations {
+		t.Run(name, func(t *testing.T) {
+			test(t, newFixture(t))
+		})
+	}
+}
+
+func mustCreateOrder(t *testing.T, repo OrderRepository, userID uint, items ...order_model.OrderItem) *order_model.Order {
+	t.Helper()
+	order := &order_model.Order{UserID: userID, Items: items}
+	if err := repo.Create(context.Background(), order); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return order
+}
+
+func TestConformance_CreateAndGet(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T, f *conformanceFixture) {
+		ctx := context.Background()
+		productID := f.addProduct(10)
+		order := mustCreateOrder(t, f.repo, 1, stockItem(productID, 2), stockItem(productID, 1))
+		if order.ID == 0 || order.Version != 1 || order.Items[0].OrderID != order.ID {
+			t.Fatalf("непредвиденный заказ после создания: %+v", order)
+		}
+		if got := f.stock(productID); got != 7 {
+			t.Errorf("ожидался остаток 7, получено %d", got)
+		}
+
+		stored, err := f.repo.GetByID(ctx, order.ID, 1)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if stored.Status != order_model.StatusPending || stored.Currency != "RUB" || len(stored.Items) != 2 {
+			t.Errorf("непредвиденный заказ: %+v", stored)
+		}
+
+		if _, err := f.repo.GetByID(ctx, order.ID, 2); !errors.Is(err, ErrOrderNotFound) {
+			t.Errorf("чужой заказ: ожидалась ErrOrderNotFound, получено %v", err)
+		}
+		if _, err := f.repo.GetByID(ctx, 0, 1); !errors.Is(err, ErrOrderNotFound) {
+			t.Errorf("нулевой ID: ожидалась ErrOrderNotFound, получено %v", err)
+		}
+		if err := f.repo.Create(ctx, nil); !errors.Is(err, ErrDatabaseError) {
+			t.Errorf("Create(nil): ожидалась ErrDatabaseError, получено %v", err)
+		}
+	})
+}
+
+func TestConformance_CreateInsufficientStock(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T, f *conformanceFixture) {
+		ctx := context.Background()
+		plenty, scarce := f.addProduct(10), f.addProduct(1)
+
+		order := &order_model.Order{UserID: 1, Items: []order_model.OrderItem{stockItem(plenty, 3), stockItem(scarce, 2)}}
+		err := f.repo.Create(ctx, order)
+		var stockErr *InsufficientStockError
+		if !errors.As(err, &stockErr) || stockErr.ProductID != scarce || stockErr.Requested != 2 {
+			t.Fatalf("ожидалась InsufficientStockError по продукту %d, получено %v", scarce, err)
+		}
+		if f.stock(plenty) != 10 || f.stock(scarce) != 1 {
+			t.Errorf("неудачный заказ изменил остатки: %d, %d", f.stock(plenty), f.stock(scarce))
+		}
+		if _, total, _ := f.repo.GetAllByUser(ctx, 1, ListQueryParams{}); total != 0 {
+			t.Errorf("неудачный заказ сохранен: total=%d", total)
+		}
+	})
+}
+
+func TestConformance_GetAllByUserFiltersAndPagination(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T, f *conformanceFixture) {
+		ctx := context.Background()
+		var ids []uint
+		for i := 0; i < 5; i++ {
+			ids = append(ids, mustCreateOrder(t, f.repo, 1).ID)
+		}
+		mustCreateOrder(t, f.repo, 2)
+		if err := f.repo.UpdateStatus(ctx, ids[1], 1, order_model.StatusPending, order_model.StatusConfirmed); err != nil {
+			t.Fatalf("UpdateStatus: %v", err)
+		}
+		if err := f.repo.Delete(ctx, ids[4], 1); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+
+		orders, total, err := f.repo.GetAllByUser(ctx, 1, ListQueryParams{Offset: 1, Limit: 2})
+		if err != nil || total != 4 || len(orders) != 2 || orders[0].ID != ids[1] || orders[1].ID != ids[2] {
+			t.Errorf("страница: %+v, total=%d, err=%v", orders, total, err)
+		}
+
+		pending := order_model.StatusPending
+		orders, total, err = f.repo.GetAllByUser(ctx, 1, ListQueryParams{Status: &pending})
+		if err != nil || total != 3 || len(orders) != 3 {
+			t.Errorf("фильтр по состоянию: %d заказов, total=%d, err=%v", len(orders), total, err)
+		}
+
+		orders, total, err = f.repo.GetAllByUser(ctx, 1, ListQueryParams{Offset: -5, Limit: 0})
+		if err != nil || total != 4 || len(orders) != 4 {
+			t.Errorf("значения пагинации по умолчанию: %d заказов, total=%d, err=%v", len(orders), total, err)
+		}
+
+		if _, _, err := f.repo.GetAllByUser(ctx, 0, ListQueryParams{}); !errors.Is(err, ErrDatabaseError) {
+			t.Errorf("нулевой пользователь: ожидалась ErrDatabaseError, получено %v", err)
+		}
+	})
+}
+
+func TestConformance_UpdateMovesReservation(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T, f *conformanceFixture) {
+		ctx := context.Background()
+		first, second := f.addProduct(10), f.addProduct(5)
+		order := mustCreateOrder(t, f.repo, 1, stockItem(first, 4))
+		stale := *order
+
+		order.Items = []order_model.OrderItem{stockItem(second, 3)}
+		order.Currency = "USD"
+		if err := f.repo.Update(ctx, order); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if order.Version != 2 {
+			t.Errorf("ожидалась версия 2, получено %d", order.Version)
+		}
+		if f.stock(first) != 10 || f.stock(second) != 2 {
+			t.Errorf("резерв не перенесен: %d, %d", f.stock(first), f.stock(second))
+		}
+		stored, _ := f.repo.GetByID(ctx, order.ID, 1)
+		if stored.Currency != "USD" || len(stored.Items) != 1 || *stored.Items[0].ProductID != second {
+			t.Errorf("непредвиденный заказ после обновления: %+v", stored)
+		}
+
+		if err := f.repo.Update(ctx, &stale); !errors.Is(err, ErrVersionMismatch) {
+			t.Errorf("устаревшая версия: ожидалась ErrVersionMismatch, получено %v", err)
+		}
+
+		// Нехватка остатка оставляет заказ и резерв без изменений
+		order.Items = []order_model.OrderItem{stockItem(second, 6)}
+		if err := f.repo.Update(ctx, order); !errors.Is(err, ErrInsufficientStock) {
+			t.Errorf("ожидалась ErrInsufficientStock, получено %v", err)
+		}
+		if f.stock(second) != 2 || order.Version != 2 {
+			t.Errorf("неудачное обновление изменило состояние: остаток %d, версия %d", f.stock(second), order.Version)
+		}
+
+		missing := &order_model.Order{ID: 999, UserID: 1, Version: 1}
+		if err := f.repo.Update(ctx, missing); !errors.Is(err, ErrOrderNotFound) {
+			t.Errorf("ожидалась ErrOrderNotFound, получено %v", err)
+		}
+		if err := f.repo.Update(ctx, &order_model.Order{UserID: 1}); !errors.Is(err, ErrDatabaseError) {
+			t.Errorf("нулевой ID: ожидалась ErrDatabaseError, получено %v", err)
+		}
+	})
+}
+
+func TestConformance_UpdateStatusReleasesStock(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T, f *conformanceFixture) {
+		ctx := context.Background()
+		productID := f.addProduct(10)
+		order := mustCreateOrder(t, f.repo, 1, stockItem(productID, 4))
+
+		if err := f.repo.UpdateStatus(ctx, order.ID, 1, order_model.StatusConfirmed, order_model.StatusPaid); !errors.Is(err, ErrNoRowsAffected) {
+			t.Errorf("неверное исходное состояние: ожидалась ErrNoRowsAffected, получено %v", err)
+		}
+		if err := f.repo.UpdateStatus(ctx, order.ID, 1, order_model.StatusPending, order_model.StatusCancelled); err != nil {
+			t.Fatalf("UpdateStatus: %v", err)
+		}
+		if got := f.stock(productID); got != 10 {
+			t.Errorf("отмена не вернула резерв: остаток %d", got)
+		}
+		stored, _ := f.repo.GetByID(ctx, order.ID, 1)
+		if stored.Status != order_model.StatusCancelled || stored.Version != 2 {
+			t.Errorf("непредвиденный заказ после отмены: %+v", stored)
+		}
+
+		// Удаление отмененного заказа не возвращает резерв повторно
+		if err := f.repo.Delete(ctx, order.ID, 1); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if got := f.stock(productID); got != 10 {
+			t.Errorf("резерв возвращен дважды: остаток %d", got)
+		}
+		if err := f.repo.UpdateStatus(ctx, 0, 1, order_model.StatusPending, order_model.StatusCancelled); !errors.Is(err, ErrDatabaseError) {
+			t.Errorf("нулевой ID: ожидалась ErrDatabaseError, получено %v", err)
+		}
+	})
+}
+
+func TestConformance_SoftDelete(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T, f *conformanceFixture) {
+		ctx := context.Background()
+		productID := f.addProduct(10)
+		order := mustCreateOrder(t, f.repo, 1, stockItem(productID, 3))
+
+		if err := f.repo.Delete(ctx, order.ID, 2); !errors.Is(err, ErrOrderNotFound) {
+			t.Errorf("чужой заказ: ожидалась ErrOrderNotFound, получено %v", err)
+		}
+		if err := f.repo.Delete(ctx, order.ID, 1); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if got := f.stock(productID); got != 10 {
+			t.Errorf("удаление не вернуло резерв: остаток %d", got)
+		}
+		if _, err := f.repo.GetByID(ctx, order.ID, 1); !errors.Is(err, ErrOrderNotFound) {
+			t.Errorf("удаленный заказ: ожидалась ErrOrderNotFound, получено %v", err)
+		}
+		if err := f.repo.Delete(ctx, order.ID, 1); !errors.Is(err, ErrOrderNotFound) {
+			t.Errorf("повторное удаление: ожидалась ErrOrderNotFound, получено %v", err)
+		}
+		if err := f.repo.UpdateStatus(ctx, order.ID, 1, order_model.StatusPending, order_model.StatusCancelled); !errors.Is(err, ErrNoRowsAffected) {
+			t.Errorf("смена состояния удаленного заказа: ожидалась ErrNoRowsAffected, получено %v", err)
+		}
+		if err := f.repo.Delete(ctx, 0, 1); !errors.Is(err, ErrDatabaseError) {
+			t.Errorf("нулевой ID: ожидалась ErrDatabaseError, получено %v", err)
+		}
+	})
+}
+
+func TestConformance_ConcurrentReservationsDoNotOversell(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T, f *conformanceFixture) {
+		ctx := context.Background()
+		productID := f.addProduct(5)
+
+		const buyers = 10
+		var wg sync.WaitGroup
+		results := make(chan error, buyers)
+		for i := 0; i < buyers; i++ {
+			wg.Add(1)
+			go func(userID uint) {
+				defer wg.Done()
+				order := &order_model.Order{UserID: userID, Items: []order_model.OrderItem{stockItem(productID, 1)}}
+				results <- f.repo.Create(ctx, order)
+			}(uint(i + 1))
+		}
+		wg.Wait()
+		close(results)
+
+		created := 0
+		for err := range results {
+			switch {
+			case err == nil:
+				created++
+			case !errors.Is(err, ErrInsufficientStock):
+				t.Errorf("непредвиденная ошибка: %v", err)
+			}
+		}
+		if created != 5 || f.stock(productID) != 0 {
+			t.Errorf("ожидалось 5 заказов и нулевой остаток, получено %d заказов, остаток %d", created, f.stock(productID))
+		}
+	})
+}
@@ -3,9 +3,12 @@ package order_rep
 import (
 	"context"
 	"errors"
+	"path/filepath"
+	"sync"
 	"testing"
 
 	"github.com/IlyushinDM/user-order-api/internal/models/order_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/product_model"
 	"github.com/sirupsen/logrus"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -20,12 +23,50 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	if err != nil {
 		t.Fatalf("failed to open test db: %v", err)
 	}
-	if err := db.AutoMigrate(&order_model.Order{}); err != nil {
+	if err := db.AutoMigrate(&product_model.Product{}, &order_model.Order{}, &order_model.OrderItem{}); err != nil {
 		t.Fatalf("failed to migrate: %v", err)
 	}
 	return db
 }
 
+// newConcurrentTestRepo создает репозиторий поверх файловой БД: в отличие от :memory:,
+// все соединения пула видят одни и те же данные, а BEGIN IMMEDIATE сериализует записи.
+func newConcurrentTestRepo(t *testing.T) *orderRepository {
+	dsn := filepath.Join(t.TempDir(), "orders.db") + "?_busy_timeout=10000&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if err := db.AutoMigrate(&product_model.Product{}, &order_model.Order{}, &order_model.OrderItem{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	return &orderRepository{db: db, log: log}
+}
+
+func seedProduct(t *testing.T, db *gorm.DB, stock int) *product_model.Product {
+	product := &product_model.Product{Name: "SKU", Price: 10, Stock: stock}
+	if err := db.Create(product).Error; err != nil {
+		t.Fatalf("failed to seed product: %v", err)
+	}
+	return product
+}
+
+func productStock(t *testing.T, db *gorm.DB, id uint) int {
+	var product product_model.Product
+	if err := db.Unscoped().First(&product, id).Error; err != nil {
+		t.Fatalf("failed to load product: %v", err)
+	}
+	return product.Stock
+}
+
+func stockItem(productID uint, quantity int) order_model.OrderItem {
+	return order_model.OrderItem{ProductID: &productID, ProductName: "SKU", Quantity: quantity, UnitPrice: 10}
+}
+
 func newTestRepo(t *testing.T) *orderRepository {
 	db := setupTestDB(t)
 	log := logrus.New()
@@ -35,10 +76,8 @@ func newTestRepo(t *testing.T) *orderRepository {
 func TestCreateOrder_Success(t *testing.T) {
 	repo := newTestRepo(t)
 	order := &order_model.Order{
-		UserID:      1,
-		ProductName: "Test Product",
-		Quantity:    2,
-		Price:       100,
+		UserID: 1,
+		Items:  []order_model.OrderItem{{ProductName: "Test Product", Quantity: 2, UnitPrice: 100}},
 	}
 	err := repo.Create(context.Background(), order)
 	if err != nil {
@@ -49,6 +88,73 @@ func TestCreateOrder_Success(t *testing.T) {
 	}
 }
 
+func TestCreateOrder_WithItems(t *testing.T) {
+	repo := newTestRepo(t)
+	order := &order_model.Order{
+		UserID:   3,
+		Currency: "RUB",
+		Items: []order_model.OrderItem{
+			{ProductName: "A", Quantity: 2, UnitPrice: 1000},
+			{ProductName: "B", Quantity: 1, UnitPrice: 550},
+		},
+	}
+	if err := repo.Create(context.Background(), order); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, item := range order.Items {
+		if item.ID == 0 || item.OrderID != order.ID {
+			t.Errorf("expected item to be persisted and linked to order, got %+v", item)
+		}
+	}
+	got, _ := repo.GetByID(context.Background(), order.ID, order.UserID)
+	if got.Total().String() != "25.50" {
+		t.Errorf("expected total 25.50, got %v", got.Total())
+	}
+	if got.Currency != "RUB" {
+		t.Errorf("expected currency RUB, got %q", got.Currency)
+	}
+}
+
+func TestOrderItem_PriceRoundTripIsExact(t *testing.T) {
+	repo := newTestRepo(t)
+	order := &order_model.Order{UserID: 4, Currency: "USD", Items: []order_model.OrderItem{
+		{ProductName: "A", Quantity: 3, UnitPrice: 10},
+		{ProductName: "B", Quantity: 1, UnitPrice: 20},
+		{ProductName: "C", Quantity: 7, UnitPrice: 1999},
+	}}
+	if err := repo.Create(context.Background(), order); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	got, _ := repo.GetByID(context.Background(), order.ID, order.UserID)
+	if got.Items[0].UnitPrice != 10 || got.Items[2].UnitPrice != 1999 {
+		t.Errorf("unit prices changed after round trip: %+v", got.Items)
+	}
+	if got.Total().String() != "140.43" {
+		t.Errorf("expected total 140.43, got %v", got.Total())
+	}
+}
+
+func TestCreateOrder_RollbackOnItemError(t *testing.T) {
+	repo := newTestRepo(t)
+	// Таблица позиций отсутствует, поэтому вставка позиций завершится ошибкой
+	if err := repo.db.Migrator().DropTable(&order_model.OrderItem{}); err != nil {
+		t.Fatalf("failed to drop items table: %v", err)
+	}
+	order := &order_model.Order{
+		UserID: 4,
+		Items:  []order_model.OrderItem{{ProductName: "A", Quantity: 1, UnitPrice: 1}},
+	}
+	err := repo.Create(context.Background(), order)
+	if !errors.Is(err, ErrDatabaseError) {
+		t.Fatalf("expected ErrDatabaseError, got %v", err)
+	}
+	var ordersCount int64
+	repo.db.Model(&order_model.Order{}).Where("user_id = ?", 4).Count(&ordersCount)
+	if ordersCount != 0 {
+		t.Errorf("expected order insert to be rolled back, found %d orders", ordersCount)
+	}
+}
+
 func TestCreateOrder_NilOrder(t *testing.T) {
 	repo := newTestRepo(t)
 	err := repo.Create(context.Background(), nil)
@@ -60,10 +166,8 @@ func TestCreateOrder_NilOrder(t *testing.T) {
 func TestGetByID_Success(t *testing.T) {
 	repo := newTestRepo(t)
 	order := &order_model.Order{
-		UserID:      2,
-		ProductName: "Test2",
-		Quantity:    1,
-		Price:       50,
+		UserID: 2,
+		Items:  []order_model.OrderItem{{ProductName: "Test2", Quantity: 1, UnitPrice: 50}},
 	}
 	if err := repo.Create(context.Background(), order); err != nil {
 		t.Fatalf("create failed: %v", err)
@@ -75,6 +179,9 @@ func TestGetByID_Success(t *testing.T) {
 	if got.ID != order.ID || got.UserID != order.UserID {
 		t.Errorf("unexpected order returned: %+v", got)
 	}
+	if len(got.Items) != 1 || got.Items[0].ProductName != "Test2" {
+		t.Errorf("expected order items to be loaded, got %+v", got.Items)
+	}
 }
 
 func TestGetByID_NotFound(t *testing.T) {
@@ -98,13 +205,11 @@ func TestGetAllByUser_Success(t *testing.T) {
 	userID := uint(10)
 	for i := 0; i < 5; i++ {
 		repo.Create(context.Background(), &order_model.Order{
-			UserID:      userID,
-			ProductName: "Bulk",
-			Quantity:    1,
-			Price:       10,
+			UserID: userID,
+			Items:  []order_model.OrderItem{{ProductName: "Bulk", Quantity: 1, UnitPrice: 10}},
 		})
 	}
-	orders, total, err := repo.GetAllByUser(context.Background(), userID, 0, 10)
+	orders, total, err := repo.GetAllByUser(context.Background(), userID, ListQueryParams{Offset: 0, Limit: 10})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -115,7 +220,7 @@ func TestGetAllByUser_Success(t *testing.T) {
 
 func TestGetAllByUser_Empty(t *testing.T) {
 	repo := newTestRepo(t)
-	orders, total, err := repo.GetAllByUser(context.Background(), 12345, 0, 10)
+	orders, total, err := repo.GetAllByUser(context.Background(), 12345, ListQueryParams{Offset: 0, Limit: 10})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -126,40 +231,168 @@ func TestGetAllByUser_Empty(t *testing.T) {
 
 func TestGetAllByUser_InvalidUserID(t *testing.T) {
 	repo := newTestRepo(t)
-	_, _, err := repo.GetAllByUser(context.Background(), 0, 0, 10)
+	_, _, err := repo.GetAllByUser(context.Background(), 0, ListQueryParams{Offset: 0, Limit: 10})
 	if err == nil || !errors.Is(err, ErrDatabaseError) {
 		t.Errorf("expected ErrDatabaseError, got %v", err)
 	}
 }
 
+func TestGetAllByUser_StatusFilter(t *testing.T) {
+	repo := newTestRepo(t)
+	userID := uint(11)
+	statuses := []order_model.OrderStatus{
+		order_model.StatusPending, order_model.StatusCancelled, order_model.StatusPending,
+	}
+	for _, status := range statuses {
+		repo.Create(context.Background(), &order_model.Order{
+			UserID: userID,
+			Items:  []order_model.OrderItem{{ProductName: "Filtered", Quantity: 1, UnitPrice: 10}},
+			Status: status,
+		})
+	}
+	status := order_model.StatusPending
+	orders, total, err := repo.GetAllByUser(context.Background(), userID, ListQueryParams{Limit: 10, Status: &status})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(orders) != 2 || total != 2 {
+		t.Errorf("expected 2 pending orders, got %d, total %d", len(orders), total)
+	}
+}
+
+func TestUpdateStatus_Success(t *testing.T) {
+	repo := newTestRepo(t)
+	order := &order_model.Order{UserID: 21, Items: []order_model.OrderItem{{ProductName: "Status", Quantity: 1, UnitPrice: 5}}, Status: order_model.StatusPending}
+	repo.Create(context.Background(), order)
+
+	err := repo.UpdateStatus(context.Background(), order.ID, order.UserID, order_model.StatusPending, order_model.StatusConfirmed)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	got, _ := repo.GetByID(context.Background(), order.ID, order.UserID)
+	if got.Status != order_model.StatusConfirmed {
+		t.Errorf("expected status confirmed, got %s", got.Status)
+	}
+}
+
+func TestUpdateStatus_StaleFromStatus(t *testing.T) {
+	repo := newTestRepo(t)
+	order := &order_model.Order{UserID: 22, Items: []order_model.OrderItem{{ProductName: "Status", Quantity: 1, UnitPrice: 5}}, Status: order_model.StatusCancelled}
+	repo.Create(context.Background(), order)
+
+	err := repo.UpdateStatus(context.Background(), order.ID, order.UserID, order_model.StatusPending, order_model.StatusConfirmed)
+	if !errors.Is(err, ErrNoRowsAffected) {
+		t.Errorf("expected ErrNoRowsAffected, got %v", err)
+	}
+}
+
+func TestUpdateOrder_DoesNotOverwriteStatus(t *testing.T) {
+	repo := newTestRepo(t)
+	order := &order_model.Order{UserID: 23, Items: []order_model.OrderItem{{ProductName: "Old", Quantity: 1, UnitPrice: 5}}, Status: order_model.StatusPending}
+	repo.Create(context.Background(), order)
+	repo.UpdateStatus(context.Background(), order.ID, order.UserID, order_model.StatusPending, order_model.StatusCancelled)
+
+	// Устаревшая копия заказа все еще содержит состояние pending и прежнюю версию
+	order.Items = []order_model.OrderItem{{ProductName: "New", Quantity: 1, UnitPrice: 5}}
+	if err := repo.Update(context.Background(), order); !errors.Is(err, ErrVersionMismatch) {
+		t.Fatalf("expected ErrVersionMismatch, got %v", err)
+	}
+	got, _ := repo.GetByID(context.Background(), order.ID, order.UserID)
+	if got.Status != order_model.StatusCancelled {
+		t.Errorf("expected status to stay cancelled, got %s", got.Status)
+	}
+	if len(got.Items) != 1 || got.Items[0].ProductName != "Old" {
+		t.Errorf("expected items to stay unchanged, got %+v", got.Items)
+	}
+}
+
+func TestUpdateOrder_IncrementsVersion(t *testing.T) {
+	repo := newTestRepo(t)
+	order := &order_model.Order{UserID: 24, Items: []order_model.OrderItem{{ProductName: "Old", Quantity: 1, UnitPrice: 5}}}
+	repo.Create(context.Background(), order)
+	if order.Version != 1 {
+		t.Fatalf("expected new order to have version 1, got %d", order.Version)
+	}
+
+	order.Items = []order_model.OrderItem{{ProductName: "New", Quantity: 1, UnitPrice: 5}}
+	if err := repo.Update(context.Background(), order); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	got, _ := repo.GetByID(context.Background(), order.ID, order.UserID)
+	if order.Version != 2 || got.Version != 2 {
+		t.Errorf("expected version 2, got in-memory %d, stored %d", order.Version, got.Version)
+	}
+}
+
+func TestUpdateOrder_StaleVersion(t *testing.T) {
+	repo := newTestRepo(t)
+	order := &order_model.Order{UserID: 25, Items: []order_model.OrderItem{{ProductName: "Old", Quantity: 1, UnitPrice: 5}}}
+	repo.Create(context.Background(), order)
+
+	// Два клиента прочитали заказ с одной и той же версией
+	first, _ := repo.GetByID(context.Background(), order.ID, order.UserID)
+	second, _ := repo.GetByID(context.Background(), order.ID, order.UserID)
+
+	first.Items = []order_model.OrderItem{{ProductName: "First", Quantity: 1, UnitPrice: 5}}
+	if err := repo.Update(context.Background(), first); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	second.Items = []order_model.OrderItem{{ProductName: "Second", Quantity: 1, UnitPrice: 5}}
+	if err := repo.Update(context.Background(), second); !errors.Is(err, ErrVersionMismatch) {
+		t.Fatalf("expected ErrVersionMismatch, got %v", err)
+	}
+
+	got, _ := repo.GetByID(context.Background(), order.ID, order.UserID)
+	if len(got.Items) != 1 || got.Items[0].ProductName != "First" {
+		t.Errorf("expected first update to survive, got %+v", got.Items)
+	}
+}
+
+func TestUpdateStatus_IncrementsVersion(t *testing.T) {
+	repo := newTestRepo(t)
+	order := &order_model.Order{UserID: 26, Items: []order_model.OrderItem{{ProductName: "Status", Quantity: 1, UnitPrice: 5}}, Status: order_model.StatusPending}
+	repo.Create(context.Background(), order)
+
+	if err := repo.UpdateStatus(context.Background(), order.ID, order.UserID, order_model.StatusPending, order_model.StatusConfirmed); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	got, _ := repo.GetByID(context.Background(), order.ID, order.UserID)
+	if got.Version != 2 {
+		t.Errorf("expected version 2, got %d", got.Version)
+	}
+}
+
 func TestUpdateOrder_Success(t *testing.T) {
 	repo := newTestRepo(t)
 	order := &order_model.Order{
-		UserID:      20,
-		ProductName: "Old",
-		Quantity:    1,
-		Price:       5,
+		UserID: 20,
+		Items:  []order_model.OrderItem{{ProductName: "Old", Quantity: 1, UnitPrice: 5}},
 	}
 	repo.Create(context.Background(), order)
-	order.ProductName = "New"
-	order.Quantity = 2
+	order.Items = []order_model.OrderItem{
+		{ProductName: "New", Quantity: 2, UnitPrice: 5},
+		{ProductName: "Extra", Quantity: 1, UnitPrice: 3},
+	}
 	err := repo.Update(context.Background(), order)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
 	got, _ := repo.GetByID(context.Background(), order.ID, order.UserID)
-	if got.ProductName != "New" || got.Quantity != 2 {
+	if len(got.Items) != 2 || got.Items[0].ProductName != "New" || got.Items[0].Quantity != 2 {
 		t.Errorf("update did not persist: %+v", got)
 	}
+	var itemsCount int64
+	repo.db.Model(&order_model.OrderItem{}).Where("order_id = ?", order.ID).Count(&itemsCount)
+	if itemsCount != 2 {
+		t.Errorf("expected old items to be replaced, got %d items", itemsCount)
+	}
 }
 
 func TestDeleteOrder_Success(t *testing.T) {
 	repo := newTestRepo(t)
 	order := &order_model.Order{
-		UserID:      30,
-		ProductName: "DeleteMe",
-		Quantity:    1,
-		Price:       1,
+		UserID: 30,
+		Items:  []order_model.OrderItem{{ProductName: "DeleteMe", Quantity: 1, UnitPrice: 1}},
 	}
 	repo.Create(context.Background(), order)
 	err := repo.Delete(context.Background(), order.ID, order.UserID)
@@ -195,3 +428,248 @@ func TestNewGormOrderRepository_NilLogger(t *testing.T) {
 		t.Error("expected repo, got nil")
 	}
 }
+
+func TestCreateOrder_ReservesStock(t *testing.T) {
+	repo := newTestRepo(t)
+	product := seedProduct(t, repo.db, 5)
+
+	order := &order_model.Order{UserID: 40, Status: order_model.StatusPending,
+		Items: []order_model.OrderItem{stockItem(product.ID, 2), stockItem(product.ID, 1)}}
+	if err := repo.Create(context.Background(), order); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := productStock(t, repo.db, product.ID); got != 2 {
+		t.Errorf("expected stock 2 after reservation, got %d", got)
+	}
+}
+
+func TestCreateOrder_InsufficientStockRollsBack(t *testing.T) {
+	repo := newTestRepo(t)
+	plenty := seedProduct(t, repo.db, 10)
+	scarce := seedProduct(t, repo.db, 1)
+
+	order := &order_model.Order{UserID: 41, Status: order_model.StatusPending,
+		Items: []order_model.OrderItem{stockItem(plenty.ID, 3), stockItem(scarce.ID, 2)}}
+	err := repo.Create(context.Background(), order)
+
+	var stockErr *InsufficientStockError
+	if !errors.As(err, &stockErr) || stockErr.ProductID != scarce.ID {
+		t.Fatalf("expected InsufficientStockError for product %d, got %v", scarce.ID, err)
+	}
+	if !errors.Is(err, ErrInsufficientStock) {
+		t.Errorf("expected ErrInsufficientStock, got %v", err)
+	}
+	if got := productStock(t, repo.db, plenty.ID); got != 10 {
+		t.Errorf("expected reservation of other products to roll back, stock %d", got)
+	}
+	var ordersCount int64
+	repo.db.Model(&order_model.Order{}).Where("user_id = ?", 41).Count(&ordersCount)
+	if ordersCount != 0 {
+		t.Errorf("expected order to be rolled back, found %d", ordersCount)
+	}
+}
+
+func TestUpdateOrder_MovesReservation(t *testing.T) {
+	repo := newTestRepo(t)
+	first := seedProduct(t, repo.db, 5)
+	second := seedProduct(t, repo.db, 5)
+
+	order := &order_model.Order{UserID: 42, Status: order_model.StatusPending,
+		Items: []order_model.OrderItem{stockItem(first.ID, 4)}}
+	if err := repo.Create(context.Background(), order); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	order.Items = []order_model.OrderItem{stockItem(first.ID, 1), stockItem(second.ID, 5)}
+	if err := repo.Update(context.Background(), order); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := productStock(t, repo.db, first.ID); got != 4 {
+		t.Errorf("expected first stock 4, got %d", got)
+	}
+	if got := productStock(t, repo.db, second.ID); got != 0 {
+		t.Errorf("expected second stock 0, got %d", got)
+	}
+
+	order.Items = []order_model.OrderItem{stockItem(second.ID, 6)}
+	if err := repo.Update(context.Background(), order); !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("expected ErrInsufficientStock, got %v", err)
+	}
+	if got := productStock(t, repo.db, second.ID); got != 0 {
+		t.Errorf("expected failed update to keep reservation, stock %d", got)
+	}
+}
+
+func TestUpdateStatus_ReleasesStock(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      []order_model.OrderStatus
+		wantStock int
+	}{
+		{"отмена ожидающего заказа", []order_model.OrderStatus{order_model.StatusCancelled}, 5},
+		{"подтверждение сохраняет резерв", []order_model.OrderStatus{order_model.StatusConfirmed}, 3},
+		{"возврат оплаченного заказа", []order_model.OrderStatus{
+			order_model.StatusConfirmed, order_model.StatusPaid, order_model.StatusRefunded}, 5},
+		{"возврат доставленного заказа", []order_model.OrderStatus{
+			order_model.StatusConfirmed, order_model.StatusPaid, order_model.StatusShipped,
+			order_model.StatusDelivered, order_model.StatusRefunded}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestRepo(t)
+			product := seedProduct(t, repo.db, 5)
+			order := &order_model.Order{UserID: 43, Status: order_model.StatusPending,
+				Items: []order_model.OrderItem{stockItem(product.ID, 2)}}
+			if err := repo.Create(context.Background(), order); err != nil {
+				t.Fatalf("create failed: %v", err)
+			}
+
+			from := order_model.StatusPending
+			for _, to := range tt.path {
+				if err := repo.UpdateStatus(context.Background(), order.ID, order.UserID, from, to); err != nil {
+					t.Fatalf("transition %s -> %s failed: %v", from, to, err)
+				}
+				from = to
+			}
+			if got := productStock(t, repo.db, product.ID); got != tt.wantStock {
+				t.Errorf("expected stock %d, got %d", tt.wantStock, got)
+			}
+		})
+	}
+}
+
+func TestDeleteOrder_ReleasesStock(t *testing.T) {
+	repo := newTestRepo(t)
+	product := seedProduct(t, repo.db, 5)
+	order := &order_model.Order{UserID: 44, Status: order_model.StatusPending,
+		Items: []order_model.OrderItem{stockItem(product.ID, 3)}}
+	if err := repo.Create(context.Background(), order); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	if err := repo.Delete(context.Background(), order.ID, order.UserID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := productStock(t, repo.db, product.ID); got != 5 {
+		t.Errorf("expected stock 5 after delete, got %d", got)
+	}
+	if err := repo.Delete(context.Background(), order.ID, order.UserID); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("expected ErrOrderNotFound on repeated delete, got %v", err)
+	}
+	if got := productStock(t, repo.db, product.ID); got != 5 {
+		t.Errorf("expected repeated delete not to release twice, got %d", got)
+	}
+}
+
+func TestDeleteOrder_CancelledDoesNotReleaseTwice(t *testing.T) {
+	repo := newTestRepo(t)
+	product := seedProduct(t, repo.db, 5)
+	order := &order_model.Order{UserID: 45, Status: order_model.StatusPending,
+		Items: []order_model.OrderItem{stockItem(product.ID, 3)}}
+	if err := repo.Create(context.Background(), order); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if err := repo.UpdateStatus(context.Background(), order.ID, order.UserID,
+		order_model.StatusPending, order_model.StatusCancelled); err != nil {
+		t.Fatalf("cancel failed: %v", err)
+	}
+
+	if err := repo.Delete(context.Background(), order.ID, order.UserID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := productStock(t, repo.db, product.ID); got != 5 {
+		t.Errorf("expected stock 5, got %d", got)
+	}
+}
+
+func TestCreateOrder_ConcurrentReservationsDoNotOversell(t *testing.T) {
+	repo := newConcurrentTestRepo(t)
+	const stock, workers = 10, 40
+	product := seedProduct(t, repo.db, stock)
+
+	var (
+		wg           sync.WaitGroup
+		mu           sync.Mutex
+		succeeded    int
+		insufficient int
+		unexpected   []error
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(userID uint) {
+			defer wg.Done()
+			order := &order_model.Order{UserID: userID, Status: order_model.StatusPending,
+				Items: []order_model.OrderItem{stockItem(product.ID, 1)}}
+			err := repo.Create(context.Background(), order)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				succeeded++
+			case errors.Is(err, ErrInsufficientStock):
+				insufficient++
+			default:
+				unexpected = append(unexpected, err)
+			}
+		}(uint(100 + i))
+	}
+	wg.Wait()
+
+	if len(unexpected) > 0 {
+		t.Fatalf("unexpected errors: %v", unexpected)
+	}
+	if succeeded != stock || insufficient != workers-stock {
+		t.Errorf("expected %d successes and %d rejections, got %d and %d",
+			stock, workers-stock, succeeded, insufficient)
+	}
+	if got := productStock(t, repo.db, product.ID); got != 0 {
+		t.Errorf("expected stock 0, got %d", got)
+	}
+}
+
+func TestConcurrentCreateAndDelete_KeepsStockConsistent(t *testing.T) {
+	repo := newConcurrentTestRepo(t)
+	const stock, workers = 5, 30
+	product := seedProduct(t, repo.db, stock)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*2)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(userID uint) {
+			defer wg.Done()
+			order := &order_model.Order{UserID: userID, Status: order_model.StatusPending,
+				Items: []order_model.OrderItem{stockItem(product.ID, 2)}}
+			err := repo.Create(context.Background(), order)
+			if errors.Is(err, ErrInsufficientStock) {
+				return
+			}
+			if err != nil {
+				errs <- err
+				return
+			}
+			// Два параллельных удаления одного заказа должны вернуть резерв ровно один раз
+			var inner sync.WaitGroup
+			for j := 0; j < 2; j++ {
+				inner.Add(1)
+				go func() {
+					defer inner.Done()
+					if err := repo.Delete(context.Background(), order.ID, userID); err != nil && !errors.Is(err, ErrOrderNotFound) {
+						errs <- err
+					}
+				}()
+			}
+			inner.Wait()
+		}(uint(200 + i))
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if got := productStock(t, repo.db, product.ID); got != stock {
+		t.Errorf("expected all reservations to be released, stock %d", got)
+	}
+}
@@ -0,0 +1,247 @@
+package product_rep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/product_model"
+	"github.com/IlyushinDM/user-order-api/internal/repository/database"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Определение пользовательских ошибок репозитория продуктов
+var (
+	ErrProductNotFound = errors.New("продукт не найден")
+	ErrDatabaseError   = errors.New("ошибка базы данных")
+	ErrInvalidInput    = errors.New("неверный входной параметр")
+)
+
+// ProductRepository определяет интерфейс для операций с каталогом продуктов.
+type ProductRepository interface {
+	Create(ctx context.Context, product *product_model.Product) error
+	Update(ctx context.Context, product *product_model.Product) error
+	SetStock(ctx context.Context, id uint, stock int) error
+	Delete(ctx context.Context, id uint) error
+	GetByID(ctx context.Context, id uint) (*product_model.Product, error)
+	GetByIDs(ctx context.Context, ids []uint) (map[uint]product_model.Product, error)
+	GetAll(ctx context.Context, params ListQueryParams) ([]product_model.Product, int64, error)
+}
+
+// ListQueryParams содержит параметры пагинации и поиска для GetAll
+type ListQueryParams struct {
+	Offset int
+	Limit  int
+	// Search - подстрока для поиска по названию и описанию без учета регистра (пустая строка - без поиска)
+	Search string
+}
+
+// productRepository реализует интерфейс ProductRepository с использованием GORM.
+type productRepository struct {
+	db  *gorm.DB
+	log *logrus.Logger
+}
+
+// NewGormProductRepository создает новый экземпляр репозитория продуктов.
+func NewGormProductRepository(db *gorm.DB, log *logrus.Logger) ProductRepository {
+	if db == nil {
+		logrus.Fatal("Экземпляр GORM DB равен nil в NewGormProductRepository")
+	}
+	if log == nil {
+		defaultLog := logrus.New()
+		defaultLog.SetLevel(logrus.InfoLevel)
+		defaultLog.Warn("Экземпляр Logrus logger равен nil в NewGormProductRepository, используется логгер по умолчанию")
+		log = defaultLog
+	}
+	return &productRepository{db: db, log: log}
+}
+
+// conn возвращает транзакцию из контекста, если она открыта через database.TxManager, иначе r.db
+func (r *productRepository) conn(ctx context.Context) *gorm.DB {
+	return database.Conn(ctx, r.db)
+}
+
+// Create создает новую запись продукта в базе данных
+func (r *productRepository) Create(ctx context.Context, product *product_model.Product) error {
+	logger := r.log.WithContext(ctx).WithField("method", "ProductRepository.Create")
+	if product == nil {
+		logger.Error("Попытка создать nil продукт")
+		return fmt.Errorf("%w: объект продукта равен nil", ErrInvalidInput)
+	}
+
+	if err := r.conn(ctx).Create(product).Error; err != nil {
+		logger.WithError(err).Error("Не удалось создать продукт")
+		return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	logger.WithField("product_id", product.ID).Info("Продукт успешно создан")
+	return nil
+}
+
+// Update сохраняет название, описание, цену и валюту существующего продукта.
+// Остаток не перезаписывается, чтобы не затереть параллельные резервы заказов (см. SetStock).
+func (r *productRepository) Update(ctx context.Context, product *product_model.Product) error {
+	logger := r.log.WithContext(ctx).WithField("method", "ProductRepository.Update")
+	if product == nil {
+		logger.Error("Попытка обновить nil продукт")
+		return fmt.Errorf("%w: объект продукта равен nil", ErrInvalidInput)
+	}
+	logger = logger.WithField("product_id", product.ID)
+	if product.ID == 0 {
+		logger.Error("Попытка обновить продукт с нулевым ID")
+		return fmt.Errorf("%w: ID продукта равен нулю, невозможно обновить", ErrInvalidInput)
+	}
+
+	// Select явно перечисляет поля, чтобы пустое описание тоже сохранялось
+	result := r.conn(ctx).Model(product).
+		Select("name", "description", "price", "currency", "updated_at").
+		Updates(product)
+	if result.Error != nil {
+		logger.WithError(result.Error).Error("Не удалось обновить продукт")
+		return fmt.Errorf("%w: %v", ErrDatabaseError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		logger.Warn("Продукт для обновления не найден")
+		return ErrProductNotFound
+	}
+
+	logger.Info("Продукт успешно обновлен")
+	return nil
+}
+
+// SetStock устанавливает свободный остаток продукта (например, после инвентаризации)
+func (r *productRepository) SetStock(ctx context.Context, id uint, stock int) error {
+	logger := r.log.WithContext(ctx).WithField("method", "ProductRepository.SetStock").WithFields(
+		logrus.Fields{"product_id": id, "stock": stock})
+	if id == 0 || stock < 0 {
+		logger.Error("Попытка установить остаток с нулевым ID или отрицательным значением")
+		return fmt.Errorf("%w: ID продукта должен быть положительным, остаток - неотрицательным", ErrInvalidInput)
+	}
+
+	result := r.conn(ctx).Model(&product_model.Product{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"stock": stock, "updated_at": time.Now()})
+	if result.Error != nil {
+		logger.WithError(result.Error).Error("Не удалось установить остаток продукта")
+		return fmt.Errorf("%w: %v", ErrDatabaseError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		logger.Warn("Продукт для установки остатка не найден")
+		return ErrProductNotFound
+	}
+
+	logger.Info("Остаток продукта успешно установлен")
+	return nil
+}
+
+// Delete выполняет мягкое удаление продукта по ID.
+// Позиции существующих заказов сохраняют снимок названия и цены продукта.
+func (r *productRepository) Delete(ctx context.Context, id uint) error {
+	logger := r.log.WithContext(ctx).WithField("method", "ProductRepository.Delete").WithField("product_id", id)
+	if id == 0 {
+		logger.Error("Попытка удалить продукт с нулевым ID")
+		return fmt.Errorf("%w: ID продукта равен нулю, невозможно удалить", ErrInvalidInput)
+	}
+
+	result := r.conn(ctx).Delete(&product_model.Product{}, id)
+	if result.Error != nil {
+		logger.WithError(result.Error).Error("Не удалось удалить продукт")
+		return fmt.Errorf("%w: %v", ErrDatabaseError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		logger.Warn("Продукт для удаления не найден")
+		return ErrProductNotFound
+	}
+
+	logger.Info("Продукт успешно удален (мягкое удаление)")
+	return nil
+}
+
+// GetByID извлекает продукт по его ID
+func (r *productRepository) GetByID(ctx context.Context, id uint) (*product_model.Product, error) {
+	logger := r.log.WithContext(ctx).WithField("method", "ProductRepository.GetByID").WithField("product_id", id)
+	if id == 0 {
+		logger.Warn("Попытка получить продукт с нулевым ID")
+		return nil, ErrProductNotFound
+	}
+
+	var product product_model.Product
+	if err := r.conn(ctx).First(&product, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn("Продукт не найден по ID")
+			return nil, ErrProductNotFound
+		}
+		logger.WithError(err).Error("Не удалось получить продукт по ID")
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	logger.Debug("Продукт успешно получен по ID")
+	return &product, nil
+}
+
+// GetByIDs извлекает продукты по набору ID одним запросом.
+// Отсутствующие (или удаленные) продукты не попадают в результат, проверка полноты - на стороне вызывающего.
+func (r *productRepository) GetByIDs(ctx context.Context, ids []uint) (map[uint]product_model.Product, error) {
+	logger := r.log.WithContext(ctx).WithField("method", "ProductRepository.GetByIDs").WithField("ids_count", len(ids))
+	products := make(map[uint]product_model.Product, len(ids))
+	if len(ids) == 0 {
+		return products, nil
+	}
+
+	var found []product_model.Product
+	if err := r.conn(ctx).Where("id IN ?", ids).Find(&found).Error; err != nil {
+		logger.WithError(err).Error("Не удалось получить продукты по списку ID")
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	for _, p := range found {
+		products[p.ID] = p
+	}
+
+	logger.WithField("found_count", len(products)).Debug("Продукты получены по списку ID")
+	return products, nil
+}
+
+// GetAll извлекает постраничный список продуктов с необязательным поиском
+func (r *productRepository) GetAll(
+	ctx context.Context, params ListQueryParams,
+) ([]product_model.Product, int64, error) {
+	offset, limit := params.Offset, params.Limit
+	logger := r.log.WithContext(ctx).WithField("method", "ProductRepository.GetAll").WithFields(
+		logrus.Fields{"offset": offset, "limit": limit})
+	if offset < 0 {
+		offset = 0
+		logger.Warn("Предоставлен отрицательный offset, по умолчанию установлено 0")
+	}
+	if limit <= 0 {
+		limit = 10
+		logger.Warn("Предоставлен неверный или неположительный limit, по умолчанию установлено 10")
+	}
+
+	query := r.conn(ctx).Model(&product_model.Product{})
+	if search := strings.TrimSpace(params.Search); search != "" {
+		pattern := "%" + strings.ToLower(search) + "%"
+		query = query.Where("LOWER(name) LIKE ? OR LOWER(description) LIKE ?", pattern, pattern)
+		logger.Debugf("Применение поиска: %q", search)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		logger.WithError(err).Error("Не удалось подсчитать количество продуктов")
+		return nil, 0, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	var products []product_model.Product
+	if err := query.Session(&gorm.Session{}).Order("id").Offset(offset).Limit(limit).Find(&products).Error; err != nil {
+		logger.WithError(err).Error("Не удалось получить постраничный список продуктов")
+		return nil, 0, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"retrieved_count": len(products),
+		"total_count":     total,
+	}).Info("Продукты успешно получены")
+	return products, total, nil
+}
@@ -0,0 +1,187 @@
+package product_rep
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/product_model"
+	"github.com/sirupsen/logrus"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestRepo(t *testing.T) *productRepository {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if err := db.AutoMigrate(&product_model.Product{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return &productRepository{db: db, log: logrus.New()}
+}
+
+func seedProducts(t *testing.T, repo *productRepository, products ...product_model.Product) []product_model.Product {
+	for i := range products {
+		if err := repo.Create(context.Background(), &products[i]); err != nil {
+			t.Fatalf("seed failed: %v", err)
+		}
+	}
+	return products
+}
+
+func TestCreateAndGetByID(t *testing.T) {
+	repo := newTestRepo(t)
+	p := seedProducts(t, repo, product_model.Product{Name: "Кофе", Description: "Зерно", Price: 1250})[0]
+
+	got, err := repo.GetByID(context.Background(), p.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got.Name != "Кофе" || got.Price != 1250 {
+		t.Errorf("unexpected product: %+v", got)
+	}
+}
+
+func TestCreate_Nil(t *testing.T) {
+	repo := newTestRepo(t)
+	if err := repo.Create(context.Background(), nil); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput, got %v", err)
+	}
+}
+
+func TestGetByID_NotFound(t *testing.T) {
+	repo := newTestRepo(t)
+	if _, err := repo.GetByID(context.Background(), 42); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("expected ErrProductNotFound, got %v", err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	repo := newTestRepo(t)
+	p := seedProducts(t, repo, product_model.Product{Name: "Чай", Description: "Черный", Price: 3})[0]
+
+	p.Name = "Чай зеленый"
+	p.Description = ""
+	p.Price = 4
+	if err := repo.Update(context.Background(), &p); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	got, _ := repo.GetByID(context.Background(), p.ID)
+	if got.Name != "Чай зеленый" || got.Description != "" || got.Price != 4 {
+		t.Errorf("unexpected product after update: %+v", got)
+	}
+}
+
+func TestUpdate_NotFound(t *testing.T) {
+	repo := newTestRepo(t)
+	err := repo.Update(context.Background(), &product_model.Product{ID: 99, Name: "X", Price: 1})
+	if !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("expected ErrProductNotFound, got %v", err)
+	}
+}
+
+func TestUpdate_KeepsStock(t *testing.T) {
+	repo := newTestRepo(t)
+	p := seedProducts(t, repo, product_model.Product{Name: "Мука", Price: 2, Stock: 5})[0]
+
+	p.Price = 3
+	p.Stock = 100
+	if err := repo.Update(context.Background(), &p); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	got, _ := repo.GetByID(context.Background(), p.ID)
+	if got.Stock != 5 || got.Price != 3 {
+		t.Errorf("expected stock to stay 5 and price 3, got %+v", got)
+	}
+}
+
+func TestSetStock(t *testing.T) {
+	repo := newTestRepo(t)
+	p := seedProducts(t, repo, product_model.Product{Name: "Соль", Price: 1, Stock: 1})[0]
+
+	if err := repo.SetStock(context.Background(), p.ID, 40); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	got, _ := repo.GetByID(context.Background(), p.ID)
+	if got.Stock != 40 {
+		t.Errorf("expected stock 40, got %d", got.Stock)
+	}
+
+	if err := repo.SetStock(context.Background(), p.ID, -1); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput, got %v", err)
+	}
+	if err := repo.SetStock(context.Background(), 999, 1); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("expected ErrProductNotFound, got %v", err)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	repo := newTestRepo(t)
+	p := seedProducts(t, repo, product_model.Product{Name: "Сахар", Price: 1})[0]
+
+	if err := repo.Delete(context.Background(), p.ID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := repo.GetByID(context.Background(), p.ID); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("expected deleted product to be hidden, got %v", err)
+	}
+	if err := repo.Delete(context.Background(), p.ID); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("expected ErrProductNotFound on repeated delete, got %v", err)
+	}
+}
+
+func TestGetByIDs(t *testing.T) {
+	repo := newTestRepo(t)
+	ps := seedProducts(t, repo,
+		product_model.Product{Name: "A", Price: 1},
+		product_model.Product{Name: "B", Price: 2},
+		product_model.Product{Name: "C", Price: 3},
+	)
+	_ = repo.Delete(context.Background(), ps[2].ID)
+
+	got, err := repo.GetByIDs(context.Background(), []uint{ps[0].ID, ps[2].ID, 100})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected 1 product, got %d", len(got))
+	}
+	if got[ps[0].ID].Name != "A" {
+		t.Errorf("unexpected product: %+v", got[ps[0].ID])
+	}
+}
+
+func TestGetAll_SearchAndPagination(t *testing.T) {
+	repo := newTestRepo(t)
+	seedProducts(t, repo,
+		product_model.Product{Name: "Coffee beans", Price: 10},
+		product_model.Product{Name: "Tea", Description: "goes well with COFFEE cake", Price: 3},
+		product_model.Product{Name: "Milk", Price: 1},
+	)
+
+	products, total, err := repo.GetAll(context.Background(), ListQueryParams{Offset: 0, Limit: 1, Search: "coffee"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if total != 2 {
+		t.Errorf("expected total 2, got %d", total)
+	}
+	if len(products) != 1 || products[0].Name != "Coffee beans" {
+		t.Errorf("unexpected page: %+v", products)
+	}
+
+	products, total, err = repo.GetAll(context.Background(), ListQueryParams{Offset: 0, Limit: 10})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if total != 3 || len(products) != 3 {
+		t.Errorf("expected 3 products, got total=%d len=%d", total, len(products))
+	}
+}
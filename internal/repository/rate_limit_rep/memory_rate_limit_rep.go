@@ -0,0 +1,97 @@
+package rate_limit_rep
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/rate_limit_model"
+	"github.com/sirupsen/logrus"
+)
+
+// memorySweepInterval задает, как часто хранилище в памяти удаляет заполненные корзины
+const memorySweepInterval = time.Minute
+
+// bucket - корзина токенов одного клиента
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time // Время, когда корзина заполнится и ее можно удалить
+}
+
+// memoryRateLimitRepository хранит корзины токенов в памяти процесса.
+// Подходит для одного экземпляра приложения; при нескольких экземплярах лимит действует на каждый отдельно.
+type memoryRateLimitRepository struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+	log       *logrus.Logger
+}
+
+// NewMemoryRateLimitRepository создает хранилище корзин токенов в памяти процесса
+func NewMemoryRateLimitRepository(log *logrus.Logger) RateLimitRepository {
+	if log == nil {
+		defaultLog := logrus.New()
+		defaultLog.SetLevel(logrus.InfoLevel)
+		defaultLog.Warn("Экземпляр логгера Logrus равен nil в NewMemoryRateLimitRepository, используется логгер по умолчанию")
+		log = defaultLog
+	}
+	return &memoryRateLimitRepository{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+		log:     log,
+	}
+}
+
+// Take пополняет корзину за прошедшее время и забирает из нее токен
+func (r *memoryRateLimitRepository) Take(ctx context.Context, key string, limit rate_limit_model.Limit) (rate_limit_model.Result, error) {
+	if err := validateTake(key, limit); err != nil {
+		return rate_limit_model.Result{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	r.sweepLocked(now)
+
+	capacity := float64(limit.Requests)
+	interval := limit.Interval()
+	b, ok := r.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		r.buckets[key] = b
+	} else if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+float64(elapsed)/float64(interval))
+		b.updated = now
+	}
+
+	result := rate_limit_model.Result{Limit: limit.Requests}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) * float64(interval))
+		r.log.WithContext(ctx).WithField("limit", limit.String()).Debug("Лимит запросов исчерпан")
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = time.Duration((capacity - b.tokens) * float64(interval))
+	b.full = now.Add(result.Reset)
+	return result, nil
+}
+
+// sweepLocked удаляет заполненные корзины: новая корзина для того же ключа будет такой же.
+// Вызывается под r.mu не чаще memorySweepInterval.
+func (r *memoryRateLimitRepository) sweepLocked(now time.Time) {
+	if now.Sub(r.lastSweep) < memorySweepInterval {
+		return
+	}
+	r.lastSweep = now
+	for key, b := range r.buckets {
+		if !now.Before(b.full) {
+			delete(r.buckets, key)
+		}
+	}
+}
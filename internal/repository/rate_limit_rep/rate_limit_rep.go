@@ -0,0 +1,27 @@
+package rate_limit_rep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/rate_limit_model"
+)
+
+// Определение ошибок хранилища лимитов запросов
+var ErrInvalidInput = errors.New("неверные входные данные лимита запросов")
+
+// RateLimitRepository хранит состояние корзин токенов ограничителя запросов.
+// Реализация для нескольких экземпляров приложения может хранить корзины во внешнем хранилище.
+type RateLimitRepository interface {
+	// Take забирает один токен из корзины key с лимитом limit. Если токенов нет,
+	// запрос не учитывается, а Result.Allowed равен false.
+	Take(ctx context.Context, key string, limit rate_limit_model.Limit) (rate_limit_model.Result, error)
+}
+
+func validateTake(key string, limit rate_limit_model.Limit) error {
+	if key == "" || limit.Requests <= 0 || limit.Period <= 0 {
+		return fmt.Errorf("%w: требуются ключ и положительный лимит", ErrInvalidInput)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package rate_limit_rep
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/rate_limit_model"
+	"github.com/sirupsen/logrus"
+)
+
+// newTestRepo создает хранилище с управляемыми часами
+func newTestRepo() (*memoryRateLimitRepository, *time.Time) {
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	repo := NewMemoryRateLimitRepository(log).(*memoryRateLimitRepository)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	repo.now = func() time.Time { return now }
+	return repo, &now
+}
+
+func TestTake_AllowsBurstThenRejects(t *testing.T) {
+	repo, _ := newTestRepo()
+	limit := rate_limit_model.Limit{Requests: 3, Period: time.Minute}
+
+	for i := 2; i >= 0; i-- {
+		result, err := repo.Take(context.Background(), "ip:1", limit)
+		if err != nil || !result.Allowed {
+			t.Fatalf("expected request to be allowed, got %+v, %v", result, err)
+		}
+		if result.Remaining != i || result.Limit != 3 {
+			t.Errorf("expected remaining %d of 3, got %+v", i, result)
+		}
+	}
+
+	result, err := repo.Take(context.Background(), "ip:1", limit)
+	if err != nil || result.Allowed {
+		t.Fatalf("expected request to be rejected, got %+v, %v", result, err)
+	}
+	// Один токен появляется за Period/Requests, корзина заполняется за Period
+	if result.RetryAfter != 20*time.Second || result.Reset != time.Minute || result.Remaining != 0 {
+		t.Errorf("unexpected rejection result: %+v", result)
+	}
+}
+
+func TestTake_RefillsOverTime(t *testing.T) {
+	repo, now := newTestRepo()
+	limit := rate_limit_model.Limit{Requests: 2, Period: time.Minute}
+
+	repo.Take(context.Background(), "ip:1", limit)
+	repo.Take(context.Background(), "ip:1", limit)
+
+	*now = now.Add(10 * time.Second)
+	if result, _ := repo.Take(context.Background(), "ip:1", limit); result.Allowed {
+		t.Fatalf("expected rejection before a token is refilled, got %+v", result)
+	}
+	if result, _ := repo.Take(context.Background(), "ip:1", limit); result.RetryAfter != 20*time.Second {
+		t.Errorf("expected retry after 20s, got %s", result.RetryAfter)
+	}
+
+	*now = now.Add(20 * time.Second)
+	if result, _ := repo.Take(context.Background(), "ip:1", limit); !result.Allowed {
+		t.Fatalf("expected request to be allowed after refill, got %+v", result)
+	}
+
+	// Корзина не переполняется сверх емкости
+	*now = now.Add(time.Hour)
+	if result, _ := repo.Take(context.Background(), "ip:1", limit); result.Remaining != 1 {
+		t.Errorf("expected remaining 1 after long pause, got %d", result.Remaining)
+	}
+}
+
+func TestTake_KeysAreIndependent(t *testing.T) {
+	repo, _ := newTestRepo()
+	limit := rate_limit_model.Limit{Requests: 1, Period: time.Minute}
+
+	repo.Take(context.Background(), "ip:1", limit)
+	if result, _ := repo.Take(context.Background(), "ip:2", limit); !result.Allowed {
+		t.Errorf("expected another client to have its own bucket")
+	}
+}
+
+func TestTake_SweepsFullBuckets(t *testing.T) {
+	repo, now := newTestRepo()
+	limit := rate_limit_model.Limit{Requests: 2, Period: time.Minute}
+
+	repo.Take(context.Background(), "ip:1", limit)
+	*now = now.Add(2 * memorySweepInterval)
+	repo.Take(context.Background(), "ip:2", limit)
+
+	if _, ok := repo.buckets["ip:1"]; ok {
+		t.Errorf("expected refilled bucket to be swept")
+	}
+	if _, ok := repo.buckets["ip:2"]; !ok {
+		t.Errorf("expected active bucket to be kept")
+	}
+}
+
+func TestTake_InvalidInput(t *testing.T) {
+	repo, _ := newTestRepo()
+	for _, tc := range []struct {
+		key   string
+		limit rate_limit_model.Limit
+	}{
+		{"", rate_limit_model.Limit{Requests: 1, Period: time.Minute}},
+		{"ip:1", rate_limit_model.Limit{Requests: 0, Period: time.Minute}},
+		{"ip:1", rate_limit_model.Limit{Requests: 1}},
+	} {
+		if _, err := repo.Take(context.Background(), tc.key, tc.limit); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("expected ErrInvalidInput for %q %+v, got %v", tc.key, tc.limit, err)
+		}
+	}
+}
+
+func TestTake_ConcurrentRequestsRespectLimit(t *testing.T) {
+	repo, _ := newTestRepo()
+	limit := rate_limit_model.Limit{Requests: 10, Period: time.Hour}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if result, err := repo.Take(context.Background(), "ip:1", limit); err == nil && result.Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != 10 {
+		t.Errorf("expected exactly 10 allowed requests, got %d", allowed)
+	}
+}
@@ -0,0 +1,204 @@
+package token_rep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/token_model"
+	"github.com/IlyushinDM/user-order-api/internal/repository/database"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Определение ошибок репозитория токенов
+var (
+	ErrTokenNotFound     = errors.New("токен не найден")
+	ErrTokenAlreadyUsed  = errors.New("refresh токен уже использован или отозван")
+	ErrDatabaseError     = errors.New("ошибка базы данных")
+	ErrInvalidTokenInput = errors.New("неверные входные данные токена")
+)
+
+// TokenRepository определяет интерфейс для хранения refresh токенов и списка отзыва access токенов.
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token *token_model.RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*token_model.RefreshToken, error)
+	MarkRefreshTokenUsed(ctx context.Context, id uint) error
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAccessToken(ctx context.Context, token *token_model.RevokedAccessToken) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	// DeleteExpired удаляет записи, которые больше не нужны к моменту now: отозванные access токены
+	// с истекшим сроком действия и refresh токены семейств, все токены которых просрочены
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+// gormTokenRepository реализует TokenRepository с использованием GORM
+type gormTokenRepository struct {
+	db  *gorm.DB
+	log *logrus.Logger
+}
+
+// NewGormTokenRepository создает новый репозиторий токенов с использованием GORM
+func NewGormTokenRepository(db *gorm.DB, log *logrus.Logger) TokenRepository {
+	if db == nil {
+		logrus.Fatal("Экземпляр GORM DB равен nil в NewGormTokenRepository")
+	}
+	if log == nil {
+		defaultLog := logrus.New()
+		defaultLog.SetLevel(logrus.InfoLevel)
+		defaultLog.Warn("Экземпляр логгера Logrus равен nil в NewGormTokenRepository, используется логгер по умолчанию")
+		log = defaultLog
+	}
+	return &gormTokenRepository{db: db, log: log}
+}
+
+// conn возвращает транзакцию из контекста, если она открыта через database.TxManager, иначе r.db
+func (r *gormTokenRepository) conn(ctx context.Context) *gorm.DB {
+	return database.Conn(ctx, r.db)
+}
+
+// CreateRefreshToken сохраняет новый refresh токен
+func (r *gormTokenRepository) CreateRefreshToken(ctx context.Context, token *token_model.RefreshToken) error {
+	logger := r.log.WithContext(ctx).WithField("method", "TokenRepository.CreateRefreshToken")
+
+	if token == nil || token.UserID == 0 || token.FamilyID == "" || token.TokenHash == "" {
+		logger.Warn("Попытка сохранить некорректный refresh токен")
+		return fmt.Errorf("%w: refresh токен должен содержать пользователя, семейство и хеш", ErrInvalidTokenInput)
+	}
+
+	if err := r.conn(ctx).Create(token).Error; err != nil {
+		logger.WithError(err).Error("Не удалось сохранить refresh токен")
+		return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	logger.WithFields(logrus.Fields{"user_id": token.UserID, "token_id": token.ID}).Debug("Refresh токен сохранен")
+	return nil
+}
+
+// GetRefreshTokenByHash ищет refresh токен по его хешу
+func (r *gormTokenRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*token_model.RefreshToken, error) {
+	logger := r.log.WithContext(ctx).WithField("method", "TokenRepository.GetRefreshTokenByHash")
+
+	if tokenHash == "" {
+		return nil, ErrTokenNotFound
+	}
+
+	var token token_model.RefreshToken
+	err := r.conn(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Debug("Refresh токен не найден")
+			return nil, ErrTokenNotFound
+		}
+		logger.WithError(err).Error("Не удалось получить refresh токен")
+		return nil, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	return &token, nil
+}
+
+// MarkRefreshTokenUsed помечает refresh токен как использованный.
+// Обновление выполняется условно, поэтому из двух параллельных запросов
+// с одним токеном успешен будет только один.
+func (r *gormTokenRepository) MarkRefreshTokenUsed(ctx context.Context, id uint) error {
+	logger := r.log.WithContext(ctx).WithField("method", "TokenRepository.MarkRefreshTokenUsed").WithField("token_id", id)
+
+	result := r.conn(ctx).Model(&token_model.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", time.Now().UTC())
+	if result.Error != nil {
+		logger.WithError(result.Error).Error("Не удалось пометить refresh токен как использованный")
+		return fmt.Errorf("%w: %v", ErrDatabaseError, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		logger.Warn("Refresh токен уже использован или отозван")
+		return ErrTokenAlreadyUsed
+	}
+
+	return nil
+}
+
+// RevokeFamily отзывает все refresh токены семейства
+func (r *gormTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	logger := r.log.WithContext(ctx).WithField("method", "TokenRepository.RevokeFamily").WithField("family_id", familyID)
+
+	if familyID == "" {
+		return fmt.Errorf("%w: пустой идентификатор семейства", ErrInvalidTokenInput)
+	}
+
+	result := r.conn(ctx).Model(&token_model.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now().UTC())
+	if result.Error != nil {
+		logger.WithError(result.Error).Error("Не удалось отозвать семейство refresh токенов")
+		return fmt.Errorf("%w: %v", ErrDatabaseError, result.Error)
+	}
+
+	logger.WithField("revoked", result.RowsAffected).Info("Семейство refresh токенов отозвано")
+	return nil
+}
+
+// RevokeAccessToken добавляет jti access токена в список отзыва.
+// Повторный отзыв того же токена не считается ошибкой.
+func (r *gormTokenRepository) RevokeAccessToken(ctx context.Context, token *token_model.RevokedAccessToken) error {
+	logger := r.log.WithContext(ctx).WithField("method", "TokenRepository.RevokeAccessToken")
+
+	if token == nil || token.JTI == "" {
+		return fmt.Errorf("%w: пустой jti", ErrInvalidTokenInput)
+	}
+
+	err := r.conn(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(token).Error
+	if err != nil {
+		logger.WithError(err).Error("Не удалось отозвать access токен")
+		return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+
+	logger.WithField("user_id", token.UserID).Info("Access токен отозван")
+	return nil
+}
+
+// IsAccessTokenRevoked проверяет, находится ли jti в списке отзыва
+func (r *gormTokenRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	if jti == "" {
+		return false, nil
+	}
+
+	var count int64
+	err := r.conn(ctx).Model(&token_model.RevokedAccessToken{}).Where("jti = ?", jti).Count(&count).Error
+	if err != nil {
+		r.log.WithContext(ctx).WithField("method", "TokenRepository.IsAccessTokenRevoked").
+			WithError(err).Error("Не удалось проверить список отзыва")
+		return false, fmt.Errorf("%w: %v", ErrDatabaseError, err)
+	}
+	return count > 0, nil
+}
+
+// DeleteExpired удаляет просроченные записи списка отзыва и refresh токенов.
+// Refresh токены удаляются целыми семействами: пока в семействе есть действующий токен,
+// его использованные токены нужны для обнаружения повторного предъявления.
+func (r *gormTokenRepository) DeleteExpired(ctx context.Context, now time.Time) error {
+	logger := r.log.WithContext(ctx).WithField("method", "TokenRepository.DeleteExpired")
+	now = now.UTC()
+
+	revoked := r.conn(ctx).Where("expires_at <= ?", now).Delete(&token_model.RevokedAccessToken{})
+	if revoked.Error != nil {
+		logger.WithError(revoked.Error).Error("Не удалось удалить просроченные записи списка отзыва")
+		return fmt.Errorf("%w: %v", ErrDatabaseError, revoked.Error)
+	}
+
+	expiredFamilies := r.conn(ctx).Model(&token_model.RefreshToken{}).
+		Select("family_id").Group("family_id").Having("MAX(expires_at) <= ?", now)
+	refresh := r.conn(ctx).Where("family_id IN (?)", expiredFamilies).Delete(&token_model.RefreshToken{})
+	if refresh.Error != nil {
+		logger.WithError(refresh.Error).Error("Не удалось удалить просроченные refresh токены")
+		return fmt.Errorf("%w: %v", ErrDatabaseError, refresh.Error)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"revoked_access_tokens": revoked.RowsAffected,
+		"refresh_tokens":        refresh.RowsAffected,
+	}).Debug("Просроченные токены удалены")
+	return nil
+}
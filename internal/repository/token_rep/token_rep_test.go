@@ -0,0 +1,195 @@
+package token_rep
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/token_model"
+	"github.com/sirupsen/logrus"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestRepo(t *testing.T) *gormTokenRepository {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if err := db.AutoMigrate(&token_model.RefreshToken{}, &token_model.RevokedAccessToken{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return &gormTokenRepository{db: db, log: logrus.New()}
+}
+
+func newRefreshToken(userID uint, family, hash string) *token_model.RefreshToken {
+	return &token_model.RefreshToken{
+		UserID:    userID,
+		FamilyID:  family,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+}
+
+func TestCreateAndGetRefreshToken(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	token := newRefreshToken(1, "family-1", "hash-1")
+	if err := repo.CreateRefreshToken(ctx, token); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	got, err := repo.GetRefreshTokenByHash(ctx, "hash-1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got.ID != token.ID || got.FamilyID != "family-1" || got.UserID != 1 {
+		t.Errorf("unexpected token returned: %+v", got)
+	}
+}
+
+func TestCreateRefreshToken_InvalidInput(t *testing.T) {
+	repo := newTestRepo(t)
+	err := repo.CreateRefreshToken(context.Background(), &token_model.RefreshToken{UserID: 1})
+	if !errors.Is(err, ErrInvalidTokenInput) {
+		t.Errorf("expected ErrInvalidTokenInput, got %v", err)
+	}
+}
+
+func TestGetRefreshTokenByHash_NotFound(t *testing.T) {
+	repo := newTestRepo(t)
+	_, err := repo.GetRefreshTokenByHash(context.Background(), "missing")
+	if !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("expected ErrTokenNotFound, got %v", err)
+	}
+}
+
+func TestMarkRefreshTokenUsed_OnlyOnce(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	token := newRefreshToken(1, "family-1", "hash-1")
+	if err := repo.CreateRefreshToken(ctx, token); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	if err := repo.MarkRefreshTokenUsed(ctx, token.ID); err != nil {
+		t.Fatalf("expected first use to succeed, got %v", err)
+	}
+	if err := repo.MarkRefreshTokenUsed(ctx, token.ID); !errors.Is(err, ErrTokenAlreadyUsed) {
+		t.Errorf("expected ErrTokenAlreadyUsed on second use, got %v", err)
+	}
+}
+
+func TestRevokeFamily(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	for _, hash := range []string{"hash-1", "hash-2"} {
+		if err := repo.CreateRefreshToken(ctx, newRefreshToken(1, "family-1", hash)); err != nil {
+			t.Fatalf("create failed: %v", err)
+		}
+	}
+	other := newRefreshToken(1, "family-2", "hash-3")
+	if err := repo.CreateRefreshToken(ctx, other); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	if err := repo.RevokeFamily(ctx, "family-1"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for _, hash := range []string{"hash-1", "hash-2"} {
+		got, err := repo.GetRefreshTokenByHash(ctx, hash)
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+		if got.RevokedAt == nil {
+			t.Errorf("expected token %s to be revoked", hash)
+		}
+	}
+	got, _ := repo.GetRefreshTokenByHash(ctx, "hash-3")
+	if got.RevokedAt != nil {
+		t.Error("expected token from another family to stay active")
+	}
+	if err := repo.MarkRefreshTokenUsed(ctx, other.ID); err != nil {
+		t.Errorf("expected active token to be usable, got %v", err)
+	}
+}
+
+func TestRevokeAccessToken(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	revoked, err := repo.IsAccessTokenRevoked(ctx, "jti-1")
+	if err != nil || revoked {
+		t.Fatalf("expected token not to be revoked, got %v, %v", revoked, err)
+	}
+
+	entry := &token_model.RevokedAccessToken{JTI: "jti-1", UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}
+	if err := repo.RevokeAccessToken(ctx, entry); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	// Повторный отзыв не должен приводить к ошибке
+	if err := repo.RevokeAccessToken(ctx, entry); err != nil {
+		t.Fatalf("expected idempotent revoke, got %v", err)
+	}
+
+	revoked, err = repo.IsAccessTokenRevoked(ctx, "jti-1")
+	if err != nil || !revoked {
+		t.Errorf("expected token to be revoked, got %v, %v", revoked, err)
+	}
+}
+
+func TestDeleteExpired(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	tokens := []*token_model.RefreshToken{
+		// Все токены семейства просрочены - семейство удаляется
+		{UserID: 1, FamilyID: "expired", TokenHash: "expired-1", ExpiresAt: now.Add(-2 * time.Hour)},
+		{UserID: 1, FamilyID: "expired", TokenHash: "expired-2", ExpiresAt: now.Add(-time.Hour)},
+		// Просроченный токен живого семейства нужен для обнаружения повторного предъявления
+		{UserID: 2, FamilyID: "active", TokenHash: "active-1", ExpiresAt: now.Add(-time.Hour)},
+		{UserID: 2, FamilyID: "active", TokenHash: "active-2", ExpiresAt: now.Add(time.Hour)},
+	}
+	for _, token := range tokens {
+		if err := repo.CreateRefreshToken(ctx, token); err != nil {
+			t.Fatalf("failed to create token: %v", err)
+		}
+	}
+	for _, entry := range []*token_model.RevokedAccessToken{
+		{JTI: "jti-expired", UserID: 1, ExpiresAt: now.Add(-time.Minute)},
+		{JTI: "jti-active", UserID: 2, ExpiresAt: now.Add(time.Hour)},
+	} {
+		if err := repo.RevokeAccessToken(ctx, entry); err != nil {
+			t.Fatalf("failed to revoke token: %v", err)
+		}
+	}
+
+	if err := repo.DeleteExpired(ctx, now); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for hash, kept := range map[string]bool{"expired-1": false, "expired-2": false, "active-1": true, "active-2": true} {
+		_, err := repo.GetRefreshTokenByHash(ctx, hash)
+		if kept && err != nil {
+			t.Errorf("expected refresh token %s to be kept, got %v", hash, err)
+		}
+		if !kept && !errors.Is(err, ErrTokenNotFound) {
+			t.Errorf("expected refresh token %s to be deleted, got %v", hash, err)
+		}
+	}
+	for jti, kept := range map[string]bool{"jti-expired": false, "jti-active": true} {
+		revoked, err := repo.IsAccessTokenRevoked(ctx, jti)
+		if err != nil || revoked != kept {
+			t.Errorf("unexpected revocation state of %s: revoked=%v err=%v", jti, revoked, err)
+		}
+	}
+}
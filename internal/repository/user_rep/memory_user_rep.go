@@ -0,0 +1,230 @@
+package user_rep
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/user_model"
+	"github.com/sirupsen/logrus"
+)
+
+// memoryUserRepository хранит пользователей в памяти процесса.
+// Поведение совпадает с GormUserRepository, что проверяет общий набор тестов;
+// подходит для тестов и локального запуска, данные теряются при перезапуске.
+type memoryUserRepository struct {
+	mu     sync.RWMutex
+	users  map[uint]*user_model.User
+	nextID uint
+	log    *logrus.Logger
+}
+
+// NewMemoryUserRepository создает репозиторий пользователей в памяти процесса
+func NewMemoryUserRepository(log *logrus.Logger) UserRepository {
+	if log == nil {
+		defaultLog := logrus.New()
+		defaultLog.SetLevel(logrus.InfoLevel)
+		defaultLog.Warn("Экземпляр логгера Logrus равен nil в NewMemoryUserRepository, используется логгер по умолчанию")
+		log = defaultLog
+	}
+	return &memoryUserRepository{users: make(map[uint]*user_model.User), log: log}
+}
+
+// copyUser возвращает копию пользователя без связанных заказов: их репозиторий пользователей не хранит
+func copyUser(user *user_model.User) *user_model.User {
+	clone := *user
+	clone.Orders = nil
+	return &clone
+}
+
+// emailTakenLocked сообщает, занят ли email другим пользователем. Вызывается под блокировкой.
+func (r *memoryUserRepository) emailTakenLocked(email string, exceptID uint) bool {
+	for id, user := range r.users {
+		if id != exceptID && user.Email == email {
+			return true
+		}
+	}
+	return false
+}
+
+// Create сохраняет нового пользователя; email должен быть уникальным
+func (r *memoryUserRepository) Create(ctx context.Context, user *user_model.User) error {
+	logger := r.log.WithContext(ctx).WithField("method", "MemoryUserRepository.Create")
+
+	if user == nil {
+		logger.Error("Попытка создать nil пользователя")
+		return fmt.Errorf("%w: объект пользователя равен nil", ErrInvalidInput)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.emailTakenLocked(user.Email, 0) {
+		logger.WithField("email", user.Email).Error("Не удалось создать пользователя: email уже используется")
+		return fmt.Errorf("%w: email %q уже используется", ErrDatabaseError, user.Email)
+	}
+	if user.Version == 0 {
+		user.Version = 1
+	}
+	if user.Role == "" {
+		user.Role = user_model.RoleUser
+	}
+	r.nextID++
+	user.ID = r.nextID
+	r.users[user.ID] = copyUser(user)
+
+	logger.WithField("user_id", user.ID).Info("Пользователь успешно создан")
+	return nil
+}
+
+// Update изменяет ненулевые поля пользователя, если его версия равна user.Version
+func (r *memoryUserRepository) Update(ctx context.Context, user *user_model.User) error {
+	logger := r.log.WithContext(ctx).WithField("method", "MemoryUserRepository.Update")
+
+	if user == nil {
+		logger.Error("Попытка обновить nil пользователя")
+		return fmt.Errorf("%w: объект пользователя равен nil", ErrInvalidInput)
+	}
+	if user.ID == 0 {
+		logger.Error("Попытка обновить пользователя с нулевым ID")
+		return fmt.Errorf("%w: ID пользователя равен нулю, невозможно обновить", ErrInvalidInput)
+	}
+	logger = logger.WithField("user_id", user.ID)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.ID]
+	if !ok {
+		logger.Warn("Попытка обновления пользователя, но нет затронутых записей (пользователь не найден)")
+		return ErrNoRowsAffected
+	}
+	if stored.Version != user.Version {
+		logger.WithField("version", user.Version).Warn("Пользователь не обновлен: версия изменилась после чтения")
+		return ErrVersionMismatch
+	}
+	if user.Email != "" && r.emailTakenLocked(user.Email, user.ID) {
+		logger.WithField("email", user.Email).Error("Не удалось обновить пользователя: email уже используется")
+		return fmt.Errorf("%w: email %q уже используется", ErrDatabaseError, user.Email)
+	}
+
+	// Как и Updates в GORM, нулевые значения полей не перезаписывают сохраненные
+	if user.Name != "" {
+		stored.Name = user.Name
+	}
+	if user.Email != "" {
+		stored.Email = user.Email
+	}
+	if user.Age != 0 {
+		stored.Age = user.Age
+	}
+	if user.PasswordHash != "" {
+		stored.PasswordHash = user.PasswordHash
+	}
+	if user.Role != "" {
+		stored.Role = user.Role
+	}
+	stored.Version++
+	user.Version = stored.Version
+
+	logger.Info("Пользователь успешно обновлен")
+	return nil
+}
+
+// Delete удаляет пользователя по ID
+func (r *memoryUserRepository) Delete(ctx context.Context, id uint) error {
+	logger := r.log.WithContext(ctx).WithField("method", "MemoryUserRepository.Delete").WithField("user_id", id)
+
+	if id == 0 {
+		logger.Error("Попытка удалить пользователя с нулевым ID")
+		return fmt.Errorf("%w: ID пользователя равен нулю, невозможно удалить", ErrInvalidInput)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[id]; !ok {
+		logger.Warn("Попытка удаления пользователя, но нет затронутых записей (пользователь не найден)")
+		return ErrUserNotFound
+	}
+	delete(r.users, id)
+
+	logger.Info("Пользователь успешно удален")
+	return nil
+}
+
+// GetByID возвращает копию пользователя по ID
+func (r *memoryUserRepository) GetByID(ctx context.Context, id uint) (*user_model.User, error) {
+	logger := r.log.WithContext(ctx).WithField("method", "MemoryUserRepository.GetByID").WithField("user_id", id)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		logger.Warn("Пользователь не найден по ID")
+		return nil, ErrUserNotFound
+	}
+	return copyUser(user), nil
+}
+
+// GetByEmail возвращает копию пользователя по адресу электронной почты
+func (r *memoryUserRepository) GetByEmail(ctx context.Context, email string) (*user_model.User, error) {
+	logger := r.log.WithContext(ctx).WithField("method", "MemoryUserRepository.GetByEmail").WithField("email", email)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if email != "" {
+		for _, user := range r.users {
+			if user.Email == email {
+				return copyUser(user), nil
+			}
+		}
+	}
+	logger.Warn("Пользователь не найден по адресу электронной почты")
+	return nil, ErrUserNotFound
+}
+
+// GetAll возвращает страницу пользователей, упорядоченных по ID, с теми же фильтрами, что и GORM версия
+func (r *memoryUserRepository) GetAll(ctx context.Context, params ListQueryParams) ([]user_model.User, int64, error) {
+	logger := r.log.WithContext(ctx).WithField("method", "MemoryUserRepository.GetAll")
+
+	r.mu.RLock()
+	matched := make([]user_model.User, 0, len(r.users))
+	for _, user := range r.users {
+		if params.MinAge != nil && *params.MinAge > 0 && user.Age < *params.MinAge {
+			continue
+		}
+		if params.MaxAge != nil && *params.MaxAge > 0 && user.Age > *params.MaxAge {
+			continue
+		}
+		if params.Name != nil && *params.Name != "" &&
+			!strings.Contains(strings.ToLower(user.Name), strings.ToLower(*params.Name)) {
+			continue
+		}
+		matched = append(matched, *copyUser(user))
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID < matched[j].ID })
+	total := int64(len(matched))
+
+	page, limit := params.Page, params.Limit
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+	if offset > len(matched) {
+		offset = len(matched)
+	}
+	end := min(offset+limit, len(matched))
+
+	logger.WithFields(logrus.Fields{"page": page, "limit": limit, "total_count": total}).Debug("Пользователи получены")
+	return matched[offset:end], total, nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/IlyushinDM/user-order-api/internal/models/user_model"
+	"github.com/IlyushinDM/user-order-api/internal/repository/database"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
@@ -16,6 +17,8 @@ var (
 	ErrDatabaseError  = errors.New("операция с базой данных не удалась")
 	ErrNoRowsAffected = errors.New("нет затронутых записей")
 	ErrInvalidInput   = errors.New("неверный входной параметр")
+	// ErrVersionMismatch означает, что запись изменена другим запросом после чтения
+	ErrVersionMismatch = errors.New("версия пользователя не совпадает")
 )
 
 // UserRepository определяет интерфейс для операций с пользовательскими данными.
@@ -59,6 +62,11 @@ func NewGormUserRepository(db *gorm.DB, log *logrus.Logger) UserRepository {
 	return &GormUserRepository{db: db, log: log}
 }
 
+// conn возвращает транзакцию из контекста, если она открыта через database.TxManager, иначе r.db
+func (r *GormUserRepository) conn(ctx context.Context) *gorm.DB {
+	return database.Conn(ctx, r.db)
+}
+
 // Create создает новую запись пользователя в базе данных
 func (r *GormUserRepository) Create(ctx context.Context, user *user_model.User) error {
 	logger := r.log.WithContext(ctx).WithField("method", "UserRepository.Create")
@@ -67,8 +75,11 @@ func (r *GormUserRepository) Create(ctx context.Context, user *user_model.User)
 		logger.Error("Попытка создать nil пользователя")
 		return fmt.Errorf("%w: объект пользователя равен nil", ErrInvalidInput)
 	}
+	if user.Version == 0 {
+		user.Version = 1
+	}
 
-	result := r.db.WithContext(ctx).Create(user)
+	result := r.conn(ctx).Create(user)
 	if result.Error != nil {
 		logger.WithError(result.Error).Error("Не удалось создать пользователя")
 		return fmt.Errorf("%w: %v", ErrDatabaseError, result.Error)
@@ -91,17 +102,31 @@ func (r *GormUserRepository) Update(ctx context.Context, user *user_model.User)
 		return fmt.Errorf("%w: ID пользователя равен нулю, невозможно обновить", ErrInvalidInput)
 	}
 
-	// Model(user) ограничивает обновление пользователем с заданным ID
-	// Updates(user) обновляет ненулевые поля из объекта пользователя
-	result := r.db.WithContext(ctx).Model(user).Updates(user)
+	// Model(user) ограничивает обновление пользователем с заданным ID, а условие на версию
+	// отклоняет обновление, если запись изменили после чтения.
+	// Updates(user) обновляет ненулевые поля из объекта пользователя, включая новую версию.
+	expectedVersion := user.Version
+	user.Version = expectedVersion + 1
+	result := r.conn(ctx).Model(user).Where("version = ?", expectedVersion).Updates(user)
 
 	if result.Error != nil {
+		user.Version = expectedVersion
 		logger.WithError(result.Error).Error("Не удалось обновить пользователя")
 		return fmt.Errorf("%w: %v", ErrDatabaseError, result.Error)
 	}
 
 	if result.RowsAffected == 0 {
-		logger.Warn("Попытка обновления пользователя, но нет затронутых записей (возможно, пользователь не найден или нет изменений)")
+		user.Version = expectedVersion
+		var count int64
+		if err := r.conn(ctx).Model(&user_model.User{}).Where("id = ?", user.ID).Count(&count).Error; err != nil {
+			logger.WithError(err).Error("Не удалось проверить существование пользователя после неудачного обновления")
+			return fmt.Errorf("%w: %v", ErrDatabaseError, err)
+		}
+		if count > 0 {
+			logger.WithField("version", expectedVersion).Warn("Пользователь не обновлен: версия изменилась после чтения")
+			return ErrVersionMismatch
+		}
+		logger.Warn("Попытка обновления пользователя, но нет затронутых записей (пользователь не найден)")
 		return ErrNoRowsAffected
 	}
 
@@ -119,7 +144,7 @@ func (r *GormUserRepository) Delete(ctx context.Context, id uint) error {
 	}
 
 	// Delete GORM по умолчанию выполняет мягкое удаление, если модель имеет поле DeletedAt
-	result := r.db.WithContext(ctx).Delete(&user_model.User{}, id)
+	result := r.conn(ctx).Delete(&user_model.User{}, id)
 
 	if result.Error != nil {
 		logger.WithError(result.Error).Error("Не удалось удалить пользователя")
@@ -146,7 +171,7 @@ func (r *GormUserRepository) GetByID(ctx context.Context, id uint) (*user_model.
 		return nil, ErrUserNotFound
 	}
 
-	result := r.db.WithContext(ctx).First(&user, id)
+	result := r.conn(ctx).First(&user, id)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -171,7 +196,7 @@ func (r *GormUserRepository) GetByEmail(ctx context.Context, email string) (*use
 		return nil, ErrUserNotFound
 	}
 
-	result := r.db.WithContext(ctx).Where("email = ?", email).First(&user)
+	result := r.conn(ctx).Where("email = ?", email).First(&user)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -186,7 +211,8 @@ func (r *GormUserRepository) GetByEmail(ctx context.Context, email string) (*use
 	return &user, nil
 }
 
-// GetAll извлекает постраничный список пользователей с необязательными фильтрами
+// GetAll извлекает постраничный список пользователей, упорядоченных по ID, с необязательными фильтрами.
+// Фильтр по имени ищет подстроку без учета регистра.
 func (r *GormUserRepository) GetAll(
 	ctx context.Context,
 	params ListQueryParams,
@@ -195,7 +221,7 @@ func (r *GormUserRepository) GetAll(
 	var users []user_model.User
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&user_model.User{})
+	query := r.conn(ctx).Model(&user_model.User{})
 
 	// Применение фильтров на основе структуры ListQueryParams
 	if params.MinAge != nil && *params.MinAge > 0 {
@@ -207,8 +233,8 @@ func (r *GormUserRepository) GetAll(
 		logger.Debugf("Применение фильтра: age <= %d", *params.MaxAge)
 	}
 	if params.Name != nil && *params.Name != "" {
-		query = query.Where("name LIKE ?", "%"+*params.Name+"%")
-		logger.Debugf("Применение фильтра: name LIKE %%%s%%", *params.Name)
+		query = query.Where("LOWER(name) LIKE LOWER(?)", "%"+*params.Name+"%")
+		logger.Debugf("Применение фильтра: LOWER(name) LIKE LOWER(%%%s%%)", *params.Name)
 	}
 
 	// Подсчет общего количества записей, соответствующих фильтрам
@@ -238,7 +264,7 @@ func (r *GormUserRepository) GetAll(
 	}
 
 	offset := (page - 1) * limit
-	result := query.Offset(offset).Limit(limit).Find(&users)
+	result := query.Order("id").Offset(offset).Limit(limit).Find(&users)
 
 	if result.Error != nil {
 		logger.WithError(result.Error).Error("Не удалось получить постраничный список пользователей")
@@ -0,0 +1,253 @@
+package user_rep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/user_model"
+	"github.com/sirupsen/logrus"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Общий набор тестов для всех реализаций UserRepository: GORM и в памяти
+// должны вести себя одинаково.
+
+func newConformanceLogger() *logrus.Logger {
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	return log
+}
+
+func newGormConformanceRepo(t *testing.T) UserRepository {
+	dsn := filepath.Join(t.TempDir(), "users.db") + "?_busy_timeout=10000&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if err := db.AutoMigrate(&user_model.User{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return NewGormUserRepository(db, newConformanceLogger())
+}
+
+func newMemoryConformanceRepo(t *testing.T) UserRepository {
+	return NewMemoryUserRepository(newConformanceLogger())
+}
+
+var implementations = map[string]func(t *testing.T) UserRepository{
+	"gorm":   newGormConformanceRepo,
+	"memory": newMemoryConformanceRepo,
+}
+
+func forEachImplementation(t *testing.T, test func(t *testing.T, repo UserRepository)) {
+	for name, newRepo := range implementations {
+		t.Run(name, func(t *testing.T) {
+			test(t, newRepo(t))
+		})
+	}
+}
+
+func mustCreateUser(t *testing.T, repo UserRepository, name, email string, age int) *user_model.User {
+	t.Helper()
+	user := &user_model.User{Name: name, Email: email, Age: age, PasswordHash: "hash"}
+	if err := repo.Create(context.Background(), user); err != nil {
+		t.Fatalf("Create(%s): %v", email, err)
+	}
+	return user
+}
+
+func TestConformance_CreateAndGet(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T, repo UserRepository) {
+		ctx := context.Background()
+		user := mustCreateUser(t, repo, "Alice", "alice@example.com", 30)
+		if user.ID == 0 || user.Version != 1 || user.Role != user_model.RoleUser {
+			t.Fatalf("непредвиденный пользователь после создания: %+v", user)
+		}
+
+		byID, err := repo.GetByID(ctx, user.ID)
+		if err != nil || byID.Email != "alice@example.com" || byID.Version != 1 {
+			t.Errorf("GetByID: %+v, %v", byID, err)
+		}
+		byEmail, err := repo.GetByEmail(ctx, "alice@example.com")
+		if err != nil || byEmail.ID != user.ID {
+			t.Errorf("GetByEmail: %+v, %v", byEmail, err)
+		}
+
+		// Возвращенная копия не связана с хранимой записью
+		byID.Name = "Changed"
+		again, _ := repo.GetByID(ctx, user.ID)
+		if again.Name != "Alice" {
+			t.Errorf("изменение полученной копии повлияло на хранимого пользователя: %q", again.Name)
+		}
+
+		if _, err := repo.GetByID(ctx, 0); !errors.Is(err, ErrUserNotFound) {
+			t.Errorf("GetByID(0): ожидалась ErrUserNotFound, получено %v", err)
+		}
+		if _, err := repo.GetByEmail(ctx, "missing@example.com"); !errors.Is(err, ErrUserNotFound) {
+			t.Errorf("GetByEmail: ожидалась ErrUserNotFound, получено %v", err)
+		}
+		if err := repo.Create(ctx, nil); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("Create(nil): ожидалась ErrInvalidInput, получено %v", err)
+		}
+	})
+}
+
+func TestConformance_UniqueEmail(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T, repo UserRepository) {
+		ctx := context.Background()
+		mustCreateUser(t, repo, "Alice", "alice@example.com", 30)
+		bob := mustCreateUser(t, repo, "Bob", "bob@example.com", 40)
+
+		dup := &user_model.User{Name: "Alice 2", Email: "alice@example.com", Age: 31, PasswordHash: "hash"}
+		if err := repo.Create(ctx, dup); !errors.Is(err, ErrDatabaseError) {
+			t.Errorf("Create с занятым email: ожидалась ErrDatabaseError, получено %v", err)
+		}
+
+		bob.Email = "alice@example.com"
+		if err := repo.Update(ctx, bob); !errors.Is(err, ErrDatabaseError) {
+			t.Errorf("Update на занятый email: ожидалась ErrDatabaseError, получено %v", err)
+		}
+		stored, _ := repo.GetByID(ctx, bob.ID)
+		if stored.Email != "bob@example.com" || stored.Version != 1 {
+			t.Errorf("неудачное обновление изменило пользователя: %+v", stored)
+		}
+	})
+}
+
+func TestConformance_UpdateVersioning(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T, repo UserRepository) {
+		ctx := context.Background()
+		user := mustCreateUser(t, repo, "Alice", "alice@example.com", 30)
+		stale := *user
+
+		user.Name = "Alice Smith"
+		user.Age = 0 // нулевые поля не перезаписываются
+		if err := repo.Update(ctx, user); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if user.Version != 2 {
+			t.Errorf("ожидалась версия 2 после обновления, получено %d", user.Version)
+		}
+		stored, _ := repo.GetByID(ctx, user.ID)
+		if stored.Name != "Alice Smith" || stored.Age != 30 || stored.Version != 2 {
+			t.Errorf("непредвиденный пользователь после обновления: %+v", stored)
+		}
+
+		stale.Name = "Stale"
+		if err := repo.Update(ctx, &stale); !errors.Is(err, ErrVersionMismatch) {
+			t.Errorf("ожидалась ErrVersionMismatch, получено %v", err)
+		}
+		if stale.Version != 1 {
+			t.Errorf("версия устаревшей копии изменилась: %d", stale.Version)
+		}
+
+		missing := &user_model.User{ID: 999, Name: "Ghost", Version: 1}
+		if err := repo.Update(ctx, missing); !errors.Is(err, ErrNoRowsAffected) {
+			t.Errorf("ожидалась ErrNoRowsAffected, получено %v", err)
+		}
+		if err := repo.Update(ctx, &user_model.User{Name: "Zero"}); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("ожидалась ErrInvalidInput, получено %v", err)
+		}
+	})
+}
+
+func TestConformance_Delete(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T, repo UserRepository) {
+		ctx := context.Background()
+		user := mustCreateUser(t, repo, "Alice", "alice@example.com", 30)
+
+		if err := repo.Delete(ctx, user.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := repo.GetByID(ctx, user.ID); !errors.Is(err, ErrUserNotFound) {
+			t.Errorf("после удаления ожидалась ErrUserNotFound, получено %v", err)
+		}
+		if err := repo.Delete(ctx, user.ID); !errors.Is(err, ErrUserNotFound) {
+			t.Errorf("повторное удаление: ожидалась ErrUserNotFound, получено %v", err)
+		}
+		if err := repo.Delete(ctx, 0); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("Delete(0): ожидалась ErrInvalidInput, получено %v", err)
+		}
+		// Email удаленного пользователя снова свободен
+		mustCreateUser(t, repo, "Alice", "alice@example.com", 30)
+	})
+}
+
+func TestConformance_GetAllFiltersAndPagination(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T, repo UserRepository) {
+		ctx := context.Background()
+		for i := 1; i <= 5; i++ {
+			mustCreateUser(t, repo, fmt.Sprintf("User %d", i), fmt.Sprintf("user%d@example.com", i), 20+i*5)
+		}
+		mustCreateUser(t, repo, "Other", "other@example.com", 50)
+
+		users, total, err := repo.GetAll(ctx, ListQueryParams{Page: 2, Limit: 2})
+		if err != nil || total != 6 || len(users) != 2 || users[0].Email != "user3@example.com" {
+			t.Errorf("вторая страница: %+v, total=%d, err=%v", users, total, err)
+		}
+
+		minAge, maxAge, name := 30, 40, "User"
+		users, total, err = repo.GetAll(ctx, ListQueryParams{Page: 1, Limit: 10, MinAge: &minAge, MaxAge: &maxAge, Name: &name})
+		if err != nil || total != 3 || len(users) != 3 {
+			t.Errorf("фильтры: %+v, total=%d, err=%v", users, total, err)
+		}
+
+		// Имя ищется без учета регистра
+		mixedCase := "uSeR"
+		users, total, err = repo.GetAll(ctx, ListQueryParams{Page: 1, Limit: 10, Name: &mixedCase})
+		if err != nil || total != 5 || len(users) != 5 || users[0].Email != "user1@example.com" {
+			t.Errorf("фильтр по имени в другом регистре: %+v, total=%d, err=%v", users, total, err)
+		}
+
+		users, total, err = repo.GetAll(ctx, ListQueryParams{Page: 5, Limit: 10})
+		if err != nil || total != 6 || len(users) != 0 {
+			t.Errorf("страница за пределами списка: %+v, total=%d, err=%v", users, total, err)
+		}
+
+		users, _, err = repo.GetAll(ctx, ListQueryParams{Page: 0, Limit: 0})
+		if err != nil || len(users) != 6 {
+			t.Errorf("значения пагинации по умолчанию: %d пользователей, err=%v", len(users), err)
+		}
+	})
+}
+
+func TestConformance_ConcurrentUpdatesOfSameVersion(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T, repo UserRepository) {
+		ctx := context.Background()
+		user := mustCreateUser(t, repo, "Alice", "alice@example.com", 30)
+
+		const writers = 8
+		var wg sync.WaitGroup
+		results := make(chan error, writers)
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				update := *user
+				update.Name = fmt.Sprintf("Writer %d", i)
+				results <- repo.Update(ctx, &update)
+			}(i)
+		}
+		wg.Wait()
+		close(results)
+
+		succeeded := 0
+		for err := range results {
+			switch {
+			case err == nil:
+				succeeded++
+			case !errors.Is(err, ErrVersionMismatch):
+				t.Errorf("непредвиденная ошибка: %v", err)
+			}
+		}
+		if succeeded != 1 {
+			t.Errorf("ожидалось ровно одно успешное обновление версии 1, получено %d", succeeded)
+		}
+	})
+}
@@ -98,6 +98,55 @@ func TestUpdateUser_NoRowsAffected(t *testing.T) {
 	}
 }
 
+func TestUpdateUser_IncrementsVersion(t *testing.T) {
+	repo, cleanup := newTestRepo(t)
+	defer cleanup()
+	ctx := context.Background()
+	user := &user_model.User{Name: "Versioned", Email: "versioned@example.com", Age: 25}
+	_ = repo.Create(ctx, user)
+	if user.Version != 1 {
+		t.Fatalf("expected new user to have version 1, got %d", user.Version)
+	}
+
+	user.Name = "Versioned Again"
+	if err := repo.Update(ctx, user); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	stored, _ := repo.GetByID(ctx, user.ID)
+	if user.Version != 2 || stored.Version != 2 {
+		t.Errorf("expected version 2, got in-memory %d, stored %d", user.Version, stored.Version)
+	}
+}
+
+func TestUpdateUser_StaleVersion(t *testing.T) {
+	repo, cleanup := newTestRepo(t)
+	defer cleanup()
+	ctx := context.Background()
+	user := &user_model.User{Name: "Shared", Email: "shared@example.com", Age: 25}
+	_ = repo.Create(ctx, user)
+
+	first, _ := repo.GetByID(ctx, user.ID)
+	second, _ := repo.GetByID(ctx, user.ID)
+
+	first.Name = "First"
+	if err := repo.Update(ctx, first); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	second.Name = "Second"
+	err := repo.Update(ctx, second)
+	if !errors.Is(err, ErrVersionMismatch) {
+		t.Fatalf("expected ErrVersionMismatch, got %v", err)
+	}
+	if second.Version != 1 {
+		t.Errorf("expected rejected copy to keep version 1, got %d", second.Version)
+	}
+
+	stored, _ := repo.GetByID(ctx, user.ID)
+	if stored.Name != "First" {
+		t.Errorf("expected first update to survive, got %s", stored.Name)
+	}
+}
+
 func TestDeleteUser_Success(t *testing.T) {
 	repo, cleanup := newTestRepo(t)
 	defer cleanup()
@@ -5,51 +5,86 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/IlyushinDM/user-order-api/internal/models/money_model"
 	"github.com/IlyushinDM/user-order-api/internal/models/order_model"
 	"github.com/IlyushinDM/user-order-api/internal/repository/order_rep"
+	"github.com/IlyushinDM/user-order-api/internal/repository/product_rep"
+	"github.com/IlyushinDM/user-order-api/internal/utils/metrics_util"
 	"github.com/sirupsen/logrus"
 )
 
 // Определение ошибок сервисного слоя для заказов
 var (
-	ErrOrderNotFound        = errors.New("заказ не найден")
-	ErrInvalidServiceInput  = errors.New("недопустимые входные данные сервиса")
-	ErrServiceDatabaseError = errors.New("ошибка базы данных сервиса")
-	ErrNoUpdateFields       = errors.New("нет полей для обновления")
+	ErrOrderNotFound           = errors.New("заказ не найден")
+	ErrInvalidServiceInput     = errors.New("недопустимые входные данные сервиса")
+	ErrServiceDatabaseError    = errors.New("ошибка базы данных сервиса")
+	ErrNoUpdateFields          = errors.New("нет полей для обновления")
+	ErrInvalidStatusTransition = errors.New("недопустимая смена состояния заказа")
+	ErrOrderNotEditable        = errors.New("заказ в текущем состоянии нельзя изменить")
+	ErrProductNotFound         = errors.New("продукт не найден в каталоге")
+	ErrInsufficientStock       = errors.New("недостаточно товара на складе")
+	ErrCurrencyMismatch        = errors.New("валюта продукта не совпадает с валютой заказа")
+	ErrVersionMismatch         = errors.New("заказ изменен другим запросом")
 )
 
+// StatusTransitionError описывает отклоненную смену состояния заказа.
+// Сопоставляется с ErrInvalidStatusTransition через errors.Is.
+type StatusTransitionError struct {
+	From order_model.OrderStatus
+	To   order_model.OrderStatus
+}
+
+func (e *StatusTransitionError) Error() string {
+	return fmt.Sprintf("%s: %s -> %s", ErrInvalidStatusTransition.Error(), e.From, e.To)
+}
+
+// Is позволяет проверять ошибку через errors.Is(err, ErrInvalidStatusTransition)
+func (e *StatusTransitionError) Is(target error) bool {
+	return target == ErrInvalidStatusTransition
+}
+
 // OrderService определяет интерфейс для бизнес-логики заказов
 type OrderService interface {
 	CreateOrder(ctx context.Context, userID uint,
 		req order_model.CreateOrderRequest) (*order_model.Order, error)
+	// UpdateOrder заменяет позиции заказа, если его текущая версия равна version
 	UpdateOrder(ctx context.Context, orderID uint,
-		userID uint, req order_model.UpdateOrderRequest) (*order_model.Order, error)
+		userID uint, version uint, req order_model.UpdateOrderRequest) (*order_model.Order, error)
 	DeleteOrder(ctx context.Context, orderID uint, userID uint) error
 	GetOrderByID(ctx context.Context, orderID uint, userID uint) (*order_model.Order, error)
 	GetAllOrdersByUser(ctx context.Context,
-		userID uint, page, limit int) ([]order_model.Order, int64, error)
+		userID uint, page, limit int, filters map[string]any) ([]order_model.Order, int64, error)
+	ChangeOrderStatus(ctx context.Context, orderID uint,
+		userID uint, status order_model.OrderStatus) (*order_model.Order, error)
+	CancelOrder(ctx context.Context, orderID uint, userID uint) (*order_model.Order, error)
 }
 
 type orderService struct {
-	orderRepo order_rep.OrderRepository
-	log       *logrus.Logger
+	orderRepo   order_rep.OrderRepository
+	productRepo product_rep.ProductRepository
+	log         *logrus.Logger
 }
 
-// NewOrderService создает новый сервис заказов
+// NewOrderService создает новый сервис заказов.
+// Каталог продуктов используется для получения актуальных названий и цен позиций.
 func NewOrderService(
 	orderRepo order_rep.OrderRepository,
+	productRepo product_rep.ProductRepository,
 	log *logrus.Logger,
 ) OrderService {
 	if orderRepo == nil {
 		logrus.Fatal("Экземпляр OrderRepository равен nil в NewOrderService")
 	}
+	if productRepo == nil {
+		logrus.Fatal("Экземпляр ProductRepository равен nil в NewOrderService")
+	}
 	if log == nil {
 		defaultLog := logrus.New()
 		defaultLog.SetLevel(logrus.InfoLevel)
 		defaultLog.Warn("Экземпляр логгера Logrus равен nil в NewOrderService, используется логгер по умолчанию")
 		log = defaultLog
 	}
-	return &orderService{orderRepo: orderRepo, log: log}
+	return &orderService{orderRepo: orderRepo, productRepo: productRepo, log: log}
 }
 
 func (s *orderService) CreateOrder(ctx context.Context,
@@ -65,27 +100,29 @@ func (s *orderService) CreateOrder(ctx context.Context,
 		logger.Warn("Попытка создать заказ с нулевым ID пользователя")
 		return nil, fmt.Errorf("%w: ID пользователя должен быть положительным", ErrInvalidServiceInput)
 	}
-	if req.ProductName == "" || req.Quantity <= 0 || req.Price <= 0 {
-		logger.WithFields(logrus.Fields{
-			"product_name": req.ProductName,
-			"quantity":     req.Quantity,
-			"price":        req.Price,
-		}).Warn("Недопустимые входные данные для создания заказа")
-		return nil, fmt.Errorf(
-			"%w: название продукта, количество и цена обязательны и должны быть положительными", ErrInvalidServiceInput)
+	if !money_model.IsSupportedCurrency(req.Currency) {
+		logger.WithField("currency", req.Currency).Warn("Неподдерживаемая валюта заказа")
+		return nil, fmt.Errorf("%w: валюта %q не поддерживается", ErrInvalidServiceInput, req.Currency)
+	}
+	items, err := s.buildOrderItems(ctx, req.Currency, req.Items)
+	if err != nil {
+		logger.WithError(err).Warn("Недопустимые входные данные для создания заказа")
+		return nil, err
 	}
 
 	order := &order_model.Order{
-		UserID:      userID,
-		ProductName: req.ProductName,
-		Quantity:    req.Quantity,
-		Price:       req.Price,
+		UserID:   userID,
+		Status:   order_model.StatusPending,
+		Currency: req.Currency,
+		Items:    items,
 	}
 
 	if err := s.orderRepo.Create(ctx, order); err != nil {
 		logger.WithError(err).Error("Не удалось создать заказ в репозитории")
 		// Маппинг ошибок репозитория на ошибки сервиса
 		switch {
+		case errors.Is(err, order_rep.ErrInsufficientStock):
+			return nil, fmt.Errorf("%w: %v", ErrInsufficientStock, err)
 		case errors.Is(err, order_rep.ErrDatabaseError):
 			return nil, fmt.Errorf("%w: не удалось сохранить заказ в базу данных", ErrServiceDatabaseError)
 		default:
@@ -94,13 +131,62 @@ func (s *orderService) CreateOrder(ctx context.Context,
 	}
 
 	logger.WithField("order_id", order.ID).Info("Заказ успешно создан")
+	metrics_util.ObserveOrderOperation(metrics_util.OrderCreated)
 	return order, nil
 }
 
+// buildOrderItems проверяет позиции из запроса и преобразует их в модели.
+// Название и цена каждой позиции берутся из каталога продуктов; валюта продукта
+// должна совпадать с валютой заказа, суммы в разных валютах не складываются.
+func (s *orderService) buildOrderItems(
+	ctx context.Context,
+	currency string,
+	reqItems []order_model.OrderItemRequest,
+) ([]order_model.OrderItem, error) {
+	if len(reqItems) == 0 {
+		return nil, fmt.Errorf("%w: заказ должен содержать хотя бы одну позицию", ErrInvalidServiceInput)
+	}
+	productIDs := make([]uint, 0, len(reqItems))
+	for i, reqItem := range reqItems {
+		if reqItem.ProductID == 0 || reqItem.Quantity <= 0 {
+			return nil, fmt.Errorf(
+				"%w: позиция %d: ID продукта и количество обязательны и должны быть положительными",
+				ErrInvalidServiceInput, i+1)
+		}
+		productIDs = append(productIDs, reqItem.ProductID)
+	}
+
+	products, err := s.productRepo.GetByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("%w: не удалось получить продукты из каталога", ErrServiceDatabaseError)
+	}
+
+	items := make([]order_model.OrderItem, 0, len(reqItems))
+	for i, reqItem := range reqItems {
+		product, ok := products[reqItem.ProductID]
+		if !ok {
+			return nil, fmt.Errorf("%w: позиция %d: продукт с ID %d", ErrProductNotFound, i+1, reqItem.ProductID)
+		}
+		if product.Currency != currency {
+			return nil, fmt.Errorf("%w: позиция %d: продукт с ID %d в валюте %s, заказ в валюте %s",
+				ErrCurrencyMismatch, i+1, reqItem.ProductID, product.Currency, currency)
+		}
+		productID := product.ID
+		items = append(items, order_model.OrderItem{
+			ProductID:   &productID,
+			ProductName: product.Name,
+			Quantity:    reqItem.Quantity,
+			UnitPrice:   product.Price,
+		})
+	}
+	return items, nil
+}
+
 func (s *orderService) UpdateOrder(
 	ctx context.Context,
 	orderID uint,
 	userID uint,
+	version uint,
 	req order_model.UpdateOrderRequest,
 ) (*order_model.Order, error) {
 	logger := s.log.WithContext(ctx).WithField(
@@ -130,28 +216,48 @@ func (s *orderService) UpdateOrder(
 		}
 	}
 
-	updated := false
-	if req.ProductName != "" && req.ProductName != order.ProductName {
-		order.ProductName = req.ProductName
-		updated = true
-		logger.Debug("Обновление названия продукта заказа")
-	}
-	if req.Quantity > 0 && req.Quantity != order.Quantity {
-		order.Quantity = req.Quantity
-		updated = true
-		logger.Debug("Обновление количества заказа")
+	// Быстрая проверка версии; окончательно ее гарантирует условное обновление в репозитории
+	if order.Version != version {
+		logger.WithFields(logrus.Fields{"expected": version, "actual": order.Version}).
+			Warn("Обновление не удалось: версия заказа не совпадает с If-Match")
+		return nil, ErrVersionMismatch
 	}
-	if req.Price > 0 && req.Price != order.Price {
-		order.Price = req.Price
-		updated = true
-		logger.Debug("Обновление цены заказа")
+
+	if !order.Status.IsEditable() {
+		logger.WithField("status", order.Status).Warn("Обновление не удалось: заказ в текущем состоянии нельзя изменить")
+		return nil, fmt.Errorf("%w: состояние %s", ErrOrderNotEditable, order.Status)
 	}
 
-	if !updated {
+	if len(req.Items) == 0 {
+		if req.Currency != "" {
+			logger.Warn("Попытка изменить валюту заказа без замены позиций")
+			return nil, fmt.Errorf("%w: валюту заказа можно изменить только вместе с позициями", ErrInvalidServiceInput)
+		}
 		logger.Info("Нет полей для обновления заказа")
 		return order, ErrNoUpdateFields
 	}
 
+	currency := order.Currency
+	if req.Currency != "" {
+		currency = req.Currency
+	}
+	if currency == "" {
+		currency = money_model.DefaultCurrency
+	}
+	if !money_model.IsSupportedCurrency(currency) {
+		logger.WithField("currency", currency).Warn("Неподдерживаемая валюта заказа")
+		return nil, fmt.Errorf("%w: валюта %q не поддерживается", ErrInvalidServiceInput, currency)
+	}
+
+	items, err := s.buildOrderItems(ctx, currency, req.Items)
+	if err != nil {
+		logger.WithError(err).Warn("Недопустимые позиции для обновления заказа")
+		return nil, err
+	}
+	order.Items = items
+	order.Currency = currency
+	logger.Debug("Замена позиций заказа")
+
 	if err := s.orderRepo.Update(ctx, order); err != nil {
 		logger.WithError(err).Error("Не удалось обновить заказ в репозитории")
 		// Маппинг ошибок репозитория
@@ -162,6 +268,10 @@ func (s *orderService) UpdateOrder(
 		case errors.Is(err, order_rep.ErrNoRowsAffected):
 			logger.Warn("Обновление не удалось в репозитории: Строки не затронуты при сохранении")
 			return nil, ErrOrderNotFound
+		case errors.Is(err, order_rep.ErrVersionMismatch):
+			return nil, ErrVersionMismatch
+		case errors.Is(err, order_rep.ErrInsufficientStock):
+			return nil, fmt.Errorf("%w: %v", ErrInsufficientStock, err)
 		case errors.Is(err, order_rep.ErrDatabaseError):
 			return nil, fmt.Errorf("%w: ошибка базы данных при сохранении обновленного заказа", ErrServiceDatabaseError)
 		default:
@@ -170,6 +280,7 @@ func (s *orderService) UpdateOrder(
 	}
 
 	logger.Info("Заказ успешно обновлен")
+	metrics_util.ObserveOrderOperation(metrics_util.OrderUpdated)
 	return order, nil
 }
 
@@ -204,6 +315,7 @@ func (s *orderService) DeleteOrder(ctx context.Context, orderID uint, userID uin
 	}
 
 	logger.Info("Заказ успешно удален")
+	metrics_util.ObserveOrderOperation(metrics_util.OrderDeleted)
 	return nil
 }
 
@@ -247,6 +359,7 @@ func (s *orderService) GetAllOrdersByUser(
 	userID uint,
 	page,
 	limit int,
+	filters map[string]any,
 ) ([]order_model.Order, int64, error) {
 	logger := s.log.WithContext(ctx).WithField(
 		"method",
@@ -270,8 +383,21 @@ func (s *orderService) GetAllOrdersByUser(
 	offset := (page - 1) * limit
 	logger = logger.WithField("offset", offset)
 
-	// Вызываем метод репозитория со смещением и лимитом
-	orders, total, err := s.orderRepo.GetAllByUser(ctx, userID, offset, limit)
+	queryParams := order_rep.ListQueryParams{
+		Offset: offset,
+		Limit:  limit,
+	}
+	if status, ok := filters["status"].(order_model.OrderStatus); ok {
+		if !status.IsValid() {
+			logger.Warnf("Указано неизвестное состояние заказа для фильтрации: %s", status)
+			return nil, 0, fmt.Errorf("%w: неизвестное состояние заказа %q", ErrInvalidServiceInput, status)
+		}
+		queryParams.Status = &status
+		logger.Debugf("Применение параметра фильтра: status = %s", status)
+	}
+
+	// Вызываем метод репозитория со смещением, лимитом и фильтрами
+	orders, total, err := s.orderRepo.GetAllByUser(ctx, userID, queryParams)
 	if err != nil {
 		logger.WithError(err).Error("Не удалось получить заказы для пользователя из репозитория")
 		switch {
@@ -289,3 +415,61 @@ func (s *orderService) GetAllOrdersByUser(
 		}).Info("Заказы для пользователя успешно получены")
 	return orders, total, nil
 }
+
+// ChangeOrderStatus переводит заказ в новое состояние, если такой переход допустим.
+// Недопустимый переход возвращает *StatusTransitionError.
+func (s *orderService) ChangeOrderStatus(
+	ctx context.Context,
+	orderID uint,
+	userID uint,
+	status order_model.OrderStatus,
+) (*order_model.Order, error) {
+	logger := s.log.WithContext(ctx).WithField(
+		"method",
+		"OrderService.ChangeOrderStatus").WithFields(logrus.Fields{"order_id": orderID, "user_id": userID, "to": status})
+
+	if orderID == 0 || userID == 0 {
+		logger.Warn("Попытка изменить состояние заказа с нулевым ID заказа или ID пользователя")
+		return nil, fmt.Errorf("%w: ID заказа и ID пользователя должны быть положительными", ErrInvalidServiceInput)
+	}
+	if !status.IsValid() {
+		logger.Warn("Указано неизвестное целевое состояние заказа")
+		return nil, fmt.Errorf("%w: неизвестное состояние заказа %q", ErrInvalidServiceInput, status)
+	}
+
+	order, err := s.GetOrderByID(ctx, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !order.Status.CanTransitionTo(status) {
+		logger.WithField("from", order.Status).Warn("Отклонена недопустимая смена состояния заказа")
+		return nil, &StatusTransitionError{From: order.Status, To: status}
+	}
+
+	if err := s.orderRepo.UpdateStatus(ctx, orderID, userID, order.Status, status); err != nil {
+		logger.WithError(err).Error("Не удалось изменить состояние заказа в репозитории")
+		switch {
+		case errors.Is(err, order_rep.ErrNoRowsAffected):
+			// Состояние изменилось между чтением и записью: переход проверялся для устаревшего состояния
+			logger.Warn("Состояние заказа было изменено параллельным запросом")
+			return nil, &StatusTransitionError{From: order.Status, To: status}
+		case errors.Is(err, order_rep.ErrDatabaseError):
+			return nil, fmt.Errorf("%w: ошибка базы данных при смене состояния заказа", ErrServiceDatabaseError)
+		default:
+			return nil, fmt.Errorf("%w: не удалось изменить состояние заказа", ErrServiceDatabaseError)
+		}
+	}
+
+	logger.WithField("from", order.Status).Info("Состояние заказа успешно изменено")
+	metrics_util.ObserveOrderOperation(metrics_util.OrderStatusChanged)
+	order.Status = status
+	// Репозиторий увеличил версию вместе со сменой состояния
+	order.Version++
+	return order, nil
+}
+
+// CancelOrder отменяет заказ, который еще не был оплачен
+func (s *orderService) CancelOrder(ctx context.Context, orderID uint, userID uint) (*order_model.Order, error) {
+	return s.ChangeOrderStatus(ctx, orderID, userID, order_model.StatusCancelled)
+}
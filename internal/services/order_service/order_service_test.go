@@ -4,8 +4,11 @@ import (
 	"context"
 	"testing"
 
+	"github.com/IlyushinDM/user-order-api/internal/models/money_model"
 	"github.com/IlyushinDM/user-order-api/internal/models/order_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/product_model"
 	"github.com/IlyushinDM/user-order-api/internal/repository/order_rep"
+	"github.com/IlyushinDM/user-order-api/internal/repository/product_rep"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
@@ -17,7 +20,8 @@ type mockOrderRepo struct {
 	UpdateFn       func(ctx context.Context, order *order_model.Order) error
 	DeleteFn       func(ctx context.Context, orderID, userID uint) error
 	GetByIDFn      func(ctx context.Context, orderID, userID uint) (*order_model.Order, error)
-	GetAllByUserFn func(ctx context.Context, userID uint, offset, limit int) ([]order_model.Order, int64, error)
+	GetAllByUserFn func(ctx context.Context, userID uint, params order_rep.ListQueryParams) ([]order_model.Order, int64, error)
+	UpdateStatusFn func(ctx context.Context, orderID, userID uint, from, to order_model.OrderStatus) error
 }
 
 func (m *mockOrderRepo) Create(ctx context.Context, order *order_model.Order) error {
@@ -36,8 +40,67 @@ func (m *mockOrderRepo) GetByID(ctx context.Context, orderID, userID uint) (*ord
 	return m.GetByIDFn(ctx, orderID, userID)
 }
 
-func (m *mockOrderRepo) GetAllByUser(ctx context.Context, userID uint, offset, limit int) ([]order_model.Order, int64, error) {
-	return m.GetAllByUserFn(ctx, userID, offset, limit)
+func (m *mockOrderRepo) GetAllByUser(ctx context.Context, userID uint, params order_rep.ListQueryParams) ([]order_model.Order, int64, error) {
+	return m.GetAllByUserFn(ctx, userID, params)
+}
+
+func (m *mockOrderRepo) UpdateStatus(ctx context.Context, orderID, userID uint, from, to order_model.OrderStatus) error {
+	return m.UpdateStatusFn(ctx, orderID, userID, from, to)
+}
+
+// --- Mock implementation for product_rep.ProductRepository ---
+
+type mockProductRepo struct {
+	GetByIDsFn func(ctx context.Context, ids []uint) (map[uint]product_model.Product, error)
+}
+
+func (m *mockProductRepo) Create(ctx context.Context, product *product_model.Product) error {
+	return nil
+}
+
+func (m *mockProductRepo) Update(ctx context.Context, product *product_model.Product) error {
+	return nil
+}
+
+func (m *mockProductRepo) SetStock(ctx context.Context, id uint, stock int) error {
+	return nil
+}
+
+func (m *mockProductRepo) Delete(ctx context.Context, id uint) error {
+	return nil
+}
+
+func (m *mockProductRepo) GetByID(ctx context.Context, id uint) (*product_model.Product, error) {
+	return nil, product_rep.ErrProductNotFound
+}
+
+func (m *mockProductRepo) GetByIDs(ctx context.Context, ids []uint) (map[uint]product_model.Product, error) {
+	return m.GetByIDsFn(ctx, ids)
+}
+
+func (m *mockProductRepo) GetAll(ctx context.Context, params product_rep.ListQueryParams) ([]product_model.Product, int64, error) {
+	return nil, 0, nil
+}
+
+// newTestCatalog возвращает каталог с фиксированным набором продуктов
+func newTestCatalog() *mockProductRepo {
+	catalog := map[uint]product_model.Product{
+		1: {ID: 1, Name: "TestProduct", Price: 1050, Currency: "RUB"},
+		2: {ID: 2, Name: "Other", Price: 400, Currency: "RUB"},
+		3: {ID: 3, Name: "New", Price: 300, Currency: "RUB"},
+		4: {ID: 4, Name: "Imported", Price: 500, Currency: "USD"},
+	}
+	return &mockProductRepo{
+		GetByIDsFn: func(ctx context.Context, ids []uint) (map[uint]product_model.Product, error) {
+			found := make(map[uint]product_model.Product)
+			for _, id := range ids {
+				if p, ok := catalog[id]; ok {
+					found[id] = p
+				}
+			}
+			return found, nil
+		},
+	}
 }
 
 // --- Tests ---
@@ -50,38 +113,137 @@ func TestCreateOrder_Success(t *testing.T) {
 		},
 	}
 	log := logrus.New()
-	svc := NewOrderService(mockRepo, log)
+	svc := NewOrderService(mockRepo, newTestCatalog(), log)
 
-	req := order_model.CreateOrderRequest{
-		ProductName: "TestProduct",
-		Quantity:    2,
-		Price:       10.5,
-	}
+	req := order_model.CreateOrderRequest{Currency: "RUB", Items: []order_model.OrderItemRequest{
+		{ProductID: 1, Quantity: 2},
+		{ProductID: 2, Quantity: 1},
+	}}
 	order, err := svc.CreateOrder(context.Background(), 42, req)
 	assert.NoError(t, err)
 	assert.NotNil(t, order)
 	assert.Equal(t, uint(42), order.UserID)
-	assert.Equal(t, "TestProduct", order.ProductName)
-	assert.Equal(t, 2, order.Quantity)
-	assert.Equal(t, 10.5, order.Price)
+	assert.Equal(t, order_model.StatusPending, order.Status)
+	if assert.Len(t, order.Items, 2) {
+		assert.Equal(t, "TestProduct", order.Items[0].ProductName)
+		assert.Equal(t, 2, order.Items[0].Quantity)
+		assert.Equal(t, money_model.Amount(1050), order.Items[0].UnitPrice)
+	}
+	assert.Equal(t, "25.00", order.Total().String())
 }
 
 func TestCreateOrder_InvalidInput(t *testing.T) {
 	mockRepo := &mockOrderRepo{}
 	log := logrus.New()
-	svc := NewOrderService(mockRepo, log)
+	svc := NewOrderService(mockRepo, newTestCatalog(), log)
 
 	_, err := svc.CreateOrder(context.Background(), 0, order_model.CreateOrderRequest{})
 	assert.ErrorIs(t, err, ErrInvalidServiceInput)
 
-	_, err = svc.CreateOrder(context.Background(), 1, order_model.CreateOrderRequest{
-		ProductName: "",
-		Quantity:    0,
-		Price:       0,
-	})
+	_, err = svc.CreateOrder(context.Background(), 1, order_model.CreateOrderRequest{})
+	assert.ErrorIs(t, err, ErrInvalidServiceInput)
+
+	_, err = svc.CreateOrder(context.Background(), 1, order_model.CreateOrderRequest{Currency: "RUB", Items: []order_model.OrderItemRequest{
+		{ProductID: 1, Quantity: 1},
+		{ProductID: 0, Quantity: 0},
+	}})
 	assert.ErrorIs(t, err, ErrInvalidServiceInput)
 }
 
+func TestCreateOrder_PriceFromCatalog(t *testing.T) {
+	var saved *order_model.Order
+	mockRepo := &mockOrderRepo{
+		CreateFn: func(ctx context.Context, order *order_model.Order) error {
+			saved = order
+			return nil
+		},
+	}
+	svc := NewOrderService(mockRepo, newTestCatalog(), logrus.New())
+
+	_, err := svc.CreateOrder(context.Background(), 1, order_model.CreateOrderRequest{Currency: "RUB", Items: []order_model.OrderItemRequest{
+		{ProductID: 2, Quantity: 3},
+	}})
+	assert.NoError(t, err)
+	if assert.NotNil(t, saved) && assert.Len(t, saved.Items, 1) {
+		item := saved.Items[0]
+		if assert.NotNil(t, item.ProductID) {
+			assert.Equal(t, uint(2), *item.ProductID)
+		}
+		assert.Equal(t, "Other", item.ProductName)
+		assert.Equal(t, money_model.Amount(400), item.UnitPrice)
+	}
+}
+
+func TestCreateOrder_UnknownProduct(t *testing.T) {
+	svc := NewOrderService(&mockOrderRepo{}, newTestCatalog(), logrus.New())
+
+	_, err := svc.CreateOrder(context.Background(), 1, order_model.CreateOrderRequest{Currency: "RUB", Items: []order_model.OrderItemRequest{
+		{ProductID: 1, Quantity: 1},
+		{ProductID: 99, Quantity: 1},
+	}})
+	assert.ErrorIs(t, err, ErrProductNotFound)
+}
+
+func TestCreateOrder_CatalogError(t *testing.T) {
+	catalog := &mockProductRepo{
+		GetByIDsFn: func(ctx context.Context, ids []uint) (map[uint]product_model.Product, error) {
+			return nil, product_rep.ErrDatabaseError
+		},
+	}
+	svc := NewOrderService(&mockOrderRepo{}, catalog, logrus.New())
+
+	_, err := svc.CreateOrder(context.Background(), 1, order_model.CreateOrderRequest{Currency: "RUB", Items: []order_model.OrderItemRequest{
+		{ProductID: 1, Quantity: 1},
+	}})
+	assert.ErrorIs(t, err, ErrServiceDatabaseError)
+}
+
+func TestCreateOrder_Currency(t *testing.T) {
+	mockRepo := &mockOrderRepo{
+		CreateFn: func(ctx context.Context, order *order_model.Order) error { return nil },
+	}
+	svc := NewOrderService(mockRepo, newTestCatalog(), logrus.New())
+	items := []order_model.OrderItemRequest{{ProductID: 4, Quantity: 2}}
+
+	order, err := svc.CreateOrder(context.Background(), 1, order_model.CreateOrderRequest{Currency: "USD", Items: items})
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", order.Currency)
+	assert.Equal(t, "10.00", order.Total().String())
+
+	_, err = svc.CreateOrder(context.Background(), 1, order_model.CreateOrderRequest{Currency: "RUB", Items: items})
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = svc.CreateOrder(context.Background(), 1, order_model.CreateOrderRequest{Currency: "JPY", Items: items})
+	assert.ErrorIs(t, err, ErrInvalidServiceInput)
+}
+
+func TestUpdateOrder_CurrencyWithoutItems(t *testing.T) {
+	mockRepo := &mockOrderRepo{
+		GetByIDFn: func(ctx context.Context, orderID, userID uint) (*order_model.Order, error) {
+			return &order_model.Order{ID: orderID, UserID: userID, Currency: "RUB", Status: order_model.StatusPending, Version: 1}, nil
+		},
+	}
+	svc := NewOrderService(mockRepo, newTestCatalog(), logrus.New())
+
+	_, err := svc.UpdateOrder(context.Background(), 1, 2, 1, order_model.UpdateOrderRequest{Currency: "USD"})
+	assert.ErrorIs(t, err, ErrInvalidServiceInput)
+}
+
+func TestCreateOrder_InsufficientStock(t *testing.T) {
+	mockRepo := &mockOrderRepo{
+		CreateFn: func(ctx context.Context, order *order_model.Order) error {
+			return &order_rep.InsufficientStockError{ProductID: 1, Requested: 2}
+		},
+	}
+	svc := NewOrderService(mockRepo, newTestCatalog(), logrus.New())
+
+	_, err := svc.CreateOrder(context.Background(), 1, order_model.CreateOrderRequest{Currency: "RUB", Items: []order_model.OrderItemRequest{
+		{ProductID: 1, Quantity: 2},
+	}})
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+	assert.NotErrorIs(t, err, ErrServiceDatabaseError)
+}
+
 func TestCreateOrder_RepoError(t *testing.T) {
 	mockRepo := &mockOrderRepo{
 		CreateFn: func(ctx context.Context, order *order_model.Order) error {
@@ -89,13 +251,11 @@ func TestCreateOrder_RepoError(t *testing.T) {
 		},
 	}
 	log := logrus.New()
-	svc := NewOrderService(mockRepo, log)
+	svc := NewOrderService(mockRepo, newTestCatalog(), log)
 
-	req := order_model.CreateOrderRequest{
-		ProductName: "Test",
-		Quantity:    1,
-		Price:       1,
-	}
+	req := order_model.CreateOrderRequest{Currency: "RUB", Items: []order_model.OrderItemRequest{
+		{ProductID: 1, Quantity: 1},
+	}}
 	_, err := svc.CreateOrder(context.Background(), 1, req)
 	assert.ErrorIs(t, err, ErrServiceDatabaseError)
 }
@@ -104,11 +264,11 @@ func TestUpdateOrder_Success(t *testing.T) {
 	mockRepo := &mockOrderRepo{
 		GetByIDFn: func(ctx context.Context, orderID, userID uint) (*order_model.Order, error) {
 			return &order_model.Order{
-				ID:          orderID,
-				UserID:      userID,
-				ProductName: "Old",
-				Quantity:    1,
-				Price:       1,
+				ID:      orderID,
+				UserID:  userID,
+				Items:   []order_model.OrderItem{{ProductName: "Old", Quantity: 1, UnitPrice: 1}},
+				Status:  order_model.StatusPending,
+				Version: 1,
 			}, nil
 		},
 		UpdateFn: func(ctx context.Context, order *order_model.Order) error {
@@ -116,41 +276,37 @@ func TestUpdateOrder_Success(t *testing.T) {
 		},
 	}
 	log := logrus.New()
-	svc := NewOrderService(mockRepo, log)
+	svc := NewOrderService(mockRepo, newTestCatalog(), log)
 
-	req := order_model.UpdateOrderRequest{
-		ProductName: "New",
-		Quantity:    2,
-		Price:       3,
-	}
-	order, err := svc.UpdateOrder(context.Background(), 1, 2, req)
+	req := order_model.UpdateOrderRequest{Items: []order_model.OrderItemRequest{
+		{ProductID: 3, Quantity: 2},
+	}}
+	order, err := svc.UpdateOrder(context.Background(), 1, 2, 1, req)
 	assert.NoError(t, err)
-	assert.Equal(t, "New", order.ProductName)
-	assert.Equal(t, 2, order.Quantity)
-	assert.Equal(t, 3.0, order.Price)
+	if assert.Len(t, order.Items, 1) {
+		assert.Equal(t, "New", order.Items[0].ProductName)
+		assert.Equal(t, 2, order.Items[0].Quantity)
+		assert.Equal(t, money_model.Amount(300), order.Items[0].UnitPrice)
+	}
 }
 
 func TestUpdateOrder_NoFieldsToUpdate(t *testing.T) {
 	mockRepo := &mockOrderRepo{
 		GetByIDFn: func(ctx context.Context, orderID, userID uint) (*order_model.Order, error) {
 			return &order_model.Order{
-				ID:          orderID,
-				UserID:      userID,
-				ProductName: "Same",
-				Quantity:    1,
-				Price:       1,
+				ID:      orderID,
+				UserID:  userID,
+				Items:   []order_model.OrderItem{{ProductName: "Same", Quantity: 1, UnitPrice: 1}},
+				Status:  order_model.StatusPending,
+				Version: 1,
 			}, nil
 		},
 	}
 	log := logrus.New()
-	svc := NewOrderService(mockRepo, log)
+	svc := NewOrderService(mockRepo, newTestCatalog(), log)
 
-	req := order_model.UpdateOrderRequest{
-		ProductName: "Same",
-		Quantity:    1,
-		Price:       1,
-	}
-	order, err := svc.UpdateOrder(context.Background(), 1, 2, req)
+	req := order_model.UpdateOrderRequest{}
+	order, err := svc.UpdateOrder(context.Background(), 1, 2, 1, req)
 	assert.ErrorIs(t, err, ErrNoUpdateFields)
 	assert.NotNil(t, order)
 }
@@ -162,12 +318,12 @@ func TestUpdateOrder_RepoNotFound(t *testing.T) {
 		},
 	}
 	log := logrus.New()
-	svc := NewOrderService(mockRepo, log)
+	svc := NewOrderService(mockRepo, newTestCatalog(), log)
 
-	req := order_model.UpdateOrderRequest{
-		ProductName: "New",
-	}
-	_, err := svc.UpdateOrder(context.Background(), 1, 2, req)
+	req := order_model.UpdateOrderRequest{Items: []order_model.OrderItemRequest{
+		{ProductID: 3, Quantity: 1},
+	}}
+	_, err := svc.UpdateOrder(context.Background(), 1, 2, 1, req)
 	assert.ErrorIs(t, err, ErrOrderNotFound)
 }
 
@@ -175,11 +331,11 @@ func TestUpdateOrder_RepoUpdateError(t *testing.T) {
 	mockRepo := &mockOrderRepo{
 		GetByIDFn: func(ctx context.Context, orderID, userID uint) (*order_model.Order, error) {
 			return &order_model.Order{
-				ID:          orderID,
-				UserID:      userID,
-				ProductName: "Old",
-				Quantity:    1,
-				Price:       1,
+				ID:      orderID,
+				UserID:  userID,
+				Items:   []order_model.OrderItem{{ProductName: "Old", Quantity: 1, UnitPrice: 1}},
+				Status:  order_model.StatusPending,
+				Version: 1,
 			}, nil
 		},
 		UpdateFn: func(ctx context.Context, order *order_model.Order) error {
@@ -187,12 +343,12 @@ func TestUpdateOrder_RepoUpdateError(t *testing.T) {
 		},
 	}
 	log := logrus.New()
-	svc := NewOrderService(mockRepo, log)
+	svc := NewOrderService(mockRepo, newTestCatalog(), log)
 
-	req := order_model.UpdateOrderRequest{
-		ProductName: "New",
-	}
-	_, err := svc.UpdateOrder(context.Background(), 1, 2, req)
+	req := order_model.UpdateOrderRequest{Items: []order_model.OrderItemRequest{
+		{ProductID: 3, Quantity: 1},
+	}}
+	_, err := svc.UpdateOrder(context.Background(), 1, 2, 1, req)
 	assert.ErrorIs(t, err, ErrServiceDatabaseError)
 }
 
@@ -203,7 +359,7 @@ func TestDeleteOrder_Success(t *testing.T) {
 		},
 	}
 	log := logrus.New()
-	svc := NewOrderService(mockRepo, log)
+	svc := NewOrderService(mockRepo, newTestCatalog(), log)
 
 	err := svc.DeleteOrder(context.Background(), 1, 2)
 	assert.NoError(t, err)
@@ -216,7 +372,7 @@ func TestDeleteOrder_RepoNotFound(t *testing.T) {
 		},
 	}
 	log := logrus.New()
-	svc := NewOrderService(mockRepo, log)
+	svc := NewOrderService(mockRepo, newTestCatalog(), log)
 
 	err := svc.DeleteOrder(context.Background(), 1, 2)
 	assert.ErrorIs(t, err, ErrOrderNotFound)
@@ -229,7 +385,7 @@ func TestDeleteOrder_RepoError(t *testing.T) {
 		},
 	}
 	log := logrus.New()
-	svc := NewOrderService(mockRepo, log)
+	svc := NewOrderService(mockRepo, newTestCatalog(), log)
 
 	err := svc.DeleteOrder(context.Background(), 1, 2)
 	assert.ErrorIs(t, err, ErrServiceDatabaseError)
@@ -239,16 +395,14 @@ func TestGetOrderByID_Success(t *testing.T) {
 	mockRepo := &mockOrderRepo{
 		GetByIDFn: func(ctx context.Context, orderID, userID uint) (*order_model.Order, error) {
 			return &order_model.Order{
-				ID:          orderID,
-				UserID:      userID,
-				ProductName: "Test",
-				Quantity:    1,
-				Price:       1,
+				ID:     orderID,
+				UserID: userID,
+				Items:  []order_model.OrderItem{{ProductName: "Test", Quantity: 1, UnitPrice: 1}},
 			}, nil
 		},
 	}
 	log := logrus.New()
-	svc := NewOrderService(mockRepo, log)
+	svc := NewOrderService(mockRepo, newTestCatalog(), log)
 
 	order, err := svc.GetOrderByID(context.Background(), 1, 2)
 	assert.NoError(t, err)
@@ -264,7 +418,7 @@ func TestGetOrderByID_RepoNotFound(t *testing.T) {
 		},
 	}
 	log := logrus.New()
-	svc := NewOrderService(mockRepo, log)
+	svc := NewOrderService(mockRepo, newTestCatalog(), log)
 
 	_, err := svc.GetOrderByID(context.Background(), 1, 2)
 	assert.ErrorIs(t, err, ErrOrderNotFound)
@@ -277,7 +431,7 @@ func TestGetOrderByID_RepoError(t *testing.T) {
 		},
 	}
 	log := logrus.New()
-	svc := NewOrderService(mockRepo, log)
+	svc := NewOrderService(mockRepo, newTestCatalog(), log)
 
 	_, err := svc.GetOrderByID(context.Background(), 1, 2)
 	assert.ErrorIs(t, err, ErrServiceDatabaseError)
@@ -285,17 +439,17 @@ func TestGetOrderByID_RepoError(t *testing.T) {
 
 func TestGetAllOrdersByUser_Success(t *testing.T) {
 	mockRepo := &mockOrderRepo{
-		GetAllByUserFn: func(ctx context.Context, userID uint, offset, limit int) ([]order_model.Order, int64, error) {
+		GetAllByUserFn: func(ctx context.Context, userID uint, params order_rep.ListQueryParams) ([]order_model.Order, int64, error) {
 			return []order_model.Order{
-				{ID: 1, UserID: userID, ProductName: "A", Quantity: 1, Price: 1},
-				{ID: 2, UserID: userID, ProductName: "B", Quantity: 2, Price: 2},
+				{ID: 1, UserID: userID, Items: []order_model.OrderItem{{ProductName: "A", Quantity: 1, UnitPrice: 1}}},
+				{ID: 2, UserID: userID, Items: []order_model.OrderItem{{ProductName: "B", Quantity: 2, UnitPrice: 2}}},
 			}, 2, nil
 		},
 	}
 	log := logrus.New()
-	svc := NewOrderService(mockRepo, log)
+	svc := NewOrderService(mockRepo, newTestCatalog(), log)
 
-	orders, total, err := svc.GetAllOrdersByUser(context.Background(), 2, 1, 10)
+	orders, total, err := svc.GetAllOrdersByUser(context.Background(), 2, 1, 10, nil)
 	assert.NoError(t, err)
 	assert.Len(t, orders, 2)
 	assert.Equal(t, int64(2), total)
@@ -303,22 +457,177 @@ func TestGetAllOrdersByUser_Success(t *testing.T) {
 
 func TestGetAllOrdersByUser_RepoError(t *testing.T) {
 	mockRepo := &mockOrderRepo{
-		GetAllByUserFn: func(ctx context.Context, userID uint, offset, limit int) ([]order_model.Order, int64, error) {
+		GetAllByUserFn: func(ctx context.Context, userID uint, params order_rep.ListQueryParams) ([]order_model.Order, int64, error) {
 			return nil, 0, order_rep.ErrDatabaseError
 		},
 	}
 	log := logrus.New()
-	svc := NewOrderService(mockRepo, log)
+	svc := NewOrderService(mockRepo, newTestCatalog(), log)
 
-	_, _, err := svc.GetAllOrdersByUser(context.Background(), 2, 1, 10)
+	_, _, err := svc.GetAllOrdersByUser(context.Background(), 2, 1, 10, nil)
 	assert.ErrorIs(t, err, ErrServiceDatabaseError)
 }
 
 func TestGetAllOrdersByUser_InvalidInput(t *testing.T) {
 	mockRepo := &mockOrderRepo{}
 	log := logrus.New()
-	svc := NewOrderService(mockRepo, log)
+	svc := NewOrderService(mockRepo, newTestCatalog(), log)
+
+	_, _, err := svc.GetAllOrdersByUser(context.Background(), 0, 1, 10, nil)
+	assert.ErrorIs(t, err, ErrInvalidServiceInput)
+}
+
+func TestGetAllOrdersByUser_StatusFilter(t *testing.T) {
+	var gotParams order_rep.ListQueryParams
+	mockRepo := &mockOrderRepo{
+		GetAllByUserFn: func(ctx context.Context, userID uint, params order_rep.ListQueryParams) ([]order_model.Order, int64, error) {
+			gotParams = params
+			return []order_model.Order{}, 0, nil
+		},
+	}
+	svc := NewOrderService(mockRepo, newTestCatalog(), logrus.New())
+
+	_, _, err := svc.GetAllOrdersByUser(context.Background(), 2, 2, 10,
+		map[string]any{"status": order_model.StatusShipped})
+	assert.NoError(t, err)
+	assert.Equal(t, 10, gotParams.Offset)
+	if assert.NotNil(t, gotParams.Status) {
+		assert.Equal(t, order_model.StatusShipped, *gotParams.Status)
+	}
+}
+
+func TestGetAllOrdersByUser_UnknownStatus(t *testing.T) {
+	svc := NewOrderService(&mockOrderRepo{}, newTestCatalog(), logrus.New())
 
-	_, _, err := svc.GetAllOrdersByUser(context.Background(), 0, 1, 10)
+	_, _, err := svc.GetAllOrdersByUser(context.Background(), 2, 1, 10,
+		map[string]any{"status": order_model.OrderStatus("lost")})
 	assert.ErrorIs(t, err, ErrInvalidServiceInput)
 }
+
+func TestUpdateOrder_NotEditable(t *testing.T) {
+	mockRepo := &mockOrderRepo{
+		GetByIDFn: func(ctx context.Context, orderID, userID uint) (*order_model.Order, error) {
+			return &order_model.Order{ID: orderID, UserID: userID, Items: []order_model.OrderItem{{ProductName: "A", Quantity: 1, UnitPrice: 1}},
+				Status: order_model.StatusShipped, Version: 1}, nil
+		},
+	}
+	svc := NewOrderService(mockRepo, newTestCatalog(), logrus.New())
+
+	_, err := svc.UpdateOrder(context.Background(), 1, 2, 1, order_model.UpdateOrderRequest{Items: []order_model.OrderItemRequest{{ProductID: 1, Quantity: 5}}})
+	assert.ErrorIs(t, err, ErrOrderNotEditable)
+}
+
+func TestChangeOrderStatus_Transitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    order_model.OrderStatus
+		to      order_model.OrderStatus
+		allowed bool
+	}{
+		{"подтверждение нового заказа", order_model.StatusPending, order_model.StatusConfirmed, true},
+		{"оплата подтвержденного заказа", order_model.StatusConfirmed, order_model.StatusPaid, true},
+		{"отправка оплаченного заказа", order_model.StatusPaid, order_model.StatusShipped, true},
+		{"доставка отправленного заказа", order_model.StatusShipped, order_model.StatusDelivered, true},
+		{"возврат доставленного заказа", order_model.StatusDelivered, order_model.StatusRefunded, true},
+		{"отмена нового заказа", order_model.StatusPending, order_model.StatusCancelled, true},
+		{"отправка отмененного заказа", order_model.StatusCancelled, order_model.StatusShipped, false},
+		{"отмена оплаченного заказа", order_model.StatusPaid, order_model.StatusCancelled, false},
+		{"отправка неоплаченного заказа", order_model.StatusPending, order_model.StatusShipped, false},
+		{"повторная доставка", order_model.StatusDelivered, order_model.StatusDelivered, false},
+		{"выход из возврата", order_model.StatusRefunded, order_model.StatusPending, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updateCalled := false
+			mockRepo := &mockOrderRepo{
+				GetByIDFn: func(ctx context.Context, orderID, userID uint) (*order_model.Order, error) {
+					return &order_model.Order{ID: orderID, UserID: userID, Status: tt.from}, nil
+				},
+				UpdateStatusFn: func(ctx context.Context, orderID, userID uint, from, to order_model.OrderStatus) error {
+					updateCalled = true
+					assert.Equal(t, tt.from, from)
+					assert.Equal(t, tt.to, to)
+					return nil
+				},
+			}
+			svc := NewOrderService(mockRepo, newTestCatalog(), logrus.New())
+
+			order, err := svc.ChangeOrderStatus(context.Background(), 1, 2, tt.to)
+			if tt.allowed {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.to, order.Status)
+				assert.True(t, updateCalled)
+				return
+			}
+			assert.ErrorIs(t, err, ErrInvalidStatusTransition)
+			var transitionErr *StatusTransitionError
+			if assert.ErrorAs(t, err, &transitionErr) {
+				assert.Equal(t, tt.from, transitionErr.From)
+				assert.Equal(t, tt.to, transitionErr.To)
+			}
+			assert.False(t, updateCalled)
+		})
+	}
+}
+
+func TestChangeOrderStatus_ConcurrentChange(t *testing.T) {
+	mockRepo := &mockOrderRepo{
+		GetByIDFn: func(ctx context.Context, orderID, userID uint) (*order_model.Order, error) {
+			return &order_model.Order{ID: orderID, UserID: userID, Status: order_model.StatusPending}, nil
+		},
+		UpdateStatusFn: func(ctx context.Context, orderID, userID uint, from, to order_model.OrderStatus) error {
+			return order_rep.ErrNoRowsAffected
+		},
+	}
+	svc := NewOrderService(mockRepo, newTestCatalog(), logrus.New())
+
+	_, err := svc.CancelOrder(context.Background(), 1, 2)
+	assert.ErrorIs(t, err, ErrInvalidStatusTransition)
+}
+
+func TestCancelOrder_NotFound(t *testing.T) {
+	mockRepo := &mockOrderRepo{
+		GetByIDFn: func(ctx context.Context, orderID, userID uint) (*order_model.Order, error) {
+			return nil, order_rep.ErrOrderNotFound
+		},
+	}
+	svc := NewOrderService(mockRepo, newTestCatalog(), logrus.New())
+
+	_, err := svc.CancelOrder(context.Background(), 1, 2)
+	assert.ErrorIs(t, err, ErrOrderNotFound)
+}
+
+func TestUpdateOrder_StaleVersion(t *testing.T) {
+	updateCalled := false
+	mockRepo := &mockOrderRepo{
+		GetByIDFn: func(ctx context.Context, orderID, userID uint) (*order_model.Order, error) {
+			return &order_model.Order{ID: orderID, UserID: userID, Status: order_model.StatusPending, Version: 4}, nil
+		},
+		UpdateFn: func(ctx context.Context, order *order_model.Order) error {
+			updateCalled = true
+			return nil
+		},
+	}
+	svc := NewOrderService(mockRepo, newTestCatalog(), logrus.New())
+
+	_, err := svc.UpdateOrder(context.Background(), 1, 2, 3, order_model.UpdateOrderRequest{Items: []order_model.OrderItemRequest{{ProductID: 1, Quantity: 1}}})
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+	assert.False(t, updateCalled)
+}
+
+func TestUpdateOrder_RepoVersionMismatch(t *testing.T) {
+	mockRepo := &mockOrderRepo{
+		GetByIDFn: func(ctx context.Context, orderID, userID uint) (*order_model.Order, error) {
+			return &order_model.Order{ID: orderID, UserID: userID, Status: order_model.StatusPending, Version: 1}, nil
+		},
+		UpdateFn: func(ctx context.Context, order *order_model.Order) error {
+			return order_rep.ErrVersionMismatch
+		},
+	}
+	svc := NewOrderService(mockRepo, newTestCatalog(), logrus.New())
+
+	_, err := svc.UpdateOrder(context.Background(), 1, 2, 1, order_model.UpdateOrderRequest{Items: []order_model.OrderItemRequest{{ProductID: 1, Quantity: 1}}})
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+	assert.NotErrorIs(t, err, ErrServiceDatabaseError)
+}
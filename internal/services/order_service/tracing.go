@@ -0,0 +1,102 @@
+package order_service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/order_model"
+	"github.com/IlyushinDM/user-order-api/internal/utils/tracing_util"
+)
+
+// tracedOrderService создает спан для каждого метода сервиса заказов
+type tracedOrderService struct {
+	next OrderService
+}
+
+// NewTracedOrderService оборачивает сервис заказов трассировкой OpenTelemetry.
+// Спан метода содержит user_id и order_id, ошибка метода записывается в спан.
+func NewTracedOrderService(next OrderService) OrderService {
+	return &tracedOrderService{next: next}
+}
+
+func (s *tracedOrderService) CreateOrder(
+	ctx context.Context,
+	userID uint,
+	req order_model.CreateOrderRequest,
+) (*order_model.Order, error) {
+	ctx, span := tracing_util.Start(ctx, "OrderService.CreateOrder", tracing_util.UserID(userID))
+	order, err := s.next.CreateOrder(ctx, userID, req)
+	if err == nil {
+		span.SetAttributes(tracing_util.OrderID(order.ID))
+	}
+	tracing_util.End(span, err)
+	return order, err
+}
+
+func (s *tracedOrderService) UpdateOrder(
+	ctx context.Context,
+	orderID uint,
+	userID uint,
+	version uint,
+	req order_model.UpdateOrderRequest,
+) (*order_model.Order, error) {
+	ctx, span := tracing_util.Start(ctx, "OrderService.UpdateOrder",
+		tracing_util.UserID(userID), tracing_util.OrderID(orderID))
+	order, err := s.next.UpdateOrder(ctx, orderID, userID, version, req)
+	// Запрос без изменений не является ошибкой: обработчик возвращает текущее состояние
+	if errors.Is(err, ErrNoUpdateFields) {
+		tracing_util.End(span, nil)
+	} else {
+		tracing_util.End(span, err)
+	}
+	return order, err
+}
+
+func (s *tracedOrderService) DeleteOrder(ctx context.Context, orderID uint, userID uint) error {
+	ctx, span := tracing_util.Start(ctx, "OrderService.DeleteOrder",
+		tracing_util.UserID(userID), tracing_util.OrderID(orderID))
+	err := s.next.DeleteOrder(ctx, orderID, userID)
+	tracing_util.End(span, err)
+	return err
+}
+
+func (s *tracedOrderService) GetOrderByID(ctx context.Context, orderID uint, userID uint) (*order_model.Order, error) {
+	ctx, span := tracing_util.Start(ctx, "OrderService.GetOrderByID",
+		tracing_util.UserID(userID), tracing_util.OrderID(orderID))
+	order, err := s.next.GetOrderByID(ctx, orderID, userID)
+	tracing_util.End(span, err)
+	return order, err
+}
+
+func (s *tracedOrderService) GetAllOrdersByUser(
+	ctx context.Context,
+	userID uint,
+	page, limit int,
+	filters map[string]any,
+) ([]order_model.Order, int64, error) {
+	ctx, span := tracing_util.Start(ctx, "OrderService.GetAllOrdersByUser", tracing_util.UserID(userID))
+	orders, total, err := s.next.GetAllOrdersByUser(ctx, userID, page, limit, filters)
+	tracing_util.End(span, err)
+	return orders, total, err
+}
+
+func (s *tracedOrderService) ChangeOrderStatus(
+	ctx context.Context,
+	orderID uint,
+	userID uint,
+	status order_model.OrderStatus,
+) (*order_model.Order, error) {
+	ctx, span := tracing_util.Start(ctx, "OrderService.ChangeOrderStatus",
+		tracing_util.UserID(userID), tracing_util.OrderID(orderID))
+	order, err := s.next.ChangeOrderStatus(ctx, orderID, userID, status)
+	tracing_util.End(span, err)
+	return order, err
+}
+
+func (s *tracedOrderService) CancelOrder(ctx context.Context, orderID uint, userID uint) (*order_model.Order, error) {
+	ctx, span := tracing_util.Start(ctx, "OrderService.CancelOrder",
+		tracing_util.UserID(userID), tracing_util.OrderID(orderID))
+	order, err := s.next.CancelOrder(ctx, orderID, userID)
+	tracing_util.End(span, err)
+	return order, err
+}
@@ -0,0 +1,63 @@
+package order_service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/order_model"
+	"github.com/IlyushinDM/user-order-api/internal/repository/order_rep"
+	"github.com/IlyushinDM/user-order-api/internal/utils/tracing_util"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracedOrderService(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	var repoSpan trace.SpanContext
+	mockRepo := &mockOrderRepo{
+		CreateFn: func(ctx context.Context, order *order_model.Order) error {
+			repoSpan = trace.SpanContextFromContext(ctx)
+			order.ID = 9
+			return nil
+		},
+		GetByIDFn: func(ctx context.Context, orderID, userID uint) (*order_model.Order, error) {
+			return nil, order_rep.ErrOrderNotFound
+		},
+	}
+	log := logrus.New()
+	log.SetLevel(logrus.PanicLevel)
+	svc := NewTracedOrderService(NewOrderService(mockRepo, newTestCatalog(), log))
+
+	req := order_model.CreateOrderRequest{Currency: "RUB", Items: []order_model.OrderItemRequest{{ProductID: 1, Quantity: 1}}}
+	_, err := svc.CreateOrder(context.Background(), 42, req)
+	require.NoError(t, err)
+	_, err = svc.GetOrderByID(context.Background(), 7, 42)
+	require.ErrorIs(t, err, ErrOrderNotFound)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	created := spans[0]
+	assert.Equal(t, "OrderService.CreateOrder", created.Name())
+	assert.Contains(t, created.Attributes(), tracing_util.UserID(42))
+	// ID созданного заказа известен только после вызова сервиса
+	assert.Contains(t, created.Attributes(), tracing_util.OrderID(9))
+	assert.Equal(t, codes.Unset, created.Status().Code)
+	// Репозиторий получает контекст со спаном метода сервиса
+	assert.Equal(t, created.SpanContext().SpanID(), repoSpan.SpanID())
+
+	failed := spans[1]
+	assert.Equal(t, "OrderService.GetOrderByID", failed.Name())
+	assert.Contains(t, failed.Attributes(), tracing_util.OrderID(7))
+	assert.Equal(t, codes.Error, failed.Status().Code)
+}
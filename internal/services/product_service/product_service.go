@@ -0,0 +1,227 @@
+package product_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/money_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/product_model"
+	"github.com/IlyushinDM/user-order-api/internal/repository/product_rep"
+	"github.com/sirupsen/logrus"
+)
+
+// Определение ошибок сервисного слоя для каталога продуктов
+var (
+	ErrProductNotFound      = errors.New("продукт не найден")
+	ErrInvalidServiceInput  = errors.New("недопустимые входные данные сервиса")
+	ErrServiceDatabaseError = errors.New("ошибка базы данных сервиса")
+	ErrNoUpdateFields       = errors.New("нет полей для обновления")
+)
+
+// ProductService определяет интерфейс для бизнес-логики каталога продуктов
+type ProductService interface {
+	CreateProduct(ctx context.Context, req product_model.CreateProductRequest) (*product_model.Product, error)
+	GetProductByID(ctx context.Context, id uint) (*product_model.Product, error)
+	GetAllProducts(ctx context.Context, page, limit int, search string) ([]product_model.Product, int64, error)
+	UpdateProduct(ctx context.Context, id uint, req product_model.UpdateProductRequest) (*product_model.Product, error)
+	DeleteProduct(ctx context.Context, id uint) error
+}
+
+type productService struct {
+	productRepo product_rep.ProductRepository
+	log         *logrus.Logger
+}
+
+// NewProductService создает новый сервис каталога продуктов
+func NewProductService(productRepo product_rep.ProductRepository, log *logrus.Logger) ProductService {
+	if productRepo == nil {
+		logrus.Fatal("Экземпляр ProductRepository равен nil в NewProductService")
+	}
+	if log == nil {
+		defaultLog := logrus.New()
+		defaultLog.SetLevel(logrus.InfoLevel)
+		defaultLog.Warn("Экземпляр логгера Logrus равен nil в NewProductService, используется логгер по умолчанию")
+		log = defaultLog
+	}
+	return &productService{productRepo: productRepo, log: log}
+}
+
+// mapRepoError преобразует ошибки репозитория в ошибки сервиса
+func mapRepoError(err error, action string) error {
+	switch {
+	case errors.Is(err, product_rep.ErrProductNotFound):
+		return ErrProductNotFound
+	case errors.Is(err, product_rep.ErrInvalidInput):
+		return fmt.Errorf("%w: %v", ErrInvalidServiceInput, err)
+	default:
+		return fmt.Errorf("%w: не удалось %s", ErrServiceDatabaseError, action)
+	}
+}
+
+func (s *productService) CreateProduct(
+	ctx context.Context,
+	req product_model.CreateProductRequest,
+) (*product_model.Product, error) {
+	logger := s.log.WithContext(ctx).WithField("method", "ProductService.CreateProduct")
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || req.Price <= 0 || req.Stock < 0 {
+		logger.Warn("Недопустимые входные данные для создания продукта")
+		return nil, fmt.Errorf(
+			"%w: название обязательно, цена должна быть положительной, остаток - неотрицательным", ErrInvalidServiceInput)
+	}
+
+	currency := req.Currency
+	if currency == "" {
+		currency = money_model.DefaultCurrency
+	}
+	if !money_model.IsSupportedCurrency(currency) {
+		logger.WithField("currency", currency).Warn("Неподдерживаемая валюта продукта")
+		return nil, fmt.Errorf("%w: валюта %q не поддерживается", ErrInvalidServiceInput, currency)
+	}
+
+	product := &product_model.Product{
+		Name:        name,
+		Description: req.Description,
+		Price:       req.Price,
+		Currency:    currency,
+		Stock:       req.Stock,
+	}
+	if err := s.productRepo.Create(ctx, product); err != nil {
+		logger.WithError(err).Error("Не удалось создать продукт в репозитории")
+		return nil, mapRepoError(err, "создать продукт")
+	}
+
+	logger.WithField("product_id", product.ID).Info("Продукт успешно создан")
+	return product, nil
+}
+
+func (s *productService) GetProductByID(ctx context.Context, id uint) (*product_model.Product, error) {
+	logger := s.log.WithContext(ctx).WithField("method", "ProductService.GetProductByID").WithField("product_id", id)
+	if id == 0 {
+		logger.Warn("Попытка получить продукт с нулевым ID")
+		return nil, fmt.Errorf("%w: ID продукта должен быть положительным", ErrInvalidServiceInput)
+	}
+
+	product, err := s.productRepo.GetByID(ctx, id)
+	if err != nil {
+		logger.WithError(err).Warn("Не удалось получить продукт из репозитория")
+		return nil, mapRepoError(err, "получить продукт")
+	}
+	return product, nil
+}
+
+func (s *productService) GetAllProducts(
+	ctx context.Context,
+	page, limit int,
+	search string,
+) ([]product_model.Product, int64, error) {
+	logger := s.log.WithContext(ctx).WithField("method", "ProductService.GetAllProducts").WithFields(
+		logrus.Fields{"page": page, "limit": limit, "search": search})
+	if page <= 0 {
+		page = 1
+		logger.Warn("Указан недопустимый номер страницы, используется значение по умолчанию 1")
+	}
+	if limit <= 0 {
+		limit = 10
+		logger.Warn("Указан недопустимый лимит, используется значение по умолчанию 10")
+	}
+
+	products, total, err := s.productRepo.GetAll(ctx, product_rep.ListQueryParams{
+		Offset: (page - 1) * limit,
+		Limit:  limit,
+		Search: strings.TrimSpace(search),
+	})
+	if err != nil {
+		logger.WithError(err).Error("Не удалось получить список продуктов из репозитория")
+		return nil, 0, mapRepoError(err, "получить список продуктов")
+	}
+
+	logger.WithField("total", total).Info("Список продуктов успешно получен")
+	return products, total, nil
+}
+
+func (s *productService) UpdateProduct(
+	ctx context.Context,
+	id uint,
+	req product_model.UpdateProductRequest,
+) (*product_model.Product, error) {
+	logger := s.log.WithContext(ctx).WithField("method", "ProductService.UpdateProduct").WithField("product_id", id)
+	if id == 0 {
+		logger.Warn("Попытка обновить продукт с нулевым ID")
+		return nil, fmt.Errorf("%w: ID продукта должен быть положительным", ErrInvalidServiceInput)
+	}
+
+	product, err := s.productRepo.GetByID(ctx, id)
+	if err != nil {
+		logger.WithError(err).Warn("Не удалось получить продукт для обновления")
+		return nil, mapRepoError(err, "найти продукт для обновления")
+	}
+
+	fieldsChanged := req.Name != nil || req.Description != nil || req.Price != nil || req.Currency != nil
+	if !fieldsChanged && req.Stock == nil {
+		logger.Info("Нет полей для обновления продукта")
+		return product, ErrNoUpdateFields
+	}
+	if req.Stock != nil && *req.Stock < 0 {
+		return nil, fmt.Errorf("%w: остаток не может быть отрицательным", ErrInvalidServiceInput)
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, fmt.Errorf("%w: название продукта не может быть пустым", ErrInvalidServiceInput)
+		}
+		product.Name = name
+	}
+	if req.Description != nil {
+		product.Description = *req.Description
+	}
+	if req.Price != nil {
+		if *req.Price <= 0 {
+			return nil, fmt.Errorf("%w: цена должна быть положительной", ErrInvalidServiceInput)
+		}
+		product.Price = *req.Price
+	}
+	if req.Currency != nil {
+		if !money_model.IsSupportedCurrency(*req.Currency) {
+			return nil, fmt.Errorf("%w: валюта %q не поддерживается", ErrInvalidServiceInput, *req.Currency)
+		}
+		product.Currency = *req.Currency
+	}
+
+	if fieldsChanged {
+		if err := s.productRepo.Update(ctx, product); err != nil {
+			logger.WithError(err).Error("Не удалось обновить продукт в репозитории")
+			return nil, mapRepoError(err, "сохранить продукт")
+		}
+	}
+	// Остаток устанавливается отдельной операцией, чтобы обновление описания не затирало резервы заказов
+	if req.Stock != nil {
+		if err := s.productRepo.SetStock(ctx, id, *req.Stock); err != nil {
+			logger.WithError(err).Error("Не удалось установить остаток продукта в репозитории")
+			return nil, mapRepoError(err, "установить остаток продукта")
+		}
+		product.Stock = *req.Stock
+	}
+
+	logger.Info("Продукт успешно обновлен")
+	return product, nil
+}
+
+func (s *productService) DeleteProduct(ctx context.Context, id uint) error {
+	logger := s.log.WithContext(ctx).WithField("method", "ProductService.DeleteProduct").WithField("product_id", id)
+	if id == 0 {
+		logger.Warn("Попытка удалить продукт с нулевым ID")
+		return fmt.Errorf("%w: ID продукта должен быть положительным", ErrInvalidServiceInput)
+	}
+
+	if err := s.productRepo.Delete(ctx, id); err != nil {
+		logger.WithError(err).Warn("Не удалось удалить продукт в репозитории")
+		return mapRepoError(err, "удалить продукт")
+	}
+
+	logger.Info("Продукт успешно удален")
+	return nil
+}
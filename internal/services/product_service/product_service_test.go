@@ -0,0 +1,183 @@
+package product_service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/money_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/product_model"
+	"github.com/IlyushinDM/user-order-api/internal/repository/product_rep"
+	"github.com/IlyushinDM/user-order-api/internal/services/product_service"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockProductRepository реализует интерфейс ProductRepository для тестирования
+type MockProductRepository struct {
+	mock.Mock
+}
+
+func (m *MockProductRepository) Create(ctx context.Context, product *product_model.Product) error {
+	args := m.Called(ctx, product)
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) Update(ctx context.Context, product *product_model.Product) error {
+	args := m.Called(ctx, product)
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) SetStock(ctx context.Context, id uint, stock int) error {
+	args := m.Called(ctx, id, stock)
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockProductRepository) GetByID(ctx context.Context, id uint) (*product_model.Product, error) {
+	args := m.Called(ctx, id)
+	product, _ := args.Get(0).(*product_model.Product)
+	return product, args.Error(1)
+}
+
+func (m *MockProductRepository) GetByIDs(ctx context.Context, ids []uint) (map[uint]product_model.Product, error) {
+	args := m.Called(ctx, ids)
+	products, _ := args.Get(0).(map[uint]product_model.Product)
+	return products, args.Error(1)
+}
+
+func (m *MockProductRepository) GetAll(ctx context.Context, params product_rep.ListQueryParams) ([]product_model.Product, int64, error) {
+	args := m.Called(ctx, params)
+	products, _ := args.Get(0).([]product_model.Product)
+	return products, args.Get(1).(int64), args.Error(2)
+}
+
+func newService(repo *MockProductRepository) product_service.ProductService {
+	return product_service.NewProductService(repo, logrus.New())
+}
+
+func TestCreateProduct(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("успешное создание", func(t *testing.T) {
+		repo := new(MockProductRepository)
+		repo.On("Create", ctx, mock.MatchedBy(func(p *product_model.Product) bool {
+			return p.Name == "Кофе" && p.Price == 990 && p.Currency == "RUB"
+		})).Return(nil)
+
+		product, err := newService(repo).CreateProduct(ctx, product_model.CreateProductRequest{Name: "  Кофе ", Price: 990})
+		assert.NoError(t, err)
+		assert.Equal(t, "Кофе", product.Name)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("неположительная цена", func(t *testing.T) {
+		repo := new(MockProductRepository)
+		_, err := newService(repo).CreateProduct(ctx, product_model.CreateProductRequest{Name: "Кофе", Price: 0})
+		assert.ErrorIs(t, err, product_service.ErrInvalidServiceInput)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("неподдерживаемая валюта", func(t *testing.T) {
+		repo := new(MockProductRepository)
+		_, err := newService(repo).CreateProduct(ctx, product_model.CreateProductRequest{Name: "Кофе", Price: 100, Currency: "JPY"})
+		assert.ErrorIs(t, err, product_service.ErrInvalidServiceInput)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ошибка базы данных", func(t *testing.T) {
+		repo := new(MockProductRepository)
+		repo.On("Create", ctx, mock.Anything).Return(product_rep.ErrDatabaseError)
+		_, err := newService(repo).CreateProduct(ctx, product_model.CreateProductRequest{Name: "Кофе", Price: 1})
+		assert.ErrorIs(t, err, product_service.ErrServiceDatabaseError)
+	})
+}
+
+func TestGetProductByID_NotFound(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockProductRepository)
+	repo.On("GetByID", ctx, uint(5)).Return(nil, product_rep.ErrProductNotFound)
+
+	_, err := newService(repo).GetProductByID(ctx, 5)
+	assert.ErrorIs(t, err, product_service.ErrProductNotFound)
+}
+
+func TestGetAllProducts_Pagination(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockProductRepository)
+	repo.On("GetAll", ctx, product_rep.ListQueryParams{Offset: 20, Limit: 10, Search: "чай"}).
+		Return([]product_model.Product{{ID: 21, Name: "Чай"}}, int64(21), nil)
+
+	products, total, err := newService(repo).GetAllProducts(ctx, 3, 10, " чай ")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(21), total)
+	assert.Len(t, products, 1)
+	repo.AssertExpectations(t)
+}
+
+func TestUpdateProduct(t *testing.T) {
+	ctx := context.Background()
+	price := money_model.Amount(1500)
+	empty := ""
+
+	t.Run("частичное обновление", func(t *testing.T) {
+		repo := new(MockProductRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(&product_model.Product{ID: 1, Name: "Кофе", Description: "Зерно", Price: 10}, nil)
+		repo.On("Update", ctx, mock.MatchedBy(func(p *product_model.Product) bool {
+			return p.Name == "Кофе" && p.Description == "" && p.Price == 1500
+		})).Return(nil)
+
+		product, err := newService(repo).UpdateProduct(ctx, 1, product_model.UpdateProductRequest{Price: &price, Description: &empty})
+		assert.NoError(t, err)
+		assert.Equal(t, "15.00", product.Price.String())
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("нет полей для обновления", func(t *testing.T) {
+		repo := new(MockProductRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(&product_model.Product{ID: 1, Name: "Кофе", Price: 10}, nil)
+
+		product, err := newService(repo).UpdateProduct(ctx, 1, product_model.UpdateProductRequest{})
+		assert.ErrorIs(t, err, product_service.ErrNoUpdateFields)
+		assert.NotNil(t, product)
+	})
+
+	t.Run("установка остатка без изменения остальных полей", func(t *testing.T) {
+		repo := new(MockProductRepository)
+		stock := 25
+		repo.On("GetByID", ctx, uint(1)).Return(&product_model.Product{ID: 1, Name: "Кофе", Price: 10, Stock: 3}, nil)
+		repo.On("SetStock", ctx, uint(1), 25).Return(nil)
+
+		product, err := newService(repo).UpdateProduct(ctx, 1, product_model.UpdateProductRequest{Stock: &stock})
+		assert.NoError(t, err)
+		assert.Equal(t, 25, product.Stock)
+		repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("пустое название", func(t *testing.T) {
+		repo := new(MockProductRepository)
+		repo.On("GetByID", ctx, uint(1)).Return(&product_model.Product{ID: 1, Name: "Кофе", Price: 10}, nil)
+		blank := "  "
+
+		_, err := newService(repo).UpdateProduct(ctx, 1, product_model.UpdateProductRequest{Name: &blank})
+		assert.ErrorIs(t, err, product_service.ErrInvalidServiceInput)
+		repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestDeleteProduct(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockProductRepository)
+	repo.On("Delete", ctx, uint(3)).Return(product_rep.ErrProductNotFound)
+
+	err := newService(repo).DeleteProduct(ctx, 3)
+	assert.ErrorIs(t, err, product_service.ErrProductNotFound)
+
+	err = newService(repo).DeleteProduct(ctx, 0)
+	assert.ErrorIs(t, err, product_service.ErrInvalidServiceInput)
+}
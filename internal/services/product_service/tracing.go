@@ -0,0 +1,74 @@
+package product_service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/product_model"
+	"github.com/IlyushinDM/user-order-api/internal/utils/tracing_util"
+)
+
+// tracedProductService создает спан для каждого метода сервиса каталога продуктов
+type tracedProductService struct {
+	next ProductService
+}
+
+// NewTracedProductService оборачивает сервис каталога продуктов трассировкой OpenTelemetry.
+// Спан метода содержит product_id, ошибка метода записывается в спан.
+func NewTracedProductService(next ProductService) ProductService {
+	return &tracedProductService{next: next}
+}
+
+func (s *tracedProductService) CreateProduct(
+	ctx context.Context,
+	req product_model.CreateProductRequest,
+) (*product_model.Product, error) {
+	ctx, span := tracing_util.Start(ctx, "ProductService.CreateProduct")
+	product, err := s.next.CreateProduct(ctx, req)
+	if err == nil {
+		span.SetAttributes(tracing_util.ProductID(product.ID))
+	}
+	tracing_util.End(span, err)
+	return product, err
+}
+
+func (s *tracedProductService) GetProductByID(ctx context.Context, id uint) (*product_model.Product, error) {
+	ctx, span := tracing_util.Start(ctx, "ProductService.GetProductByID", tracing_util.ProductID(id))
+	product, err := s.next.GetProductByID(ctx, id)
+	tracing_util.End(span, err)
+	return product, err
+}
+
+func (s *tracedProductService) GetAllProducts(
+	ctx context.Context,
+	page, limit int,
+	search string,
+) ([]product_model.Product, int64, error) {
+	ctx, span := tracing_util.Start(ctx, "ProductService.GetAllProducts")
+	products, total, err := s.next.GetAllProducts(ctx, page, limit, search)
+	tracing_util.End(span, err)
+	return products, total, err
+}
+
+func (s *tracedProductService) UpdateProduct(
+	ctx context.Context,
+	id uint,
+	req product_model.UpdateProductRequest,
+) (*product_model.Product, error) {
+	ctx, span := tracing_util.Start(ctx, "ProductService.UpdateProduct", tracing_util.ProductID(id))
+	product, err := s.next.UpdateProduct(ctx, id, req)
+	// Запрос без изменений не является ошибкой: обработчик возвращает текущее состояние
+	if errors.Is(err, ErrNoUpdateFields) {
+		tracing_util.End(span, nil)
+	} else {
+		tracing_util.End(span, err)
+	}
+	return product, err
+}
+
+func (s *tracedProductService) DeleteProduct(ctx context.Context, id uint) error {
+	ctx, span := tracing_util.Start(ctx, "ProductService.DeleteProduct", tracing_util.ProductID(id))
+	err := s.next.DeleteProduct(ctx, id)
+	tracing_util.End(span, err)
+	return err
+}
@@ -0,0 +1,45 @@
+package user_service
+
+import "sync"
+
+// loginLocks сериализует попытки входа с одним email в пределах процесса.
+// Без этого параллельные запросы с неверным паролем успевали бы пройти проверку
+// блокировки до того, как первый из них запишет неудачу.
+type loginLocks struct {
+	mu    sync.Mutex
+	locks map[string]*loginLock
+}
+
+// loginLock - мьютекс одного email и число запросов, которые держат или ждут его
+type loginLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// Lock захватывает блокировку email и возвращает функцию ее освобождения.
+// Запись удаляется, когда блокировку больше никто не ждет.
+func (l *loginLocks) Lock(email string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*loginLock)
+	}
+	lock, ok := l.locks[email]
+	if !ok {
+		lock = &loginLock{}
+		l.locks[email] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	lock.mu.Lock()
+	return func() {
+		lock.mu.Unlock()
+
+		l.mu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(l.locks, email)
+		}
+		l.mu.Unlock()
+	}
+}
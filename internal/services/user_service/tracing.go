@@ -0,0 +1,133 @@
+package user_service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/IlyushinDM/user-order-api/internal/models/login_attempt_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/user_model"
+	"github.com/IlyushinDM/user-order-api/internal/utils/tracing_util"
+)
+
+// tracedUserService создает спан для каждого метода сервиса пользователей
+type tracedUserService struct {
+	next UserService
+}
+
+// NewTracedUserService оборачивает сервис пользователей трассировкой OpenTelemetry.
+// Спан метода содержит user_id, если он известен; email, пароли и токены в спаны не попадают.
+// Ошибка метода записывается в спан.
+func NewTracedUserService(next UserService) UserService {
+	return &tracedUserService{next: next}
+}
+
+func (s *tracedUserService) CreateUser(ctx context.Context, req user_model.CreateUserRequest) (*user_model.User, error) {
+	ctx, span := tracing_util.Start(ctx, "UserService.CreateUser")
+	user, err := s.next.CreateUser(ctx, req)
+	if err == nil {
+		span.SetAttributes(tracing_util.UserID(user.ID))
+	}
+	tracing_util.End(span, err)
+	return user, err
+}
+
+func (s *tracedUserService) UpdateUser(
+	ctx context.Context,
+	id uint,
+	version uint,
+	req user_model.UpdateUserRequest,
+) (*user_model.User, error) {
+	ctx, span := tracing_util.Start(ctx, "UserService.UpdateUser", tracing_util.UserID(id))
+	user, err := s.next.UpdateUser(ctx, id, version, req)
+	// Запрос без изменений не является ошибкой: обработчик возвращает текущее состояние
+	if errors.Is(err, ErrNoUpdateFields) {
+		tracing_util.End(span, nil)
+	} else {
+		tracing_util.End(span, err)
+	}
+	return user, err
+}
+
+func (s *tracedUserService) DeleteUser(ctx context.Context, id uint) error {
+	ctx, span := tracing_util.Start(ctx, "UserService.DeleteUser", tracing_util.UserID(id))
+	err := s.next.DeleteUser(ctx, id)
+	tracing_util.End(span, err)
+	return err
+}
+
+func (s *tracedUserService) GetUserByID(ctx context.Context, id uint) (*user_model.User, error) {
+	ctx, span := tracing_util.Start(ctx, "UserService.GetUserByID", tracing_util.UserID(id))
+	user, err := s.next.GetUserByID(ctx, id)
+	tracing_util.End(span, err)
+	return user, err
+}
+
+func (s *tracedUserService) GetUserByEmail(ctx context.Context, email string) (*user_model.User, error) {
+	ctx, span := tracing_util.Start(ctx, "UserService.GetUserByEmail")
+	user, err := s.next.GetUserByEmail(ctx, email)
+	if err == nil {
+		span.SetAttributes(tracing_util.UserID(user.ID))
+	}
+	tracing_util.End(span, err)
+	return user, err
+}
+
+func (s *tracedUserService) GetAllUsers(
+	ctx context.Context,
+	page, limit int,
+	filters map[string]any,
+) ([]user_model.User, int64, error) {
+	ctx, span := tracing_util.Start(ctx, "UserService.GetAllUsers")
+	users, total, err := s.next.GetAllUsers(ctx, page, limit, filters)
+	tracing_util.End(span, err)
+	return users, total, err
+}
+
+func (s *tracedUserService) LoginUser(
+	ctx context.Context,
+	req user_model.LoginRequest,
+	client login_attempt_model.ClientInfo,
+) (*user_model.LoginResponse, error) {
+	ctx, span := tracing_util.Start(ctx, "UserService.LoginUser")
+	resp, err := s.next.LoginUser(ctx, req, client)
+	tracing_util.End(span, err)
+	return resp, err
+}
+
+func (s *tracedUserService) RefreshTokens(ctx context.Context, refreshToken string) (*user_model.LoginResponse, error) {
+	ctx, span := tracing_util.Start(ctx, "UserService.RefreshTokens")
+	resp, err := s.next.RefreshTokens(ctx, refreshToken)
+	tracing_util.End(span, err)
+	return resp, err
+}
+
+func (s *tracedUserService) LogoutUser(
+	ctx context.Context,
+	userID uint,
+	refreshToken, accessTokenID string,
+	accessExpiresAt time.Time,
+) error {
+	ctx, span := tracing_util.Start(ctx, "UserService.LogoutUser", tracing_util.UserID(userID))
+	err := s.next.LogoutUser(ctx, userID, refreshToken, accessTokenID, accessExpiresAt)
+	tracing_util.End(span, err)
+	return err
+}
+
+func (s *tracedUserService) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	ctx, span := tracing_util.Start(ctx, "UserService.IsTokenRevoked")
+	revoked, err := s.next.IsTokenRevoked(ctx, tokenID)
+	tracing_util.End(span, err)
+	return revoked, err
+}
+
+func (s *tracedUserService) GetLoginHistory(
+	ctx context.Context,
+	userID uint,
+	page, limit int,
+) ([]login_attempt_model.LoginAttempt, int64, error) {
+	ctx, span := tracing_util.Start(ctx, "UserService.GetLoginHistory", tracing_util.UserID(userID))
+	attempts, total, err := s.next.GetLoginHistory(ctx, userID, page, limit)
+	tracing_util.End(span, err)
+	return attempts, total, err
+}
@@ -4,10 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
+	"github.com/IlyushinDM/user-order-api/internal/models/login_attempt_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/token_model"
 	"github.com/IlyushinDM/user-order-api/internal/models/user_model"
+	"github.com/IlyushinDM/user-order-api/internal/repository/login_attempt_rep"
+	"github.com/IlyushinDM/user-order-api/internal/repository/token_rep"
 	"github.com/IlyushinDM/user-order-api/internal/repository/user_rep"
 	"github.com/IlyushinDM/user-order-api/internal/utils/jwt_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/metrics_util"
 	"github.com/IlyushinDM/user-order-api/internal/utils/password_util"
 	"github.com/sirupsen/logrus"
 )
@@ -22,30 +31,100 @@ var (
 	ErrServiceDatabaseError = errors.New("ошибка при взаимодействии с репозиторием")
 	ErrNoUpdateFields       = errors.New("не были предоставлены поля для изменения")
 	ErrInvalidServiceInput  = errors.New("входные данные для метода сервиса недопустимы")
+	ErrInvalidRefreshToken  = errors.New("refresh токен недействителен или просрочен")
+	ErrRefreshTokenReused   = errors.New("повторное использование refresh токена, сессия отозвана")
+	ErrVersionMismatch      = errors.New("пользователь изменен другим запросом")
+	ErrLoginThrottled       = errors.New("вход отложен после неудачной попытки")
+	ErrAccountLocked        = errors.New("вход временно заблокирован после неудачных попыток")
 )
 
+// LoginBlockedError сообщает, что вход отклонен без проверки пароля, и когда его можно повторить.
+// Оборачивает ErrAccountLocked при блокировке и ErrLoginThrottled при задержке после неудачи.
+type LoginBlockedError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *LoginBlockedError) Error() string {
+	return fmt.Sprintf("%s, повторите через %s", e.Unwrap(), e.RetryAfter.Round(time.Second))
+}
+
+func (e *LoginBlockedError) Unwrap() error {
+	if e.Locked {
+		return ErrAccountLocked
+	}
+	return ErrLoginThrottled
+}
+
+// tokenTypeBearer - тип токена, возвращаемый клиенту при входе
+const tokenTypeBearer = "Bearer"
+
 // UserService определяет интерфейс для бизнес-логики пользователей.
 type UserService interface {
 	CreateUser(ctx context.Context, req user_model.CreateUserRequest) (*user_model.User, error)
-	UpdateUser(ctx context.Context, id uint, req user_model.UpdateUserRequest) (*user_model.User, error)
+	// UpdateUser изменяет пользователя, если его текущая версия равна version
+	UpdateUser(ctx context.Context, id uint, version uint, req user_model.UpdateUserRequest) (*user_model.User, error)
 	DeleteUser(ctx context.Context, id uint) error
 	GetUserByID(ctx context.Context, id uint) (*user_model.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*user_model.User, error)
 	GetAllUsers(ctx context.Context, page, limit int, filters map[string]any) ([]user_model.User, int64, error)
-	LoginUser(ctx context.Context, req user_model.LoginRequest) (string, error)
+	// LoginUser выполняет вход; client указывает, откуда выполнена попытка, для журнала входов
+	LoginUser(ctx context.Context, req user_model.LoginRequest, client login_attempt_model.ClientInfo) (*user_model.LoginResponse, error)
+	RefreshTokens(ctx context.Context, refreshToken string) (*user_model.LoginResponse, error)
+	LogoutUser(ctx context.Context, userID uint, refreshToken, accessTokenID string, accessExpiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, tokenID string) (bool, error)
+	// GetLoginHistory возвращает страницу попыток входа пользователя от новых к старым
+	GetLoginHistory(ctx context.Context, userID uint, page, limit int) ([]login_attempt_model.LoginAttempt, int64, error)
 }
 
 type userService struct {
-	userRepo  user_rep.UserRepository
-	log       *logrus.Logger
-	jwtSecret string
-	jwtExpSec int
+	userRepo      user_rep.UserRepository
+	tokenRepo     token_rep.TokenRepository
+	loginRepo     login_attempt_rep.LoginAttemptRepository
+	lockout       login_attempt_model.LockoutPolicy
+	loginLocks    loginLocks
+	now           func() time.Time
+	log           *logrus.Logger
+	jwtSecret     string
+	jwtExpSec     int
+	refreshExpSec int
+
+	// Время последней очистки просроченных токенов, см. deleteExpiredTokens
+	tokenCleanupMu   sync.Mutex
+	lastTokenCleanup time.Time
+}
+
+// NewUserService создает новый сервис пользователей без журнала входов и блокировки.
+// jwtExp задает время жизни access токена, refreshExp - время жизни refresh токена (в секундах).
+func NewUserService(
+	repo user_rep.UserRepository,
+	tokenRepo token_rep.TokenRepository,
+	log *logrus.Logger,
+	jwtSecret string,
+	jwtExp int,
+	refreshExp int,
+) UserService {
+	return NewUserServiceWithLoginAudit(repo, tokenRepo, nil, login_attempt_model.LockoutPolicy{}, log, jwtSecret, jwtExp, refreshExp)
 }
 
-// NewUserService создает новый сервис пользователей
-func NewUserService(repo user_rep.UserRepository, log *logrus.Logger, jwtSecret string, jwtExp int) UserService {
+// NewUserServiceWithLoginAudit создает сервис пользователей, который записывает каждую попытку входа
+// в loginRepo и задерживает или блокирует вход после неудачных попыток согласно lockout.
+// Если loginRepo равен nil, журнал входов и блокировка не используются.
+func NewUserServiceWithLoginAudit(
+	repo user_rep.UserRepository,
+	tokenRepo token_rep.TokenRepository,
+	loginRepo login_attempt_rep.LoginAttemptRepository,
+	lockout login_attempt_model.LockoutPolicy,
+	log *logrus.Logger,
+	jwtSecret string,
+	jwtExp int,
+	refreshExp int,
+) UserService {
 	if repo == nil {
-		logrus.Fatal("Экземпляр UserRepository равен nil в NewUserService")
+		logrus.Panic("Экземпляр UserRepository равен nil в NewUserService")
+	}
+	if tokenRepo == nil {
+		logrus.Panic("Экземпляр TokenRepository равен nil в NewUserService")
 	}
 	if log == nil {
 		defaultLog := logrus.New()
@@ -59,15 +138,29 @@ func NewUserService(repo user_rep.UserRepository, log *logrus.Logger, jwtSecret
 	if jwtExp <= 0 {
 		log.Warn("Срок действия JWT не установлен или некорректен (<= 0) в NewUserService")
 	}
+	if refreshExp <= 0 {
+		log.Warn("Срок действия refresh токена не установлен или некорректен (<= 0) в NewUserService")
+	}
 
-	return &userService{userRepo: repo, log: log, jwtSecret: jwtSecret, jwtExpSec: jwtExp}
+	return &userService{
+		userRepo:      repo,
+		tokenRepo:     tokenRepo,
+		loginRepo:     loginRepo,
+		lockout:       lockout,
+		now:           time.Now,
+		log:           log,
+		jwtSecret:     jwtSecret,
+		jwtExpSec:     jwtExp,
+		refreshExpSec: refreshExp,
+	}
 }
 
 // CreateUser создает нового пользователя после проверки на существование и хеширования пароля
 func (s *userService) CreateUser(ctx context.Context, req user_model.CreateUserRequest) (*user_model.User, error) {
 	logger := s.log.WithContext(ctx).WithField("method", "UserService.CreateUser").WithField("email", req.Email)
 
-	if req.Email == "" || req.Password == "" || req.Name == "" {
+	name := strings.TrimSpace(req.Name)
+	if req.Email == "" || req.Password == "" || name == "" {
 		logger.Warn("Недопустимые входные данные для создания пользователя")
 		return nil, ErrInvalidServiceInput
 	}
@@ -89,10 +182,11 @@ func (s *userService) CreateUser(ctx context.Context, req user_model.CreateUserR
 	}
 
 	user := &user_model.User{
-		Name:         req.Name,
+		Name:         name,
 		Email:        req.Email,
 		Age:          req.Age,
 		PasswordHash: hashedPassword,
+		Role:         user_model.RoleUser,
 	}
 
 	if err := s.userRepo.Create(ctx, user); err != nil {
@@ -101,6 +195,7 @@ func (s *userService) CreateUser(ctx context.Context, req user_model.CreateUserR
 	}
 
 	logger.WithField("user_id", user.ID).Info("Пользователь успешно создан")
+	metrics_util.UsersCreated.Inc()
 	return user, nil
 }
 
@@ -108,6 +203,7 @@ func (s *userService) CreateUser(ctx context.Context, req user_model.CreateUserR
 func (s *userService) UpdateUser(
 	ctx context.Context,
 	id uint,
+	version uint,
 	req user_model.UpdateUserRequest,
 ) (*user_model.User, error) {
 	logger := s.log.WithContext(ctx).WithField("method", "UserService.UpdateUser").WithField("user_id", id)
@@ -129,11 +225,24 @@ func (s *userService) UpdateUser(
 		return nil, fmt.Errorf("%w: ошибка базы данных при поиске пользователя для обновления", err)
 	}
 
+	// Быстрая проверка версии; окончательно ее гарантирует условное обновление в репозитории
+	if user.Version != version {
+		logger.WithFields(logrus.Fields{"expected": version, "actual": user.Version}).
+			Warn("Обновление не удалось: версия пользователя не совпадает с If-Match")
+		return nil, ErrVersionMismatch
+	}
+
 	updated := false
-	if req.Name != "" && req.Name != user.Name {
-		user.Name = req.Name
-		updated = true
-		logger.Debug("Обновление имени пользователя")
+	if req.Name != "" {
+		name := strings.TrimSpace(req.Name)
+		if name == "" {
+			return nil, fmt.Errorf("%w: имя пользователя не может быть пустым", ErrInvalidServiceInput)
+		}
+		if name != user.Name {
+			user.Name = name
+			updated = true
+			logger.Debug("Обновление имени пользователя")
+		}
 	}
 	if req.Age > 0 && req.Age != user.Age {
 		user.Age = req.Age
@@ -155,6 +264,15 @@ func (s *userService) UpdateUser(
 		updated = true
 		logger.Debug("Обновление email пользователя")
 	}
+	if req.Role != "" && req.Role != user.Role {
+		if !user_model.IsValidRole(req.Role) {
+			logger.Warnf("Обновление не удалось: неизвестная роль %q", req.Role)
+			return nil, fmt.Errorf("%w: неизвестная роль пользователя", ErrInvalidServiceInput)
+		}
+		user.Role = req.Role
+		updated = true
+		logger.WithField("role", req.Role).Info("Изменение роли пользователя")
+	}
 
 	if !updated {
 		logger.Info("Нет полей для обновления у пользователя")
@@ -167,6 +285,9 @@ func (s *userService) UpdateUser(
 			logger.Warn("Обновление не удалось: Пользователь не найден или нет изменений при обновлении в репозитории")
 			return nil, ErrUserNotFound
 		}
+		if errors.Is(err, user_rep.ErrVersionMismatch) {
+			return nil, ErrVersionMismatch
+		}
 		return nil, fmt.Errorf("%w: не удалось сохранить обновленного пользователя через репозиторий", err)
 	}
 
@@ -270,40 +391,383 @@ func (s *userService) GetAllUsers(
 	return users, total, nil
 }
 
-// LoginUser аутентифицирует пользователя и генерирует JWT токен
-func (s *userService) LoginUser(ctx context.Context, req user_model.LoginRequest) (string, error) {
+// LoginUser аутентифицирует пользователя и выпускает пару токенов: access JWT и refresh токен.
+// Каждый вход открывает новое семейство refresh токенов. Если включен журнал входов,
+// попытка записывается в него, а после неудачных попыток вход задерживается или блокируется
+// без проверки пароля. Неудачи считаются по email, поэтому блокировка не выдает, существует ли пользователь.
+func (s *userService) LoginUser(
+	ctx context.Context,
+	req user_model.LoginRequest,
+	client login_attempt_model.ClientInfo,
+) (_ *user_model.LoginResponse, err error) {
+	defer func() { metrics_util.ObserveLogin(err == nil) }()
 	logger := s.log.WithContext(ctx).WithField("method", "UserService.LoginUser").WithField("email", req.Email)
 
 	// Базовая валидация входных данных сервиса
 	if req.Email == "" || req.Password == "" {
 		logger.Warn("Недопустимые входные данные для входа")
-		return "", ErrInvalidServiceInput
+		return nil, ErrInvalidServiceInput
+	}
+
+	// Проверка блокировки, пароля и запись исхода для одного email выполняются последовательно
+	if s.loginRepo != nil && s.lockout.Enabled() {
+		defer s.loginLocks.Lock(login_attempt_model.NormalizeEmail(req.Email))()
 	}
 
 	user, err := s.userRepo.GetByEmail(ctx, req.Email)
 	// Обработка ошибок репозитория
-	if err != nil {
-		if errors.Is(err, user_rep.ErrUserNotFound) {
-			logger.Warn("Попытка входа не удалась: Пользователь не найден в репозитории")
-			return "", ErrInvalidCredentials
-		}
+	if err != nil && !errors.Is(err, user_rep.ErrUserNotFound) {
 		logger.WithError(err).Error("Ошибка базы данных при попытке входа")
-		return "", fmt.Errorf("%w: ошибка базы данных при поиске пользователя для входа", err)
+		return nil, fmt.Errorf("%w: ошибка базы данных при поиске пользователя для входа", err)
+	}
+	var userID *uint
+	if user != nil && err == nil {
+		userID = &user.ID
+	}
+
+	if err := s.checkLoginAllowed(ctx, logger, req.Email, userID, client); err != nil {
+		return nil, err
+	}
+
+	if userID == nil {
+		logger.Warn("Попытка входа не удалась: Пользователь не найден в репозитории")
+		s.recordLoginAttempt(ctx, logger, req.Email, nil, client, login_attempt_model.OutcomeUnknownUser)
+		return nil, ErrInvalidCredentials
 	}
 
 	if !password_util.CheckPasswordHash(req.Password, user.PasswordHash) {
 		logger.Warn("Попытка входа не удалась: Неверный пароль")
-		return "", ErrInvalidCredentials
+		s.recordLoginAttempt(ctx, logger, req.Email, userID, client, login_attempt_model.OutcomeInvalidPassword)
+		return nil, ErrInvalidCredentials
 	}
 
-	token, err := jwt_util.GenerateJWT(user.ID, user.Email, s.jwtSecret, s.jwtExpSec)
+	familyID, err := jwt_util.NewTokenID()
 	if err != nil {
-		logger.WithError(err).Error("Не удалось сгенерировать JWT токен")
-		return "", fmt.Errorf("%w: не удалось сгенерировать токен аутентификации", ErrInternalServiceError)
+		logger.WithError(err).Error("Не удалось сгенерировать идентификатор семейства refresh токенов")
+		return nil, fmt.Errorf("%w: не удалось сгенерировать токен аутентификации", ErrInternalServiceError)
 	}
 
+	tokens, err := s.issueTokens(ctx, user, familyID)
+	if err != nil {
+		logger.WithError(err).Error("Не удалось выпустить токены")
+		return nil, err
+	}
+
+	s.recordLoginAttempt(ctx, logger, req.Email, userID, client, login_attempt_model.OutcomeSuccess)
 	logger.WithField("user_id", user.ID).Info("Пользователь успешно вошел в систему")
-	return token, nil
+	return tokens, nil
+}
+
+// checkLoginAllowed возвращает LoginBlockedError, если после неудачных попыток вход с email
+// пока запрещен. Отклоненная попытка записывается в журнал, но не продлевает запрет.
+func (s *userService) checkLoginAllowed(
+	ctx context.Context,
+	logger *logrus.Entry,
+	email string,
+	userID *uint,
+	client login_attempt_model.ClientInfo,
+) error {
+	if s.loginRepo == nil || !s.lockout.Enabled() {
+		return nil
+	}
+
+	failures, err := s.loginRepo.RecentFailures(ctx, login_attempt_model.NormalizeEmail(email), s.lockout.MaxFailures)
+	if err != nil {
+		logger.WithError(err).Error("Не удалось получить историю неудачных попыток входа")
+		return fmt.Errorf("%w: не удалось проверить блокировку входа", ErrServiceDatabaseError)
+	}
+
+	now := s.now()
+	until, locked := s.lockout.BlockedUntil(failures, now)
+	if until.IsZero() {
+		return nil
+	}
+
+	outcome := login_attempt_model.OutcomeThrottled
+	if locked {
+		outcome = login_attempt_model.OutcomeLocked
+	}
+	logger.WithFields(logrus.Fields{"failures": len(failures), "blocked_until": until, "locked": locked}).
+		Warn("Попытка входа отклонена после неудачных попыток")
+	s.recordLoginAttempt(ctx, logger, email, userID, client, outcome)
+	return &LoginBlockedError{RetryAfter: until.Sub(now), Locked: locked}
+}
+
+// recordLoginAttempt записывает попытку входа в журнал. Ошибка записи не прерывает вход,
+// а запись выполняется и после отключения клиента, чтобы неудачные попытки не терялись.
+func (s *userService) recordLoginAttempt(
+	ctx context.Context,
+	logger *logrus.Entry,
+	email string,
+	userID *uint,
+	client login_attempt_model.ClientInfo,
+	outcome string,
+) {
+	if s.loginRepo == nil {
+		return
+	}
+
+	attempt := &login_attempt_model.LoginAttempt{
+		UserID:    userID,
+		Email:     login_attempt_model.NormalizeEmail(email),
+		IP:        truncate(client.IP, maxClientIPLength),
+		UserAgent: truncate(client.UserAgent, maxUserAgentLength),
+		Outcome:   outcome,
+		CreatedAt: s.now().UTC(),
+	}
+	if err := s.loginRepo.Create(context.WithoutCancel(ctx), attempt); err != nil {
+		logger.WithError(err).WithField("outcome", outcome).Error("Не удалось записать попытку входа в журнал")
+	}
+}
+
+// Ограничения длины полей журнала входов, совпадают с размерами столбцов таблицы
+const (
+	maxClientIPLength  = 64
+	maxUserAgentLength = 512
+)
+
+// truncate обрезает строку до max байт, не разрывая символы UTF-8
+func truncate(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	for max > 0 && !utf8.RuneStart(value[max]) {
+		max--
+	}
+	return value[:max]
+}
+
+// GetLoginHistory возвращает страницу истории входов пользователя
+func (s *userService) GetLoginHistory(
+	ctx context.Context,
+	userID uint,
+	page, limit int,
+) ([]login_attempt_model.LoginAttempt, int64, error) {
+	logger := s.log.WithContext(ctx).WithFields(logrus.Fields{"method": "UserService.GetLoginHistory", "user_id": userID})
+
+	if userID == 0 {
+		logger.Warn("Попытка получить историю входов с нулевым ID пользователя")
+		return nil, 0, ErrInvalidServiceInput
+	}
+	if page <= 0 {
+		page = 1
+		logger.Warn("Предоставлен некорректный номер страницы, используется страница 1 по умолчанию")
+	}
+	if limit <= 0 {
+		limit = 10
+		logger.Warnf("Предоставлен некорректный лимит, используется лимит %d по умолчанию", limit)
+	}
+
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		if errors.Is(err, user_rep.ErrUserNotFound) {
+			logger.Warn("Пользователь для истории входов не найден")
+			return nil, 0, ErrUserNotFound
+		}
+		logger.WithError(err).Error("Не удалось получить пользователя для истории входов")
+		return nil, 0, fmt.Errorf("%w: не удалось получить пользователя через репозиторий", err)
+	}
+
+	if s.loginRepo == nil {
+		return []login_attempt_model.LoginAttempt{}, 0, nil
+	}
+
+	attempts, total, err := s.loginRepo.GetByUserID(ctx, userID, page, limit)
+	if err != nil {
+		logger.WithError(err).Error("Не удалось получить историю входов из репозитория")
+		return nil, 0, fmt.Errorf("%w: не удалось получить историю входов", ErrServiceDatabaseError)
+	}
+
+	logger.WithFields(logrus.Fields{"count": len(attempts), "total": total}).Info("История входов получена")
+	return attempts, total, nil
+}
+
+// RefreshTokens обменивает refresh токен на новую пару токенов (ротация).
+// Предъявленный токен становится недействительным. Повторное предъявление
+// уже использованного токена считается признаком кражи и отзывает всё семейство.
+func (s *userService) RefreshTokens(ctx context.Context, refreshToken string) (*user_model.LoginResponse, error) {
+	logger := s.log.WithContext(ctx).WithField("method", "UserService.RefreshTokens")
+
+	if refreshToken == "" {
+		logger.Warn("Пустой refresh токен")
+		return nil, ErrInvalidRefreshToken
+	}
+
+	stored, err := s.tokenRepo.GetRefreshTokenByHash(ctx, jwt_util.HashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, token_rep.ErrTokenNotFound) {
+			logger.Warn("Refresh токен не найден")
+			return nil, ErrInvalidRefreshToken
+		}
+		logger.WithError(err).Error("Ошибка базы данных при поиске refresh токена")
+		return nil, fmt.Errorf("%w: ошибка базы данных при поиске refresh токена", ErrServiceDatabaseError)
+	}
+	logger = logger.WithFields(logrus.Fields{"user_id": stored.UserID, "family_id": stored.FamilyID})
+
+	if stored.RevokedAt != nil {
+		logger.Warn("Предъявлен отозванный refresh токен")
+		return nil, ErrInvalidRefreshToken
+	}
+	if stored.UsedAt != nil {
+		return nil, s.revokeReusedFamily(ctx, logger, stored.FamilyID)
+	}
+	if stored.IsExpired(time.Now()) {
+		logger.Warn("Предъявлен просроченный refresh токен")
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Условное обновление защищает от гонки двух параллельных обменов одного токена
+	if err := s.tokenRepo.MarkRefreshTokenUsed(ctx, stored.ID); err != nil {
+		if errors.Is(err, token_rep.ErrTokenAlreadyUsed) {
+			return nil, s.revokeReusedFamily(ctx, logger, stored.FamilyID)
+		}
+		logger.WithError(err).Error("Не удалось пометить refresh токен как использованный")
+		return nil, fmt.Errorf("%w: не удалось обновить refresh токен", ErrServiceDatabaseError)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, user_rep.ErrUserNotFound) {
+			logger.Warn("Владелец refresh токена не найден, семейство отзывается")
+			if revokeErr := s.tokenRepo.RevokeFamily(ctx, stored.FamilyID); revokeErr != nil {
+				logger.WithError(revokeErr).Error("Не удалось отозвать семейство refresh токенов")
+			}
+			return nil, ErrInvalidRefreshToken
+		}
+		logger.WithError(err).Error("Ошибка базы данных при поиске владельца refresh токена")
+		return nil, fmt.Errorf("%w: ошибка базы данных при поиске пользователя", err)
+	}
+
+	tokens, err := s.issueTokens(ctx, user, stored.FamilyID)
+	if err != nil {
+		logger.WithError(err).Error("Не удалось выпустить новую пару токенов")
+		return nil, err
+	}
+
+	logger.Info("Пара токенов успешно обновлена")
+	return tokens, nil
+}
+
+// LogoutUser завершает сессию: отзывает семейство refresh токенов и
+// добавляет текущий access токен в список отзыва до истечения его срока действия.
+func (s *userService) LogoutUser(
+	ctx context.Context,
+	userID uint,
+	refreshToken,
+	accessTokenID string,
+	accessExpiresAt time.Time,
+) error {
+	logger := s.log.WithContext(ctx).WithField("method", "UserService.LogoutUser").WithField("user_id", userID)
+
+	if userID == 0 || refreshToken == "" {
+		logger.Warn("Недопустимые входные данные для выхода")
+		return ErrInvalidServiceInput
+	}
+
+	stored, err := s.tokenRepo.GetRefreshTokenByHash(ctx, jwt_util.HashToken(refreshToken))
+	switch {
+	case err == nil && stored.UserID != userID:
+		logger.WithField("token_owner_id", stored.UserID).Warn("Попытка выхода с чужим refresh токеном")
+		return ErrInvalidRefreshToken
+	case err == nil:
+		if err := s.tokenRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+			logger.WithError(err).Error("Не удалось отозвать семейство refresh токенов")
+			return fmt.Errorf("%w: не удалось отозвать refresh токены", ErrServiceDatabaseError)
+		}
+	case errors.Is(err, token_rep.ErrTokenNotFound):
+		// Неизвестный refresh токен не мешает отозвать текущий access токен
+		logger.Warn("Refresh токен для выхода не найден")
+	default:
+		logger.WithError(err).Error("Ошибка базы данных при поиске refresh токена")
+		return fmt.Errorf("%w: ошибка базы данных при поиске refresh токена", ErrServiceDatabaseError)
+	}
+
+	if accessTokenID != "" {
+		entry := &token_model.RevokedAccessToken{
+			JTI:       accessTokenID,
+			UserID:    userID,
+			ExpiresAt: accessExpiresAt,
+		}
+		if err := s.tokenRepo.RevokeAccessToken(ctx, entry); err != nil {
+			logger.WithError(err).Error("Не удалось отозвать access токен")
+			return fmt.Errorf("%w: не удалось отозвать access токен", ErrServiceDatabaseError)
+		}
+	}
+
+	logger.Info("Пользователь вышел из системы, токены отозваны")
+	s.deleteExpiredTokens(ctx, logger)
+	return nil
+}
+
+// expiredTokensCleanupInterval задает, как часто выход из системы удаляет просроченные токены
+const expiredTokensCleanupInterval = time.Minute
+
+// deleteExpiredTokens удаляет просроченные записи списка отзыва и refresh токены,
+// но не чаще раза в expiredTokensCleanupInterval. Ошибка очистки не прерывает выход:
+// записи будут удалены при следующей очистке.
+func (s *userService) deleteExpiredTokens(ctx context.Context, logger *logrus.Entry) {
+	now := s.now()
+	s.tokenCleanupMu.Lock()
+	if now.Sub(s.lastTokenCleanup) < expiredTokensCleanupInterval {
+		s.tokenCleanupMu.Unlock()
+		return
+	}
+	s.lastTokenCleanup = now
+	s.tokenCleanupMu.Unlock()
+
+	if err := s.tokenRepo.DeleteExpired(ctx, now); err != nil {
+		logger.WithError(err).Warn("Не удалось удалить просроченные токены")
+	}
+}
+
+// IsTokenRevoked проверяет, отозван ли access токен с указанным jti
+func (s *userService) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	revoked, err := s.tokenRepo.IsAccessTokenRevoked(ctx, tokenID)
+	if err != nil {
+		s.log.WithContext(ctx).WithField("method", "UserService.IsTokenRevoked").
+			WithError(err).Error("Не удалось проверить список отзыва access токенов")
+		return false, fmt.Errorf("%w: не удалось проверить список отзыва", ErrServiceDatabaseError)
+	}
+	return revoked, nil
+}
+
+// issueTokens выпускает access JWT и новый refresh токен в указанном семействе
+func (s *userService) issueTokens(ctx context.Context, user *user_model.User, familyID string) (*user_model.LoginResponse, error) {
+	accessToken, err := jwt_util.GenerateJWT(user.ID, user.Email, user.Role, s.jwtSecret, s.jwtExpSec)
+	if err != nil {
+		return nil, fmt.Errorf("%w: не удалось сгенерировать токен аутентификации", ErrInternalServiceError)
+	}
+
+	refreshToken, err := jwt_util.GenerateRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("%w: не удалось сгенерировать refresh токен", ErrInternalServiceError)
+	}
+
+	stored := &token_model.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: jwt_util.HashToken(refreshToken),
+		ExpiresAt: time.Now().Add(time.Duration(s.refreshExpSec) * time.Second).UTC(),
+	}
+	if err := s.tokenRepo.CreateRefreshToken(ctx, stored); err != nil {
+		return nil, fmt.Errorf("%w: не удалось сохранить refresh токен", ErrServiceDatabaseError)
+	}
+
+	return &user_model.LoginResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    tokenTypeBearer,
+		ExpiresIn:    s.jwtExpSec,
+	}, nil
+}
+
+// revokeReusedFamily отзывает семейство при повторном использовании refresh токена
+func (s *userService) revokeReusedFamily(ctx context.Context, logger *logrus.Entry, familyID string) error {
+	logger.Warn("Обнаружено повторное использование refresh токена, семейство отзывается")
+	if err := s.tokenRepo.RevokeFamily(ctx, familyID); err != nil {
+		logger.WithError(err).Error("Не удалось отозвать семейство refresh токенов")
+		return fmt.Errorf("%w: не удалось отозвать refresh токены", ErrServiceDatabaseError)
+	}
+	return ErrRefreshTokenReused
 }
 
 // GetUserByEmail получает пользователя по Email
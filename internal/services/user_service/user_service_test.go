@@ -2,14 +2,28 @@ package user_service_test
 
 import (
 	"context"
+	"errors"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/IlyushinDM/user-order-api/internal/models/login_attempt_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/token_model"
 	"github.com/IlyushinDM/user-order-api/internal/models/user_model"
+	"github.com/IlyushinDM/user-order-api/internal/repository/login_attempt_rep"
+	"github.com/IlyushinDM/user-order-api/internal/repository/token_rep"
 	"github.com/IlyushinDM/user-order-api/internal/repository/user_rep"
 	"github.com/IlyushinDM/user-order-api/internal/services/user_service"
+	"github.com/IlyushinDM/user-order-api/internal/utils/jwt_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/password_util"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
 // MockUserRepository реализует интерфейс UserRepository для тестирования
@@ -47,13 +61,81 @@ func (m *MockUserRepository) GetAll(ctx context.Context, params user_rep.ListQue
 	return args.Get(0).([]user_model.User), args.Get(1).(int64), args.Error(2)
 }
 
+// MockLoginAttemptRepository реализует интерфейс LoginAttemptRepository для тестирования
+type MockLoginAttemptRepository struct {
+	mock.Mock
+}
+
+func (m *MockLoginAttemptRepository) Create(ctx context.Context, attempt *login_attempt_model.LoginAttempt) error {
+	args := m.Called(ctx, attempt)
+	return args.Error(0)
+}
+
+func (m *MockLoginAttemptRepository) RecentFailures(ctx context.Context, email string, limit int) ([]time.Time, error) {
+	args := m.Called(ctx, email, limit)
+	failures, _ := args.Get(0).([]time.Time)
+	return failures, args.Error(1)
+}
+
+func (m *MockLoginAttemptRepository) GetByUserID(
+	ctx context.Context,
+	userID uint,
+	page, limit int,
+) ([]login_attempt_model.LoginAttempt, int64, error) {
+	args := m.Called(ctx, userID, page, limit)
+	attempts, _ := args.Get(0).([]login_attempt_model.LoginAttempt)
+	total, _ := args.Get(1).(int64)
+	return attempts, total, args.Error(2)
+}
+
+// MockTokenRepository реализует интерфейс TokenRepository для тестирования
+type MockTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockTokenRepository) CreateRefreshToken(ctx context.Context, token *token_model.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*token_model.RefreshToken, error) {
+	args := m.Called(ctx, tokenHash)
+	token, _ := args.Get(0).(*token_model.RefreshToken)
+	return token, args.Error(1)
+}
+
+func (m *MockTokenRepository) MarkRefreshTokenUsed(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	args := m.Called(ctx, familyID)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) RevokeAccessToken(ctx context.Context, token *token_model.RevokedAccessToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) DeleteExpired(ctx context.Context, now time.Time) error {
+	args := m.Called(ctx, now)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	args := m.Called(ctx, jti)
+	return args.Bool(0), args.Error(1)
+}
+
 // TestNewUserService тестирует создание нового сервиса
 func TestNewUserService(t *testing.T) {
 	t.Run("Успешное создание сервиса", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
 		logger := logrus.New()
 
-		service := user_service.NewUserService(mockRepo, logger, "secret", 3600)
+		service := user_service.NewUserService(mockRepo, new(MockTokenRepository), logger, "secret", 3600, 7200)
 		assert.NotNil(t, service)
 	})
 
@@ -64,7 +146,7 @@ func TestNewUserService(t *testing.T) {
 			}
 		}()
 
-		user_service.NewUserService(nil, logrus.New(), "secret", 3600)
+		user_service.NewUserService(nil, new(MockTokenRepository), logrus.New(), "secret", 3600, 7200)
 	})
 }
 
@@ -72,7 +154,7 @@ func TestNewUserService(t *testing.T) {
 func TestGetUserByID(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockUserRepository)
-	service := user_service.NewUserService(mockRepo, logrus.New(), "secret", 3600)
+	service := user_service.NewUserService(mockRepo, new(MockTokenRepository), logrus.New(), "secret", 3600, 7200)
 
 	testUser := &user_model.User{
 		ID:    1,
@@ -103,17 +185,47 @@ func TestGetUserByID(t *testing.T) {
 	})
 }
 
+// TestCreateUser тестирует создание пользователя
+func TestCreateUser(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Имя сохраняется без пробелов по краям", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := user_service.NewUserService(mockRepo, new(MockTokenRepository), logrus.New(), "secret", 3600, 7200)
+		req := user_model.CreateUserRequest{Name: "  Alice  ", Email: "alice@example.com", Age: 30, Password: "secret123"}
+
+		mockRepo.On("GetByEmail", ctx, req.Email).Return((*user_model.User)(nil), user_rep.ErrUserNotFound)
+		mockRepo.On("Create", ctx, mock.AnythingOfType("*user_model.User")).Return(nil)
+
+		user, err := service.CreateUser(ctx, req)
+		assert.NoError(t, err)
+		assert.Equal(t, "Alice", user.Name)
+	})
+
+	t.Run("Ошибка: имя из одних пробелов", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := user_service.NewUserService(mockRepo, new(MockTokenRepository), logrus.New(), "secret", 3600, 7200)
+
+		_, err := service.CreateUser(ctx, user_model.CreateUserRequest{
+			Name: "   ", Email: "alice@example.com", Age: 30, Password: "secret123",
+		})
+		assert.ErrorIs(t, err, user_service.ErrInvalidServiceInput)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
 // TestUpdateUser тестирует обновление пользователя
 func TestUpdateUser(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockUserRepository)
-	service := user_service.NewUserService(mockRepo, logrus.New(), "secret", 3600)
+	service := user_service.NewUserService(mockRepo, new(MockTokenRepository), logrus.New(), "secret", 3600, 7200)
 
 	existingUser := &user_model.User{
-		ID:    1,
-		Name:  "Old Name",
-		Email: "old@example.com",
-		Age:   30,
+		ID:      1,
+		Name:    "Old Name",
+		Email:   "old@example.com",
+		Age:     30,
+		Version: 1,
 	}
 
 	t.Run("Успешное обновление пользователя", func(t *testing.T) {
@@ -127,7 +239,7 @@ func TestUpdateUser(t *testing.T) {
 		mockRepo.On("GetByEmail", ctx, updateReq.Email).Return((*user_model.User)(nil), user_rep.ErrUserNotFound)
 		mockRepo.On("Update", ctx, mock.AnythingOfType("*user_model.User")).Return(nil)
 
-		updatedUser, err := service.UpdateUser(ctx, existingUser.ID, updateReq)
+		updatedUser, err := service.UpdateUser(ctx, existingUser.ID, existingUser.Version, updateReq)
 		assert.NoError(t, err)
 		assert.Equal(t, updateReq.Name, updatedUser.Name)
 		assert.Equal(t, updateReq.Email, updatedUser.Email)
@@ -147,17 +259,86 @@ func TestUpdateUser(t *testing.T) {
 		mockRepo.On("GetByID", ctx, existingUser.ID).Return(existingUser, nil)
 		mockRepo.On("GetByEmail", ctx, updateReq.Email).Return(otherUser, nil)
 
-		_, err := service.UpdateUser(ctx, existingUser.ID, updateReq)
+		_, err := service.UpdateUser(ctx, existingUser.ID, existingUser.Version, updateReq)
 		assert.Error(t, err)
 		assert.Equal(t, user_service.ErrEmailAlreadyTaken, err)
 	})
+
+	t.Run("Успешное изменение роли", func(t *testing.T) {
+		roleRepo := new(MockUserRepository)
+		roleService := user_service.NewUserService(roleRepo, new(MockTokenRepository), logrus.New(), "secret", 3600, 7200)
+		user := &user_model.User{ID: 3, Name: "Agent", Email: "agent@example.com", Age: 25, Role: user_model.RoleUser, Version: 1}
+
+		roleRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+		roleRepo.On("Update", ctx, mock.AnythingOfType("*user_model.User")).Return(nil)
+
+		updatedUser, err := roleService.UpdateUser(ctx, user.ID, 1, user_model.UpdateUserRequest{Role: user_model.RoleSupport})
+		assert.NoError(t, err)
+		assert.Equal(t, user_model.RoleSupport, updatedUser.Role)
+	})
+
+	t.Run("Ошибка: неизвестная роль", func(t *testing.T) {
+		roleRepo := new(MockUserRepository)
+		roleService := user_service.NewUserService(roleRepo, new(MockTokenRepository), logrus.New(), "secret", 3600, 7200)
+		user := &user_model.User{ID: 4, Role: user_model.RoleUser, Version: 1}
+
+		roleRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+
+		_, err := roleService.UpdateUser(ctx, user.ID, 1, user_model.UpdateUserRequest{Role: "superuser"})
+		assert.ErrorIs(t, err, user_service.ErrInvalidServiceInput)
+		roleRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Имя сохраняется без пробелов по краям", func(t *testing.T) {
+		nameRepo := new(MockUserRepository)
+		nameService := user_service.NewUserService(nameRepo, new(MockTokenRepository), logrus.New(), "secret", 3600, 7200)
+		user := &user_model.User{ID: 6, Name: "Bob", Role: user_model.RoleUser, Version: 1}
+
+		nameRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+
+		// Совпадающее после обрезки имя не считается изменением
+		_, err := nameService.UpdateUser(ctx, user.ID, 1, user_model.UpdateUserRequest{Name: " Bob "})
+		assert.ErrorIs(t, err, user_service.ErrNoUpdateFields)
+
+		nameRepo.On("Update", ctx, mock.AnythingOfType("*user_model.User")).Return(nil)
+		updatedUser, err := nameService.UpdateUser(ctx, user.ID, 1, user_model.UpdateUserRequest{Name: "  Robert "})
+		assert.NoError(t, err)
+		assert.Equal(t, "Robert", updatedUser.Name)
+
+		_, err = nameService.UpdateUser(ctx, user.ID, 1, user_model.UpdateUserRequest{Name: "   "})
+		assert.ErrorIs(t, err, user_service.ErrInvalidServiceInput)
+	})
+
+	t.Run("Ошибка: устаревшая версия", func(t *testing.T) {
+		versionRepo := new(MockUserRepository)
+		versionService := user_service.NewUserService(versionRepo, new(MockTokenRepository), logrus.New(), "secret", 3600, 7200)
+		user := &user_model.User{ID: 5, Name: "Stale", Role: user_model.RoleUser, Version: 3}
+
+		versionRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+
+		_, err := versionService.UpdateUser(ctx, user.ID, 2, user_model.UpdateUserRequest{Name: "Fresh"})
+		assert.ErrorIs(t, err, user_service.ErrVersionMismatch)
+		versionRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Ошибка: версия изменилась во время обновления", func(t *testing.T) {
+		versionRepo := new(MockUserRepository)
+		versionService := user_service.NewUserService(versionRepo, new(MockTokenRepository), logrus.New(), "secret", 3600, 7200)
+		user := &user_model.User{ID: 6, Name: "Racing", Role: user_model.RoleUser, Version: 1}
+
+		versionRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+		versionRepo.On("Update", ctx, mock.AnythingOfType("*user_model.User")).Return(user_rep.ErrVersionMismatch)
+
+		_, err := versionService.UpdateUser(ctx, user.ID, 1, user_model.UpdateUserRequest{Name: "Fresh"})
+		assert.ErrorIs(t, err, user_service.ErrVersionMismatch)
+	})
 }
 
 // TestDeleteUser тестирует удаление пользователя
 func TestDeleteUser(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockUserRepository)
-	service := user_service.NewUserService(mockRepo, logrus.New(), "secret", 3600)
+	service := user_service.NewUserService(mockRepo, new(MockTokenRepository), logrus.New(), "secret", 3600, 7200)
 
 	t.Run("Успешное удаление пользователя", func(t *testing.T) {
 		userID := uint(1)
@@ -181,7 +362,7 @@ func TestDeleteUser(t *testing.T) {
 func TestGetAllUsers(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockUserRepository)
-	service := user_service.NewUserService(mockRepo, logrus.New(), "secret", 3600)
+	service := user_service.NewUserService(mockRepo, new(MockTokenRepository), logrus.New(), "secret", 3600, 7200)
 
 	testUsers := []user_model.User{
 		{ID: 1, Name: "User 1"},
@@ -210,3 +391,337 @@ func TestGetAllUsers(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+// TestLoginUser тестирует вход пользователя и выпуск пары токенов
+func TestLoginUser(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockUserRepository)
+	tokenRepo := new(MockTokenRepository)
+	service := user_service.NewUserService(mockRepo, tokenRepo, logrus.New(), "secret", 900, 7200)
+
+	hash, err := password_util.HashPassword("password123")
+	assert.NoError(t, err)
+	user := &user_model.User{ID: 1, Email: "test@example.com", PasswordHash: hash}
+
+	t.Run("Успешный вход", func(t *testing.T) {
+		mockRepo.On("GetByEmail", ctx, user.Email).Return(user, nil).Once()
+		tokenRepo.On("CreateRefreshToken", ctx, mock.MatchedBy(func(token *token_model.RefreshToken) bool {
+			return token.UserID == user.ID && token.FamilyID != "" && token.ExpiresAt.After(time.Now())
+		})).Return(nil).Once()
+
+		tokens, err := service.LoginUser(ctx, user_model.LoginRequest{Email: user.Email, Password: "password123"}, login_attempt_model.ClientInfo{})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, tokens.Token)
+		assert.NotEmpty(t, tokens.RefreshToken)
+		assert.Equal(t, "Bearer", tokens.TokenType)
+		assert.Equal(t, 900, tokens.ExpiresIn)
+
+		claims, err := jwt_util.ValidateJWT(tokens.Token, "secret")
+		assert.NoError(t, err)
+		assert.NotEmpty(t, claims.ID)
+	})
+
+	t.Run("Ошибка: неверный пароль", func(t *testing.T) {
+		mockRepo.On("GetByEmail", ctx, user.Email).Return(user, nil).Once()
+
+		_, err := service.LoginUser(ctx, user_model.LoginRequest{Email: user.Email, Password: "wrong"}, login_attempt_model.ClientInfo{})
+		assert.ErrorIs(t, err, user_service.ErrInvalidCredentials)
+	})
+}
+
+// TestLoginUser_Audit тестирует запись попыток входа в журнал и блокировку после неудачных попыток
+func TestLoginUser_Audit(t *testing.T) {
+	ctx := context.Background()
+	policy := login_attempt_model.LockoutPolicy{
+		MaxFailures:     3,
+		Window:          15 * time.Minute,
+		LockoutDuration: 10 * time.Minute,
+		BaseDelay:       time.Second,
+	}
+	client := login_attempt_model.ClientInfo{IP: "192.0.2.10", UserAgent: "test-agent"}
+
+	hash, err := password_util.HashPassword("password123")
+	assert.NoError(t, err)
+	user := &user_model.User{ID: 1, Email: "test@example.com", PasswordHash: hash}
+
+	setup := func() (*MockUserRepository, *MockTokenRepository, *MockLoginAttemptRepository, user_service.UserService) {
+		mockRepo := new(MockUserRepository)
+		tokenRepo := new(MockTokenRepository)
+		loginRepo := new(MockLoginAttemptRepository)
+		service := user_service.NewUserServiceWithLoginAudit(mockRepo, tokenRepo, loginRepo, policy, logrus.New(), "secret", 900, 7200)
+		return mockRepo, tokenRepo, loginRepo, service
+	}
+	attemptWith := func(userID *uint, outcome string) any {
+		return mock.MatchedBy(func(attempt *login_attempt_model.LoginAttempt) bool {
+			sameUser := (userID == nil && attempt.UserID == nil) ||
+				(userID != nil && attempt.UserID != nil && *attempt.UserID == *userID)
+			return sameUser && attempt.Outcome == outcome && attempt.Email == "test@example.com" &&
+				attempt.IP == client.IP && attempt.UserAgent == client.UserAgent && !attempt.CreatedAt.IsZero()
+		})
+	}
+
+	t.Run("Успешный вход записывается в журнал", func(t *testing.T) {
+		mockRepo, tokenRepo, loginRepo, service := setup()
+		mockRepo.On("GetByEmail", ctx, "Test@Example.com").Return(user, nil).Once()
+		loginRepo.On("RecentFailures", ctx, "test@example.com", 3).Return([]time.Time{}, nil).Once()
+		tokenRepo.On("CreateRefreshToken", ctx, mock.Anything).Return(nil).Once()
+		loginRepo.On("Create", mock.Anything, attemptWith(&user.ID, login_attempt_model.OutcomeSuccess)).Return(nil).Once()
+
+		_, err := service.LoginUser(ctx, user_model.LoginRequest{Email: "Test@Example.com", Password: "password123"}, client)
+		assert.NoError(t, err)
+		loginRepo.AssertExpectations(t)
+	})
+
+	t.Run("Неверный пароль и неизвестный email записываются как неудачи", func(t *testing.T) {
+		mockRepo, _, loginRepo, service := setup()
+		mockRepo.On("GetByEmail", ctx, user.Email).Return(user, nil).Once()
+		mockRepo.On("GetByEmail", ctx, "TEST@example.com").Return((*user_model.User)(nil), user_rep.ErrUserNotFound).Once()
+		loginRepo.On("RecentFailures", ctx, "test@example.com", 3).Return([]time.Time{time.Now().Add(-time.Minute)}, nil).Twice()
+		loginRepo.On("Create", mock.Anything, attemptWith(&user.ID, login_attempt_model.OutcomeInvalidPassword)).Return(nil).Once()
+		loginRepo.On("Create", mock.Anything, attemptWith(nil, login_attempt_model.OutcomeUnknownUser)).Return(nil).Once()
+
+		_, err := service.LoginUser(ctx, user_model.LoginRequest{Email: user.Email, Password: "wrong"}, client)
+		assert.ErrorIs(t, err, user_service.ErrInvalidCredentials)
+		_, err = service.LoginUser(ctx, user_model.LoginRequest{Email: "TEST@example.com", Password: "wrong"}, client)
+		assert.ErrorIs(t, err, user_service.ErrInvalidCredentials)
+		loginRepo.AssertExpectations(t)
+	})
+
+	t.Run("Вход отложен сразу после неудачи", func(t *testing.T) {
+		mockRepo, _, loginRepo, service := setup()
+		mockRepo.On("GetByEmail", ctx, user.Email).Return(user, nil).Once()
+		loginRepo.On("RecentFailures", ctx, "test@example.com", 3).Return([]time.Time{time.Now()}, nil).Once()
+		loginRepo.On("Create", mock.Anything, attemptWith(&user.ID, login_attempt_model.OutcomeThrottled)).Return(nil).Once()
+
+		_, err := service.LoginUser(ctx, user_model.LoginRequest{Email: user.Email, Password: "password123"}, client)
+		assert.ErrorIs(t, err, user_service.ErrLoginThrottled)
+		assert.NotErrorIs(t, err, user_service.ErrAccountLocked)
+		loginRepo.AssertExpectations(t)
+	})
+
+	t.Run("Серия неудач блокирует вход даже с верным паролем", func(t *testing.T) {
+		mockRepo, tokenRepo, loginRepo, service := setup()
+		now := time.Now()
+		failures := []time.Time{now.Add(-time.Minute), now.Add(-2 * time.Minute), now.Add(-3 * time.Minute)}
+		mockRepo.On("GetByEmail", ctx, user.Email).Return(user, nil).Once()
+		loginRepo.On("RecentFailures", ctx, "test@example.com", 3).Return(failures, nil).Once()
+		loginRepo.On("Create", mock.Anything, attemptWith(&user.ID, login_attempt_model.OutcomeLocked)).Return(nil).Once()
+
+		_, err := service.LoginUser(ctx, user_model.LoginRequest{Email: user.Email, Password: "password123"}, client)
+		assert.ErrorIs(t, err, user_service.ErrAccountLocked)
+		var blocked *user_service.LoginBlockedError
+		if assert.ErrorAs(t, err, &blocked) {
+			assert.True(t, blocked.Locked)
+			assert.InDelta(t, (9 * time.Minute).Seconds(), blocked.RetryAfter.Seconds(), 5)
+		}
+		tokenRepo.AssertNotCalled(t, "CreateRefreshToken", mock.Anything, mock.Anything)
+		loginRepo.AssertExpectations(t)
+	})
+}
+
+// TestLoginUser_ConcurrentFailuresRespectLockout проверяет, что параллельные попытки входа
+// с неверным паролем не обходят блокировку: пароль проверяется не больше MaxFailures раз
+func TestLoginUser_ConcurrentFailuresRespectLockout(t *testing.T) {
+	ctx := context.Background()
+	policy := login_attempt_model.LockoutPolicy{
+		MaxFailures:     3,
+		Window:          15 * time.Minute,
+		LockoutDuration: 10 * time.Minute,
+	}
+
+	// Файловая база, чтобы параллельные запросы видели записи друг друга
+	dsn := filepath.Join(t.TempDir(), "logins.db") + "?_busy_timeout=10000"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&login_attempt_model.LoginAttempt{}))
+	log := logrus.New()
+	log.SetLevel(logrus.ErrorLevel)
+	loginRepo := login_attempt_rep.NewGormLoginAttemptRepository(db, log)
+
+	hash, err := password_util.HashPassword("password123")
+	require.NoError(t, err)
+	user := &user_model.User{ID: 1, Email: "test@example.com", PasswordHash: hash}
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+	service := user_service.NewUserServiceWithLoginAudit(mockRepo, new(MockTokenRepository), loginRepo, policy, log, "secret", 900, 7200)
+
+	const attempts = 10
+	errs := make(chan error, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.LoginUser(ctx, user_model.LoginRequest{Email: user.Email, Password: "wrong"}, login_attempt_model.ClientInfo{})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	invalid, locked := 0, 0
+	for err := range errs {
+		switch {
+		case errors.Is(err, user_service.ErrInvalidCredentials):
+			invalid++
+		case errors.Is(err, user_service.ErrAccountLocked):
+			locked++
+		default:
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	assert.Equal(t, policy.MaxFailures, invalid)
+	assert.Equal(t, attempts-policy.MaxFailures, locked)
+
+	var failures int64
+	require.NoError(t, db.Model(&login_attempt_model.LoginAttempt{}).
+		Where("outcome = ?", login_attempt_model.OutcomeInvalidPassword).Count(&failures).Error)
+	assert.Equal(t, int64(policy.MaxFailures), failures)
+}
+
+// TestGetLoginHistory тестирует получение истории входов пользователя
+func TestGetLoginHistory(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockUserRepository)
+	loginRepo := new(MockLoginAttemptRepository)
+	service := user_service.NewUserServiceWithLoginAudit(
+		mockRepo, new(MockTokenRepository), loginRepo, login_attempt_model.LockoutPolicy{}, logrus.New(), "secret", 900, 7200)
+
+	t.Run("История существующего пользователя", func(t *testing.T) {
+		attempts := []login_attempt_model.LoginAttempt{{ID: 1, Outcome: login_attempt_model.OutcomeSuccess}}
+		mockRepo.On("GetByID", ctx, uint(1)).Return(&user_model.User{ID: 1}, nil).Once()
+		loginRepo.On("GetByUserID", ctx, uint(1), 1, 10).Return(attempts, int64(1), nil).Once()
+
+		got, total, err := service.GetLoginHistory(ctx, 1, 0, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, attempts, got)
+		assert.Equal(t, int64(1), total)
+	})
+
+	t.Run("Ошибка: пользователь не найден", func(t *testing.T) {
+		mockRepo.On("GetByID", ctx, uint(2)).Return((*user_model.User)(nil), user_rep.ErrUserNotFound).Once()
+
+		_, _, err := service.GetLoginHistory(ctx, 2, 1, 10)
+		assert.ErrorIs(t, err, user_service.ErrUserNotFound)
+	})
+}
+
+// TestRefreshTokens тестирует ротацию refresh токенов
+func TestRefreshTokens(t *testing.T) {
+	ctx := context.Background()
+	user := &user_model.User{ID: 1, Email: "test@example.com"}
+
+	newService := func() (*MockUserRepository, *MockTokenRepository, user_service.UserService) {
+		mockRepo := new(MockUserRepository)
+		tokenRepo := new(MockTokenRepository)
+		return mockRepo, tokenRepo, user_service.NewUserService(mockRepo, tokenRepo, logrus.New(), "secret", 900, 7200)
+	}
+
+	t.Run("Успешная ротация", func(t *testing.T) {
+		mockRepo, tokenRepo, service := newService()
+		stored := &token_model.RefreshToken{ID: 5, UserID: 1, FamilyID: "family", ExpiresAt: time.Now().Add(time.Hour)}
+
+		tokenRepo.On("GetRefreshTokenByHash", ctx, jwt_util.HashToken("old")).Return(stored, nil)
+		tokenRepo.On("MarkRefreshTokenUsed", ctx, uint(5)).Return(nil)
+		mockRepo.On("GetByID", ctx, uint(1)).Return(user, nil)
+		tokenRepo.On("CreateRefreshToken", ctx, mock.MatchedBy(func(token *token_model.RefreshToken) bool {
+			return token.FamilyID == "family" && token.TokenHash != jwt_util.HashToken("old")
+		})).Return(nil)
+
+		tokens, err := service.RefreshTokens(ctx, "old")
+		assert.NoError(t, err)
+		assert.NotEqual(t, "old", tokens.RefreshToken)
+		tokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("Ошибка: неизвестный токен", func(t *testing.T) {
+		_, tokenRepo, service := newService()
+		tokenRepo.On("GetRefreshTokenByHash", ctx, jwt_util.HashToken("unknown")).Return(nil, token_rep.ErrTokenNotFound)
+
+		_, err := service.RefreshTokens(ctx, "unknown")
+		assert.ErrorIs(t, err, user_service.ErrInvalidRefreshToken)
+	})
+
+	t.Run("Ошибка: просроченный токен", func(t *testing.T) {
+		_, tokenRepo, service := newService()
+		stored := &token_model.RefreshToken{ID: 5, UserID: 1, FamilyID: "family", ExpiresAt: time.Now().Add(-time.Minute)}
+		tokenRepo.On("GetRefreshTokenByHash", ctx, jwt_util.HashToken("expired")).Return(stored, nil)
+
+		_, err := service.RefreshTokens(ctx, "expired")
+		assert.ErrorIs(t, err, user_service.ErrInvalidRefreshToken)
+	})
+
+	t.Run("Повторное использование отзывает семейство", func(t *testing.T) {
+		_, tokenRepo, service := newService()
+		usedAt := time.Now().Add(-time.Minute)
+		stored := &token_model.RefreshToken{ID: 5, UserID: 1, FamilyID: "family", ExpiresAt: time.Now().Add(time.Hour), UsedAt: &usedAt}
+		tokenRepo.On("GetRefreshTokenByHash", ctx, jwt_util.HashToken("reused")).Return(stored, nil)
+		tokenRepo.On("RevokeFamily", ctx, "family").Return(nil)
+
+		_, err := service.RefreshTokens(ctx, "reused")
+		assert.ErrorIs(t, err, user_service.ErrRefreshTokenReused)
+		tokenRepo.AssertCalled(t, "RevokeFamily", ctx, "family")
+	})
+
+	t.Run("Параллельный обмен того же токена отзывает семейство", func(t *testing.T) {
+		_, tokenRepo, service := newService()
+		stored := &token_model.RefreshToken{ID: 5, UserID: 1, FamilyID: "family", ExpiresAt: time.Now().Add(time.Hour)}
+		tokenRepo.On("GetRefreshTokenByHash", ctx, jwt_util.HashToken("raced")).Return(stored, nil)
+		tokenRepo.On("MarkRefreshTokenUsed", ctx, uint(5)).Return(token_rep.ErrTokenAlreadyUsed)
+		tokenRepo.On("RevokeFamily", ctx, "family").Return(nil)
+
+		_, err := service.RefreshTokens(ctx, "raced")
+		assert.ErrorIs(t, err, user_service.ErrRefreshTokenReused)
+	})
+}
+
+// TestLogoutUser тестирует завершение сессии
+func TestLogoutUser(t *testing.T) {
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Minute)
+
+	t.Run("Успешный выход", func(t *testing.T) {
+		tokenRepo := new(MockTokenRepository)
+		service := user_service.NewUserService(new(MockUserRepository), tokenRepo, logrus.New(), "secret", 900, 7200)
+		stored := &token_model.RefreshToken{ID: 5, UserID: 1, FamilyID: "family"}
+
+		tokenRepo.On("GetRefreshTokenByHash", ctx, jwt_util.HashToken("refresh")).Return(stored, nil)
+		tokenRepo.On("RevokeFamily", ctx, "family").Return(nil)
+		tokenRepo.On("RevokeAccessToken", ctx, &token_model.RevokedAccessToken{JTI: "jti", UserID: 1, ExpiresAt: expiresAt}).Return(nil)
+		tokenRepo.On("DeleteExpired", ctx, mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+		err := service.LogoutUser(ctx, 1, "refresh", "jti", expiresAt)
+		assert.NoError(t, err)
+		tokenRepo.AssertExpectations(t)
+
+		// Повторный выход в пределах интервала очистки не удаляет токены снова
+		err = service.LogoutUser(ctx, 1, "refresh", "jti", expiresAt)
+		assert.NoError(t, err)
+		tokenRepo.AssertNumberOfCalls(t, "DeleteExpired", 1)
+	})
+
+	t.Run("Ошибка очистки не прерывает выход", func(t *testing.T) {
+		tokenRepo := new(MockTokenRepository)
+		service := user_service.NewUserService(new(MockUserRepository), tokenRepo, logrus.New(), "secret", 900, 7200)
+
+		tokenRepo.On("GetRefreshTokenByHash", ctx, jwt_util.HashToken("refresh")).Return((*token_model.RefreshToken)(nil), token_rep.ErrTokenNotFound)
+		tokenRepo.On("RevokeAccessToken", ctx, mock.Anything).Return(nil)
+		tokenRepo.On("DeleteExpired", ctx, mock.AnythingOfType("time.Time")).Return(token_rep.ErrDatabaseError)
+
+		err := service.LogoutUser(ctx, 1, "refresh", "jti", expiresAt)
+		assert.NoError(t, err)
+		tokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("Ошибка: чужой refresh токен", func(t *testing.T) {
+		tokenRepo := new(MockTokenRepository)
+		service := user_service.NewUserService(new(MockUserRepository), tokenRepo, logrus.New(), "secret", 900, 7200)
+		stored := &token_model.RefreshToken{ID: 5, UserID: 2, FamilyID: "family"}
+
+		tokenRepo.On("GetRefreshTokenByHash", ctx, jwt_util.HashToken("refresh")).Return(stored, nil)
+
+		err := service.LogoutUser(ctx, 1, "refresh", "jti", expiresAt)
+		assert.ErrorIs(t, err, user_service.ErrInvalidRefreshToken)
+		tokenRepo.AssertNotCalled(t, "RevokeFamily", ctx, "family")
+	})
+}
@@ -3,9 +3,16 @@ package config_util
 import (
 	"errors"
 	"fmt"
+	"net"
 	"os"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/IlyushinDM/user-order-api/internal/models/login_attempt_model"
+	"github.com/IlyushinDM/user-order-api/internal/models/rate_limit_model"
+	"github.com/IlyushinDM/user-order-api/internal/utils/i18n_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/tracing_util"
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/sirupsen/logrus"
 )
@@ -17,19 +24,27 @@ type Config struct {
 	Port    string `env:"PORT" env-default:"8080"`
 
 	// Настройки базы данных
-	DBHost            string        `env:"DB_HOST" env-required:"true"`
-	DBPort            string        `env:"DB_PORT" env-required:"true"`
-	DBUser            string        `env:"DB_USER" env-required:"true"`
-	DBName            string        `env:"DB_NAME" env-required:"true"`
-	DBPassword        string        `env:"DB_PASSWORD" env-required:"true"`
+	DBDriver string `env:"DB_DRIVER" env-default:"postgres"` // postgres или sqlite
+	// Путь к файлу SQLite или ":memory:" для базы в памяти процесса; используется только при DB_DRIVER=sqlite
+	DBPath string `env:"DB_PATH" env-default:"user-order-api.db"`
+	// Параметры подключения к PostgreSQL; обязательны при DB_DRIVER=postgres
+	DBHost            string        `env:"DB_HOST"`
+	DBPort            string        `env:"DB_PORT"`
+	DBUser            string        `env:"DB_USER"`
+	DBName            string        `env:"DB_NAME"`
+	DBPassword        string        `env:"DB_PASSWORD"`
 	DBMaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS" env-default:"10"`
 	DBMaxOpenConns    int           `env:"DB_MAX_OPEN_CONNS" env-default:"100"`
 	DBConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME" env-default:"1h"`
 	DBConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME" env-default:"30m"`
+	// Применять миграции при старте; если выключено, приложение только проверяет версию схемы
+	DBMigrateOnStart bool `env:"DB_MIGRATE_ON_START" env-default:"true"`
 
 	// Настройки JWT
 	JWTSecret     string        `env:"JWT_SECRET" env-required:"true"`
-	JWTExpiration time.Duration `env:"JWT_EXPIRATION" env-required:"true"` // парсинг "1h" в time.Duration
+	JWTExpiration time.Duration `env:"JWT_EXPIRATION" env-required:"true"` // время жизни access токена, парсинг "15m" в time.Duration
+	// Время жизни refresh токена
+	JWTRefreshExpiration time.Duration `env:"JWT_REFRESH_EXPIRATION" env-default:"720h"`
 
 	// Настройки HTTP сервера
 	ReadTimeout    int `env:"HTTP_READ_TIMEOUT" env-default:"5"`
@@ -39,6 +54,136 @@ type Config struct {
 
 	// Таймаут для graceful shutdown
 	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" env-default:"15s"`
+	// Пауза между переводом /readyz в неуспешное состояние и остановкой сервера:
+	// за это время балансировщик перестает направлять новые запросы
+	ShutdownDrainDelay time.Duration `env:"SHUTDOWN_DRAIN_DELAY" env-default:"5s"`
+
+	// Ограничение времени каждой проверки готовности (/readyz)
+	ReadinessTimeout time.Duration `env:"READINESS_TIMEOUT" env-default:"2s"`
+
+	// Настройки идемпотентности запросов
+	IdempotencyTTL   time.Duration `env:"IDEMPOTENCY_TTL" env-default:"24h"`        // время хранения ответа по ключу
+	IdempotencyLease time.Duration `env:"IDEMPOTENCY_LEASE" env-default:"1m"`       // время захвата ключа выполняющимся запросом
+	IdempotencyStore string        `env:"IDEMPOTENCY_STORE" env-default:"database"` // memory или database
+
+	// Язык сообщений API, если клиент не указал поддерживаемый язык в Accept-Language: ru или en
+	DefaultLanguage string `env:"DEFAULT_LANGUAGE" env-default:"ru"`
+
+	// Настройки трассировки OpenTelemetry
+	TracingExporter     string  `env:"TRACING_EXPORTER" env-default:"none"`                       // none, otlp, stdout или file
+	TracingOTLPEndpoint string  `env:"TRACING_OTLP_ENDPOINT" env-default:"http://localhost:4318"` // URL приемника OTLP/HTTP
+	TracingFilePath     string  `env:"TRACING_FILE_PATH" env-default:"traces.jsonl"`              // файл для экспортера file
+	TracingSampleRatio  float64 `env:"TRACING_SAMPLE_RATIO" env-default:"1"`                      // доля записываемых новых трасс
+
+	// Ограничение частоты запросов в формате "запросов/период": по IP для публичных маршрутов,
+	// по пользователю из JWT для защищенных
+	RateLimitEnabled  bool   `env:"RATE_LIMIT_ENABLED" env-default:"true"`
+	RateLimitLogin    string `env:"RATE_LIMIT_LOGIN" env-default:"5/1m"`     // POST /auth/login
+	RateLimitRegister string `env:"RATE_LIMIT_REGISTER" env-default:"10/1h"` // POST /api/users
+	RateLimitRefresh  string `env:"RATE_LIMIT_REFRESH" env-default:"30/1m"`  // POST /auth/refresh
+	RateLimitAPI      string `env:"RATE_LIMIT_API" env-default:"300/1m"`     // защищенные маршруты /api и /auth/logout
+	// Адреса или подсети прокси, которым доверяется X-Forwarded-For при определении IP клиента.
+	// Если не заданы, используется адрес соединения.
+	TrustedProxies []string `env:"TRUSTED_PROXIES" env-separator:","`
+
+	// Защита входа от подбора пароля: неудачные попытки считаются по email.
+	// После каждой неудачи вход задерживается на LOGIN_FAILURE_DELAY, удваиваемую с каждой неудачей,
+	// а LOGIN_MAX_FAILURES неудач за LOGIN_FAILURE_WINDOW блокируют вход на LOGIN_LOCKOUT_DURATION.
+	LoginMaxFailures     int           `env:"LOGIN_MAX_FAILURES" env-default:"5"` // 0 отключает задержку и блокировку
+	LoginFailureWindow   time.Duration `env:"LOGIN_FAILURE_WINDOW" env-default:"15m"`
+	LoginLockoutDuration time.Duration `env:"LOGIN_LOCKOUT_DURATION" env-default:"15m"`
+	LoginFailureDelay    time.Duration `env:"LOGIN_FAILURE_DELAY" env-default:"1s"` // 0 отключает задержку
+}
+
+// LoginLockoutPolicy возвращает политику задержки и блокировки входа из настроек LOGIN_*
+func (c *Config) LoginLockoutPolicy() login_attempt_model.LockoutPolicy {
+	return login_attempt_model.LockoutPolicy{
+		MaxFailures:     c.LoginMaxFailures,
+		Window:          c.LoginFailureWindow,
+		LockoutDuration: c.LoginLockoutDuration,
+		BaseDelay:       c.LoginFailureDelay,
+	}
+}
+
+// validateLoginLockout проверяет настройки блокировки входа
+func validateLoginLockout(cfg *Config) error {
+	if cfg.LoginMaxFailures < 0 {
+		return fmt.Errorf("недопустимое значение LOGIN_MAX_FAILURES: %d, ожидается неотрицательное целое", cfg.LoginMaxFailures)
+	}
+	if cfg.LoginFailureDelay < 0 {
+		return fmt.Errorf("недопустимое значение LOGIN_FAILURE_DELAY: %s, ожидается неотрицательная длительность", cfg.LoginFailureDelay)
+	}
+	if cfg.LoginMaxFailures == 0 {
+		return nil
+	}
+	if cfg.LoginFailureWindow <= 0 {
+		return fmt.Errorf("недопустимое значение LOGIN_FAILURE_WINDOW: %s, ожидается положительная длительность", cfg.LoginFailureWindow)
+	}
+	if cfg.LoginLockoutDuration <= 0 {
+		return fmt.Errorf("недопустимое значение LOGIN_LOCKOUT_DURATION: %s, ожидается положительная длительность", cfg.LoginLockoutDuration)
+	}
+	return nil
+}
+
+// validateIdempotency проверяет сроки хранения ответов и захвата ключей идемпотентности
+func validateIdempotency(cfg *Config) error {
+	if cfg.IdempotencyTTL <= 0 {
+		return fmt.Errorf("недопустимое значение IDEMPOTENCY_TTL: %s, ожидается положительная длительность", cfg.IdempotencyTTL)
+	}
+	if cfg.IdempotencyLease <= 0 {
+		return fmt.Errorf("недопустимое значение IDEMPOTENCY_LEASE: %s, ожидается положительная длительность", cfg.IdempotencyLease)
+	}
+	return nil
+}
+
+// Поддерживаемые значения DB_DRIVER
+const (
+	DBDriverPostgres = "postgres"
+	DBDriverSQLite   = "sqlite"
+)
+
+// validateDBConfig проверяет драйвер базы данных и обязательные для него параметры
+func validateDBConfig(cfg *Config) error {
+	switch cfg.DBDriver {
+	case DBDriverPostgres:
+		var missing []string
+		for name, value := range map[string]string{
+			"DB_HOST": cfg.DBHost, "DB_PORT": cfg.DBPort, "DB_USER": cfg.DBUser,
+			"DB_NAME": cfg.DBName, "DB_PASSWORD": cfg.DBPassword,
+		} {
+			if value == "" {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			sort.Strings(missing)
+			return fmt.Errorf("для DB_DRIVER=postgres не заданы переменные: %s", strings.Join(missing, ", "))
+		}
+	case DBDriverSQLite:
+		if cfg.DBPath == "" {
+			return errors.New("для DB_DRIVER=sqlite не задан DB_PATH")
+		}
+	default:
+		return fmt.Errorf("неизвестный драйвер базы данных DB_DRIVER=%q", cfg.DBDriver)
+	}
+	return nil
+}
+
+// validateTrustedProxies проверяет, что каждый доверенный прокси - IP адрес или подсеть CIDR
+func validateTrustedProxies(proxies []string) error {
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(proxy); err == nil {
+			continue
+		}
+		if net.ParseIP(proxy) == nil {
+			return fmt.Errorf("недопустимое значение TRUSTED_PROXIES: %q не является IP адресом или подсетью CIDR", proxy)
+		}
+	}
+	return nil
 }
 
 // LoadConfig загружает конфигурацию приложения
@@ -65,19 +210,77 @@ func LoadConfig(log *logrus.Logger) (*Config, error) {
 		}
 	}
 
+	if err := validateDBConfig(&cfg); err != nil {
+		log.WithError(err).Error("Критическая ошибка в настройках базы данных")
+		return nil, err
+	}
+
+	if _, err := i18n_util.ParseLang(cfg.DefaultLanguage); err != nil {
+		log.WithError(err).Error("Критическая ошибка в настройке DEFAULT_LANGUAGE")
+		return nil, fmt.Errorf("недопустимое значение DEFAULT_LANGUAGE: %w", err)
+	}
+
+	if err := tracing_util.ValidateExporter(cfg.TracingExporter); err != nil {
+		log.WithError(err).Error("Критическая ошибка в настройке TRACING_EXPORTER")
+		return nil, fmt.Errorf("недопустимое значение TRACING_EXPORTER: %w", err)
+	}
+	if cfg.TracingSampleRatio < 0 || cfg.TracingSampleRatio > 1 {
+		log.Errorf("Критическая ошибка в настройке TRACING_SAMPLE_RATIO: %v", cfg.TracingSampleRatio)
+		return nil, fmt.Errorf("недопустимое значение TRACING_SAMPLE_RATIO: %v, ожидается число от 0 до 1", cfg.TracingSampleRatio)
+	}
+
+	for _, limit := range []struct{ name, value string }{
+		{"RATE_LIMIT_LOGIN", cfg.RateLimitLogin},
+		{"RATE_LIMIT_REGISTER", cfg.RateLimitRegister},
+		{"RATE_LIMIT_REFRESH", cfg.RateLimitRefresh},
+		{"RATE_LIMIT_API", cfg.RateLimitAPI},
+	} {
+		if _, err := rate_limit_model.ParseLimit(limit.value); err != nil {
+			log.WithError(err).Errorf("Критическая ошибка в настройке %s", limit.name)
+			return nil, fmt.Errorf("недопустимое значение %s: %w", limit.name, err)
+		}
+	}
+	if err := validateTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.WithError(err).Error("Критическая ошибка в настройке TRUSTED_PROXIES")
+		return nil, err
+	}
+	if err := validateLoginLockout(&cfg); err != nil {
+		log.WithError(err).Error("Критическая ошибка в настройках блокировки входа")
+		return nil, err
+	}
+	if err := validateIdempotency(&cfg); err != nil {
+		log.WithError(err).Error("Критическая ошибка в настройках идемпотентности")
+		return nil, err
+	}
+
 	// Если мы дошли сюда без возврата ошибки, значит, конфигурация успешно загружена
 	// либо из .env + env, либо только из env
 	log.Info("Конфигурация успешно загружена")
 
 	// Логирование загруженных значений для отладки
 	log.Debugf("APP_ENV: %s", cfg.AppEnv)
-	log.Infof("DB_HOST: %s, DB_PORT: %s, DB_NAME: %s", cfg.DBHost, cfg.DBPort, cfg.DBName)
+	if cfg.DBDriver == DBDriverSQLite {
+		log.Infof("DB_DRIVER: %s, DB_PATH: %s", cfg.DBDriver, cfg.DBPath)
+	} else {
+		log.Infof("DB_DRIVER: %s, DB_HOST: %s, DB_PORT: %s, DB_NAME: %s", cfg.DBDriver, cfg.DBHost, cfg.DBPort, cfg.DBName)
+	}
 	log.Debugf("DB_MAX_IDLE_CONNS: %d, DB_MAX_OPEN_CONNS: %d", cfg.DBMaxIdleConns, cfg.DBMaxOpenConns)
 	log.Debugf("DB_CONN_MAX_LIFETIME: %s, DB_CONN_MAX_IDLE_TIME: %s", cfg.DBConnMaxLifetime, cfg.DBConnMaxIdleTime)
-	log.Debugf("JWT_EXPIRATION: %s", cfg.JWTExpiration)
+	log.Debugf("DB_MIGRATE_ON_START: %t", cfg.DBMigrateOnStart)
+	log.Debugf("JWT_EXPIRATION: %s, JWT_REFRESH_EXPIRATION: %s", cfg.JWTExpiration, cfg.JWTRefreshExpiration)
 	log.Debugf("HTTP_READ_TIMEOUT: %d, HTTP_WRITE_TIMEOUT: %d, HTTP_IDLE_TIMEOUT: %d, HTTP_MAX_HEADER_BYTES: %d",
 		cfg.ReadTimeout, cfg.WriteTimeout, cfg.IdleTimeout, cfg.MaxHeaderBytes)
-	log.Debugf("SHUTDOWN_TIMEOUT: %s", cfg.ShutdownTimeout)
+	log.Debugf("SHUTDOWN_TIMEOUT: %s, SHUTDOWN_DRAIN_DELAY: %s", cfg.ShutdownTimeout, cfg.ShutdownDrainDelay)
+	log.Debugf("READINESS_TIMEOUT: %s", cfg.ReadinessTimeout)
+	log.Debugf("IDEMPOTENCY_TTL: %s, IDEMPOTENCY_LEASE: %s, IDEMPOTENCY_STORE: %s",
+		cfg.IdempotencyTTL, cfg.IdempotencyLease, cfg.IdempotencyStore)
+	log.Debugf("DEFAULT_LANGUAGE: %s", cfg.DefaultLanguage)
+	log.Debugf("TRACING_EXPORTER: %s, TRACING_SAMPLE_RATIO: %v", cfg.TracingExporter, cfg.TracingSampleRatio)
+	log.Debugf("RATE_LIMIT_ENABLED: %t, RATE_LIMIT_LOGIN: %s, RATE_LIMIT_REGISTER: %s, RATE_LIMIT_REFRESH: %s, RATE_LIMIT_API: %s",
+		cfg.RateLimitEnabled, cfg.RateLimitLogin, cfg.RateLimitRegister, cfg.RateLimitRefresh, cfg.RateLimitAPI)
+	log.Debugf("TRUSTED_PROXIES: %v", cfg.TrustedProxies)
+	log.Debugf("LOGIN_MAX_FAILURES: %d, LOGIN_FAILURE_WINDOW: %s, LOGIN_LOCKOUT_DURATION: %s, LOGIN_FAILURE_DELAY: %s",
+		cfg.LoginMaxFailures, cfg.LoginFailureWindow, cfg.LoginLockoutDuration, cfg.LoginFailureDelay)
 
 	return &cfg, nil
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/IlyushinDM/user-order-api/internal/models/login_attempt_model"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
@@ -57,11 +58,33 @@ func TestLoadConfig_SuccessFromEnv(t *testing.T) {
 	assert.Equal(t, 100, cfg.DBMaxOpenConns)
 	assert.Equal(t, time.Hour, cfg.DBConnMaxLifetime)
 	assert.Equal(t, 30*time.Minute, cfg.DBConnMaxIdleTime)
+	assert.Equal(t, DBDriverPostgres, cfg.DBDriver)
+	assert.True(t, cfg.DBMigrateOnStart)
 	assert.Equal(t, 5, cfg.ReadTimeout)
 	assert.Equal(t, 10, cfg.WriteTimeout)
 	assert.Equal(t, 60, cfg.IdleTimeout)
 	assert.Equal(t, 1048576, cfg.MaxHeaderBytes)
 	assert.Equal(t, 15*time.Second, cfg.ShutdownTimeout)
+	assert.Equal(t, 5*time.Second, cfg.ShutdownDrainDelay)
+	assert.Equal(t, 2*time.Second, cfg.ReadinessTimeout)
+	assert.Equal(t, 24*time.Hour, cfg.IdempotencyTTL)
+	assert.Equal(t, time.Minute, cfg.IdempotencyLease)
+	assert.Equal(t, "ru", cfg.DefaultLanguage)
+	assert.Equal(t, "none", cfg.TracingExporter)
+	assert.Equal(t, "http://localhost:4318", cfg.TracingOTLPEndpoint)
+	assert.Equal(t, 1.0, cfg.TracingSampleRatio)
+	assert.True(t, cfg.RateLimitEnabled)
+	assert.Equal(t, "5/1m", cfg.RateLimitLogin)
+	assert.Equal(t, "10/1h", cfg.RateLimitRegister)
+	assert.Equal(t, "30/1m", cfg.RateLimitRefresh)
+	assert.Equal(t, "300/1m", cfg.RateLimitAPI)
+	assert.Empty(t, cfg.TrustedProxies)
+	assert.Equal(t, login_attempt_model.LockoutPolicy{
+		MaxFailures:     5,
+		Window:          15 * time.Minute,
+		LockoutDuration: 15 * time.Minute,
+		BaseDelay:       time.Second,
+	}, cfg.LoginLockoutPolicy())
 }
 
 func TestLoadConfig_MissingRequiredEnv(t *testing.T) {
@@ -125,3 +148,159 @@ func TestLoadConfig_OverridesDefaults(t *testing.T) {
 	assert.Equal(t, 2048, cfg.MaxHeaderBytes)
 	assert.Equal(t, 30*time.Second, cfg.ShutdownTimeout)
 }
+
+func TestLoadConfig_SQLiteWithoutPostgresSettings(t *testing.T) {
+	cleanup := setRequiredEnv()
+	defer cleanup()
+	for _, k := range []string{"DB_HOST", "DB_PORT", "DB_USER", "DB_NAME", "DB_PASSWORD"} {
+		os.Unsetenv(k)
+	}
+	os.Setenv("DB_DRIVER", "sqlite")
+	os.Setenv("DB_PATH", ":memory:")
+	defer os.Unsetenv("DB_DRIVER")
+	defer os.Unsetenv("DB_PATH")
+
+	log := logrus.New()
+	log.SetLevel(logrus.FatalLevel)
+
+	cfg, err := LoadConfig(log)
+	assert.NoError(t, err)
+	assert.Equal(t, DBDriverSQLite, cfg.DBDriver)
+	assert.Equal(t, ":memory:", cfg.DBPath)
+}
+
+func TestLoadConfig_PostgresRequiresConnectionSettings(t *testing.T) {
+	cleanup := setRequiredEnv()
+	defer cleanup()
+	os.Unsetenv("DB_HOST")
+	os.Unsetenv("DB_PASSWORD")
+
+	log := logrus.New()
+	log.SetLevel(logrus.FatalLevel)
+
+	cfg, err := LoadConfig(log)
+	assert.Nil(t, cfg)
+	assert.ErrorContains(t, err, "DB_HOST, DB_PASSWORD")
+}
+
+func TestLoadConfig_UnknownDriver(t *testing.T) {
+	cleanup := setRequiredEnv()
+	defer cleanup()
+	os.Setenv("DB_DRIVER", "mysql")
+	defer os.Unsetenv("DB_DRIVER")
+
+	log := logrus.New()
+	log.SetLevel(logrus.FatalLevel)
+
+	cfg, err := LoadConfig(log)
+	assert.Nil(t, cfg)
+	assert.ErrorContains(t, err, "mysql")
+}
+
+func TestLoadConfig_UnsupportedLanguage(t *testing.T) {
+	cleanup := setRequiredEnv()
+	defer cleanup()
+	os.Setenv("DEFAULT_LANGUAGE", "de")
+	defer os.Unsetenv("DEFAULT_LANGUAGE")
+
+	log := logrus.New()
+	log.SetLevel(logrus.FatalLevel)
+
+	cfg, err := LoadConfig(log)
+	assert.Nil(t, cfg)
+	assert.ErrorContains(t, err, "DEFAULT_LANGUAGE")
+}
+
+func TestLoadConfig_InvalidTracingSettings(t *testing.T) {
+	cleanup := setRequiredEnv()
+	defer cleanup()
+
+	log := logrus.New()
+	log.SetLevel(logrus.FatalLevel)
+
+	os.Setenv("TRACING_EXPORTER", "zipkin")
+	cfg, err := LoadConfig(log)
+	os.Unsetenv("TRACING_EXPORTER")
+	assert.Nil(t, cfg)
+	assert.ErrorContains(t, err, "TRACING_EXPORTER")
+
+	os.Setenv("TRACING_SAMPLE_RATIO", "1.5")
+	defer os.Unsetenv("TRACING_SAMPLE_RATIO")
+	cfg, err = LoadConfig(log)
+	assert.Nil(t, cfg)
+	assert.ErrorContains(t, err, "TRACING_SAMPLE_RATIO")
+}
+
+func TestLoadConfig_InvalidRateLimitSettings(t *testing.T) {
+	cleanup := setRequiredEnv()
+	defer cleanup()
+
+	log := logrus.New()
+	log.SetLevel(logrus.FatalLevel)
+
+	os.Setenv("RATE_LIMIT_LOGIN", "5 per minute")
+	cfg, err := LoadConfig(log)
+	os.Unsetenv("RATE_LIMIT_LOGIN")
+	assert.Nil(t, cfg)
+	assert.ErrorContains(t, err, "RATE_LIMIT_LOGIN")
+
+	os.Setenv("TRUSTED_PROXIES", "10.0.0.0/8,proxy.local")
+	defer os.Unsetenv("TRUSTED_PROXIES")
+	cfg, err = LoadConfig(log)
+	assert.Nil(t, cfg)
+	assert.ErrorContains(t, err, "TRUSTED_PROXIES")
+
+	os.Setenv("TRUSTED_PROXIES", "10.0.0.0/8,192.168.1.10")
+	cfg, err = LoadConfig(log)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/8", "192.168.1.10"}, cfg.TrustedProxies)
+}
+
+func TestLoadConfig_InvalidLoginLockoutSettings(t *testing.T) {
+	cleanup := setRequiredEnv()
+	defer cleanup()
+
+	log := logrus.New()
+	log.SetLevel(logrus.FatalLevel)
+
+	for name, value := range map[string]string{
+		"LOGIN_MAX_FAILURES":     "-1",
+		"LOGIN_FAILURE_WINDOW":   "0s",
+		"LOGIN_LOCKOUT_DURATION": "0s",
+		"LOGIN_FAILURE_DELAY":    "-1s",
+	} {
+		os.Setenv(name, value)
+		cfg, err := LoadConfig(log)
+		os.Unsetenv(name)
+		assert.Nil(t, cfg, name)
+		assert.ErrorContains(t, err, name)
+	}
+
+	// Нулевой LOGIN_MAX_FAILURES отключает блокировку, и ее остальные настройки не проверяются
+	os.Setenv("LOGIN_MAX_FAILURES", "0")
+	os.Setenv("LOGIN_LOCKOUT_DURATION", "0s")
+	defer os.Unsetenv("LOGIN_MAX_FAILURES")
+	defer os.Unsetenv("LOGIN_LOCKOUT_DURATION")
+	cfg, err := LoadConfig(log)
+	assert.NoError(t, err)
+	assert.False(t, cfg.LoginLockoutPolicy().Enabled())
+}
+
+func TestLoadConfig_InvalidIdempotencySettings(t *testing.T) {
+	cleanup := setRequiredEnv()
+	defer cleanup()
+
+	log := logrus.New()
+	log.SetLevel(logrus.FatalLevel)
+
+	for name, value := range map[string]string{
+		"IDEMPOTENCY_TTL":   "0s",
+		"IDEMPOTENCY_LEASE": "-1s",
+	} {
+		os.Setenv(name, value)
+		cfg, err := LoadConfig(log)
+		os.Unsetenv(name)
+		assert.Nil(t, cfg, name)
+		assert.ErrorContains(t, err, name)
+	}
+}
@@ -0,0 +1,113 @@
+// Package i18n_util содержит каталог сообщений API на поддерживаемых языках
+// и выбор языка ответа по заголовку Accept-Language.
+package i18n_util
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Lang - код языка сообщений API
+type Lang string
+
+// Поддерживаемые языки. Русский - исходный язык сообщений сервисов и язык по умолчанию.
+const (
+	Ru Lang = "ru"
+	En Lang = "en"
+
+	Default = Ru
+)
+
+// ErrUnsupportedLang возвращается для языка, которого нет в каталоге
+var ErrUnsupportedLang = errors.New("язык не поддерживается")
+
+// contextKey - ключ выбранного языка в контексте gin
+const contextKey = "lang"
+
+// Message - ключ сообщения в каталоге
+type Message string
+
+// ParseLang проверяет код языка из конфигурации; пустое значение означает язык по умолчанию
+func ParseLang(value string) (Lang, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return Default, nil
+	}
+	lang := Lang(value)
+	if !lang.supported() {
+		return "", fmt.Errorf("%w: %q (доступны ru, en)", ErrUnsupportedLang, value)
+	}
+	return lang, nil
+}
+
+func (l Lang) supported() bool {
+	return l == Ru || l == En
+}
+
+// Negotiate выбирает язык ответа по значению заголовка Accept-Language.
+// Языки перебираются по убыванию веса q, региональный вариант (en-US) соответствует базовому языку (en).
+// Если ни один язык из заголовка не поддерживается, возвращается fallback.
+func Negotiate(acceptLanguage string, fallback Lang) Lang {
+	type candidate struct {
+		lang Lang
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if lang := Lang(base); q > 0 && lang.supported() {
+			candidates = append(candidates, candidate{lang: lang, q: q})
+		}
+	}
+	if len(candidates) == 0 {
+		return fallback
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].lang
+}
+
+// T возвращает сообщение key на языке lang, подставляя args по правилам fmt.Sprintf.
+// Если перевода нет, используется русский текст, если нет и его - сам ключ.
+func T(lang Lang, key Message, args ...any) string {
+	translations, ok := catalog[key]
+	if !ok {
+		return string(key)
+	}
+	text, ok := translations[lang]
+	if !ok {
+		text = translations[Default]
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// SetLang сохраняет язык ответа в контексте запроса
+func SetLang(c *gin.Context, lang Lang) {
+	c.Set(contextKey, lang)
+}
+
+// FromContext возвращает язык ответа, выбранный для запроса, или язык по умолчанию
+func FromContext(c *gin.Context) Lang {
+	if value, ok := c.Get(contextKey); ok {
+		if lang, ok := value.(Lang); ok {
+			return lang
+		}
+	}
+	return Default
+}
@@ -0,0 +1,55 @@
+package i18n_util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogIsComplete(t *testing.T) {
+	for key, translations := range catalog {
+		for _, lang := range []Lang{Ru, En} {
+			assert.NotEmpty(t, translations[lang], "нет перевода %s для %s", key, lang)
+		}
+	}
+}
+
+func TestParseLang(t *testing.T) {
+	lang, err := ParseLang("")
+	require.NoError(t, err)
+	assert.Equal(t, Default, lang)
+
+	lang, err = ParseLang(" EN ")
+	require.NoError(t, err)
+	assert.Equal(t, En, lang)
+
+	_, err = ParseLang("de")
+	assert.ErrorIs(t, err, ErrUnsupportedLang)
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   Lang
+	}{
+		{"", Ru},
+		{"en", En},
+		{"EN-gb", En},
+		{"de, en;q=0.5, ru;q=0.7", Ru},
+		{"ru;q=0, en;q=0.1", En},
+		{"en;q=abc", Ru},
+		{"*", Ru},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Negotiate(tt.header, Ru), "Accept-Language: %q", tt.header)
+	}
+	assert.Equal(t, En, Negotiate("fr", En))
+}
+
+func TestT(t *testing.T) {
+	assert.Equal(t, "Unknown order status: lost", T(En, MsgUnknownOrderStatus, "lost"))
+	assert.Equal(t, "Неизвестное состояние заказа: lost", T(Ru, MsgUnknownOrderStatus, "lost"))
+	assert.Equal(t, "Некорректный формат ID заказа", T("de", MsgInvalidOrderID))
+	assert.Equal(t, "missing.key", T(En, "missing.key"))
+}
@@ -0,0 +1,119 @@
+package i18n_util
+
+// Ключи сообщений, которые обработчики и middleware передают клиенту
+const (
+	MsgAuthHeaderRequired    Message = "auth.header_required"
+	MsgAuthHeaderFormat      Message = "auth.header_format"
+	MsgInsufficientRights    Message = "access.insufficient_rights"
+	MsgInvalidUserID         Message = "request.invalid_user_id"
+	MsgInvalidOrderID        Message = "request.invalid_order_id"
+	MsgInvalidProductID      Message = "request.invalid_product_id"
+	MsgUnknownOrderStatus    Message = "request.unknown_order_status"
+	MsgInvalidPage           Message = "request.invalid_page"
+	MsgInvalidLimit          Message = "request.invalid_limit"
+	MsgInvalidMinAge         Message = "request.invalid_min_age"
+	MsgInvalidMaxAge         Message = "request.invalid_max_age"
+	MsgInvalidIfMatchValue   Message = "request.invalid_if_match_value"
+	MsgMalformedBody         Message = "request.malformed_body"
+	MsgInvalidFieldType      Message = "request.invalid_field_type"
+	MsgInvalidAmount         Message = "request.invalid_amount"
+	MsgInvalidRequest        Message = "request.invalid"
+	MsgBodyUnreadable        Message = "request.body_unreadable"
+	MsgIdempotencyKeyTooLong Message = "idempotency.key_too_long"
+	MsgRateLimitExceeded     Message = "rate_limit.exceeded"
+)
+
+// Ключи сообщений валидатора; аргументы - имя поля и параметр правила
+const (
+	MsgValidationRequired Message = "validation.required"
+	MsgValidationEmail    Message = "validation.email"
+	MsgValidationGT       Message = "validation.gt"
+	MsgValidationGTE      Message = "validation.gte"
+	MsgValidationLT       Message = "validation.lt"
+	MsgValidationLTE      Message = "validation.lte"
+	MsgValidationMinLen   Message = "validation.min_len"
+	MsgValidationMaxLen   Message = "validation.max_len"
+	MsgValidationMinItems Message = "validation.min_items"
+	MsgValidationMaxItems Message = "validation.max_items"
+	MsgValidationOneOf    Message = "validation.oneof"
+	MsgValidationISO4217  Message = "validation.iso4217"
+	MsgValidationName     Message = "validation.name"
+	MsgValidationAge      Message = "validation.age"
+	MsgValidationInvalid  Message = "validation.invalid"
+)
+
+// ProblemTitle возвращает ключ заголовка ошибки API с кодом code
+func ProblemTitle(code string) Message {
+	return Message("problem." + code)
+}
+
+// catalog содержит переводы всех сообщений. Каждое сообщение обязано иметь перевод на все языки.
+var catalog = map[Message]map[Lang]string{
+	// Заголовки ошибок API, ключ - стабильный код ошибки
+	"problem.validation_failed":           {Ru: "Некорректные входные данные", En: "Invalid input"},
+	"problem.invalid_if_match":            {Ru: "Некорректный заголовок If-Match", En: "Invalid If-Match header"},
+	"problem.unauthorized":                {Ru: "Требуется аутентификация", En: "Authentication required"},
+	"problem.token_invalid":               {Ru: "Неверный или просроченный токен", En: "Invalid or expired token"},
+	"problem.token_expired":               {Ru: "Токен просрочен", En: "Token expired"},
+	"problem.token_revoked":               {Ru: "Токен отозван", En: "Token revoked"},
+	"problem.invalid_credentials":         {Ru: "Неверный email или пароль", En: "Invalid email or password"},
+	"problem.refresh_token_invalid":       {Ru: "Refresh токен недействителен или просрочен", En: "Refresh token is invalid or expired"},
+	"problem.refresh_token_reused":        {Ru: "Refresh токен уже использован, сессия завершена", En: "Refresh token has already been used, the session is terminated"},
+	"problem.forbidden":                   {Ru: "Доступ запрещен", En: "Forbidden"},
+	"problem.route_not_found":             {Ru: "Маршрут не найден", En: "Route not found"},
+	"problem.user_not_found":              {Ru: "Пользователь не найден", En: "User not found"},
+	"problem.order_not_found":             {Ru: "Заказ не найден", En: "Order not found"},
+	"problem.product_not_found":           {Ru: "Продукт не найден", En: "Product not found"},
+	"problem.method_not_allowed":          {Ru: "Метод не поддерживается для этого маршрута", En: "Method not allowed for this route"},
+	"problem.email_already_taken":         {Ru: "Email уже используется другим пользователем", En: "Email is already used by another user"},
+	"problem.order_not_editable":          {Ru: "Заказ в текущем состоянии нельзя изменить", En: "Order cannot be modified in its current status"},
+	"problem.invalid_status_transition":   {Ru: "Недопустимая смена состояния заказа", En: "Invalid order status transition"},
+	"problem.insufficient_stock":          {Ru: "Недостаточно товара на складе", En: "Insufficient stock"},
+	"problem.idempotency_key_in_progress": {Ru: "Запрос с этим ключом идемпотентности еще выполняется", En: "A request with this idempotency key is still in progress"},
+	"problem.version_mismatch":            {Ru: "Запись изменена другим запросом, получите актуальную версию", En: "The record was modified by another request, fetch the current version"},
+	"problem.unknown_product":             {Ru: "Продукт не найден в каталоге", En: "Product not found in the catalog"},
+	"problem.currency_mismatch":           {Ru: "Валюта продукта не совпадает с валютой заказа", En: "Product currency does not match the order currency"},
+	"problem.idempotency_key_reused":      {Ru: "Ключ идемпотентности уже использован для другого запроса", En: "Idempotency key has already been used for a different request"},
+	"problem.account_locked":              {Ru: "Вход временно заблокирован после неудачных попыток", En: "Sign-in is temporarily locked after failed attempts"},
+	"problem.precondition_required":       {Ru: "Требуется заголовок If-Match с ETag, полученным при чтении записи", En: "If-Match header with the ETag returned when reading the record is required"},
+	"problem.too_many_requests":           {Ru: "Слишком много запросов", En: "Too many requests"},
+	"problem.internal_error":              {Ru: "Внутренняя ошибка сервера", En: "Internal server error"},
+
+	// Подробности ошибок
+	MsgAuthHeaderRequired:    {Ru: "Требуется заголовок Authorization", En: "Authorization header is required"},
+	MsgAuthHeaderFormat:      {Ru: "Формат заголовка должен быть Bearer {token}", En: "Header format must be Bearer {token}"},
+	MsgInsufficientRights:    {Ru: "Недостаточно прав для выполнения операции", En: "Insufficient permissions for this operation"},
+	MsgInvalidUserID:         {Ru: "Некорректный формат ID пользователя", En: "Invalid user ID format"},
+	MsgInvalidOrderID:        {Ru: "Некорректный формат ID заказа", En: "Invalid order ID format"},
+	MsgInvalidProductID:      {Ru: "Некорректный формат ID продукта", En: "Invalid product ID format"},
+	MsgUnknownOrderStatus:    {Ru: "Неизвестное состояние заказа: %s", En: "Unknown order status: %s"},
+	MsgInvalidPage:           {Ru: "недопустимый параметр страницы: должен быть положительным целым числом", En: "invalid page parameter: must be a positive integer"},
+	MsgInvalidLimit:          {Ru: "недопустимый параметр limit: должен быть положительным целым числом", En: "invalid limit parameter: must be a positive integer"},
+	MsgInvalidMinAge:         {Ru: "недопустимый параметр min_age: должен быть положительным целым числом", En: "invalid min_age parameter: must be a positive integer"},
+	MsgInvalidMaxAge:         {Ru: "недопустимый параметр max_age: должен быть положительным целым числом", En: "invalid max_age parameter: must be a positive integer"},
+	MsgInvalidIfMatchValue:   {Ru: "Значение %s не является ETag, выданным сервером", En: "Value %s is not an ETag issued by the server"},
+	MsgMalformedBody:         {Ru: "Тело запроса не является корректным JSON", En: "Request body is not valid JSON"},
+	MsgInvalidFieldType:      {Ru: "Поле %s имеет неверный тип, ожидается %s", En: "Field %s has an invalid type, expected %s"},
+	MsgInvalidAmount:         {Ru: "Некорректная денежная сумма: ожидается строка, например \"12.50\"", En: "Invalid money amount: a string such as \"12.50\" is expected"},
+	MsgInvalidRequest:        {Ru: "Некорректный запрос", En: "Invalid request"},
+	MsgBodyUnreadable:        {Ru: "Не удалось прочитать тело запроса", En: "Failed to read the request body"},
+	MsgIdempotencyKeyTooLong: {Ru: "Ключ идемпотентности слишком длинный", En: "Idempotency key is too long"},
+	MsgRateLimitExceeded:     {Ru: "Превышен лимит запросов, повторите через %d с", En: "Rate limit exceeded, retry in %d s"},
+
+	// Сообщения валидатора
+	MsgValidationRequired: {Ru: "поле %s обязательно", En: "field %s is required"},
+	MsgValidationEmail:    {Ru: "поле %s должно содержать корректный email", En: "field %s must be a valid email address"},
+	MsgValidationGT:       {Ru: "поле %s должно быть больше %s", En: "field %s must be greater than %s"},
+	MsgValidationGTE:      {Ru: "поле %s должно быть не меньше %s", En: "field %s must be at least %s"},
+	MsgValidationLT:       {Ru: "поле %s должно быть меньше %s", En: "field %s must be less than %s"},
+	MsgValidationLTE:      {Ru: "поле %s должно быть не больше %s", En: "field %s must be at most %s"},
+	MsgValidationMinLen:   {Ru: "поле %s должно содержать не менее %s символов", En: "field %s must be at least %s characters long"},
+	MsgValidationMaxLen:   {Ru: "поле %s должно содержать не более %s символов", En: "field %s must be at most %s characters long"},
+	MsgValidationMinItems: {Ru: "поле %s должно содержать не менее %s элементов", En: "field %s must contain at least %s items"},
+	MsgValidationMaxItems: {Ru: "поле %s должно содержать не более %s элементов", En: "field %s must contain at most %s items"},
+	MsgValidationOneOf:    {Ru: "поле %s должно иметь одно из значений: %s", En: "field %s must be one of: %s"},
+	MsgValidationISO4217:  {Ru: "поле %s должно содержать код валюты ISO 4217", En: "field %s must be an ISO 4217 currency code"},
+	MsgValidationName:     {Ru: "поле %s не должно быть пустым и должно содержать не более %d символов", En: "field %s must not be blank and must be at most %d characters long"},
+	MsgValidationAge:      {Ru: "поле %s должно быть от %d до %d", En: "field %s must be between %d and %d"},
+	MsgValidationInvalid:  {Ru: "поле %s не прошло проверку %s", En: "field %s failed the %s check"},
+}
@@ -1,29 +1,46 @@
 package jwt_util
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// refreshTokenBytes - количество случайных байт в непрозрачном refresh токене
+const refreshTokenBytes = 32
+
 type Claims struct {
 	UserID uint   `json:"user_id"`
 	Email  string `json:"email"`
+	Role   string `json:"role"`
 	jwt.RegisteredClaims
 }
 
-// GenerateJWT создает новый токен JWT для пользователя
-func GenerateJWT(userID uint, email string, secret string, expirationSeconds int) (string, error) {
+// GenerateJWT создает новый токен JWT для пользователя.
+// Каждый токен получает уникальный идентификатор (jti), по которому его можно отозвать,
+// и роль пользователя, по которой принимаются решения о доступе.
+func GenerateJWT(userID uint, email string, role string, secret string, expirationSeconds int) (string, error) {
 	if secret == "" {
 		return "", fmt.Errorf("секрет не может быть пустой")
 	}
 
+	tokenID, err := NewTokenID()
+	if err != nil {
+		return "", err
+	}
+
 	expirationTime := time.Now().Add(time.Duration(expirationSeconds) * time.Second)
 	claims := &Claims{
 		UserID: userID,
 		Email:  email,
+		Role:   role,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "user-order-api",
@@ -65,3 +82,28 @@ func ValidateJWT(tokenString string, secret string) (*Claims, error) {
 
 	return claims, nil
 }
+
+// NewTokenID генерирует случайный идентификатор для jti и семейств refresh токенов
+func NewTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// GenerateRefreshToken создает непрозрачный refresh токен.
+// В базе данных хранится только его хеш (см. HashToken).
+func GenerateRefreshToken() (string, error) {
+	buf := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashToken возвращает SHA-256 хеш токена в шестнадцатеричном виде
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
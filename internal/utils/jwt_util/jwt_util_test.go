@@ -14,12 +14,13 @@ const (
 	testSecret          = "test-super-secret-key"
 	testUserID          = uint(123)
 	testUserEmail       = "test@example.com"
+	testUserRole        = "admin"
 	testExpirationShort = 1  // 1 секунда, для теста истечения срока
 	testExpirationValid = 60 // 1 минута
 )
 
 func TestGenerateJWT_Success(t *testing.T) {
-	tokenString, err := GenerateJWT(testUserID, testUserEmail, testSecret, testExpirationValid)
+	tokenString, err := GenerateJWT(testUserID, testUserEmail, testUserRole, testSecret, testExpirationValid)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, tokenString)
 	// ... (остальная часть теста без изменений)
@@ -35,7 +36,7 @@ func TestGenerateJWT_Success(t *testing.T) {
 }
 
 func TestValidateJWT_Success(t *testing.T) {
-	tokenString, err := GenerateJWT(testUserID, testUserEmail, testSecret, testExpirationValid)
+	tokenString, err := GenerateJWT(testUserID, testUserEmail, testUserRole, testSecret, testExpirationValid)
 	require.NoError(t, err)
 	require.NotEmpty(t, tokenString)
 
@@ -45,6 +46,7 @@ func TestValidateJWT_Success(t *testing.T) {
 	// ... (остальная часть теста без изменений)
 	assert.Equal(t, testUserID, claims.UserID)
 	assert.Equal(t, testUserEmail, claims.Email)
+	assert.Equal(t, testUserRole, claims.Role)
 	assert.Equal(t, "user-order-api", claims.Issuer)
 	assert.WithinDuration(t, time.Now().Add(time.Duration(testExpirationValid)*time.Second), claims.ExpiresAt.Time, 2*time.Second)
 }
@@ -56,14 +58,14 @@ func TestValidateJWT_EmptyTokenString(t *testing.T) {
 }
 
 func TestValidateJWT_EmptySecretForValidation(t *testing.T) {
-	tokenString, _ := GenerateJWT(testUserID, testUserEmail, testSecret, testExpirationValid)
+	tokenString, _ := GenerateJWT(testUserID, testUserEmail, testUserRole, testSecret, testExpirationValid)
 	_, err := ValidateJWT(tokenString, "")
 	assert.Error(t, err)
 	assert.EqualError(t, err, "secret for validation cannot be empty")
 }
 
 func TestValidateJWT_InvalidSecret(t *testing.T) {
-	tokenString, err := GenerateJWT(testUserID, testUserEmail, testSecret, testExpirationValid)
+	tokenString, err := GenerateJWT(testUserID, testUserEmail, testUserRole, testSecret, testExpirationValid)
 	require.NoError(t, err)
 
 	_, err = ValidateJWT(tokenString, "wrong-secret-key")
@@ -75,7 +77,7 @@ func TestValidateJWT_InvalidSecret(t *testing.T) {
 }
 
 func TestValidateJWT_ExpiredToken(t *testing.T) {
-	tokenString, err := GenerateJWT(testUserID, testUserEmail, testSecret, testExpirationShort)
+	tokenString, err := GenerateJWT(testUserID, testUserEmail, testUserRole, testSecret, testExpirationShort)
 	require.NoError(t, err)
 
 	time.Sleep(time.Duration(testExpirationShort+1) * time.Second)
@@ -156,7 +158,7 @@ func TestGenerateAndValidate_Integration(t *testing.T) {
 	secret := "a-bit-longer-secret-for-integration"
 	expiration := 300
 
-	tokenString, err := GenerateJWT(userID, email, secret, expiration)
+	tokenString, err := GenerateJWT(userID, email, "user", secret, expiration)
 	require.NoError(t, err, "Generation failed")
 	require.NotEmpty(t, tokenString, "Generated token string is empty")
 
@@ -171,3 +173,33 @@ func TestGenerateAndValidate_Integration(t *testing.T) {
 	assert.Equal(t, "user-order-api", claims.Issuer)
 	assert.WithinDuration(t, time.Now().Add(time.Duration(expiration)*time.Second), claims.ExpiresAt.Time, 2*time.Second)
 }
+
+func TestGenerateJWT_UniqueTokenID(t *testing.T) {
+	first, err := GenerateJWT(testUserID, testUserEmail, testUserRole, testSecret, testExpirationValid)
+	require.NoError(t, err)
+	second, err := GenerateJWT(testUserID, testUserEmail, testUserRole, testSecret, testExpirationValid)
+	require.NoError(t, err)
+
+	firstClaims, err := ValidateJWT(first, testSecret)
+	require.NoError(t, err)
+	secondClaims, err := ValidateJWT(second, testSecret)
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, firstClaims.ID, "jti должен быть заполнен")
+	assert.NotEqual(t, firstClaims.ID, secondClaims.ID, "jti должен быть уникальным для каждого токена")
+}
+
+func TestGenerateRefreshToken_UniqueAndHashed(t *testing.T) {
+	first, err := GenerateRefreshToken()
+	require.NoError(t, err)
+	second, err := GenerateRefreshToken()
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, first)
+	assert.NotEqual(t, first, second)
+
+	hash := HashToken(first)
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, HashToken(first), "хеш должен быть детерминированным")
+	assert.NotEqual(t, hash, HashToken(second))
+}
@@ -1,37 +1,122 @@
 package logger_util
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/IlyushinDM/user-order-api/internal/utils/metrics_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/redact_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/request_id_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/tracing_util"
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/sirupsen/logrus"
+	gormlogger "gorm.io/gorm/logger"
 )
 
+// OverflowPolicy определяет поведение asyncWriter при заполненной очереди
+type OverflowPolicy string
+
+const (
+	// OverflowBlock - запись ждет освобождения места в очереди, записи не теряются
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropNewest - новая запись отбрасывается
+	OverflowDropNewest OverflowPolicy = "drop-newest"
+	// OverflowDropOldest - из очереди удаляется самая старая запись, новая ставится в очередь
+	OverflowDropOldest OverflowPolicy = "drop-oldest"
+)
+
+// Форматы записей лога
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Приемники записей лога
+const (
+	OutputStdout = "stdout"
+	OutputFile   = "file"
+	OutputBoth   = "both"
+)
+
+// ErrUnknownOverflowPolicy - неизвестная политика переполнения очереди
+var ErrUnknownOverflowPolicy = errors.New("неизвестная политика переполнения очереди логов")
+
+// ParseOverflowPolicy проверяет значение LOG_OVERFLOW_POLICY
+func ParseOverflowPolicy(value string) (OverflowPolicy, error) {
+	switch policy := OverflowPolicy(value); policy {
+	case OverflowBlock, OverflowDropNewest, OverflowDropOldest:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownOverflowPolicy, value)
+	}
+}
+
 // asyncWriter реализует io.Writer для асинхронной записи логов
 type asyncWriter struct {
-	writer io.Writer      // Основной Writer (например, os.Stdout)
-	queue  chan []byte    // Канал для буферизации логов (байтовых срезов)
-	wg     sync.WaitGroup // WaitGroup для ожидания завершения горутины
-	done   chan struct{}  // Канал для сигнала завершения работы
+	writer  io.Writer      // Основной Writer (например, os.Stdout)
+	queue   chan []byte    // Канал для буферизации логов (байтовых срезов)
+	policy  OverflowPolicy // Поведение при заполненной очереди
+	dropped atomic.Uint64  // Число отброшенных записей
+	wg      sync.WaitGroup // WaitGroup для ожидания завершения горутины
+	done    chan struct{}  // Канал для сигнала завершения работы
 }
 
 // LoggerConfig содержит настройки, специфичные для логгера
 type LoggerConfig struct {
-	LogLevel string `env:"LOG_LEVEL" env-default:"info"`
+	LogLevel          string `env:"LOG_LEVEL" env-default:"info"`
+	LogFormat         string `env:"LOG_FORMAT" env-default:"text"`
+	LogOutput         string `env:"LOG_OUTPUT" env-default:"stdout"`
+	LogFilePath       string `env:"LOG_FILE_PATH" env-default:"logs/app.log"`
+	LogFileMaxSizeMB  int    `env:"LOG_FILE_MAX_SIZE_MB" env-default:"100"`
+	LogFileMaxAgeDays int    `env:"LOG_FILE_MAX_AGE_DAYS" env-default:"7"`
+	LogFileMaxBackups int    `env:"LOG_FILE_MAX_BACKUPS" env-default:"10"`
+	LogFileCompress   bool   `env:"LOG_FILE_COMPRESS" env-default:"true"`
+	LogQueueSize      int    `env:"LOG_QUEUE_SIZE" env-default:"1000"`
+	LogOverflowPolicy string `env:"LOG_OVERFLOW_POLICY" env-default:"drop-newest"`
+
+	// Скрытие чувствительных данных в записях лога
+	LogRedactEmails         bool     `env:"LOG_REDACT_EMAILS" env-default:"true"`
+	LogRedactBearerTokens   bool     `env:"LOG_REDACT_BEARER_TOKENS" env-default:"true"`
+	LogRedactJWTs           bool     `env:"LOG_REDACT_JWTS" env-default:"true"`
+	LogRedactPasswordHashes bool     `env:"LOG_REDACT_PASSWORD_HASHES" env-default:"true"`
+	LogRedactKeys           []string `env:"LOG_REDACT_KEYS" env-separator:"," env-default:"password,secret,authorization,access_token,refresh_token"`
 }
 
+// RedactRules возвращает правила скрытия чувствительных данных из настроек LOG_REDACT_*
+func (cfg LoggerConfig) RedactRules() redact_util.Rules {
+	return redact_util.Rules{
+		Emails:         cfg.LogRedactEmails,
+		BearerTokens:   cfg.LogRedactBearerTokens,
+		JWTs:           cfg.LogRedactJWTs,
+		PasswordHashes: cfg.LogRedactPasswordHashes,
+		SensitiveKeys:  cfg.LogRedactKeys,
+	}
+}
+
+// NewAsyncWriter создает asyncWriter, отбрасывающий новые записи при заполненной очереди
 func NewAsyncWriter(destWriter io.Writer, queueSize int) (*asyncWriter, error) {
+	return NewAsyncWriterWithPolicy(destWriter, queueSize, OverflowDropNewest)
+}
+
+// NewAsyncWriterWithPolicy создает asyncWriter с заданной политикой переполнения очереди
+func NewAsyncWriterWithPolicy(destWriter io.Writer, queueSize int, policy OverflowPolicy) (*asyncWriter, error) {
 	if destWriter == nil {
 		return nil, fmt.Errorf("destination writer cannot be nil")
 	}
+	if _, err := ParseOverflowPolicy(string(policy)); err != nil {
+		return nil, err
+	}
 
 	aw := &asyncWriter{
 		writer: destWriter,
 		queue:  make(chan []byte, queueSize),
+		policy: policy,
 		done:   make(chan struct{}),
 	}
 
@@ -47,16 +132,49 @@ func (aw *asyncWriter) Write(p []byte) (n int, err error) {
 	pCopy := make([]byte, len(p))
 	copy(pCopy, p)
 
-	select {
-	case aw.queue <- pCopy: // Пытаемся отправить данные в очередь
-		return len(p), nil
-	case <-aw.done: // Если получен сигнал о завершении работы
-		return 0, io.ErrClosedPipe // Возвращаем ошибку
-	default: // Если очередь заполнена
+	if aw.policy == OverflowBlock {
+		select {
+		case aw.queue <- pCopy: // Ждем места в очереди
+			return len(p), nil
+		case <-aw.done: // Если получен сигнал о завершении работы
+			return 0, io.ErrClosedPipe
+		}
+	}
+
+	for {
+		select {
+		case aw.queue <- pCopy: // Пытаемся отправить данные в очередь
+			return len(p), nil
+		case <-aw.done: // Если получен сигнал о завершении работы
+			return 0, io.ErrClosedPipe // Возвращаем ошибку
+		default: // Очередь заполнена, отброшенная запись учитывается в счетчике и метриках
+		}
+
+		if aw.policy == OverflowDropOldest {
+			// Освобождаем место, удаляя самую старую запись, и повторяем попытку
+			select {
+			case <-aw.queue:
+				aw.drop()
+			default: // Очередь успела освободиться
+			}
+			continue
+		}
+		aw.drop()
 		return len(p), nil
 	}
 }
 
+// drop учитывает отброшенную запись
+func (aw *asyncWriter) drop() {
+	aw.dropped.Add(1)
+	metrics_util.LogEntriesDropped.Inc()
+}
+
+// Dropped возвращает число записей, отброшенных из-за переполнения очереди
+func (aw *asyncWriter) Dropped() uint64 {
+	return aw.dropped.Load()
+}
+
 // processQueue - горутина, которая читает данные из очереди и записывает их в основной writer
 func (aw *asyncWriter) processQueue() {
 	defer aw.wg.Done() // Уменьшаем счетчик горутин при выходе
@@ -102,59 +220,128 @@ func (aw *asyncWriter) Close() error {
 	return nil
 }
 
-// SetupLogger настраивает логгер Logrus для асинхронного вывода в терминал.
-// Использует cleanenv для загрузки LOG_LEVEL из .env файла или переменных окружения.
+// SetupLogger настраивает логгер Logrus для асинхронного вывода в терминал и (или) файл.
+// Использует cleanenv для загрузки настроек LOG_* из .env файла или переменных окружения.
 func SetupLogger() (*logrus.Logger, func() error) {
-	log := logrus.New()
-
-	// Используем текстовый форматтер для терминала для лучшей читаемости.
-	textFormatter := &logrus.TextFormatter{
-		FullTimestamp: true,
-		ForceColors:   true, // Попробуйте включить цвета
+	// --- Загрузка настроек логгера с помощью cleanenv ---
+	var loggerCfg LoggerConfig
+	// cleanenv.ReadConfig попытается прочитать из .env и переопределить из окружения.
+	loadErr := cleanenv.ReadConfig(".env", &loggerCfg)
+	envFileMissing := errors.Is(loadErr, os.ErrNotExist)
+	if envFileMissing {
+		loadErr = cleanenv.ReadEnv(&loggerCfg)
 	}
-	log.SetFormatter(textFormatter)
 
-	// --- Настройка асинхронной записи в os.Stdout ---
-	queueSize := 1000 // Размер буфера (очереди)
+	log, closeFunc := NewLogger(loggerCfg)
 
-	// Создаем асинхронный writer, который будет писать в os.Stdout
-	asyncWriter, err := NewAsyncWriter(os.Stdout, queueSize)
-	if err != nil {
-		// Если не удалось настроить асинхронный writer для stdout (маловероятно, но обрабатываем ошибку)
-		log.WithError(err).Error("Не удалось настроить асинхронный writer для stdout. Логи будут выводиться синхронно в stdout.")
-		log.SetOutput(os.Stdout) // Возвращаемся к синхронному выводу
-		// Возвращаем nil функцию закрытия, т.к. асинхронный writer не был успешно создан
-		return log, func() error { return nil }
+	// Проверяем ошибку загрузки, когда вывод логгера уже настроен
+	if envFileMissing {
+		log.Warn("Файл .env не найден для настроек логгера. Настройки LOG_* загружены из переменных окружения.")
+	}
+	if loadErr != nil {
+		log.WithError(loadErr).Error("Ошибка загрузки настроек логгера с помощью cleanenv. Используются значения по умолчанию для некорректных настроек")
+	} else {
+		log.Debugf("Настройки логгера загружены: уровень %s, формат %s, вывод %s", loggerCfg.LogLevel, loggerCfg.LogFormat, loggerCfg.LogOutput)
 	}
 
-	// Устанавливаем созданный асинхронный writer как вывод для логгера Logrus.
-	log.SetOutput(asyncWriter)
+	return log, closeFunc
+}
 
-	// --- Загрузка уровня логирования с помощью cleanenv ---
-	var loggerCfg LoggerConfig
-	// cleanenv.ReadConfig попытается прочитать из .env и переопределить из окружения.
-	err = cleanenv.ReadConfig(".env", &loggerCfg)
+// NewLogger создает логгер Logrus по настройкам LoggerConfig.
+// Записи пишутся через общую асинхронную очередь во все выбранные приемники.
+// Некорректные значения настроек заменяются значениями по умолчанию с предупреждением в лог.
+func NewLogger(cfg LoggerConfig) (*logrus.Logger, func() error) {
+	log := logrus.New()
+	var warnings []string
 
-	// Проверяем ошибку загрузки
+	format := cfg.LogFormat
+	if format != FormatText && format != FormatJSON {
+		warnings = append(warnings, fmt.Sprintf("Некорректное значение LOG_FORMAT '%s', используется формат '%s'", format, FormatText))
+		format = FormatText
+	}
+	output := cfg.LogOutput
+	if output != OutputStdout && output != OutputFile && output != OutputBoth {
+		warnings = append(warnings, fmt.Sprintf("Некорректное значение LOG_OUTPUT '%s', используется вывод '%s'", output, OutputStdout))
+		output = OutputStdout
+	}
+	policy, err := ParseOverflowPolicy(cfg.LogOverflowPolicy)
 	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			log.Warn("Файл .env не найден для настроек логгера. LOG_LEVEL должен быть загружен из переменных окружения.")
+		warnings = append(warnings, fmt.Sprintf("Некорректное значение LOG_OVERFLOW_POLICY '%s', используется политика '%s'", cfg.LogOverflowPolicy, OverflowDropNewest))
+		policy = OverflowDropNewest
+	}
+	queueSize := cfg.LogQueueSize // Размер буфера (очереди)
+	if queueSize <= 0 {
+		warnings = append(warnings, fmt.Sprintf("Некорректное значение LOG_QUEUE_SIZE %d, используется размер 1000", queueSize))
+		queueSize = 1000
+	}
+
+	// --- Выбор приемников ---
+	var sinks multiSink
+	if output == OutputStdout || output == OutputBoth {
+		sinks = append(sinks, os.Stdout)
+	}
+	if output == OutputFile || output == OutputBoth {
+		fileSink, err := newFileSink(cfg)
+		if err != nil {
+			// Без доступного файла логи не теряются, а выводятся в stdout
+			warnings = append(warnings, fmt.Sprintf("Не удалось открыть файл логов: %v. Логи будут выводиться в stdout", err))
+			if output == OutputFile {
+				sinks = append(sinks, os.Stdout)
+			}
+			output = OutputStdout
 		} else {
-			log.WithError(err).Error("Ошибка загрузки LOG_LEVEL с помощью cleanenv. Используется уровень 'info'")
+			sinks = append(sinks, fileSink)
 		}
+	}
+
+	var formatter logrus.Formatter
+	if format == FormatJSON {
+		// JSON по строке на запись для отправки в систему сбора логов
+		formatter = &logrus.JSONFormatter{TimestampFormat: time.RFC3339Nano}
 	} else {
-		log.Debugf("LOG_LEVEL загружен: %s", loggerCfg.LogLevel)
+		// Используем текстовый форматтер для терминала для лучшей читаемости.
+		// Цвета включаются только при выводе в один терминал, чтобы в файл не попадали escape-последовательности.
+		formatter = &logrus.TextFormatter{
+			FullTimestamp: true,
+			ForceColors:   output == OutputStdout,
+			DisableColors: output != OutputStdout,
+		}
 	}
+	// Email, токены и пароли скрываются во всех записях, включая SQL запросы GORM
+	log.SetFormatter(&redact_util.Formatter{Formatter: formatter, Redactor: redact_util.New(cfg.RedactRules())})
+	// Записи, созданные через WithContext внутри трассируемого запроса, получают trace_id и span_id
+	log.AddHook(tracing_util.LogHook{})
+	// Записи, созданные через WithContext с контекстом запроса, получают request_id
+	log.AddHook(request_id_util.LogHook{})
+
+	// --- Настройка асинхронной записи во все приемники ---
+	var dest io.Writer = sinks
+	if len(sinks) == 1 {
+		dest = sinks[0]
+	}
+	asyncWriter, err := NewAsyncWriterWithPolicy(dest, queueSize, policy)
+	if err != nil {
+		// Если не удалось настроить асинхронный writer (маловероятно, но обрабатываем ошибку)
+		log.WithError(err).Error("Не удалось настроить асинхронный writer. Логи будут выводиться синхронно.")
+		log.SetOutput(dest) // Возвращаемся к синхронному выводу
+		return log, sinks.Close
+	}
+
+	// Устанавливаем созданный асинхронный writer как вывод для логгера Logrus.
+	log.SetOutput(asyncWriter)
 
 	// Настройка уровня логирования из загруженного значения
-	level, err := logrus.ParseLevel(loggerCfg.LogLevel)
+	level, err := logrus.ParseLevel(cfg.LogLevel)
 	if err != nil {
 		// Это сработает, если значение LOG_LEVEL из .env или окружения некорректно
-		log.Warnf("Некорректное значение LOG_LEVEL '%s' загружено, используется уровень 'info'", loggerCfg.LogLevel)
+		warnings = append(warnings, fmt.Sprintf("Некорректное значение LOG_LEVEL '%s' загружено, используется уровень 'info'", cfg.LogLevel))
 		level = logrus.InfoLevel
 	}
 	log.SetLevel(level)
-	// --- Конец загрузки уровня логирования ---
+
+	for _, warning := range warnings {
+		log.Warn(warning)
+	}
 
 	closeFunc := func() error {
 		return asyncWriter.Close()
@@ -164,13 +351,82 @@ func SetupLogger() (*logrus.Logger, func() error) {
 }
 
 // logrusGormWriter адаптирует логгер Logrus к интерфейсу логгера GORM.
-// Это часть исходного кода и не требует изменений.
+// Если задан Context, записи получают поля из контекста запроса (request_id, trace_id).
 type LogrusGormWriter struct {
-	Logger *logrus.Logger
+	Logger  *logrus.Logger
+	Context context.Context
 }
 
 // Printf реализует интерфейс логгера GORM.
 // Использует Tracef для логирования запросов GORM.
 func (w *LogrusGormWriter) Printf(message string, data ...interface{}) {
+	if w.Context != nil {
+		w.Logger.WithContext(w.Context).Tracef(message, data...)
+		return
+	}
 	w.Logger.Tracef(message, data...)
 }
+
+// gormLogger - логгер GORM, который пишет каждый запрос с контекстом этого запроса.
+// Формат записей совпадает со стандартным логгером GORM.
+type gormLogger struct {
+	log    *logrus.Logger
+	config gormlogger.Config
+}
+
+// NewGormLogger создает логгер GORM поверх Logrus. Записи о SQL запросах, выполненных
+// с контекстом запроса API (db.WithContext(ctx)), содержат его request_id и trace_id.
+func NewGormLogger(log *logrus.Logger, config gormlogger.Config) gormlogger.Interface {
+	return &gormLogger{log: log, config: config}
+}
+
+// enabled сообщает, попадут ли записи GORM в лог: LogrusGormWriter пишет их на уровне Trace
+func (l *gormLogger) enabled() bool {
+	return l.config.LogLevel > gormlogger.Silent && l.log.IsLevelEnabled(logrus.TraceLevel)
+}
+
+// withContext возвращает стандартный логгер GORM, пишущий с контекстом ctx
+func (l *gormLogger) withContext(ctx context.Context) gormlogger.Interface {
+	return gormlogger.New(&LogrusGormWriter{Logger: l.log, Context: ctx}, l.config)
+}
+
+// LogMode возвращает копию логгера с уровнем level
+func (l *gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	config := l.config
+	config.LogLevel = level
+	return &gormLogger{log: l.log, config: config}
+}
+
+func (l *gormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.enabled() {
+		l.withContext(ctx).Info(ctx, msg, data...)
+	}
+}
+
+func (l *gormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.enabled() {
+		l.withContext(ctx).Warn(ctx, msg, data...)
+	}
+}
+
+func (l *gormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.enabled() {
+		l.withContext(ctx).Error(ctx, msg, data...)
+	}
+}
+
+// Trace записывает SQL запрос, его длительность и число затронутых строк
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if !l.enabled() {
+		return
+	}
+	l.withContext(ctx).Trace(ctx, begin, fc, err)
+}
+
+// ParamsFilter скрывает значения параметров запроса, если включен ParameterizedQueries
+func (l *gormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if l.config.ParameterizedQueries {
+		return sql, nil
+	}
+	return sql, params
+}
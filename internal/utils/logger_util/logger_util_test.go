@@ -2,12 +2,24 @@ package logger_util
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/IlyushinDM/user-order-api/internal/utils/metrics_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/redact_util"
+	"github.com/IlyushinDM/user-order-api/internal/utils/request_id_util"
+	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/sirupsen/logrus"
+	gormlogger "gorm.io/gorm/logger"
 )
 
 // --- Tests for asyncWriter ---
@@ -110,6 +122,32 @@ func TestLogrusGormWriter_Printf(t *testing.T) {
 	}
 }
 
+func TestGormLogger_TraceUsesQueryContext(t *testing.T) {
+	logger := logrus.New()
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetLevel(logrus.TraceLevel)
+	logger.AddHook(request_id_util.LogHook{})
+
+	gormLog := NewGormLogger(logger, gormlogger.Config{LogLevel: gormlogger.Info})
+	ctx := request_id_util.WithRequestID(context.Background(), "req-7")
+	gormLog.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+
+	out := buf.String()
+	if !strings.Contains(out, "SELECT 1") || !strings.Contains(out, `"request_id":"req-7"`) {
+		t.Errorf("Expected SQL log with request_id, got: %s", out)
+	}
+
+	// Ниже уровня Trace запросы не логируются
+	buf.Reset()
+	logger.SetLevel(logrus.DebugLevel)
+	gormLog.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 2", 1 }, nil)
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output below trace level, got: %s", buf.String())
+	}
+}
+
 // --- Test LoggerConfig defaults ---
 
 func TestLoggerConfig_Default(t *testing.T) {
@@ -167,3 +205,281 @@ func TestAsyncWriter_ProcessQueue_DoneEmptyQueue(t *testing.T) {
 	aw.Close()
 	// Should not deadlock or panic
 }
+
+// blockingWriter блокирует запись, пока не будет закрыт канал release, и запоминает записанное
+type blockingWriter struct {
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+	mu      sync.Mutex
+	buf     bytes.Buffer
+}
+
+func newBlockingWriter() *blockingWriter {
+	return &blockingWriter{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.started) })
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncWriter_CountsDroppedEntries(t *testing.T) {
+	dest := newBlockingWriter()
+	aw, err := NewAsyncWriter(dest, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	before := testutil.ToFloat64(metrics_util.LogEntriesDropped)
+
+	aw.Write([]byte("first\n")) // Забирается горутиной и блокирует ее
+	<-dest.started
+	aw.Write([]byte("second\n")) // Занимает единственное место в очереди
+	if n, err := aw.Write([]byte("third\n")); err != nil || n != len("third\n") {
+		t.Errorf("Expected dropped write to report success, got n=%d, err=%v", n, err)
+	}
+
+	close(dest.release)
+	aw.Close()
+
+	if dropped := testutil.ToFloat64(metrics_util.LogEntriesDropped) - before; dropped != 1 {
+		t.Errorf("Expected 1 dropped entry, got %v", dropped)
+	}
+	if aw.Dropped() != 1 {
+		t.Errorf("Expected Dropped() = 1, got %d", aw.Dropped())
+	}
+	if out := dest.String(); out != "first\nsecond\n" {
+		t.Errorf("Expected newest entry to be dropped, got %q", out)
+	}
+}
+
+func TestAsyncWriter_DropOldest(t *testing.T) {
+	dest := newBlockingWriter()
+	aw, err := NewAsyncWriterWithPolicy(dest, 1, OverflowDropOldest)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	aw.Write([]byte("first\n"))
+	<-dest.started
+	aw.Write([]byte("second\n"))
+	aw.Write([]byte("third\n")) // Вытесняет second из очереди
+
+	close(dest.release)
+	aw.Close()
+
+	if aw.Dropped() != 1 {
+		t.Errorf("Expected Dropped() = 1, got %d", aw.Dropped())
+	}
+	if out := dest.String(); out != "first\nthird\n" {
+		t.Errorf("Expected oldest entry to be dropped, got %q", out)
+	}
+}
+
+func TestAsyncWriter_BlockKeepsAllEntries(t *testing.T) {
+	dest := newBlockingWriter()
+	aw, err := NewAsyncWriterWithPolicy(dest, 1, OverflowBlock)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	aw.Write([]byte("first\n"))
+	<-dest.started
+	aw.Write([]byte("second\n"))
+	written := make(chan struct{})
+	go func() {
+		aw.Write([]byte("third\n")) // Ждет места в очереди
+		close(written)
+	}()
+
+	close(dest.release)
+	<-written
+	aw.Close()
+
+	if aw.Dropped() != 0 {
+		t.Errorf("Expected no dropped entries, got %d", aw.Dropped())
+	}
+	if out := dest.String(); out != "first\nsecond\nthird\n" {
+		t.Errorf("Expected all entries, got %q", out)
+	}
+}
+
+func TestParseOverflowPolicy(t *testing.T) {
+	for _, value := range []string{"block", "drop-newest", "drop-oldest"} {
+		if policy, err := ParseOverflowPolicy(value); err != nil || string(policy) != value {
+			t.Errorf("Expected %q to be valid, got %q, %v", value, policy, err)
+		}
+	}
+	if _, err := ParseOverflowPolicy("drop-all"); !errors.Is(err, ErrUnknownOverflowPolicy) {
+		t.Errorf("Expected ErrUnknownOverflowPolicy, got %v", err)
+	}
+	if _, err := NewAsyncWriterWithPolicy(&bytes.Buffer{}, 1, "drop-all"); err == nil {
+		t.Errorf("Expected error for unknown policy")
+	}
+}
+
+// --- Tests for NewLogger ---
+
+func testLoggerConfig() LoggerConfig {
+	return LoggerConfig{
+		LogLevel:          "info",
+		LogFormat:         FormatText,
+		LogOutput:         OutputStdout,
+		LogQueueSize:      100,
+		LogOverflowPolicy: string(OverflowBlock),
+	}
+}
+
+// baseFormatter возвращает форматтер, обернутый форматтером скрытия данных
+func baseFormatter(log *logrus.Logger) logrus.Formatter {
+	if redacting, ok := log.Formatter.(*redact_util.Formatter); ok {
+		return redacting.Formatter
+	}
+	return log.Formatter
+}
+
+func TestNewLogger_JSONToFile(t *testing.T) {
+	cfg := testLoggerConfig()
+	cfg.LogFormat = FormatJSON
+	cfg.LogOutput = OutputFile
+	cfg.LogFilePath = filepath.Join(t.TempDir(), "nested", "app.log")
+	cfg.LogFileMaxSizeMB = 1
+
+	log, closeFunc := NewLogger(cfg)
+	log.WithField("user_id", 7).Info("hello file")
+	if err := closeFunc(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	data, err := os.ReadFile(cfg.LogFilePath)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(bytes.TrimSpace(data), &entry); err != nil {
+		t.Fatalf("Expected one JSON entry, got %q: %v", data, err)
+	}
+	if entry["msg"] != "hello file" || entry["level"] != "info" || entry["user_id"] != float64(7) {
+		t.Errorf("Unexpected entry: %v", entry)
+	}
+}
+
+func TestNewLogger_TextWithoutColorsInFile(t *testing.T) {
+	cfg := testLoggerConfig()
+	cfg.LogOutput = OutputBoth
+	cfg.LogFilePath = filepath.Join(t.TempDir(), "app.log")
+
+	log, closeFunc := NewLogger(cfg)
+	if formatter, ok := baseFormatter(log).(*logrus.TextFormatter); !ok || formatter.ForceColors || !formatter.DisableColors {
+		t.Errorf("Expected text formatter without colors, got %#v", log.Formatter)
+	}
+	log.Info("hello both")
+	closeFunc()
+
+	data, err := os.ReadFile(cfg.LogFilePath)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if !strings.Contains(string(data), `msg="hello both"`) || strings.Contains(string(data), "\x1b[") {
+		t.Errorf("Expected plain text entry in file, got %q", data)
+	}
+}
+
+func TestNewLogger_InvalidSettingsFallBackToDefaults(t *testing.T) {
+	cfg := LoggerConfig{LogLevel: "loud", LogFormat: "xml", LogOutput: "syslog", LogOverflowPolicy: "drop-all"}
+
+	log, closeFunc := NewLogger(cfg)
+	defer closeFunc()
+
+	if _, ok := baseFormatter(log).(*logrus.TextFormatter); !ok {
+		t.Errorf("Expected text formatter fallback, got %T", baseFormatter(log))
+	}
+	if log.GetLevel() != logrus.InfoLevel {
+		t.Errorf("Expected info level fallback, got %v", log.GetLevel())
+	}
+	aw, ok := log.Out.(*asyncWriter)
+	if !ok {
+		t.Fatalf("Expected async output, got %T", log.Out)
+	}
+	if aw.policy != OverflowDropNewest || cap(aw.queue) != 1000 || aw.writer != os.Stdout {
+		t.Errorf("Expected default policy, queue size and stdout, got %q, %d, %T", aw.policy, cap(aw.queue), aw.writer)
+	}
+}
+
+func TestLoggerConfig_RedactionEnabledByDefault(t *testing.T) {
+	var cfg LoggerConfig
+	if err := cleanenv.ReadEnv(&cfg); err != nil {
+		t.Fatalf("Failed to read env: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.RedactRules(), redact_util.DefaultRules()) {
+		t.Errorf("Expected default redaction rules, got %+v", cfg.RedactRules())
+	}
+}
+
+func TestNewLogger_RedactsGormQueries(t *testing.T) {
+	cfg := testLoggerConfig()
+	cfg.LogLevel = "trace"
+	cfg.LogFormat = FormatJSON
+	cfg.LogOutput = OutputFile
+	cfg.LogFilePath = filepath.Join(t.TempDir(), "app.log")
+	cfg.LogRedactEmails, cfg.LogRedactBearerTokens, cfg.LogRedactJWTs, cfg.LogRedactPasswordHashes = true, true, true, true
+	cfg.LogRedactKeys = redact_util.DefaultRules().SensitiveKeys
+
+	log, closeFunc := NewLogger(cfg)
+	// Без ParameterizedQueries значения параметров подставляются в текст запроса
+	gormLog := NewGormLogger(log, gormlogger.Config{LogLevel: gormlogger.Info})
+	const hash = "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"
+	gormLog.Trace(context.Background(), time.Now(), func() (string, int64) {
+		return `INSERT INTO "users" ("name","email","password_hash") VALUES ('Bob','bob@example.com','` + hash + `')`, 1
+	}, nil)
+	gormLog.Trace(context.Background(), time.Now(), func() (string, int64) {
+		return `SELECT * FROM "users" WHERE email = 'bob@example.com'`, 0
+	}, errors.New(`duplicate key value: Key (email)=(bob@example.com) already exists`))
+	log.WithField("email", "bob@example.com").Info("Попытка входа")
+	closeFunc()
+
+	data, err := os.ReadFile(cfg.LogFilePath)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if strings.Count(string(data), "\n") != 3 {
+		t.Fatalf("Expected 3 entries, got %q", data)
+	}
+	for _, secret := range []string{"bob@example.com", hash} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Log contains %q: %s", secret, data)
+		}
+	}
+	if !strings.Contains(string(data), "b***@example.com") {
+		t.Errorf("Expected masked email in log, got %s", data)
+	}
+}
+
+// failingWriter всегда возвращает ошибку записи
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestMultiSink_WritesToAllSinks(t *testing.T) {
+	var first, second bytes.Buffer
+	sinks := multiSink{&first, failingWriter{}, &second}
+
+	n, err := sinks.Write([]byte("entry\n"))
+	if err == nil || n != len("entry\n") {
+		t.Errorf("Expected error from failing sink and full length, got n=%d, err=%v", n, err)
+	}
+	if first.String() != "entry\n" || second.String() != "entry\n" {
+		t.Errorf("Expected entry in all working sinks, got %q and %q", first.String(), second.String())
+	}
+}
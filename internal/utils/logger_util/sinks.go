@@ -0,0 +1,57 @@
+package logger_util
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// multiSink пишет каждую запись во все приемники.
+// В отличие от io.MultiWriter, ошибка одного приемника не останавливает запись в остальные.
+type multiSink []io.Writer
+
+// Write записывает данные во все приемники и возвращает первую ошибку
+func (m multiSink) Write(p []byte) (int, error) {
+	var firstErr error
+	for _, w := range m {
+		if _, err := w.Write(p); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return len(p), firstErr
+}
+
+// Close закрывает все приемники, кроме os.Stdout
+func (m multiSink) Close() error {
+	var errs []error
+	for _, w := range m {
+		if closer, ok := w.(io.Closer); ok && w != os.Stdout {
+			errs = append(errs, closer.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// newFileSink создает файл логов с ротацией по размеру и возрасту.
+// Старые сегменты сжимаются gzip, если включен LOG_FILE_COMPRESS.
+func newFileSink(cfg LoggerConfig) (*lumberjack.Logger, error) {
+	if cfg.LogFilePath == "" {
+		return nil, fmt.Errorf("не задан LOG_FILE_PATH")
+	}
+	// Каталог создается заранее, чтобы ошибка доступа обнаружилась при запуске, а не при первой записи
+	if err := os.MkdirAll(filepath.Dir(cfg.LogFilePath), 0o755); err != nil {
+		return nil, fmt.Errorf("не удалось создать каталог для %s: %w", cfg.LogFilePath, err)
+	}
+	return &lumberjack.Logger{
+		Filename:   cfg.LogFilePath,
+		MaxSize:    cfg.LogFileMaxSizeMB,
+		MaxAge:     cfg.LogFileMaxAgeDays,
+		MaxBackups: cfg.LogFileMaxBackups,
+		LocalTime:  true,
+		Compress:   cfg.LogFileCompress,
+	}, nil
+}
@@ -0,0 +1,153 @@
+// Package metrics_util содержит метрики приложения в формате Prometheus.
+// Все метрики регистрируются в собственном реестре Registry, который отдается обработчиком Handler.
+package metrics_util
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace - общий префикс имен метрик приложения
+const Namespace = "user_order_api"
+
+// Значения метки result счетчика входов
+const (
+	LoginSucceeded = "success"
+	LoginFailed    = "failure"
+)
+
+// Значения метки operation счетчика операций с заказами
+const (
+	OrderCreated       = "created"
+	OrderUpdated       = "updated"
+	OrderStatusChanged = "status_changed"
+	OrderDeleted       = "deleted"
+)
+
+// Registry - реестр метрик приложения; кроме метрик приложения содержит метрики среды выполнения Go и процесса
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequestDuration - длительность обработки HTTP запросов по шаблону маршрута и статусу ответа
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Длительность обработки HTTP запросов в секундах.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestsInFlight - количество HTTP запросов, обрабатываемых в данный момент
+	HTTPRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "Количество HTTP запросов, обрабатываемых в данный момент.",
+	})
+
+	// UsersCreated - количество созданных пользователей
+	UsersCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "users_created_total",
+		Help:      "Количество созданных пользователей.",
+	})
+
+	// Logins - количество попыток входа по результату
+	Logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "logins_total",
+		Help:      "Количество попыток входа по результату (success, failure).",
+	}, []string{"result"})
+
+	// OrderOperations - количество успешных операций с заказами
+	OrderOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "order_operations_total",
+		Help:      "Количество успешных операций с заказами (created, updated, status_changed, deleted).",
+	}, []string{"operation"})
+
+	// RateLimitRejected - количество запросов, отклоненных ограничителем запросов, по имени лимита
+	RateLimitRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "rate_limit_rejected_total",
+		Help:      "Количество запросов, отклоненных из-за превышения лимита, по имени лимита.",
+	}, []string{"limit"})
+
+	// LogEntriesDropped - количество записей лога, отброшенных из-за переполнения очереди асинхронной записи
+	LogEntriesDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "log_entries_dropped_total",
+		Help:      "Количество записей лога, отброшенных из-за переполнения очереди асинхронной записи.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestDuration,
+		HTTPRequestsInFlight,
+		UsersCreated,
+		Logins,
+		OrderOperations,
+		RateLimitRejected,
+		LogEntriesDropped,
+	)
+	// Метки с известным набором значений создаются заранее, чтобы ряды были видны до первого события
+	for _, result := range []string{LoginSucceeded, LoginFailed} {
+		Logins.WithLabelValues(result)
+	}
+	for _, operation := range []string{OrderCreated, OrderUpdated, OrderStatusChanged, OrderDeleted} {
+		OrderOperations.WithLabelValues(operation)
+	}
+}
+
+// ObserveLogin учитывает попытку входа
+func ObserveLogin(succeeded bool) {
+	result := LoginFailed
+	if succeeded {
+		result = LoginSucceeded
+	}
+	Logins.WithLabelValues(result).Inc()
+}
+
+// ObserveOrderOperation учитывает успешную операцию с заказом
+func ObserveOrderOperation(operation string) {
+	OrderOperations.WithLabelValues(operation).Inc()
+}
+
+var (
+	dbStatsMu        sync.Mutex
+	dbStatsCollector prometheus.Collector
+)
+
+// RegisterDBStats публикует статистику пула соединений sql.DB.
+// Повторный вызов заменяет пул, статистика которого публикуется.
+func RegisterDBStats(db *sql.DB) error {
+	if db == nil {
+		return errors.New("пул соединений не предоставлен для метрик")
+	}
+	dbStatsMu.Lock()
+	defer dbStatsMu.Unlock()
+
+	if dbStatsCollector != nil {
+		Registry.Unregister(dbStatsCollector)
+	}
+	collector := collectors.NewDBStatsCollector(db, "main")
+	if err := Registry.Register(collector); err != nil {
+		return err
+	}
+	dbStatsCollector = collector
+	return nil
+}
+
+// Handler возвращает обработчик, отдающий метрики в текстовом формате Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
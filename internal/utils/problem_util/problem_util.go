@@ -0,0 +1,136 @@
+// Package problem_util формирует ответы с ошибками в формате RFC 7807 (application/problem+json).
+// Каждый класс ошибки имеет стабильный машиночитаемый код: клиенты ветвятся по полю code,
+// а не по тексту заголовка, который может меняться.
+package problem_util
+
+import (
+	"net/http"
+
+	"github.com/IlyushinDM/user-order-api/internal/utils/i18n_util"
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType - тип содержимого ответа с ошибкой
+const ContentType = "application/problem+json"
+
+// TypePrefix - префикс URI типа ошибки; полный тип равен TypePrefix + код
+const TypePrefix = "urn:user-order-api:problem:"
+
+// Problem - тело ответа с ошибкой по RFC 7807
+type Problem struct {
+	Type     string `json:"type" example:"urn:user-order-api:problem:order_not_found"` // URI типа ошибки
+	Title    string `json:"title" example:"Заказ не найден"`                           // Краткое описание класса ошибки
+	Status   int    `json:"status" example:"404"`                                      // HTTP статус ответа
+	Detail   string `json:"detail,omitempty"`                                          // Подробности конкретного случая
+	Instance string `json:"instance,omitempty" example:"/api/users/1/orders/7"`        // Путь запроса, вызвавшего ошибку
+	Code     string `json:"code" example:"order_not_found"`                            // Стабильный машиночитаемый код ошибки
+	// Нарушения правил валидации отдельных полей; заполняется только для validation_failed
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError описывает нарушение правила валидации одного поля или параметра запроса
+type FieldError struct {
+	Field   string `json:"field" example:"items[0].quantity"`                             // Имя поля в JSON или параметра запроса
+	Rule    string `json:"rule" example:"gt"`                                             // Стабильное имя нарушенного правила
+	Message string `json:"message" example:"поле items[0].quantity должно быть больше 0"` // Описание на языке ответа
+}
+
+// Kind описывает класс ошибки API: стабильный код и HTTP статус.
+// Заголовок ошибки на языке ответа берется из каталога i18n_util по коду.
+type Kind struct {
+	Code   string
+	Status int
+}
+
+// Title возвращает заголовок ошибки на языке lang
+func (k Kind) Title(lang i18n_util.Lang) string {
+	return i18n_util.T(lang, i18n_util.ProblemTitle(k.Code))
+}
+
+// Классы ошибок API. Коды являются частью контракта API и не меняются.
+var (
+	ValidationFailed     = Kind{"validation_failed", http.StatusBadRequest}
+	InvalidIfMatch       = Kind{"invalid_if_match", http.StatusBadRequest}
+	Unauthorized         = Kind{"unauthorized", http.StatusUnauthorized}
+	TokenInvalid         = Kind{"token_invalid", http.StatusUnauthorized}
+	TokenExpired         = Kind{"token_expired", http.StatusUnauthorized}
+	TokenRevoked         = Kind{"token_revoked", http.StatusUnauthorized}
+	InvalidCredentials   = Kind{"invalid_credentials", http.StatusUnauthorized}
+	RefreshTokenInvalid  = Kind{"refresh_token_invalid", http.StatusUnauthorized}
+	RefreshTokenReused   = Kind{"refresh_token_reused", http.StatusUnauthorized}
+	Forbidden            = Kind{"forbidden", http.StatusForbidden}
+	RouteNotFound        = Kind{"route_not_found", http.StatusNotFound}
+	UserNotFound         = Kind{"user_not_found", http.StatusNotFound}
+	OrderNotFound        = Kind{"order_not_found", http.StatusNotFound}
+	ProductNotFound      = Kind{"product_not_found", http.StatusNotFound}
+	MethodNotAllowed     = Kind{"method_not_allowed", http.StatusMethodNotAllowed}
+	EmailAlreadyTaken    = Kind{"email_already_taken", http.StatusConflict}
+	OrderNotEditable     = Kind{"order_not_editable", http.StatusConflict}
+	InvalidTransition    = Kind{"invalid_status_transition", http.StatusConflict}
+	InsufficientStock    = Kind{"insufficient_stock", http.StatusConflict}
+	IdempotencyInFlight  = Kind{"idempotency_key_in_progress", http.StatusConflict}
+	VersionMismatch      = Kind{"version_mismatch", http.StatusPreconditionFailed}
+	UnknownProduct       = Kind{"unknown_product", http.StatusUnprocessableEntity}
+	CurrencyMismatch     = Kind{"currency_mismatch", http.StatusUnprocessableEntity}
+	IdempotencyKeyReused = Kind{"idempotency_key_reused", http.StatusUnprocessableEntity}
+	AccountLocked        = Kind{"account_locked", http.StatusLocked}
+	PreconditionRequired = Kind{"precondition_required", http.StatusPreconditionRequired}
+	TooManyRequests      = Kind{"too_many_requests", http.StatusTooManyRequests}
+	Internal             = Kind{"internal_error", http.StatusInternalServerError}
+)
+
+// New создает описание ошибки класса kind на языке lang для запроса по пути instance
+func New(kind Kind, lang i18n_util.Lang, detail, instance string) Problem {
+	return Problem{
+		Type:     TypePrefix + kind.Code,
+		Title:    kind.Title(lang),
+		Status:   kind.Status,
+		Detail:   detail,
+		Instance: instance,
+		Code:     kind.Code,
+	}
+}
+
+// Respond прерывает обработку запроса и отвечает ошибкой класса kind.
+// Подробности берутся из каталога по ключу detail на языке запроса; пустой ключ означает ответ без подробностей.
+func Respond(c *gin.Context, kind Kind, detail i18n_util.Message, args ...any) {
+	text := ""
+	if detail != "" {
+		text = i18n_util.T(i18n_util.FromContext(c), detail, args...)
+	}
+	RespondDetail(c, kind, text)
+}
+
+// RespondDetail прерывает обработку запроса и отвечает ошибкой класса kind с готовым текстом подробностей.
+// detail попадает в ответ как есть, поэтому должен быть на языке запроса и не содержать внутренних подробностей.
+func RespondDetail(c *gin.Context, kind Kind, detail string) {
+	respond(c, New(kind, i18n_util.FromContext(c), detail, c.Request.URL.Path))
+}
+
+// RespondFields прерывает обработку запроса и отвечает ошибкой validation_failed
+// со списком нарушений правил полей на языке запроса
+func RespondFields(c *gin.Context, detail string, fields []FieldError) {
+	problem := New(ValidationFailed, i18n_util.FromContext(c), detail, c.Request.URL.Path)
+	problem.Errors = fields
+	respond(c, problem)
+}
+
+func respond(c *gin.Context, problem Problem) {
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(problem.Status, problem)
+}
+
+// NoRoute отвечает ошибкой route_not_found; подключается через gin.Engine.NoRoute
+func NoRoute(c *gin.Context) {
+	Respond(c, RouteNotFound, "")
+}
+
+// NoMethod отвечает ошибкой method_not_allowed; подключается через gin.Engine.NoMethod
+func NoMethod(c *gin.Context) {
+	Respond(c, MethodNotAllowed, "")
+}
+
+// Recovery отвечает ошибкой internal_error на панику обработчика; подключается через gin.CustomRecovery
+func Recovery(c *gin.Context, _ any) {
+	Respond(c, Internal, "")
+}
@@ -0,0 +1,95 @@
+package problem_util
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IlyushinDM/user-order-api/internal/utils/i18n_util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	problem := New(OrderNotFound, i18n_util.Ru, "заказ 7 не найден", "/api/users/1/orders/7")
+
+	assert.Equal(t, "urn:user-order-api:problem:order_not_found", problem.Type)
+	assert.Equal(t, "order_not_found", problem.Code)
+	assert.Equal(t, http.StatusNotFound, problem.Status)
+	assert.Equal(t, "Заказ не найден", problem.Title)
+	assert.Equal(t, "заказ 7 не найден", problem.Detail)
+	assert.Equal(t, "/api/users/1/orders/7", problem.Instance)
+}
+
+func TestRespond(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(NoRoute)
+	router.NoMethod(NoMethod)
+	router.GET("/conflict", func(c *gin.Context) {
+		Respond(c, InsufficientStock, "")
+	})
+	router.GET("/panic", gin.CustomRecoveryWithWriter(io.Discard, Recovery), func(c *gin.Context) {
+		panic("boom")
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		kind   Kind
+	}{
+		{"Ошибка обработчика", http.MethodGet, "/conflict", InsufficientStock},
+		{"Неизвестный маршрут", http.MethodGet, "/missing", RouteNotFound},
+		{"Неподдерживаемый метод", http.MethodPost, "/conflict", MethodNotAllowed},
+		{"Паника обработчика", http.MethodGet, "/panic", Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.kind.Status, w.Code)
+			assert.Equal(t, ContentType, w.Header().Get("Content-Type"))
+			var problem Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+			assert.Equal(t, New(tt.kind, i18n_util.Default, "", tt.path), problem)
+		})
+	}
+}
+
+func TestKindTitleIsTranslated(t *testing.T) {
+	kinds := []Kind{
+		ValidationFailed, InvalidIfMatch, Unauthorized, TokenInvalid, TokenExpired, TokenRevoked,
+		InvalidCredentials, RefreshTokenInvalid, RefreshTokenReused, Forbidden, RouteNotFound,
+		UserNotFound, OrderNotFound, ProductNotFound, MethodNotAllowed, EmailAlreadyTaken,
+		OrderNotEditable, InvalidTransition, InsufficientStock, IdempotencyInFlight, VersionMismatch,
+		UnknownProduct, CurrencyMismatch, IdempotencyKeyReused, AccountLocked, PreconditionRequired, TooManyRequests,
+		Internal,
+	}
+	for _, kind := range kinds {
+		ru, en := kind.Title(i18n_util.Ru), kind.Title(i18n_util.En)
+		assert.NotEqual(t, string(i18n_util.ProblemTitle(kind.Code)), ru, "нет заголовка для %s", kind.Code)
+		assert.NotEqual(t, ru, en, "нет английского заголовка для %s", kind.Code)
+	}
+}
+
+func TestRespondUsesRequestLanguage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/users/abc", nil)
+	i18n_util.SetLang(c, i18n_util.En)
+
+	Respond(c, ValidationFailed, i18n_util.MsgInvalidUserID)
+
+	var problem Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "Invalid input", problem.Title)
+	assert.Equal(t, "Invalid user ID format", problem.Detail)
+	assert.Equal(t, "validation_failed", problem.Code)
+}
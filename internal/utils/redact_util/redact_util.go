@@ -0,0 +1,132 @@
+package redact_util
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Mask заменяет скрытые значения
+const Mask = "[REDACTED]"
+
+// Rules определяет, какие данные скрываются в записях лога
+type Rules struct {
+	Emails         bool     // Адреса email: видны первый символ и домен (a***@example.com)
+	BearerTokens   bool     // Значения после "Bearer "
+	JWTs           bool     // Строки вида JWT (eyJ....eyJ....подпись)
+	PasswordHashes bool     // Хеши bcrypt
+	SensitiveKeys  []string // Подстроки имен полей, значения которых скрываются целиком (password, secret)
+}
+
+// DefaultRules возвращает правила, в которых включено все
+func DefaultRules() Rules {
+	return Rules{
+		Emails:         true,
+		BearerTokens:   true,
+		JWTs:           true,
+		PasswordHashes: true,
+		SensitiveKeys:  []string{"password", "secret", "authorization", "access_token", "refresh_token"},
+	}
+}
+
+var (
+	emailPattern        = regexp.MustCompile(`([A-Za-z0-9._%+-])[A-Za-z0-9._%+-]*@([A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,})`)
+	bearerPattern       = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`)
+	jwtPattern          = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	passwordHashPattern = regexp.MustCompile(`\$2[abxy]?\$\d{2}\$[./A-Za-z0-9]{53}`)
+)
+
+// Redactor скрывает чувствительные данные в строках и полях записей лога
+type Redactor struct {
+	rules Rules
+	keys  []string
+	// keyValuePattern находит пары "password=значение" и "secret: значение" внутри строк
+	keyValuePattern *regexp.Regexp
+}
+
+// New создает Redactor по правилам. Имена полей сравниваются без учета регистра.
+func New(rules Rules) *Redactor {
+	r := &Redactor{rules: rules}
+	var quoted []string
+	for _, key := range rules.SensitiveKeys {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "" {
+			continue
+		}
+		r.keys = append(r.keys, key)
+		quoted = append(quoted, regexp.QuoteMeta(key))
+	}
+	if len(quoted) > 0 {
+		// Имя может быть частью идентификатора (password_hash) и стоять в кавычках, как в SQL;
+		// значение - строка в кавычках, токен после Bearer или слово до пробела или разделителя
+		r.keyValuePattern = regexp.MustCompile(`(?i)([\w.-]*(?:` + strings.Join(quoted, "|") + `)[\w.-]*["'` + "`" + `]?\s*[=:]\s*)` +
+			`(bearer\s+\S+|"[^"]*"|'[^']*'|[^\s,;&)]+)`)
+	}
+	return r
+}
+
+// IsSensitiveKey сообщает, скрывается ли значение поля с таким именем целиком
+func (r *Redactor) IsSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range r.keys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// String скрывает чувствительные данные в строке
+func (r *Redactor) String(s string) string {
+	if r.keyValuePattern != nil {
+		s = r.keyValuePattern.ReplaceAllString(s, "${1}"+Mask)
+	}
+	if r.rules.JWTs {
+		s = jwtPattern.ReplaceAllString(s, Mask)
+	}
+	if r.rules.BearerTokens {
+		s = bearerPattern.ReplaceAllString(s, "${1}"+Mask)
+	}
+	if r.rules.PasswordHashes {
+		s = passwordHashPattern.ReplaceAllString(s, Mask)
+	}
+	if r.rules.Emails {
+		s = emailPattern.ReplaceAllString(s, "${1}***@${2}")
+	}
+	return s
+}
+
+// Value скрывает значение поля записи лога. Строки и ошибки проверяются как текст,
+// остальные типы (числа, время) не меняются.
+func (r *Redactor) Value(key string, value interface{}) interface{} {
+	if r.IsSensitiveKey(key) {
+		return Mask
+	}
+	switch v := value.(type) {
+	case string:
+		return r.String(v)
+	case error:
+		return r.String(v.Error())
+	default:
+		return value
+	}
+}
+
+// Formatter скрывает чувствительные данные в сообщении и полях записи
+// перед передачей ее основному форматтеру. Запись, которую видят хуки, не меняется.
+type Formatter struct {
+	Formatter logrus.Formatter
+	Redactor  *Redactor
+}
+
+// Format реализует logrus.Formatter
+func (f *Formatter) Format(entry *logrus.Entry) ([]byte, error) {
+	redacted := *entry
+	redacted.Message = f.Redactor.String(entry.Message)
+	redacted.Data = make(logrus.Fields, len(entry.Data))
+	for key, value := range entry.Data {
+		redacted.Data[key] = f.Redactor.Value(key, value)
+	}
+	return f.Formatter.Format(&redacted)
+}
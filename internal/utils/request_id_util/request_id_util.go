@@ -0,0 +1,78 @@
+package request_id_util
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Header - заголовок запроса и ответа с идентификатором запроса
+const Header = "X-Request-ID"
+
+// LogField - поле записи лога с идентификатором запроса
+const LogField = "request_id"
+
+// MaxLength - максимальная длина идентификатора, принимаемого от клиента
+const MaxLength = 128
+
+// contextKey - ключ идентификатора запроса в context.Context
+type contextKey struct{}
+
+// New генерирует случайный идентификатор запроса
+func New() string {
+	buf := make([]byte, 16)
+	// crypto/rand.Read не возвращает ошибок начиная с Go 1.24
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// IsValid проверяет идентификатор, полученный от клиента: длина от 1 до MaxLength символов,
+// только латинские буквы, цифры и символы - _ . : (без пробелов и переводов строк, которые
+// позволили бы подделать записи лога)
+func IsValid(id string) bool {
+	if id == "" || len(id) > MaxLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// WithRequestID возвращает контекст с идентификатором запроса
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext возвращает идентификатор запроса из контекста или пустую строку
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// LogHook добавляет request_id в записи, созданные через WithContext с контекстом запроса.
+// Записи без контекста или вне запроса не меняются.
+type LogHook struct{}
+
+// Levels возвращает уровни, для которых вызывается хук
+func (LogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire добавляет в запись идентификатор запроса
+func (LogHook) Fire(entry *logrus.Entry) error {
+	if id := FromContext(entry.Context); id != "" {
+		entry.Data[LogField] = id
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package request_id_util
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValid(t *testing.T) {
+	assert.True(t, IsValid("abc-123_DEF.gh:1"))
+	assert.True(t, IsValid(strings.Repeat("a", MaxLength)))
+	assert.False(t, IsValid(""))
+	assert.False(t, IsValid(strings.Repeat("a", MaxLength+1)))
+	assert.False(t, IsValid("bad id"))
+	assert.False(t, IsValid("id\nlevel=error"))
+	assert.False(t, IsValid("идентификатор"))
+}
+
+func TestNew(t *testing.T) {
+	first, second := New(), New()
+	assert.Len(t, first, 32)
+	assert.True(t, IsValid(first))
+	assert.NotEqual(t, first, second)
+}
+
+func TestContext(t *testing.T) {
+	assert.Empty(t, FromContext(context.Background()))
+	ctx := WithRequestID(context.Background(), "req-1")
+	assert.Equal(t, "req-1", FromContext(ctx))
+}
+
+func TestLogHook(t *testing.T) {
+	log := logrus.New()
+
+	entry := log.WithContext(WithRequestID(context.Background(), "req-1"))
+	assert.NoError(t, LogHook{}.Fire(entry))
+	assert.Equal(t, "req-1", entry.Data[LogField])
+
+	// Записи вне запроса не получают поле
+	plain := logrus.NewEntry(log)
+	assert.NoError(t, LogHook{}.Fire(plain))
+	assert.NotContains(t, plain.Data, LogField)
+}
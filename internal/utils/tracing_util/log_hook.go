@@ -0,0 +1,35 @@
+package tracing_util
+
+import (
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Поля записи лога с идентификаторами трассировки
+const (
+	LogFieldTraceID = "trace_id"
+	LogFieldSpanID  = "span_id"
+)
+
+// LogHook добавляет trace_id и span_id в записи, созданные через WithContext
+// с контекстом, который содержит спан. Записи без контекста не меняются.
+type LogHook struct{}
+
+// Levels возвращает уровни, для которых вызывается хук
+func (LogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire добавляет в запись идентификаторы текущего спана
+func (LogHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	spanContext := trace.SpanContextFromContext(entry.Context)
+	if !spanContext.IsValid() {
+		return nil
+	}
+	entry.Data[LogFieldTraceID] = spanContext.TraceID().String()
+	entry.Data[LogFieldSpanID] = spanContext.SpanID().String()
+	return nil
+}
@@ -0,0 +1,182 @@
+package tracing_util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName - имя сервиса в ресурсе трассировки и имя инструментирующей библиотеки
+const ServiceName = "user-order-api"
+
+// Поддерживаемые значения TRACING_EXPORTER
+const (
+	ExporterNone   = "none"   // спаны не экспортируются, контекст трассировки только передается дальше
+	ExporterOTLP   = "otlp"   // OTLP по HTTP, например в OpenTelemetry Collector или Jaeger
+	ExporterStdout = "stdout" // JSON в стандартный вывод, для локальной отладки
+	ExporterFile   = "file"   // JSON в файл, для локальной отладки
+)
+
+// Атрибуты спанов с идентификаторами сущностей
+const (
+	AttrUserID    = attribute.Key("user_id")
+	AttrOrderID   = attribute.Key("order_id")
+	AttrProductID = attribute.Key("product_id")
+)
+
+// ErrUnknownExporter возвращается при неизвестном значении TRACING_EXPORTER
+var ErrUnknownExporter = errors.New("неизвестный экспортер трассировки")
+
+// Options содержит настройки трассировки
+type Options struct {
+	Exporter     string  // none, otlp, stdout или file
+	OTLPEndpoint string  // URL приемника OTLP/HTTP, например http://localhost:4318
+	FilePath     string  // файл для экспортера file
+	SampleRatio  float64 // доля записываемых трасс без родительского спана, от 0 до 1
+	Version      string  // версия сервиса в ресурсе трассировки
+}
+
+// ValidateExporter проверяет значение TRACING_EXPORTER
+func ValidateExporter(exporter string) error {
+	switch exporter {
+	case ExporterNone, ExporterOTLP, ExporterStdout, ExporterFile:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownExporter, exporter)
+	}
+}
+
+// Setup настраивает глобальный провайдер трассировки и распространение контекста W3C (traceparent, baggage).
+// Возвращает функцию, которая отправляет накопленные спаны и освобождает ресурсы экспортера.
+// При экспортере none спаны не записываются, но trace ID из входящего traceparent по-прежнему
+// передается дальше и попадает в логи.
+func Setup(ctx context.Context, opts Options, log *logrus.Logger) (func(context.Context) error, error) {
+	if log == nil {
+		return nil, errors.New("логгер не предоставлен для настройки трассировки")
+	}
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		log.WithError(err).Warn("Ошибка OpenTelemetry")
+	}))
+
+	if err := ValidateExporter(opts.Exporter); err != nil {
+		return nil, err
+	}
+	if opts.Exporter == ExporterNone {
+		log.Info("Экспорт трассировки выключен (TRACING_EXPORTER=none)")
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, closeOutput, err := newExporter(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	res := resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(ServiceName),
+		semconv.ServiceVersion(opts.Version),
+	)
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// Решение о записи входящей трассы принимает вызывающий сервис
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	log.Infof("Трассировка OpenTelemetry включена, экспортер: %s", opts.Exporter)
+
+	shutdown := func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeOutput != nil {
+			err = errors.Join(err, closeOutput())
+		}
+		return err
+	}
+	return shutdown, nil
+}
+
+// newExporter создает экспортер спанов; для экспортера file также возвращает функцию закрытия файла
+func newExporter(ctx context.Context, opts Options) (sdktrace.SpanExporter, func() error, error) {
+	switch opts.Exporter {
+	case ExporterOTLP:
+		exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(opts.OTLPEndpoint))
+		if err != nil {
+			return nil, nil, fmt.Errorf("не удалось создать экспортер OTLP: %w", err)
+		}
+		return exporter, nil, nil
+	case ExporterStdout:
+		exporter, err := newWriterExporter(os.Stdout)
+		return exporter, nil, err
+	case ExporterFile:
+		file, err := os.OpenFile(opts.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("не удалось открыть файл трассировки '%s': %w", opts.FilePath, err)
+		}
+		exporter, err := newWriterExporter(file)
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		return exporter, file.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("%w: %q", ErrUnknownExporter, opts.Exporter)
+	}
+}
+
+// newWriterExporter создает экспортер, который пишет каждый спан строкой JSON
+func newWriterExporter(w io.Writer) (sdktrace.SpanExporter, error) {
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(w))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать экспортер трассировки: %w", err)
+	}
+	return exporter, nil
+}
+
+// Tracer возвращает трассировщик приложения из глобального провайдера
+func Tracer() trace.Tracer {
+	return otel.Tracer(ServiceName)
+}
+
+// Start создает дочерний спан операции name с атрибутами attrs
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End завершает спан; если операция завершилась ошибкой, ошибка записывается в спан
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// UserID возвращает атрибут с ID пользователя
+func UserID(id uint) attribute.KeyValue {
+	return AttrUserID.Int64(int64(id))
+}
+
+// OrderID возвращает атрибут с ID заказа
+func OrderID(id uint) attribute.KeyValue {
+	return AttrOrderID.Int64(int64(id))
+}
+
+// ProductID возвращает атрибут с ID продукта
+func ProductID(id uint) attribute.KeyValue {
+	return AttrProductID.Int64(int64(id))
+}
@@ -0,0 +1,109 @@
+package tracing_util
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// useRecorder подменяет глобальный провайдер трассировки на провайдер с записью спанов в память
+func useRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func quietLogger() *logrus.Logger {
+	log := logrus.New()
+	log.SetLevel(logrus.PanicLevel)
+	return log
+}
+
+func TestValidateExporter(t *testing.T) {
+	for _, exporter := range []string{ExporterNone, ExporterOTLP, ExporterStdout, ExporterFile} {
+		assert.NoError(t, ValidateExporter(exporter), exporter)
+	}
+	assert.ErrorIs(t, ValidateExporter("zipkin"), ErrUnknownExporter)
+}
+
+func TestSetup_UnknownExporter(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Options{Exporter: "zipkin"}, quietLogger())
+	assert.ErrorIs(t, err, ErrUnknownExporter)
+	assert.Nil(t, shutdown)
+}
+
+func TestSetup_FileExporter(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	shutdown, err := Setup(context.Background(), Options{
+		Exporter: ExporterFile, FilePath: path, SampleRatio: 1, Version: "test",
+	}, quietLogger())
+	require.NoError(t, err)
+
+	_, span := Start(context.Background(), "test-operation", UserID(7))
+	span.End()
+	// При завершении накопленные спаны отправляются экспортеру
+	require.NoError(t, shutdown(context.Background()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"Name":"test-operation"`)
+	assert.Contains(t, string(data), `"user_id"`)
+	assert.Contains(t, string(data), ServiceName)
+}
+
+func TestEnd_RecordsError(t *testing.T) {
+	recorder := useRecorder(t)
+
+	_, span := Start(context.Background(), "failed", OrderID(3))
+	End(span, errors.New("сбой"))
+	_, span = Start(context.Background(), "succeeded")
+	End(span, nil)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "сбой", spans[0].Status().Description)
+	assert.Len(t, spans[0].Events(), 1)
+	assert.Contains(t, spans[0].Attributes(), OrderID(3))
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+}
+
+func TestLogHook(t *testing.T) {
+	useRecorder(t)
+
+	var buf bytes.Buffer
+	log := logrus.New()
+	log.SetOutput(&buf)
+	log.SetFormatter(&logrus.JSONFormatter{})
+	log.AddHook(LogHook{})
+
+	ctx, span := Start(context.Background(), "operation")
+	defer span.End()
+
+	log.WithContext(ctx).Info("внутри спана")
+	traceID := span.SpanContext().TraceID().String()
+	assert.Contains(t, buf.String(), `"trace_id":"`+traceID+`"`)
+	assert.Contains(t, buf.String(), `"span_id":"`+span.SpanContext().SpanID().String()+`"`)
+
+	buf.Reset()
+	log.WithContext(context.Background()).Info("без спана")
+	log.Info("без контекста")
+	assert.NotContains(t, buf.String(), "trace_id")
+}
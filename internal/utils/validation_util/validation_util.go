@@ -0,0 +1,106 @@
+// Package validation_util содержит собственные правила валидации моделей запросов
+// и регистрирует их в валидаторе, которым пользуется привязка запросов gin.
+package validation_util
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Имена собственных правил; используются в тегах binding моделей и в ответах с ошибками
+const (
+	RulePersonName  = "person_name"
+	RuleProductName = "product_name"
+	RuleAge         = "age"
+)
+
+// Ограничения собственных правил
+const (
+	// MaxNameLength совпадает с размером колонок name и product_name в базе данных
+	MaxNameLength = 255
+	MinAge        = 1
+	MaxAge        = 150
+)
+
+// Register регистрирует собственные правила в валидаторе и включает
+// использование имен полей из JSON в ошибках валидации
+func Register(v *validator.Validate) error {
+	v.RegisterTagNameFunc(JSONFieldName)
+
+	rules := map[string]validator.Func{
+		RulePersonName:  validName,
+		RuleProductName: validName,
+		RuleAge:         validAge,
+	}
+	for tag, fn := range rules {
+		if err := v.RegisterValidation(tag, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	bindingOnce sync.Once
+	bindingErr  error
+)
+
+// RegisterBinding регистрирует собственные правила в валидаторе привязки запросов gin.
+// Повторные вызовы возвращают результат первой регистрации.
+func RegisterBinding() error {
+	bindingOnce.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			bindingErr = errors.New("валидатор привязки gin не является validator.Validate")
+			return
+		}
+		bindingErr = Register(v)
+	})
+	return bindingErr
+}
+
+// ValidateStruct проверяет структуру по тегам binding теми же правилами, что и привязка запросов gin.
+// Нужна там, где запрос приходит не через HTTP, например из CLI.
+func ValidateStruct(obj any) error {
+	if err := RegisterBinding(); err != nil {
+		return err
+	}
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// JSONFieldName возвращает имя поля структуры в JSON
+func JSONFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return name
+}
+
+// validName проверяет, что имя без пробелов по краям не пустое и не длиннее MaxNameLength символов
+func validName(fl validator.FieldLevel) bool {
+	if fl.Field().Kind() != reflect.String {
+		return false
+	}
+	name := strings.TrimSpace(fl.Field().String())
+	return name != "" && utf8.RuneCountInString(name) <= MaxNameLength
+}
+
+// validAge проверяет, что возраст находится в диапазоне от MinAge до MaxAge
+func validAge(fl validator.FieldLevel) bool {
+	switch fl.Field().Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		age := fl.Field().Int()
+		return age >= MinAge && age <= MaxAge
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package validation_util
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/IlyushinDM/user-order-api/internal/utils/i18n_util"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRequest struct {
+	Name    string `json:"name" validate:"person_name"`
+	Product string `json:"product_name" validate:"product_name"`
+	Age     int    `json:"age" validate:"age"`
+}
+
+func TestRegister(t *testing.T) {
+	v := validator.New()
+	require.NoError(t, Register(v))
+
+	valid := testRequest{Name: "Alice", Product: strings.Repeat("я", MaxNameLength), Age: MaxAge}
+	require.NoError(t, v.Struct(valid))
+
+	err := v.Struct(testRequest{Name: "   ", Product: strings.Repeat("я", MaxNameLength+1), Age: MaxAge + 1})
+	var validationErrs validator.ValidationErrors
+	require.ErrorAs(t, err, &validationErrs)
+
+	var failed []string
+	for _, fieldErr := range validationErrs {
+		failed = append(failed, fieldErr.Field()+":"+fieldErr.Tag())
+	}
+	assert.Equal(t, []string{"name:person_name", "product_name:product_name", "age:age"}, failed)
+
+	err = v.Struct(testRequest{Name: "Bob", Product: "Кофе", Age: 0})
+	require.ErrorAs(t, err, &validationErrs)
+	assert.Equal(t, "age", validationErrs[0].Field())
+}
+
+type bindingRequest struct {
+	Name  string `json:"name" binding:"required,person_name"`
+	Email string `json:"email" binding:"required,email"`
+	Age   int    `json:"age" binding:"age"`
+}
+
+func TestValidateStruct(t *testing.T) {
+	require.NoError(t, ValidateStruct(bindingRequest{Name: "Alice", Email: "alice@example.com", Age: 30}))
+
+	err := ValidateStruct(bindingRequest{Name: "  ", Email: "alice@example.com", Age: MaxAge + 1})
+	var validationErrs validator.ValidationErrors
+	require.ErrorAs(t, err, &validationErrs)
+
+	violations := Violations(validationErrs)
+	require.Len(t, violations, 2)
+	assert.Equal(t, Violation{
+		Field:   "name",
+		Rule:    RulePersonName,
+		Message: i18n_util.MsgValidationName,
+		Args:    []any{"name", MaxNameLength},
+	}, violations[0])
+	assert.Equal(t, "age", violations[1].Field)
+
+	assert.Equal(t,
+		"field name must not be blank and must be at most 255 characters long; field age must be between 1 and 150",
+		Describe(i18n_util.En, err))
+}
+
+func TestDescribe_NotValidationError(t *testing.T) {
+	assert.Equal(t, "boom", Describe(i18n_util.Default, errors.New("boom")))
+}
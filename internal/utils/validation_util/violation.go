@@ -0,0 +1,107 @@
+package validation_util
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/IlyushinDM/user-order-api/internal/utils/i18n_util"
+	"github.com/go-playground/validator/v10"
+)
+
+// Violation описывает нарушение правила валидации поля; текст переводится при выводе
+type Violation struct {
+	Field   string
+	Rule    string
+	Message i18n_util.Message
+	Args    []any
+}
+
+// Text возвращает текст нарушения на языке lang
+func (v Violation) Text(lang i18n_util.Lang) string {
+	return i18n_util.T(lang, v.Message, v.Args...)
+}
+
+// Violations описывает ошибки валидатора нарушениями правил полей
+func Violations(errs validator.ValidationErrors) []Violation {
+	violations := make([]Violation, 0, len(errs))
+	for _, fieldErr := range errs {
+		violations = append(violations, violationOf(fieldErr))
+	}
+	return violations
+}
+
+// Describe возвращает описание ошибки валидации на языке lang: тексты нарушений через "; ".
+// Для ошибок, которые вернул не валидатор, возвращается текст самой ошибки.
+func Describe(lang i18n_util.Lang, err error) string {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err.Error()
+	}
+	violations := Violations(validationErrs)
+	messages := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		messages = append(messages, violation.Text(lang))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// violationOf описывает нарушение правила валидатора ключом сообщения каталога
+func violationOf(fieldErr validator.FieldError) Violation {
+	field, param := fieldPath(fieldErr), fieldErr.Param()
+	violation := Violation{Field: field, Rule: fieldErr.Tag(), Args: []any{field, param}}
+	collection := fieldErr.Kind() == reflect.Slice || fieldErr.Kind() == reflect.Map
+
+	switch fieldErr.Tag() {
+	case "required":
+		violation.Message, violation.Args = i18n_util.MsgValidationRequired, []any{field}
+	case "email":
+		violation.Message, violation.Args = i18n_util.MsgValidationEmail, []any{field}
+	case "gt":
+		violation.Message = i18n_util.MsgValidationGT
+	case "gte":
+		violation.Message = i18n_util.MsgValidationGTE
+	case "lt":
+		violation.Message = i18n_util.MsgValidationLT
+	case "lte":
+		violation.Message = i18n_util.MsgValidationLTE
+	case "min":
+		switch {
+		case fieldErr.Kind() == reflect.String:
+			violation.Message = i18n_util.MsgValidationMinLen
+		case collection:
+			violation.Message = i18n_util.MsgValidationMinItems
+		default:
+			violation.Message = i18n_util.MsgValidationGTE
+		}
+	case "max":
+		switch {
+		case fieldErr.Kind() == reflect.String:
+			violation.Message = i18n_util.MsgValidationMaxLen
+		case collection:
+			violation.Message = i18n_util.MsgValidationMaxItems
+		default:
+			violation.Message = i18n_util.MsgValidationLTE
+		}
+	case "oneof":
+		violation.Message, violation.Args = i18n_util.MsgValidationOneOf, []any{field, strings.ReplaceAll(param, " ", ", ")}
+	case "iso4217":
+		violation.Message, violation.Args = i18n_util.MsgValidationISO4217, []any{field}
+	case RulePersonName, RuleProductName:
+		violation.Message, violation.Args = i18n_util.MsgValidationName, []any{field, MaxNameLength}
+	case RuleAge:
+		violation.Message, violation.Args = i18n_util.MsgValidationAge, []any{field, MinAge, MaxAge}
+	default:
+		violation.Message, violation.Args = i18n_util.MsgValidationInvalid, []any{field, fieldErr.Tag()}
+	}
+	return violation
+}
+
+// fieldPath возвращает путь к полю без имени корневой структуры, например items[0].quantity
+func fieldPath(fieldErr validator.FieldError) string {
+	namespace := fieldErr.Namespace()
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+	return namespace
+}
@@ -0,0 +1,4 @@
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS status VARCHAR(32) NOT NULL DEFAULT 'pending';
+ALTER TABLE orders ADD CONSTRAINT chk_orders_status
+  CHECK (status IN ('pending', 'confirmed', 'paid', 'shipped', 'delivered', 'cancelled', 'refunded'));
+CREATE INDEX IF NOT EXISTS idx_orders_status ON orders (status);
@@ -0,0 +1,18 @@
+ALTER TABLE orders ADD COLUMN product VARCHAR(255);
+ALTER TABLE orders ADD COLUMN quantity INT;
+ALTER TABLE orders ADD COLUMN price DECIMAL(10,2);
+
+-- Возвращаем в заказ первую позицию; остальные позиции при откате теряются
+UPDATE orders o SET product = i.product_name, quantity = i.quantity, price = i.unit_price
+FROM (
+  SELECT DISTINCT ON (order_id) order_id, product_name, quantity, unit_price
+  FROM order_items ORDER BY order_id, id
+) i
+WHERE i.order_id = o.id;
+
+DELETE FROM orders WHERE product IS NULL;
+ALTER TABLE orders ALTER COLUMN product SET NOT NULL;
+ALTER TABLE orders ALTER COLUMN quantity SET NOT NULL;
+ALTER TABLE orders ALTER COLUMN price SET NOT NULL;
+
+DROP TABLE IF EXISTS order_items;
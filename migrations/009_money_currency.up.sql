@@ -0,0 +1,3 @@
+-- Денежные суммы хранятся в DECIMAL без преобразования через float; у заказа и продукта есть валюта ISO 4217
+ALTER TABLE products ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'RUB';
+ALTER TABLE orders ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'RUB';
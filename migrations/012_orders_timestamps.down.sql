@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_orders_user_id;
+ALTER TABLE orders ALTER COLUMN user_id DROP NOT NULL;
+DROP INDEX IF EXISTS idx_orders_deleted_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS updated_at;
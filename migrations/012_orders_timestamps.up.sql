@@ -0,0 +1,10 @@
+-- Приводим таблицу заказов к модели order_model.Order: отметки времени изменения и мягкого удаления,
+-- обязательная ссылка на пользователя. В базах, которые до перехода на миграции дополнялись
+-- автомиграцией GORM, колонки уже есть: их значения сохраняются.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP;
+UPDATE orders SET updated_at = created_at WHERE updated_at IS NULL;
+ALTER TABLE orders ALTER COLUMN updated_at SET DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP NULL;
+CREATE INDEX IF NOT EXISTS idx_orders_deleted_at ON orders (deleted_at);
+ALTER TABLE orders ALTER COLUMN user_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders (user_id);
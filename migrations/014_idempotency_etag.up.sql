@@ -0,0 +1,2 @@
+-- ETag сохраненного ответа: повтор запроса по ключу идемпотентности возвращает его вместе с телом
+ALTER TABLE idempotency_keys ADD COLUMN etag VARCHAR(255);
@@ -0,0 +1,34 @@
+// Package migrations содержит SQL миграции схемы базы данных.
+// Файлы встраиваются в бинарник и применяются при запуске приложения,
+// поэтому для развертывания не нужен отдельный инструмент миграций.
+//
+// Миграции для PostgreSQL лежат в корне каталога, для SQLite - в каталоге sqlite/.
+// Наборы содержат одинаковые версии с одинаковыми именами: каждая новая миграция
+// добавляется в оба набора.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+)
+
+// FS содержит миграции PostgreSQL вида NNN_описание.up.sql и NNN_описание.down.sql
+//
+//go:embed *.sql
+var FS embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFS embed.FS
+
+// ForDialect возвращает миграции для СУБД с именем диалекта GORM (postgres или sqlite)
+func ForDialect(dialect string) (fs.FS, error) {
+	switch dialect {
+	case "postgres":
+		return FS, nil
+	case "sqlite":
+		return fs.Sub(sqliteFS, "sqlite")
+	default:
+		return nil, fmt.Errorf("нет миграций для СУБД %q", dialect)
+	}
+}
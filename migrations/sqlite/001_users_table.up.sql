@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS users (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name VARCHAR(255) NOT NULL,
+  email VARCHAR(255) UNIQUE NOT NULL,
+  age INT NOT NULL,
+  password_hash VARCHAR(255) NOT NULL
+);
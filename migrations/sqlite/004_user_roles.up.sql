@@ -0,0 +1,3 @@
+-- SQLite не умеет добавлять ограничения к существующей таблице, поэтому CHECK объявлен вместе с колонкой
+ALTER TABLE users ADD COLUMN role VARCHAR(32) NOT NULL DEFAULT 'user'
+  CHECK (role IN ('user', 'support', 'admin'));
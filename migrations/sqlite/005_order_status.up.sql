@@ -0,0 +1,3 @@
+ALTER TABLE orders ADD COLUMN status VARCHAR(32) NOT NULL DEFAULT 'pending'
+  CHECK (status IN ('pending', 'confirmed', 'paid', 'shipped', 'delivered', 'cancelled', 'refunded'));
+CREATE INDEX IF NOT EXISTS idx_orders_status ON orders (status);
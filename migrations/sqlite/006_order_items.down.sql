@@ -0,0 +1,14 @@
+-- SQLite не умеет делать существующую колонку NOT NULL, поэтому колонки возвращаются допускающими NULL
+ALTER TABLE orders ADD COLUMN product VARCHAR(255);
+ALTER TABLE orders ADD COLUMN quantity INT;
+ALTER TABLE orders ADD COLUMN price DECIMAL(10,2);
+
+-- Возвращаем в заказ первую позицию; остальные позиции при откате теряются
+UPDATE orders SET
+  product = (SELECT product_name FROM order_items WHERE order_id = orders.id ORDER BY id LIMIT 1),
+  quantity = (SELECT quantity FROM order_items WHERE order_id = orders.id ORDER BY id LIMIT 1),
+  price = (SELECT unit_price FROM order_items WHERE order_id = orders.id ORDER BY id LIMIT 1);
+
+DELETE FROM orders WHERE product IS NULL;
+
+DROP TABLE IF EXISTS order_items;
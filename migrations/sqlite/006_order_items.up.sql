@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS order_items (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+  product_name VARCHAR(255) NOT NULL,
+  quantity INT NOT NULL CHECK (quantity > 0),
+  unit_price DECIMAL(10,2) NOT NULL CHECK (unit_price > 0),
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_order_items_order_id ON order_items (order_id);
+
+-- В базах, которые до перехода на миграции дополнялись автомиграцией GORM, название продукта
+-- хранится в колонке product_name. Она добавляется, если ее нет, чтобы перенос работал для обеих схем.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS product_name VARCHAR(255);
+
+-- Переносим единственную позицию каждого существующего заказа в order_items
+INSERT INTO order_items (order_id, product_name, quantity, unit_price, created_at, updated_at)
+SELECT id, COALESCE(NULLIF(product_name, ''), product), quantity, price, created_at, created_at FROM orders;
+
+ALTER TABLE orders DROP COLUMN product;
+ALTER TABLE orders DROP COLUMN IF EXISTS product_name;
+ALTER TABLE orders DROP COLUMN quantity;
+ALTER TABLE orders DROP COLUMN price;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_order_items_product_id;
+ALTER TABLE order_items DROP COLUMN product_id;
+
+DROP TABLE IF EXISTS products;
@@ -0,0 +1,3 @@
+-- Версия записи для оптимистичной блокировки: передается клиенту в ETag и проверяется по If-Match
+ALTER TABLE users ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE orders ADD COLUMN version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_orders_user_id;
+DROP INDEX IF EXISTS idx_orders_deleted_at;
+ALTER TABLE orders DROP COLUMN deleted_at;
+ALTER TABLE orders DROP COLUMN updated_at;
@@ -0,0 +1,9 @@
+-- Приводим таблицу заказов к модели order_model.Order: отметки времени изменения и мягкого удаления.
+-- SQLite не допускает DEFAULT CURRENT_TIMESTAMP в ADD COLUMN и не умеет делать колонку NOT NULL,
+-- поэтому updated_at заполняется явно, а обязательность user_id проверяет приложение.
+-- Колонки, уже добавленные автомиграцией GORM до перехода на миграции, сохраняют свои значения.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NULL;
+UPDATE orders SET updated_at = created_at WHERE updated_at IS NULL;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP NULL;
+CREATE INDEX IF NOT EXISTS idx_orders_deleted_at ON orders (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders (user_id);